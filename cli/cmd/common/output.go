@@ -0,0 +1,93 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// 输出格式
+type OutputFormatEnum string
+
+const (
+	// 默认的表格
+	OutputFormatEnum_Table OutputFormatEnum = "table"
+	// 包含更多列的表格
+	OutputFormatEnum_Wide OutputFormatEnum = "wide"
+	OutputFormatEnum_Json OutputFormatEnum = "json"
+	OutputFormatEnum_Yaml OutputFormatEnum = "yaml"
+)
+
+const Flag_Output = "output"
+
+// 输出到标准输出，方便测试时替换
+var outputWriter io.Writer = os.Stdout
+
+// 在读取类的命令上增加 -o/--output 参数，只在这些命令上定义，避免与其他命令的 -o 参数（比如 export 的输出文件）冲突
+func AddOutputFlag(cmd *cobra.Command, usage string) {
+	cmd.Flags().StringP(Flag_Output, "o", string(OutputFormatEnum_Table), usage)
+}
+
+// 获取输出格式
+func GetOutputFormat(cmd *cobra.Command) (OutputFormatEnum, error) {
+	value, _ := cmd.Flags().GetString(Flag_Output)
+	switch OutputFormatEnum(strings.ToLower(strings.TrimSpace(value))) {
+	case "", OutputFormatEnum_Table:
+		return OutputFormatEnum_Table, nil
+	case OutputFormatEnum_Wide:
+		return OutputFormatEnum_Wide, nil
+	case OutputFormatEnum_Json:
+		return OutputFormatEnum_Json, nil
+	case OutputFormatEnum_Yaml:
+		return OutputFormatEnum_Yaml, nil
+	}
+	return "", fmt.Errorf("不支持的输出格式 %v，可选值为 json|yaml|table|wide", value)
+}
+
+// 是否为结构化的输出（json、yaml），此时不应该输出其他的日志
+func (format OutputFormatEnum) IsStructured() bool {
+	return format == OutputFormatEnum_Json || format == OutputFormatEnum_Yaml
+}
+
+// 按照 json 或者 yaml 格式输出
+func PrintStructured(format OutputFormatEnum, value interface{}) error {
+	var content []byte
+	var err error
+	switch format {
+	case OutputFormatEnum_Json:
+		content, err = json.MarshalIndent(value, "", "  ")
+		content = append(content, '\n')
+	case OutputFormatEnum_Yaml:
+		content, err = yaml.Marshal(value)
+	default:
+		return fmt.Errorf("%v 不是结构化的输出格式", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = outputWriter.Write(content)
+	return err
+}
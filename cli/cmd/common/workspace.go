@@ -277,7 +277,15 @@ func GetWorkspaceFromCmd(cmd *cobra.Command, args []string) (workspaceInfo works
 				}
 
 				if workspaceInfo.Name == "" {
-					workspaceInfo.Name = common.GetRepoName(workspaceInfo.GitCloneRepoUrl) + "-" + common.RandLowStr(3)
+					if syncDir := getFlagValue(fflags, "sync"); syncDir != "" && workspaceInfo.GitCloneRepoUrl == "" { // 同步本地文件夹时，使用文件夹的名称
+						absSyncDir, err := filepath.Abs(syncDir)
+						if err != nil {
+							return workspace.WorkspaceInfo{}, err
+						}
+						workspaceInfo.Name = filepath.Base(absSyncDir) + "-" + common.RandLowStr(3)
+					} else {
+						workspaceInfo.Name = common.GetRepoName(workspaceInfo.GitCloneRepoUrl) + "-" + common.RandLowStr(3)
+					}
 				}
 				workspaceInfo.WorkingDirectoryPath = common.FilePahtJoin4Linux("~", model.CONST_REMOTE_REPO_ROOT, workspaceInfo.Name)
 
@@ -410,7 +418,7 @@ func GetWorkspaceFromCmd(cmd *cobra.Command, args []string) (workspaceInfo works
 		ssmRemote := common.SSHRemote{}
 		common.SmartIDELog.InfoF(i18nInstance.Main.Info_ssh_connect_check, workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort)
 
-		err = ssmRemote.CheckDail(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey)
+		err = ssmRemote.CheckDail(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey, workspaceInfo.Remote.IdentityFile, workspaceInfo.Remote.JumpHosts)
 		if err != nil {
 			return workspaceInfo, err
 		}
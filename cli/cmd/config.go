@@ -24,6 +24,7 @@ import (
 
 	"github.com/leansoftX/smartide-cli/internal/biz/config"
 	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/secret"
 	"github.com/spf13/cobra"
 )
 
@@ -33,7 +34,10 @@ var configCmd = &cobra.Command{
 	Long:  i18nInstance.Config.Info_help_long,
 	Example: `  smartide config list
   smartide config set template-repo=<repourl>
-  smartide config set images-registry=<registryurl>`,
+  smartide config set images-registry=<registryurl>
+  smartide config set secret-backend=<db|file|secret-service|pass|env>
+  smartide config validate [path]
+  smartide config show [path] --merged`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
 			return nil
@@ -63,6 +67,10 @@ var configCmd = &cobra.Command{
 					configStruct.TemplateActualRepoUrl = paramVal
 				} else if paramKey == "images-registry" {
 					configStruct.ImagesRegistry = paramVal
+				} else if paramKey == "secret-backend" {
+					backend, err := secret.ParseSecretBackend(paramVal)
+					common.CheckError(err)
+					configStruct.SecretBackend = string(backend)
 				} else {
 					return nil
 				}
@@ -76,5 +84,6 @@ var configCmd = &cobra.Command{
 }
 
 func init() {
-
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configShowCmd)
 }
@@ -0,0 +1,96 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/spf13/cobra"
+)
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: i18nInstance.Config.Info_help_show_short,
+	Long:  i18nInstance.Config.Info_help_show_long,
+	Example: `  smartide config show
+  smartide config show --merged
+  smartide config show <project dir> --merged --config-overlay ./debug.yaml`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 配置文件路径
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		configFilePath, err := getConfigFilePathForValidate(path)
+		if err != nil {
+			return err
+		}
+
+		// 原始的配置文件，devcontainer.json 不支持覆盖
+		isMerged, _ := cmd.Flags().GetBool("merged")
+		if !isMerged || config.IsDevContainerJsonFile(configFilePath) {
+			content, err := os.ReadFile(configFilePath)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(content))
+			return nil
+		}
+
+		// 合并后的配置，合并的文件以注释的形式输出，不影响 yaml 格式
+		workingDir, configRelativeFilePath, err := splitConfigFilePath(configFilePath)
+		if err != nil {
+			return err
+		}
+		content, mergedFilePaths, err := config.LoadConfigContentWithOverlays(workingDir, configRelativeFilePath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# %v\n", configFilePath)
+		for _, filePath := range mergedFilePaths {
+			fmt.Printf("# + %v\n", filePath)
+		}
+		fmt.Print(string(content))
+
+		return nil
+	},
+}
+
+// 拆分为项目目录以及配置文件的相对路径，配置文件在 .ide 目录中时，项目目录为 .ide 的上级目录
+func splitConfigFilePath(configFilePath string) (workingDir string, configRelativeFilePath string, err error) {
+	configFilePath, err = filepath.Abs(configFilePath)
+	if err != nil {
+		return "", "", err
+	}
+	workingDir = filepath.Dir(configFilePath)
+	if filepath.Base(workingDir) == ".ide" {
+		workingDir = filepath.Dir(workingDir)
+	}
+	configRelativeFilePath, err = filepath.Rel(workingDir, configFilePath)
+	return workingDir, configRelativeFilePath, err
+}
+
+func init() {
+	configShowCmd.Flags().BoolP("merged", "", false, i18nInstance.Config.Info_help_flag_merged)
+}
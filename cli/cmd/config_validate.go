@@ -0,0 +1,103 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/leansoftX/smartide-cli/internal/model"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: i18nInstance.Config.Info_help_validate_short,
+	Long:  i18nInstance.Config.Info_help_validate_long,
+	Example: `  smartide config validate
+  smartide config validate <project dir>
+  smartide config validate .ide/.ide.yaml
+  smartide config validate --schema > ide.schema.json`,
+	Args:          cobra.MaximumNArgs(1),
+	SilenceUsage:  true, // 验证失败时不需要显示帮助信息
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// 输出 json schema
+		if isSchema, _ := cmd.Flags().GetBool("schema"); isSchema {
+			fmt.Println(config.ConfigJsonSchema) // 原样输出，方便重定向到文件
+			return nil
+		}
+
+		// 配置文件路径
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+		configFilePath, err := getConfigFilePathForValidate(path)
+		if err != nil {
+			return err
+		}
+
+		// 验证
+		issues, err := config.ValidateConfigFile(configFilePath)
+		if err != nil {
+			return err
+		}
+		errorCount := 0
+		for _, issue := range issues {
+			fmt.Println(issue.String())
+			if !issue.IsWarning {
+				errorCount++
+			}
+		}
+		if errorCount > 0 {
+			return fmt.Errorf("%v 验证失败，共 %v 个错误", configFilePath, errorCount)
+		}
+		common.SmartIDELog.Info(fmt.Sprintf("%v 验证通过", configFilePath))
+
+		return nil
+	},
+}
+
+// 获取需要验证的配置文件，目录时优先查找 .ide.yaml，其次是 devcontainer.json
+func getConfigFilePathForValidate(path string) (string, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !fileInfo.IsDir() {
+		return path, nil
+	}
+
+	configFilePath := filepath.Join(path, model.CONST_Default_ConfigRelativeFilePath)
+	if common.IsExist(configFilePath) {
+		return configFilePath, nil
+	}
+	if devContainerJsonFilePath := config.GetLocalDevContainerJsonRelativeFilePath(path); devContainerJsonFilePath != "" {
+		return filepath.Join(path, devContainerJsonFilePath), nil
+	}
+	return "", fmt.Errorf(i18nInstance.Config.Err_file_not_exit, configFilePath)
+}
+
+func init() {
+	configValidateCmd.Flags().BoolP("schema", "", false, "输出 .ide.yaml 的 JSON Schema，可用于编辑器的自动完成和校验")
+}
@@ -0,0 +1,88 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/leansoftX/smartide-cli/internal/dal"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: i18nInstance.Db.Info_help_short,
+	Long:  i18nInstance.Db.Info_help_long,
+	Example: `  smartide db status
+  smartide db migrate`,
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:     "migrate",
+	Short:   i18nInstance.Db.Info_help_migrate_short,
+	Long:    i18nInstance.Db.Info_help_migrate_short,
+	Example: `  smartide db migrate`,
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := dal.Migrate()
+		common.CheckError(err)
+
+		if result.FromVersion == result.ToVersion {
+			common.SmartIDELog.InfoF(i18nInstance.Db.Info_migrate_latest, result.ToVersion)
+			return
+		}
+		common.SmartIDELog.InfoF(i18nInstance.Db.Info_migrate_success, result.FromVersion, result.ToVersion)
+		if result.BackupFilePath != "" {
+			common.SmartIDELog.InfoF(i18nInstance.Db.Info_migrate_backup, result.BackupFilePath)
+		}
+	},
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:     "status",
+	Short:   i18nInstance.Db.Info_help_status_short,
+	Long:    i18nInstance.Db.Info_help_status_short,
+	Example: `  smartide db status`,
+	Run: func(cmd *cobra.Command, args []string) {
+		status, err := dal.GetMigrationStatus()
+		common.CheckError(err)
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tSTATUS\tAPPLIED\tDESCRIPTION")
+		currentVersion := 0
+		for _, item := range status {
+			state := "pending"
+			if item.IsApplied {
+				state = "applied"
+				currentVersion = item.Version
+			}
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", item.Version, state, item.AppliedAt, item.Description)
+		}
+		w.Flush()
+
+		fmt.Printf("\ncurrent: %v, latest: %v\n", currentVersion, dal.GetLatestSchemaVersion())
+	},
+}
+
+func init() {
+	dbCmd.AddCommand(dbStatusCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+}
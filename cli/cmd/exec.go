@@ -0,0 +1,170 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/leansoftX/smartide-cli/cmd/start"
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/k8s"
+	"github.com/spf13/cobra"
+)
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec",
+	Short: i18nInstance.Exec.Info_help_short,
+	Long:  i18nInstance.Exec.Info_help_long,
+	Example: `  smartide exec <workspaceid> -- make test
+  smartide exec <workspaceid> --service db -- psql -U postgres -c "select 1"
+  smartide exec <workspaceid> --user smartide -it -- bash`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			common.SmartIDELog.Error(i18nInstance.Exec.Err_command_none)
+		}
+		serviceName, _ := cmd.Flags().GetString(flag_service)
+		userName, _ := cmd.Flags().GetString(flag_user)
+		isInteractive, _ := cmd.Flags().GetBool(flag_interactive)
+		isTTY, _ := cmd.Flags().GetBool(flag_tty)
+
+		exitCode, err := execWorkspace(args[0], args[1:], serviceName, userName, isInteractive, isTTY)
+		common.CheckError(err)
+		os.Exit(exitCode)
+	},
+}
+
+const (
+	flag_service     = "service"
+	flag_user        = "user"
+	flag_interactive = "interactive"
+)
+
+// 在工作区的容器中执行命令，返回命令的退出码
+func execWorkspace(workspaceId string, command []string, serviceName string, userName string, isInteractive bool, isTTY bool) (int, error) {
+	//1. 工作区信息
+	workspaceInfo, err := getCachedWorkspace(workspaceId)
+	if err != nil {
+		return -1, err
+	}
+	if serviceName == "" {
+		serviceName = workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName
+	}
+	var stdin io.Reader
+	if isInteractive {
+		stdin = os.Stdin
+	}
+
+	//2. 执行
+	switch workspaceInfo.Mode {
+	case workspace.WorkingMode_Local:
+		//2.1. 本地模式，通过 docker api 执行
+		ctx := context.Background()
+		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return -1, err
+		}
+		defer cli.Close()
+		containerName, err := start.GetLocalServiceContainerName(ctx, cli, workspaceInfo.WorkingDirectoryPath, serviceName)
+		if err != nil {
+			return -1, err
+		}
+		if containerName == "" {
+			return -1, fmt.Errorf(i18nInstance.Exec.Err_container_none, serviceName)
+		}
+		return common.NewDocker(cli).ExecStream(ctx, containerName, userName, command, isTTY, stdin, os.Stdout, os.Stderr)
+
+	case workspace.WorkingMode_Remote:
+		//2.2. 远程主机模式，通过 ssh 在远程主机上执行 docker exec，docker 返回命令的退出码
+		sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey, workspaceInfo.Remote.IdentityFile, workspaceInfo.Remote.JumpHosts)
+		if err != nil {
+			return -1, err
+		}
+		defer sshRemote.GetSSHClient().Close()
+		containers, err := start.GetRemoteContainersWithServices(sshRemote, workspaceInfo.WorkingDirectoryPath, []string{serviceName})
+		if err != nil {
+			return -1, err
+		}
+		if len(containers) == 0 {
+			return -1, fmt.Errorf(i18nInstance.Exec.Err_container_none, serviceName)
+		}
+		containerName := strings.ReplaceAll(containers[len(containers)-1].ContainerName, "/", "")
+		sshClient, err := sshRemote.GetSSHClient().Client()
+		if err != nil {
+			return -1, err
+		}
+		dockerCommand := buildDockerExecCommand(containerName, command, userName, isInteractive, isTTY)
+		return common.RunSSHTerminalWithIO(sshClient, dockerCommand, isTTY, false, stdin, os.Stdout, os.Stderr)
+
+	case workspace.WorkingMode_K8s:
+		//2.3. k8s 模式，通过 kubectl exec 执行，service 为 pod 中的容器名称
+		k8sUtil, err := k8s.NewK8sUtil(workspaceInfo.K8sInfo.KubeConfigFilePath,
+			workspaceInfo.K8sInfo.Context,
+			workspaceInfo.K8sInfo.Namespace)
+		if err != nil {
+			return -1, err
+		}
+		k8sConfig := workspaceInfo.K8sInfo.TempK8sConfig
+		k8sConfig.Workspace.DevContainer.ServiceName = serviceName
+		pod, _, err := start.GetDevContainerPod(*k8sUtil, k8sConfig)
+		if err != nil {
+			return -1, err
+		}
+		if pod == nil {
+			return -1, fmt.Errorf(i18nInstance.Exec.Err_container_none, serviceName)
+		}
+		return k8sUtil.ExecuteCommandStreamInPod(*pod, serviceName, command, userName, isInteractive, isTTY, stdin, os.Stdout, os.Stderr)
+
+	default:
+		return -1, fmt.Errorf(i18nInstance.Exec.Err_workspace_mode_not_supported, workspaceInfo.Mode)
+	}
+}
+
+// 远程主机上执行的 docker exec 命令，参数需要转义
+func buildDockerExecCommand(containerName string, command []string, userName string, isInteractive bool, isTTY bool) string {
+	items := []string{"docker", "exec"}
+	if isInteractive {
+		items = append(items, "-i")
+	}
+	if isTTY {
+		items = append(items, "-t")
+	}
+	if userName != "" {
+		items = append(items, "-u", common.ShellQuote(userName))
+	}
+	items = append(items, common.ShellQuote(containerName))
+	for _, arg := range command {
+		items = append(items, common.ShellQuote(arg))
+	}
+	return strings.Join(items, " ")
+}
+
+func init() {
+	execCmd.Flags().StringP(flag_service, "", "", i18nInstance.Exec.Info_help_flag_service)
+	execCmd.Flags().StringP(flag_user, "u", "", i18nInstance.Exec.Info_help_flag_user)
+	execCmd.Flags().BoolP(flag_interactive, "i", false, i18nInstance.Exec.Info_help_flag_interactive)
+	execCmd.Flags().BoolP(flag_tty, "t", false, i18nInstance.Exec.Info_help_flag_tty)
+}
@@ -0,0 +1,45 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"testing"
+)
+
+func TestBuildDockerExecCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		command       []string
+		userName      string
+		isInteractive bool
+		isTTY         bool
+		want          string
+	}{
+		{"plain", []string{"make", "test"}, "", false, false, `docker exec 'dev' 'make' 'test'`},
+		{"user and tty", []string{"bash"}, "smartide", true, true, `docker exec -i -t -u 'smartide' 'dev' 'bash'`},
+		{"quote", []string{"sh", "-c", "echo 'a b'; exit 3"}, "", false, false, `docker exec 'dev' 'sh' '-c' 'echo '\''a b'\''; exit 3'`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildDockerExecCommand("dev", tt.command, tt.userName, tt.isInteractive, tt.isTTY); got != tt.want {
+				t.Errorf("buildDockerExecCommand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/internal/dal"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+var exportCmdFlags struct {
+	// 导出文件的路径
+	Output string
+
+	// 是否打包项目文件
+	IsIncludeProject bool
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: i18nInstance.Export.Info_help_short,
+	Long:  i18nInstance.Export.Info_help_long,
+	Example: `  smartide export <workspaceid>
+  smartide export <workspaceid> -o ws.tar.gz
+  smartide export <workspaceid> -o ws.tar.gz --include-project`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		//1. 工作区
+		workspaceId, err := strconv.Atoi(args[0])
+		common.CheckError(err)
+		workspaceInfo, err := dal.GetSingleWorkspace(workspaceId)
+		common.CheckError(err)
+		if workspaceInfo.IsNil() {
+			common.SmartIDELog.Error(fmt.Sprintf("根据ID（%v）未找到数据！", workspaceId))
+		}
+
+		//2. 导出
+		bundle, err := workspace.NewWorkspaceBundle(workspaceInfo)
+		common.CheckError(err)
+		outputFilePath := exportCmdFlags.Output
+		if outputFilePath == "" {
+			outputFilePath = workspaceInfo.Name + ".tar.gz"
+		}
+		projectDirPath := ""
+		if exportCmdFlags.IsIncludeProject {
+			if workspaceInfo.Mode == workspace.WorkingMode_Local {
+				projectDirPath = workspaceInfo.WorkingDirectoryPath
+			} else {
+				common.SmartIDELog.Warning(i18nInstance.Export.Warn_project_not_supported)
+			}
+		}
+		err = bundle.Export(outputFilePath, projectDirPath)
+		common.CheckError(err)
+
+		common.SmartIDELog.InfoF(i18nInstance.Export.Info_export_success, workspaceInfo.ID, outputFilePath)
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportCmdFlags.Output, "output", "o", "", i18nInstance.Export.Info_help_flag_output)
+	exportCmd.Flags().BoolVarP(&exportCmdFlags.IsIncludeProject, "include-project", "", false, i18nInstance.Export.Info_help_flag_include_project)
+}
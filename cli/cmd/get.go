@@ -33,7 +33,8 @@ var getCmd = &cobra.Command{
 	Short: i18nInstance.Get.Info_help_short,
 	Long:  i18nInstance.Get.Info_help_long,
 	Example: `  smartide get --workspaceid {workspaceid}
-  smartide get {workspaceid}`,
+  smartide get {workspaceid}
+  smartide get {workspaceid} -o json`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		/*	workspaceIdStr := getWorkspaceIdFromFlagsAndArgs(cmd, args)
@@ -53,14 +54,22 @@ var getCmd = &cobra.Command{
 			common.SmartIDELog.Error(fmt.Sprintf("根据ID（%v）未找到数据！", workspaceIdStr))
 		}
 
+		// json、yaml 格式
+		outputFormat, err := cmdCommon.GetOutputFormat(cmd)
+		common.CheckError(err)
+		if outputFormat.IsStructured() {
+			common.CheckError(cmdCommon.PrintStructured(outputFormat, workspaceInfo.ToView()))
+			return
+		}
+
 		// 打印
 		print := fmt.Sprintf(i18nInstance.Get.Info_workspace_detail_template,
 			workspaceInfo.ID, workspaceInfo.Name, workspaceInfo.CliRunningEnv, workspaceInfo.Mode, workspaceInfo.ConfigFileRelativePath, workspaceInfo.WorkingDirectoryPath,
 			workspaceInfo.GitCloneRepoUrl, workspaceInfo.GitRepoAuthType)
 		common.SmartIDELog.Console(print)
 
-		// 显示全部
-		if all, err := cmd.Flags().GetBool("all"); all && err == nil {
+		// 显示全部，wide 格式等同于 --all
+		if all, err := cmd.Flags().GetBool("all"); (all && err == nil) || outputFormat == cmdCommon.OutputFormatEnum_Wide {
 			// 端口绑定信息
 			if workspaceInfo.Extend.IsNotNil() {
 				w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
@@ -110,5 +119,6 @@ var getCmd = &cobra.Command{
 func init() {
 	getCmd.Flags().Int32P("workspaceid", "w", 0, i18nInstance.Get.Info_help_flag_workspaceid)
 	getCmd.Flags().BoolP("all", "a", false, i18nInstance.Get.Info_help_flag_all)
+	cmdCommon.AddOutputFlag(getCmd, i18nInstance.Main.Info_help_flag_output)
 
 }
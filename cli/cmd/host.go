@@ -30,6 +30,7 @@ var hostCmd = &cobra.Command{
 	Example: `  smartide host list
   smartide host get <hostid>
   smartide host add <host> --username <username> --password <password> --port <port>
+  smartide host import --pattern "build-*"
   smartide host remove <hostid>`,
 	Run: func(cmd *cobra.Command, args []string) {
 
@@ -41,4 +42,5 @@ func init() {
 	hostCmd.AddCommand(host.HostListCmd)
 	hostCmd.AddCommand(host.HostAddCmd)
 	hostCmd.AddCommand(host.HostRemoveCmd)
+	hostCmd.AddCommand(host.HostImportCmd)
 }
@@ -20,6 +20,8 @@ package host
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/leansoftX/smartide-cli/internal/apk/appinsight"
@@ -33,10 +35,12 @@ import (
 
 // initCmd represents the init command
 var HostAddCmd = &cobra.Command{
-	Use:     "add",
-	Short:   i18nInstance.Host.Info_help_host_add_short,
-	Long:    i18nInstance.Host.Info_help_host_add_long,
-	Example: `  smartide host add <host> --username <username> --password <password> --port <port>`,
+	Use:   "add",
+	Short: i18nInstance.Host.Info_help_host_add_short,
+	Long:  i18nInstance.Host.Info_help_host_add_long,
+	Example: `  smartide host add <host> --username <username> --password <password> --port <port>
+  smartide host add <host> --username <username> --identity-file ~/.ssh/id_ed25519
+  smartide host add <host> --username <username> --jump user@bastion:22`,
 	Run: func(cmd *cobra.Command, args []string) {
 		common.SmartIDELog.Info(i18nInstance.Host.Add_start)
 		appinsight.SetCliTrack(appinsight.Cli_Add_Host, args)
@@ -69,14 +73,26 @@ var HostAddCmd = &cobra.Command{
 		if fflags.Changed(flag_password) {
 			remoteInfo.Password = getFlagValue(fflags, flag_password)
 			remoteInfo.AuthType = workspace.RemoteAuthType_Password
+		} else if fflags.Changed(flag_identity_file) { // 指定的私钥文件，保存绝对路径
+			remoteInfo.IdentityFile, err = filepath.Abs(common.ExpandIdentityFilePath(getFlagValue(fflags, flag_identity_file)))
+			common.CheckError(err)
+			remoteInfo.AuthType = workspace.RemoteAuthType_SSH
+		} else if os.Getenv("SSH_AUTH_SOCK") != "" { // ssh-agent
+			remoteInfo.AuthType = workspace.RemoteAuthType_Agent
 		} else {
 			remoteInfo.AuthType = workspace.RemoteAuthType_SSH
 		}
+		// 跳板机
+		if fflags.Changed(flag_jump) {
+			remoteInfo.JumpHosts = strings.TrimSpace(getFlagValue(fflags, flag_jump))
+			_, err = common.ParseSSHJumpHosts(remoteInfo.JumpHosts, nil)
+			common.CheckError(err)
+		}
 		// 在远程模式下，首先验证远程服务器是否可以登录
 		ssmRemote := common.SSHRemote{}
 		common.SmartIDELog.InfoF(i18nInstance.Main.Info_ssh_connect_check, remoteInfo.Addr, remoteInfo.SSHPort)
 
-		err = ssmRemote.CheckDail(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName, remoteInfo.Password, "")
+		err = ssmRemote.CheckDail(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName, remoteInfo.Password, "", remoteInfo.IdentityFile, remoteInfo.JumpHosts)
 		if err != nil {
 			common.CheckError(err)
 		}
@@ -96,6 +112,8 @@ func init() {
 	HostAddCmd.Flags().StringP("username", "u", "", i18nInstance.Start.Info_help_flag_username)
 	HostAddCmd.Flags().StringP("password", "t", "", i18nInstance.Start.Info_help_flag_password)
 	HostAddCmd.Flags().IntP("port", "p", 22, i18nInstance.Start.Info_help_flag_port)
+	HostAddCmd.Flags().StringP("identity-file", "i", "", i18nInstance.Host.Info_help_flag_identity_file)
+	HostAddCmd.Flags().StringP("jump", "J", "", i18nInstance.Host.Info_help_flag_jump)
 }
 
 var (
@@ -103,6 +121,11 @@ var (
 	flag_port     = "port"
 	flag_username = "username"
 	flag_password = "password"
+
+	flag_identity_file = "identity-file"
+	flag_jump          = "jump"
+	flag_pattern       = "pattern"
+	flag_dry_run       = "dry-run"
 )
 
 // 检查参数是否填写
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strconv"
 
+	cmdCommon "github.com/leansoftX/smartide-cli/cmd/common"
 	"github.com/leansoftX/smartide-cli/internal/apk/i18n"
 	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
 	"github.com/leansoftX/smartide-cli/internal/dal"
@@ -39,7 +40,8 @@ var HostGetCmd = &cobra.Command{
 	Short: i18nInstance.Host.Info_help_get_short,
 	Long:  i18nInstance.Host.Info_help_get_long,
 	Example: ` smartide host get --hostid <hostid>
-  smartide host get <hostid>`,
+  smartide host get <hostid>
+  smartide host get <hostid> -o yaml`,
 	Run: func(cmd *cobra.Command, args []string) {
 
 		hostId := getHostIdFromFlagsAndArgs(cmd, args)
@@ -52,6 +54,14 @@ var HostGetCmd = &cobra.Command{
 		remoteInfo, err := dal.GetRemoteById(hostId)
 		entryptionKey4Host(*remoteInfo)
 		common.CheckError(err)
+
+		// json、yaml 格式
+		outputFormat, err := cmdCommon.GetOutputFormat(cmd)
+		common.CheckError(err)
+		if outputFormat.IsStructured() {
+			common.CheckError(cmdCommon.PrintStructured(outputFormat, remoteInfo.ToView()))
+			return
+		}
 		createTime := remoteInfo.CreatedTime.Format("2006-01-02 15:04:05")
 
 		print := fmt.Sprintf(i18nInstance.Host.Info_host_detail_template,
@@ -96,5 +106,6 @@ func getHostIdFromFlagsAndArgs(cmd *cobra.Command, args []string) int {
 
 func init() {
 	HostGetCmd.Flags().Int32P("hostid", "r", 0, i18nInstance.Host.Info_help_flag_hostid)
+	cmdCommon.AddOutputFlag(HostGetCmd, i18nInstance.Main.Info_help_flag_output)
 
 }
@@ -0,0 +1,118 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package host
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/internal/dal"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+// 从 ~/.ssh/config 导入主机
+var HostImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: i18nInstance.Host.Info_help_host_import_short,
+	Long:  i18nInstance.Host.Info_help_host_import_long,
+	Example: `  smartide host import
+  smartide host import --pattern "build-*"
+  smartide host import --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SmartIDELog.Info(i18nInstance.Host.Import_start)
+		fflags := cmd.Flags()
+		pattern := getFlagValue(fflags, flag_pattern)
+		isDryRun, _ := fflags.GetBool(flag_dry_run)
+
+		//1. 读取 ~/.ssh/config
+		configPath, err := workspace.GetSSHConfigFilePath()
+		common.CheckError(err)
+		file, err := os.Open(configPath)
+		common.CheckError(err)
+		defer file.Close()
+		hosts, err := workspace.LoadSSHConfigHosts(file, pattern)
+		common.CheckError(err)
+		if len(hosts) == 0 {
+			common.SmartIDELog.Info(fmt.Sprintf(i18nInstance.Host.Info_host_import_none, configPath))
+			return
+		}
+
+		//2. 已经存在的主机（地址 + 端口 + 用户名相同）不再导入
+		remotes, err := dal.GetRemoteList()
+		common.CheckError(err)
+		existKeys := map[string]bool{}
+		for _, remoteInfo := range remotes {
+			existKeys[getHostImportKey(remoteInfo)] = true
+		}
+
+		//3. 验证并导入
+		results := []string{}
+		importedCount := 0
+		for _, host := range hosts {
+			remoteInfo := host.Remote
+			key := getHostImportKey(remoteInfo)
+			var result string
+			if existKeys[key] {
+				result = i18nInstance.Host.Info_host_import_status_duplicate
+			} else if isDryRun {
+				result = i18nInstance.Host.Info_host_import_status_dry_run
+			} else {
+				common.SmartIDELog.InfoF(i18nInstance.Main.Info_ssh_connect_check, remoteInfo.Addr, remoteInfo.SSHPort)
+				connection, err := common.DialSSH(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName, "", "", remoteInfo.IdentityFile, remoteInfo.JumpHosts)
+				if err != nil {
+					common.SmartIDELog.Warning(fmt.Sprintf("%v: %v", host.Alias, err))
+					result = i18nInstance.Host.Info_host_import_status_failed
+				} else {
+					connection.Close() // 只验证能否连接，关闭时同时关闭跳板机的连接
+					hostId, err := dal.InsertOrUpdateRemote(remoteInfo)
+					common.CheckError(err)
+					result = fmt.Sprintf(i18nInstance.Host.Info_host_import_status_imported, hostId)
+					importedCount++
+				}
+			}
+			existKeys[key] = true
+			results = append(results, result)
+		}
+
+		//4. 输出
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+		fmt.Fprintln(w, i18nInstance.Host.Info_host_import_table_header)
+		for i, host := range hosts {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", host.Alias, host.Remote.Addr, host.Remote.SSHPort, host.Remote.UserName,
+				host.Remote.AuthType, host.Remote.JumpHosts, results[i])
+		}
+		w.Flush()
+		if !isDryRun {
+			common.SmartIDELog.Info(fmt.Sprintf(i18nInstance.Host.Info_host_import_success, importedCount, len(hosts)))
+		}
+	},
+}
+
+// 判断主机是否重复的 key，同一个地址上不同端口的 ssh 服务是不同的主机，e.g. root@192.168.1.2:22
+func getHostImportKey(remoteInfo workspace.RemoteInfo) string {
+	return fmt.Sprintf("%v@%v:%v", remoteInfo.UserName, remoteInfo.Addr, remoteInfo.SSHPort)
+}
+
+func init() {
+	HostImportCmd.Flags().String(flag_pattern, "", i18nInstance.Host.Info_help_flag_pattern)
+	HostImportCmd.Flags().Bool(flag_dry_run, false, i18nInstance.Host.Info_help_flag_dry_run)
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"text/tabwriter"
 
+	cmdCommon "github.com/leansoftX/smartide-cli/cmd/common"
 	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
 	"github.com/leansoftX/smartide-cli/internal/dal"
 	"github.com/leansoftX/smartide-cli/pkg/common"
@@ -36,32 +37,52 @@ var HostListCmd = &cobra.Command{
 	Long:    i18nInstance.Host.Info_help_list_long,
 	Aliases: []string{"ls"},
 	Example: `
-  smartide host list`,
+  smartide host list
+  smartide host list -o json`,
 	Run: func(cmd *cobra.Command, args []string) {
+		outputFormat, err := cmdCommon.GetOutputFormat(cmd)
+		common.CheckError(err)
 		list, err := dal.GetRemoteList()
 		common.CheckError(err)
-		printRemotes(list)
+		printRemotes(list, outputFormat)
 	},
 }
 
 // 打印 service 列表
-func printRemotes(remotes []workspace.RemoteInfo) {
+func printRemotes(remotes []workspace.RemoteInfo, outputFormat cmdCommon.OutputFormatEnum) {
+	// json、yaml 格式，即使没有数据也输出空数组
+	if outputFormat.IsStructured() {
+		views := []workspace.RemoteView{}
+		for _, remoteInfo := range remotes {
+			views = append(views, remoteInfo.ToView())
+		}
+		common.CheckError(cmdCommon.PrintStructured(outputFormat, views))
+		return
+	}
+
 	if len(remotes) <= 0 {
 		common.SmartIDELog.Info(i18nInstance.Common.Warn_dal_record_not_exit)
 		return
 	}
+	header := i18nInstance.Host.Info_host_table_header
+	if outputFormat == cmdCommon.OutputFormatEnum_Wide {
+		header += "\tUser Name\tAuth Type"
+	}
 	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
-	fmt.Fprintln(w, i18nInstance.Host.Info_host_table_header)
+	fmt.Fprintln(w, header)
 	for _, remoteInfo := range remotes {
 		entryptionKey4Host(remoteInfo)
 
 		createTime := remoteInfo.CreatedTime.Format("2006-01-02 15:04:05")
 		line := fmt.Sprintf("%v\t%v\t%v\t%v", remoteInfo.ID, remoteInfo.Addr, remoteInfo.SSHPort, createTime)
+		if outputFormat == cmdCommon.OutputFormatEnum_Wide {
+			line += fmt.Sprintf("\t%v\t%v", remoteInfo.UserName, remoteInfo.AuthType)
+		}
 		fmt.Fprintln(w, line)
 	}
 	w.Flush()
 }
 
 func init() {
-
+	cmdCommon.AddOutputFlag(HostListCmd, i18nInstance.Main.Info_help_flag_output)
 }
@@ -0,0 +1,113 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/internal/dal"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+var importCmdFlags struct {
+	// 本地模式时项目文件的目录
+	Directory string
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: i18nInstance.Import.Info_help_short,
+	Long:  i18nInstance.Import.Info_help_long,
+	Example: `  smartide import ws.tar.gz
+  smartide import ws.tar.gz --dir ~/projects/boathouse`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		bundleFilePath := args[0]
+
+		//1. 读取工作区信息
+		bundle, err := workspace.ReadWorkspaceBundle(bundleFilePath, "")
+		common.CheckError(err)
+
+		//2. 工作目录
+		workingDirectoryPath := ""
+		if bundle.Mode == workspace.WorkingMode_Local {
+			workingDirectoryPath = importCmdFlags.Directory
+			if workingDirectoryPath == "" {
+				workingDirectoryPath, err = getImportDirectoryName(bundle.Name)
+				common.CheckError(err)
+			}
+			workingDirectoryPath, err = filepath.Abs(workingDirectoryPath)
+			common.CheckError(err)
+
+			if bundle.IsIncludeProject { // 解压项目文件
+				if entries, err := os.ReadDir(workingDirectoryPath); err == nil && len(entries) > 0 {
+					common.SmartIDELog.Error(fmt.Sprintf(i18nInstance.Import.Err_directory_not_empty, workingDirectoryPath))
+				}
+				err = os.MkdirAll(workingDirectoryPath, os.ModePerm)
+				common.CheckError(err)
+				_, err = workspace.ReadWorkspaceBundle(bundleFilePath, workingDirectoryPath)
+				common.CheckError(err)
+			} else if !common.IsExist(workingDirectoryPath) {
+				common.SmartIDELog.WarningF(i18nInstance.Import.Warn_project_none, workingDirectoryPath)
+			}
+		}
+		workspaceInfo, err := bundle.ToWorkspaceInfo(workingDirectoryPath)
+		common.CheckError(err)
+
+		//3. 远程主机需要已经添加到本地
+		if bundle.Mode == workspace.WorkingMode_Remote {
+			remoteInfo, err := dal.GetRemoteByHost(bundle.Remote.Addr, bundle.Remote.UserName)
+			common.CheckError(err)
+			if remoteInfo == nil {
+				common.SmartIDELog.Error(fmt.Sprintf(i18nInstance.Import.Err_host_not_exist, bundle.Remote.UserName, bundle.Remote.Addr))
+			}
+			workspaceInfo.Remote = *remoteInfo
+		}
+
+		//4. 保存
+		workspaceId, err := dal.InsertOrUpdateWorkspace(workspaceInfo)
+		common.CheckError(err)
+		if workspaceInfo.Mode == workspace.WorkingMode_Local && common.IsExist(workingDirectoryPath) {
+			err = workspaceInfo.SaveTempFiles()
+			common.CheckError(err)
+		}
+
+		common.SmartIDELog.InfoF(i18nInstance.Import.Info_import_success, workspaceId, workspaceId)
+	},
+}
+
+// 没有指定目录时使用工作区名称作为目录名，名称来自导出文件，不能包含路径（比如 ../x、/x）
+func getImportDirectoryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || filepath.IsAbs(name) ||
+		filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf(i18nInstance.Import.Err_bundle_name_invalid, name)
+	}
+	return name, nil
+}
+
+func init() {
+	// -d 已经被全局的 --debug 使用
+	importCmd.Flags().StringVar(&importCmdFlags.Directory, "dir", "", i18nInstance.Import.Info_help_flag_dir)
+}
@@ -0,0 +1,50 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"testing"
+)
+
+func TestGetImportDirectoryName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"boathouse", "boathouse", false},
+		{" boathouse ", "boathouse", false},
+		{"", "", true},
+		{"..", "", true},
+		{"../../x", "", true},
+		{"/tmp/x", "", true},
+		{`..\x`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getImportDirectoryName(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getImportDirectoryName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getImportDirectoryName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -44,6 +44,12 @@ func InitLocalConfig(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	// 有 devcontainer.json 时直接使用，不需要初始化模板
+	if devContainerJsonFilePath := config.GetLocalDevContainerJsonRelativeFilePath(""); devContainerJsonFilePath != "" {
+		common.SmartIDELog.InfoF("使用 %v 作为配置文件", devContainerJsonFilePath)
+		return
+	}
+
 	if !hasIdeConfigYaml {
 		//获取command中的配置
 		selectedTemplateType, err := getTemplateSetting(cmd, args)
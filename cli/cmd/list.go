@@ -26,6 +26,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	cmdCommon "github.com/leansoftX/smartide-cli/cmd/common"
 	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
 	"github.com/leansoftX/smartide-cli/internal/dal"
 	"github.com/leansoftX/smartide-cli/internal/model"
@@ -39,21 +40,29 @@ var listCmd = &cobra.Command{
 	Short:   i18nInstance.List.Info_help_short,
 	Long:    i18nInstance.List.Info_help_long,
 	Aliases: []string{"ls"},
-	Example: `  smartide list`,
+	Example: `  smartide list
+  smartide list -o wide
+  smartide list -o json`,
 	Run: func(cmd *cobra.Command, args []string) {
+		outputFormat, err := cmdCommon.GetOutputFormat(cmd)
+		common.CheckError(err)
 
-		common.SmartIDELog.Info(i18nInstance.List.Info_start)
+		if !outputFormat.IsStructured() {
+			common.SmartIDELog.Info(i18nInstance.List.Info_start)
+		}
 		cliRunningEnv := workspace.CliRunningEnvEnum_Client
 		if value, _ := cmd.Flags().GetString("mode"); strings.ToLower(value) == "server" {
 			cliRunningEnv = workspace.CliRunningEvnEnum_Server
 		}
-		printWorkspaces(cliRunningEnv)
-		common.SmartIDELog.Info(i18nInstance.List.Info_end)
+		printWorkspaces(cliRunningEnv, outputFormat)
+		if !outputFormat.IsStructured() {
+			common.SmartIDELog.Info(i18nInstance.List.Info_end)
+		}
 	},
 }
 
 // 打印 service 列表
-func printWorkspaces(cliRunningEnv workspace.CliRunningEvnEnum) {
+func printWorkspaces(cliRunningEnv workspace.CliRunningEvnEnum, outputFormat cmdCommon.OutputFormatEnum) {
 	workspaceInfos, err := dal.GetWorkspaceList()
 	common.CheckError(err)
 
@@ -69,15 +78,30 @@ func printWorkspaces(cliRunningEnv workspace.CliRunningEvnEnum) {
 			workspaceInfos = append(workspaceInfos, serverWorkSpaces...)
 		}
 	}
+
+	// json、yaml 格式，即使没有数据也输出空数组
+	if outputFormat.IsStructured() {
+		views := []workspace.WorkspaceView{}
+		for _, workspaceInfo := range workspaceInfos {
+			views = append(views, workspaceInfo.ToView())
+		}
+		common.CheckError(cmdCommon.PrintStructured(outputFormat, views))
+		return
+	}
+
 	if len(workspaceInfos) <= 0 {
 		common.SmartIDELog.Info(i18nInstance.List.Info_dal_none)
 		return
 	}
+	header := i18nInstance.List.Info_workspace_list_header
+	if outputFormat == cmdCommon.OutputFormatEnum_Wide {
+		header += "\tWorking Directory\tGit Auth Type\tPorts"
+	}
 	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
-	fmt.Fprintln(w, i18nInstance.List.Info_workspace_list_header)
+	fmt.Fprintln(w, header)
 
 	// 等于标题字符的长度
-	tmpArray := strings.Split(i18nInstance.List.Info_workspace_list_header, "\t")
+	tmpArray := strings.Split(header, "\t")
 	outputArray := []string{}
 	for _, str := range tmpArray {
 		chars := ""
@@ -127,11 +151,26 @@ func printWorkspaces(cliRunningEnv workspace.CliRunningEvnEnum) {
 
 		line := fmt.Sprintf("%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v", worksapceInfo.ID, workspaceName, worksapceInfo.Mode,
 			worksapceInfo.GitCloneRepoUrl, gitBranch, configFile, host, createTime)
+		if outputFormat == cmdCommon.OutputFormatEnum_Wide {
+			ports := []string{}
+			for _, port := range worksapceInfo.Extend.Ports {
+				ports = append(ports, fmt.Sprintf("%v->%v", port.CurrentHostPort, port.ContainerPort))
+			}
+			portsStr := strings.Join(ports, ",")
+			if len(portsStr) <= 0 {
+				portsStr = "-"
+			}
+			gitAuthType := string(worksapceInfo.GitRepoAuthType)
+			if len(gitAuthType) <= 0 {
+				gitAuthType = "-"
+			}
+			line += fmt.Sprintf("\t%v\t%v\t%v", dir, gitAuthType, portsStr)
+		}
 		fmt.Fprintln(w, line)
 	}
 	w.Flush()
 }
 
 func init() {
-
+	cmdCommon.AddOutputFlag(listCmd, i18nInstance.Main.Info_help_flag_output)
 }
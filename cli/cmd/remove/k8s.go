@@ -23,6 +23,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/leansoftX/smartide-cli/cmd/start"
 	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
 	"github.com/leansoftX/smartide-cli/pkg/common"
 	"github.com/leansoftX/smartide-cli/pkg/k8s"
@@ -32,6 +33,9 @@ import (
 // 删除k8s资源
 func RemoveK8s(k8sUtil k8s.KubernetesUtil, workspaceInfo workspace.WorkspaceInfo) error {
 
+	// 生命周期：pre-stop
+	start.RunK8sPreStopHooks(k8sUtil, workspaceInfo)
+
 	// 移除k8s资源
 	common.SmartIDELog.Info("移除k8s资源...")
 
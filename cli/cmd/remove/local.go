@@ -68,16 +68,12 @@ func RemoveLocal(workspaceInfo workspace.WorkspaceInfo, isRemoveAllComposeImages
 
 	// docker-compose 删除容器
 	if len(containers) > 0 {
+		start.RunLocalPreStopHooks(ctx, cli, workspaceInfo) // 生命周期：pre-stop
 		common.SmartIDELog.Info(i18nInstance.Remove.Info_docker_removing)
-		composeCmd := exec.Command("docker-compose", "-f", workspaceInfo.TempYamlFileAbsolutePath, "--project-directory", workspaceInfo.WorkingDirectoryPath, "down", "-v")
-		composeCmd.Stdout = os.Stdout
-		composeCmd.Stderr = os.Stderr
-		if composeCmdErr := composeCmd.Run(); composeCmdErr != nil {
-			//common.SmartIDELog.Fatal(composeCmdErr)
-			if composeCmdErr != nil {
-				return composeCmdErr
-			}
-
+		err = start.ExecuteLocalCompose(ctx, cli, workspaceInfo.TempDockerCompose,
+			workspaceInfo.TempYamlFileAbsolutePath, workspaceInfo.WorkingDirectoryPath, start.ComposeActionEnum_Down)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -34,7 +34,7 @@ func RemoveRemote(workspaceInfo workspace.WorkspaceInfo,
 	// ssh 连接
 	common.SmartIDELog.Info(i18nInstance.Remove.Info_sshremote_connection_creating)
 
-	sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey)
+	sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey, workspaceInfo.Remote.IdentityFile, workspaceInfo.Remote.JumpHosts)
 	if err != nil {
 		return err
 	}
@@ -70,12 +70,16 @@ func RemoveRemote(workspaceInfo workspace.WorkspaceInfo,
 		common.SmartIDELog.Importance(i18nInstance.Start.Warn_docker_container_getnone)
 	}
 
+	// 生命周期：pre-stop
+	if len(containers) > 0 {
+		start.RunRemotePreStopHooks(sshRemote, workspaceInfo)
+	}
+
 	// 远程主机上执行 docker-compose 删除容器
 	//	if len(containers) > 0 {
 	common.SmartIDELog.Info(i18nInstance.Remove.Info_docker_removing)
-	command := fmt.Sprintf(`docker-compose -f %v --project-directory %v down -v`,
-		common.FilePahtJoin4Linux(workspaceInfo.TempYamlFileAbsolutePath), common.FilePahtJoin4Linux(workspaceInfo.WorkingDirectoryPath))
-	err = sshRemote.ExecSSHCommandRealTime(command)
+	err = start.ExecuteRemoteCompose(sshRemote, workspaceInfo.TempDockerCompose,
+		workspaceInfo.TempYamlFileAbsolutePath, workspaceInfo.WorkingDirectoryPath, start.ComposeActionEnum_Down)
 	if err != nil {
 		return err
 	}
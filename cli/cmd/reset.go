@@ -25,6 +25,7 @@ import (
 	"strings"
 	"time"
 
+	cmdCommon "github.com/leansoftX/smartide-cli/cmd/common"
 	"github.com/leansoftX/smartide-cli/cmd/remove"
 	"github.com/leansoftX/smartide-cli/internal/apk/appinsight"
 	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
@@ -72,7 +73,7 @@ var resetCmd = &cobra.Command{
 		if value, _ := cmd.Flags().GetString("mode"); strings.ToLower(value) == "server" {
 			cliRunningEnv = workspace.CliRunningEvnEnum_Server
 		}
-		printWorkspaces(cliRunningEnv)
+		printWorkspaces(cliRunningEnv, cmdCommon.OutputFormatEnum_Table)
 
 		// 逐个删除工作区
 		common.SmartIDELog.Info(i18nInstance.Reset.Info_workspace_remove_all)
@@ -88,7 +89,7 @@ var resetCmd = &cobra.Command{
 				ssmRemote := common.SSHRemote{}
 
 				common.SmartIDELog.InfoF(i18nInstance.Main.Info_ssh_connect_check, workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort)
-				err = ssmRemote.CheckDail(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey)
+				err = ssmRemote.CheckDail(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey, workspaceInfo.Remote.IdentityFile, workspaceInfo.Remote.JumpHosts)
 				if err != nil {
 					if resetCmdFalgs.IsAll { // 删除所有的时候，不顾及太多
 						common.SmartIDELog.ImportanceWithError(err)
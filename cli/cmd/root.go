@@ -120,6 +120,9 @@ var rootCmd = &cobra.Command{
 		common.ServerToken, _ = fflags.GetString(serverToken)
 		common.ServerUserGuid, _ = fflags.GetString(serverUserGuid)
 		common.Mode, _ = fflags.GetString(serverMode)
+		common.SSHHostKeyFingerprint, _ = fflags.GetString("host-key-fingerprint")
+		common.SSHCommandTimeout, _ = fflags.GetDuration("ssh-timeout")
+		config.ConfigOverlayFiles, _ = fflags.GetStringArray("config-overlay")
 
 		// 加密
 		servertoken, _ := fflags.GetString("servertoken")
@@ -158,6 +161,9 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&isDebug, "debug", "d", false, i18n.GetInstance().Main.Info_help_flag_debug)
 	rootCmd.PersistentFlags().StringP("mode", "m", string(model.RuntimeModeEnum_Client), i18n.GetInstance().Main.Info_help_flag_mode)
 	rootCmd.PersistentFlags().StringP("isInsightEnabled", "", "true", "在mode = server|pipeline 模式下是否启用“收集部分运行信息用于改进产品”")
+	rootCmd.PersistentFlags().String("host-key-fingerprint", "", i18n.GetInstance().Main.Info_help_flag_host_key_fingerprint)
+	rootCmd.PersistentFlags().Duration("ssh-timeout", 0, i18n.GetInstance().Main.Info_help_flag_ssh_timeout)
+	rootCmd.PersistentFlags().StringArray("config-overlay", []string{}, i18n.GetInstance().Main.Info_help_flag_config_overlay)
 
 	rootCmd.PersistentFlags().StringP("serverworkspaceid", "", "", i18n.GetInstance().Main.Info_help_flag_server_workspace_id)
 	rootCmd.PersistentFlags().StringP("servertoken", "", "", i18n.GetInstance().Main.Info_help_flag_server_token)
@@ -189,10 +195,16 @@ func init() {
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(getCmd)
 	rootCmd.AddCommand(hostCmd)
+	rootCmd.AddCommand(tunnelCmd)
+	rootCmd.AddCommand(sshCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
 
 	rootCmd.AddCommand(resetCmd)
 	rootCmd.AddCommand(udpateCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(dbCmd)
 
 	rootCmd.AddCommand(loginCmd)
 	rootCmd.AddCommand(logoutCmd)
@@ -0,0 +1,65 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// 所有命令的参数与全局参数合并时不能冲突，冲突的简写会在运行时 panic，同名的参数会覆盖全局参数
+func TestCommandFlags(t *testing.T) {
+	var check func(cmd *cobra.Command)
+	check = func(cmd *cobra.Command) {
+		t.Run(cmd.CommandPath(), func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("merge flags: %v", r)
+				}
+			}()
+			if err := cmd.ParseFlags([]string{}); err != nil {
+				t.Fatalf("parse flags: %v", err)
+			}
+			cmd.LocalNonPersistentFlags().VisitAll(func(flag *pflag.Flag) {
+				cmd.VisitParents(func(parent *cobra.Command) {
+					if parent.PersistentFlags().Lookup(flag.Name) != nil {
+						t.Errorf("flag --%v shadows the persistent flag of %v", flag.Name, parent.CommandPath())
+					}
+					if flag.Shorthand != "" && parent.PersistentFlags().ShorthandLookup(flag.Shorthand) != nil {
+						t.Errorf("flag -%v shadows the persistent flag of %v", flag.Shorthand, parent.CommandPath())
+					}
+				})
+			})
+		})
+		for _, child := range cmd.Commands() {
+			check(child)
+		}
+	}
+	check(rootCmd)
+
+	defer importCmd.Flags().Set("dir", "")
+	if err := importCmd.ParseFlags([]string{"--dir", "x"}); err != nil {
+		t.Fatalf("parse import flags: %v", err)
+	}
+	if importCmdFlags.Directory != "x" {
+		t.Errorf("import --dir = %v, want x", importCmdFlags.Directory)
+	}
+}
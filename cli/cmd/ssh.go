@@ -0,0 +1,164 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/internal/dal"
+	"github.com/leansoftX/smartide-cli/internal/model"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/k8s"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+)
+
+// k8s 模式下等待 kubectl port-forward 就绪的时间
+const sshK8sPortForwardTimeout = time.Second * 30
+
+// sshCmd represents the ssh command
+var sshCmd = &cobra.Command{
+	Use:   "ssh",
+	Short: i18nInstance.Ssh.Info_help_short,
+	Long:  i18nInstance.Ssh.Info_help_long,
+	Example: `  smartide ssh <workspaceid>
+  smartide ssh <workspaceid> -- ls -la
+  smartide ssh <workspaceid> -t -- htop
+  smartide ssh <workspaceid> -A -- git pull`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		isTTY, _ := cmd.Flags().GetBool(flag_tty)
+		isForwardAgent, _ := cmd.Flags().GetBool(flag_forward_agent)
+		command := strings.Join(args[1:], " ") // 与 ssh 一致，-- 之后的参数使用空格拼接
+
+		exitCode, err := sshWorkspace(args[0], command, isTTY, isForwardAgent)
+		common.CheckError(err)
+		os.Exit(exitCode)
+	},
+}
+
+const (
+	flag_tty           = "tty"
+	flag_forward_agent = "forward-agent"
+)
+
+// 连接到工作区开发容器的 ssh 服务，返回远程命令的退出码
+func sshWorkspace(workspaceId string, command string, isTTY bool, isForwardAgent bool) (int, error) {
+	//1. 工作区信息
+	workspaceInfo, err := getCachedWorkspace(workspaceId)
+	if err != nil {
+		return -1, err
+	}
+
+	//2. 开发容器的 ssh 端口，以及登录信息
+	sshPortMap, err := workspaceInfo.Extend.Ports.Find(model.CONST_DevContainer_PortDesc_SSH)
+	if err != nil {
+		return -1, fmt.Errorf(i18nInstance.Ssh.Err_ssh_port_none, workspaceId)
+	}
+	password := workspaceInfo.TempDockerCompose.GetSSHPassword(workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName)
+	if password == "" {
+		password = model.CONST_DEV_CONTAINER_USER_DEFAULT_PASSWORD
+	}
+
+	//3. 连接
+	var client *ssh.Client
+	switch workspaceInfo.Mode {
+	case workspace.WorkingMode_Local:
+		//3.1. 本地模式，容器的 ssh 端口绑定在本机
+		addr := fmt.Sprintf("localhost:%v", sshPortMap.CurrentHostPort)
+		common.SmartIDELog.Debug(fmt.Sprintf(i18nInstance.Ssh.Info_connecting, addr))
+		client, err = common.DialSSHThrough(net.Dial, addr, model.CONST_DEV_CONTAINER_CUSTOM_USER, password)
+		if err != nil {
+			return -1, err
+		}
+
+	case workspace.WorkingMode_Remote:
+		//3.2. 远程主机模式，先连接到远程主机，再连接到远程主机上绑定的容器端口
+		sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey, workspaceInfo.Remote.IdentityFile, workspaceInfo.Remote.JumpHosts)
+		if err != nil {
+			return -1, err
+		}
+		defer sshRemote.GetSSHClient().Close()
+		addr := fmt.Sprintf("localhost:%v", sshPortMap.CurrentHostPort)
+		common.SmartIDELog.Debug(fmt.Sprintf(i18nInstance.Ssh.Info_connecting, addr))
+		client, err = common.DialSSHThrough(sshRemote.GetSSHClient().Dial, addr, model.CONST_DEV_CONTAINER_CUSTOM_USER, password)
+		if err != nil {
+			return -1, err
+		}
+
+	case workspace.WorkingMode_K8s:
+		//3.3. k8s 模式，通过 kubectl port-forward 转发 pod 的 ssh 端口到本机的空闲端口
+		k8sUtil, err := k8s.NewK8sUtil(workspaceInfo.K8sInfo.KubeConfigFilePath,
+			workspaceInfo.K8sInfo.Context,
+			workspaceInfo.K8sInfo.Namespace)
+		if err != nil {
+			return -1, err
+		}
+		localPort, err := common.GetAvailablePort()
+		if err != nil {
+			return -1, err
+		}
+		portForward, err := k8sUtil.StartPortForward(fmt.Sprintf("svc/%v", sshPortMap.ServiceName), localPort, sshPortMap.CurrentHostPort, sshK8sPortForwardTimeout)
+		if err != nil {
+			return -1, err
+		}
+		defer portForward.Process.Kill()
+		addr := fmt.Sprintf("localhost:%v", localPort)
+		common.SmartIDELog.Debug(fmt.Sprintf(i18nInstance.Ssh.Info_connecting, addr))
+		client, err = common.DialSSHThrough(net.Dial, addr, model.CONST_DEV_CONTAINER_CUSTOM_USER, password)
+		if err != nil {
+			return -1, err
+		}
+
+	default:
+		return -1, fmt.Errorf(i18nInstance.Ssh.Err_workspace_mode_not_supported, workspaceInfo.Mode)
+	}
+	defer client.Close()
+
+	//4. 打开 shell 或者执行命令
+	return common.RunSSHTerminal(client, command, isTTY, isForwardAgent)
+}
+
+// 获取缓存在本地的工作区
+func getCachedWorkspace(workspaceId string) (workspaceInfo workspace.WorkspaceInfo, err error) {
+	id, err := strconv.Atoi(workspaceId)
+	if err != nil {
+		return workspaceInfo, fmt.Errorf("invalid workspace id %v", workspaceId)
+	}
+	workspaceInfo, err = dal.GetSingleWorkspace(id)
+	if err != nil {
+		return workspaceInfo, err
+	}
+	if workspaceInfo.IsNil() {
+		return workspaceInfo, errors.New(i18nInstance.Main.Err_workspace_none)
+	}
+	return workspaceInfo, nil
+}
+
+func init() {
+	sshCmd.Flags().BoolP(flag_tty, "t", false, i18nInstance.Ssh.Info_help_flag_tty)
+	sshCmd.Flags().BoolP(flag_forward_agent, "A", false, i18nInstance.Ssh.Info_help_flag_forward_agent)
+}
@@ -112,6 +112,10 @@ var startCmd = &cobra.Command{
 		})
 
 		isUnforward, _ := cmd.Flags().GetBool("unforward")
+		syncDir, _ := cmd.Flags().GetString(flag_sync)
+		if syncDir != "" && workspaceInfo.Mode != workspace.WorkingMode_Remote { // 只支持远程主机模式
+			return errors.New(i18nInstance.Start.Err_sync_remote_only)
+		}
 
 		executeStartCmdFunc := func(yamlConfig config.SmartIdeConfig, workspaceInfo workspace.WorkspaceInfo, cmdtype, userguid, workspaceid string) {
 			var imageNames []string
@@ -252,8 +256,8 @@ var startCmd = &cobra.Command{
 		if workspaceInfo.ConfigYaml.Workspace.DevContainer.IdeType == config.IdeTypeEnum_SDKOnly {
 			common.SmartIDELog.Info("当前IDE环境没有提供WebIDE入口，请使用ssh连接工作区")
 		}
-		//99.2. 死循环进行驻守，允许端口转发 && 是在本地运行
-		if !isUnforward && workspaceInfo.CliRunningEnv == workspace.CliRunningEnvEnum_Client {
+		//99.2. 死循环进行驻守，（允许端口转发 || 同步文件） && 是在本地运行
+		if (!isUnforward || syncDir != "") && workspaceInfo.CliRunningEnv == workspace.CliRunningEnvEnum_Client {
 			for {
 				time.Sleep(time.Millisecond * 300)
 			}
@@ -305,6 +309,7 @@ var (
 	flag_k8s         = "k8s"
 	flag_kubeconfig  = "kubeconfig"
 	flag_gitpassword = "gitpassword"
+	flag_sync        = "sync"
 )
 
 func entryptionKey4Workspace(workspaceInfo workspace.WorkspaceInfo) {
@@ -334,6 +339,7 @@ func init() {
 	startCmd.Flags().IntP("port", "p", 22, i18nInstance.Start.Info_help_flag_port)
 	startCmd.Flags().StringP("username", "u", "", i18nInstance.Start.Info_help_flag_username)
 	startCmd.Flags().StringP("password", "t", "", i18nInstance.Start.Info_help_flag_password)
+	startCmd.Flags().StringP("sync", "", "", i18nInstance.Start.Info_help_flag_sync)
 
 	startCmd.Flags().StringP("repourl", "r", "", i18nInstance.Start.Info_help_flag_repourl)
 	startCmd.Flags().StringP("branch", "b", "", i18nInstance.Start.Info_help_flag_branch)
@@ -0,0 +1,144 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package start
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+)
+
+// compose 操作
+type ComposeActionEnum string
+
+const (
+	ComposeActionEnum_Up   ComposeActionEnum = "up"   // docker compose up -d
+	ComposeActionEnum_Stop ComposeActionEnum = "stop" // docker compose stop
+	ComposeActionEnum_Down ComposeActionEnum = "down" // docker compose down -v
+)
+
+// 在本地执行 compose 操作
+func ExecuteLocalCompose(ctx context.Context, cli *client.Client, dockerCompose compose.DockerComposeYml,
+	composeFilePath string, projectDirectory string, action ComposeActionEnum) error {
+	project := compose.NewProject(cli, dockerCompose, projectDirectory, composeFilePath)
+	return executeComposeAction(ctx, project, action)
+}
+
+// 在远程主机上执行 compose 操作
+// 优先通过 ssh 连接访问 docker api，无法访问时（比如用户没有 docker.sock 的权限）使用 docker compose 命令行
+func ExecuteRemoteCompose(sshRemote common.SSHRemote, dockerCompose compose.DockerComposeYml,
+	composeFilePath string, projectDirectory string, action ComposeActionEnum) error {
+	ctx := context.Background()
+	composeFilePath, projectDirectory = common.FilePahtJoin4Linux(composeFilePath), common.FilePahtJoin4Linux(projectDirectory)
+
+	cli, err := sshRemote.NewDockerClient(ctx)
+	if err != nil {
+		common.SmartIDELog.Debug(fmt.Sprintf("无法通过 ssh 访问 docker api: %v", err))
+		return executeRemoteComposeCli(sshRemote, composeFilePath, projectDirectory, action)
+	}
+	defer cli.Close()
+
+	//1. 远程主机的环境
+	project := compose.NewProject(cli, dockerCompose, projectDirectory, composeFilePath)
+	homeDir, err := sshRemote.GetRemoteHome()
+	if err != nil {
+		return err
+	}
+	project.HomeDir = strings.TrimSpace(homeDir)
+	project.Environment = map[string]string{"HOME": project.HomeDir, "USER": sshRemote.SSHUserName}
+	project.ReadFile = func(filePath string) ([]byte, error) {
+		output, err := sshRemote.ExeSSHCommand("cat " + common.ShellQuote(filePath))
+		return []byte(output), err
+	}
+
+	//2. 构建上下文在远程主机上，直接在远程主机上构建，使用 BuildKit 并保存缓存信息
+	project.ImageBuilder = func(ctx context.Context, serviceName string, service compose.Service, imageName string, contextDir string) error {
+		command := fmt.Sprintf("DOCKER_BUILDKIT=1 docker build -t %v", common.ShellQuote(imageName))
+		if service.Build.Dockerfile != "" {
+			command += fmt.Sprintf(" -f %v", common.ShellQuote(common.FilePahtJoin4Linux(contextDir, service.Build.Dockerfile)))
+		}
+		if service.Build.Target != "" {
+			command += fmt.Sprintf(" --target %v", common.ShellQuote(service.Build.Target))
+		}
+		if service.Platform != "" {
+			command += fmt.Sprintf(" --platform %v", common.ShellQuote(service.Platform))
+		}
+		command += " --build-arg BUILDKIT_INLINE_CACHE=1"
+		for key, value := range service.Build.Args {
+			command += fmt.Sprintf(" --build-arg %v", common.ShellQuote(fmt.Sprintf("%v=%v", key, value)))
+		}
+		for key, value := range service.Build.Labels {
+			command += fmt.Sprintf(" --label %v", common.ShellQuote(fmt.Sprintf("%v=%v", key, value)))
+		}
+		for _, cacheFrom := range service.Build.CacheFrom {
+			image := cacheFrom.Name
+			if cacheFrom.Tag != "" {
+				image += ":" + cacheFrom.Tag
+			}
+			command += fmt.Sprintf(" --cache-from %v", common.ShellQuote(image))
+		}
+		command += " " + common.ShellQuote(contextDir)
+		common.SmartIDELog.Debug(command)
+		return sshRemote.ExecSSHCommandRealTime(command)
+	}
+	//2.1. 构建上下文的 hash 值在远程主机上计算，与本地一致忽略 .dockerignore 中的文件，不包含 .git 目录
+	project.BuildContextHasher = func(ctx context.Context, contextDir string) (string, error) {
+		return compose.HashRemoteBuildContext(contextDir, func(command string) (string, error) {
+			return sshRemote.ExeSSHCommandContext(ctx, command)
+		})
+	}
+
+	return executeComposeAction(ctx, project, action)
+}
+
+// 使用远程主机上的 compose 命令行工具，兼容 docker compose (v2) 和 docker-compose (v1)
+func executeRemoteComposeCli(sshRemote common.SSHRemote, composeFilePath string, projectDirectory string, action ComposeActionEnum) error {
+	composeCli, err := compose.GetComposeCli(sshRemote.ExeSSHCommand)
+	if err != nil {
+		return err
+	}
+
+	args := []string{string(action)}
+	switch action {
+	case ComposeActionEnum_Up:
+		args = append(args, "-d")
+	case ComposeActionEnum_Down:
+		args = append(args, "-v")
+	}
+	command := compose.GetComposeCliCommand(composeCli, composeFilePath, projectDirectory, args...)
+	common.SmartIDELog.Debug(command)
+	return sshRemote.ExecSSHCommandRealTime(command)
+}
+
+// 执行 compose 操作
+func executeComposeAction(ctx context.Context, project *compose.Project, action ComposeActionEnum) error {
+	switch action {
+	case ComposeActionEnum_Up:
+		return project.Up(ctx)
+	case ComposeActionEnum_Stop:
+		return project.Stop(ctx)
+	case ComposeActionEnum_Down:
+		return project.Down(ctx, true)
+	}
+	return fmt.Errorf("unknown compose action %v", action)
+}
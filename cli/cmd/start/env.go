@@ -67,6 +67,20 @@ func GetLocalContainersWithServices(ctx context.Context, cli *client.Client,
 	return dockerComposeContainers
 }
 
+// 本地工作区中 service 对应的容器名称，不输出容器列表，容器没有运行时返回空
+func GetLocalServiceContainerName(ctx context.Context, cli *client.Client, workingDir string, serviceName string) (string, error) {
+	if workingDir[0:1] == "~" {
+		homeDir, _ := os.UserHomeDir()
+		workingDir = filepath.Join(homeDir, workingDir[1:])
+	}
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{})
+	if err != nil {
+		return "", err
+	}
+	dockerComposeContainers := convertOriginContainer(containers, workingDir, []string{serviceName})
+	return getDevContainerName(dockerComposeContainers, serviceName), nil
+}
+
 // 检测远程服务器的环境，是否安装docker、docker-compose、git
 func GetRemoteContainersWithServices(sshRemote common.SSHRemote,
 	workingDir string, dockerComposeServices []string) (dockerComposeContainers []DockerComposeContainer, err error) {
@@ -130,12 +130,32 @@ func ExecuteK8sStartCmd(cmd *cobra.Command, k8sUtil k8s.KubernetesUtil,
 		yamlExecuteFun(*originK8sConfig, workspaceInfo, appinsight.Cli_K8s_Start, "", workspaceInfo.ID)
 	}
 
+	//1.3. 通过 Dockerfile 构建开发容器的镜像
+	workspaceName := workspaceInfo.Name
+	if workspaceInfo.GitCloneRepoUrl != "" {
+		workspaceName = common.GetRepoName(workspaceInfo.GitCloneRepoUrl)
+	}
+	if build, isBuild := originK8sConfig.Workspace.DevContainer.GetBuild(configFileRelativePath); isBuild &&
+		workspaceInfo.GitCloneRepoUrl != "" && applicationRootDirPath != "" { // git 库中只检出了配置文件
+		err = checkoutK8sBuildContext(getK8sGitActualRepoUrl(workspaceInfo), workspaceInfo.GitBranch, applicationRootDirPath, build)
+		if err != nil {
+			return nil, err
+		}
+	}
+	buildImageName, err := buildK8sDevContainerImage(*originK8sConfig, applicationRootDirPath, configFileRelativePath, workspaceName)
+	if err != nil {
+		return nil, err
+	}
+
 	//2. 是否 配置文件 & k8s yaml 有改变
 	hasChanged, err := hasChanged(workspaceInfo, *originK8sConfig) // 配置文件 或者 关联k8s yaml是否有改变
 	if err != nil {
 		return nil, err
 	}
 	tempK8sConfig := workspaceInfo.K8sInfo.TempK8sConfig
+	if buildImageName != "" && getK8sDevContainerImage(tempK8sConfig) != buildImageName { // 镜像重新构建
+		hasChanged = true
+	}
 	checkPodReady, err := getDevContainerPodReady(k8sUtil, *originK8sConfig) // pod 是否运行正常
 	isReady := checkPodReady && err == nil
 	if hasChanged || !isReady {
@@ -152,10 +172,6 @@ func ExecuteK8sStartCmd(cmd *cobra.Command, k8sUtil k8s.KubernetesUtil,
 
 		//2.2. 保存配置文件（用于kubectl apply）
 		common.SmartIDELog.Info("保存临时配置文件")
-		workspaceName := workspaceInfo.Name
-		if workspaceInfo.GitCloneRepoUrl != "" {
-			workspaceName = common.GetRepoName(workspaceInfo.GitCloneRepoUrl)
-		}
 		// ★★★★★ 把所有k8s kind转换为一个临时的k8s yaml文件
 		labels := getK8sLabels(cmd, workspaceInfo) // 获取k8s模式下的label
 		portConfigs := map[string]uint{}
@@ -176,6 +192,9 @@ func ExecuteK8sStartCmd(cmd *cobra.Command, k8sUtil k8s.KubernetesUtil,
 		if err != nil {
 			return nil, err
 		}
+		if buildImageName != "" {
+			setK8sDevContainerImage(&tempK8sConfig, buildImageName)
+		}
 		tempK8sYamlFileRelativePath, err := tempK8sConfig.SaveK8STempYaml(applicationRootDirPath)
 		// ★★★★★ 保存到目录（临时k8s yaml文件的绝对路径）
 		tempK8sYamlAbsolutePath := filepath.Join(applicationRootDirPath, tempK8sYamlFileRelativePath)
@@ -250,7 +269,14 @@ func ExecuteK8sStartCmd(cmd *cobra.Command, k8sUtil k8s.KubernetesUtil,
 	//3. 端口转发，依然需要检查对应的端口是否占用
 	common.SmartIDELog.Info("端口转发...")
 	//3.1. 端口转发，并记录到extend
-	_, _, err = GetDevContainerPod(k8sUtil, tempK8sConfig)
+	devContainerPod, _, err := GetDevContainerPod(k8sUtil, tempK8sConfig)
+	if err != nil {
+		return nil, err
+	}
+	//3.2. 生命周期：on-create、update-content、post-start
+	hooks := originK8sConfig.Workspace.DevContainer.Hooks
+	lifecycleExec := newK8sLifecycleExec(&k8sUtil, *devContainerPod, tempK8sConfig.Workspace.DevContainer.ServiceName, runAsUserName)
+	err = workspace.RunLifecycleStartStages(hooks, lifecycleExec)
 	if err != nil {
 		return nil, err
 	}
@@ -277,6 +303,11 @@ func ExecuteK8sStartCmd(cmd *cobra.Command, k8sUtil k8s.KubernetesUtil,
 		go function1(portMapInfo.ServiceName, unusedClientPort, portMapInfo.CurrentHostPort, index)
 
 	}
+	//3.3. 生命周期：post-attach
+	err = workspace.RunLifecycleStage(hooks, workspace.LifecycleStageEnum_PostAttach, lifecycleExec)
+	if err != nil {
+		return nil, err
+	}
 
 	if workspaceInfo.CliRunningEnv == workspace.CliRunningEnvEnum_Client {
 		//8. 保存到db
@@ -326,7 +357,7 @@ func execSSHPolicy(workspaceInfo workspace.WorkspaceInfo, host string, token str
 							path := filepath.Join(homeDir, ".ssh")
 							if _, err := common.PathExists(path, 0700); err == nil {
 
-								commad := `echo -e 'Host *\n	StrictHostKeyChecking no' >>  ~/.ssh/config && sudo chmod 700 ~/.ssh/config`
+								commad := `echo -e 'Host *\n	StrictHostKeyChecking accept-new' >>  ~/.ssh/config && sudo chmod 700 ~/.ssh/config`
 								common.RunCmd(commad, true)
 								commad = fmt.Sprintf(`echo -e '%v' >>  ~/.ssh/id_rsa && sudo chmod 600 ~/.ssh/id_rsa`, idRsa)
 								common.RunCmd(commad, true)
@@ -653,10 +684,7 @@ func downloadConfigAndLinkFiles(workspaceInfo workspace.WorkspaceInfo) (
 	configFileRelativePath string, linkK8sYamlRelativePaths []string, err error) {
 
 	//3.1. 下载配置文件
-	gitActualRepoUrl := workspaceInfo.GitCloneRepoUrl
-	if workspaceInfo.GitRepoAuthType == workspace.GitRepoAuthType_Basic {
-		gitActualRepoUrl, _ = common.AddUsernamePassword4ActualGitRpoUrl(gitActualRepoUrl, workspaceInfo.GitUserName, workspaceInfo.GitPassword)
-	}
+	gitActualRepoUrl := getK8sGitActualRepoUrl(workspaceInfo)
 	gitRepoRootDirPath, fileRelativePaths, err := downloadFilesByGit(gitActualRepoUrl, workspaceInfo.GitBranch, workspaceInfo.ConfigFileRelativePath)
 	if err != nil {
 		return
@@ -698,6 +726,15 @@ func downloadConfigAndLinkFiles(workspaceInfo workspace.WorkspaceInfo) (
 	return gitRepoRootDirPath, configFileRelativePath, linkK8sYamlRelativePaths, nil
 }
 
+// 克隆使用的 git 地址，basic 认证时包含用户名以及密码
+func getK8sGitActualRepoUrl(workspaceInfo workspace.WorkspaceInfo) string {
+	gitActualRepoUrl := workspaceInfo.GitCloneRepoUrl
+	if workspaceInfo.GitRepoAuthType == workspace.GitRepoAuthType_Basic {
+		gitActualRepoUrl, _ = common.AddUsernamePassword4ActualGitRpoUrl(gitActualRepoUrl, workspaceInfo.GitUserName, workspaceInfo.GitPassword)
+	}
+	return gitActualRepoUrl
+}
+
 // 等待webide可以访问，并打开
 func waitingAndOpenBrower(workspaceInfo workspace.WorkspaceInfo, originK8sConfig config.SmartIdeK8SConfig) error {
 	var ideBindingPort int
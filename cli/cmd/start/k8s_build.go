@@ -0,0 +1,225 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package start
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/internal/dal"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// k8s 模式下通过 Dockerfile 构建开发容器的镜像，没有设置 dev-container.build 时返回空
+// 默认使用本机的 docker 构建，设置了 build.host 时通过 ssh 使用对应主机上的 docker 构建（构建上下文仍然从本地上传）
+// 设置了镜像仓库时推送到镜像仓库，否则只能在与构建主机共用 docker 镜像的集群（比如 k3s、docker desktop）中使用
+// 镜像的 tag 为镜像id的前12位，构建配置没有变化时镜像id不变，不会重新构建以及推送
+func buildK8sDevContainerImage(k8sConfig config.SmartIdeK8SConfig, applicationRootDirPath string, configFileRelativePath string,
+	workspaceName string) (imageName string, err error) {
+	devContainer := k8sConfig.Workspace.DevContainer
+	build, isBuild := devContainer.GetBuild(configFileRelativePath)
+	if !isBuild {
+		return "", nil
+	}
+	if applicationRootDirPath == "" || !common.IsExist(applicationRootDirPath) {
+		return "", fmt.Errorf(i18nInstance.Start.Err_k8s_build_context_not_exist, devContainer.ServiceName)
+	}
+
+	//1. 只包含开发容器的 compose 项目，构建上下文相对于工作目录
+	ctx := context.Background()
+	cli, closeCli, err := newK8sBuildDockerClient(ctx, devContainer.Build.Host)
+	if err != nil {
+		return "", err
+	}
+	defer closeCli()
+	dockerCompose := compose.DockerComposeYml{
+		Services: map[string]compose.Service{
+			devContainer.ServiceName: {Image: devContainer.GetBuildImageName(workspaceName), Build: build},
+		},
+	}
+	project := compose.NewProject(cli, dockerCompose, applicationRootDirPath, filepath.Join(applicationRootDirPath, configFileRelativePath))
+
+	//2. 构建，并使用镜像id作为 tag
+	imageId, err := project.EnsureImage(ctx, devContainer.ServiceName)
+	if err != nil {
+		return "", err
+	}
+	imageName = fmt.Sprintf("%v:%v", devContainer.GetBuildImageName(workspaceName), getShortImageId(imageId))
+	if err = cli.ImageTag(ctx, imageId, imageName); err != nil {
+		return "", err
+	}
+
+	//3. 推送到镜像仓库
+	if devContainer.Build.Registry != "" {
+		if project.IsImageInRegistry(ctx, imageName) {
+			common.SmartIDELog.InfoF(i18nInstance.Start.Info_k8s_build_image_exist, imageName)
+		} else if err = project.PushImage(ctx, devContainer.ServiceName, imageName); err != nil {
+			return "", err
+		}
+	}
+	return imageName, nil
+}
+
+// git 库中的工作区只稀疏检出了配置文件以及关联的 k8s yaml，构建前还需要检出构建上下文以及 Dockerfile
+func checkoutK8sBuildContext(gitActualRepoUrl string, branch string, applicationRootDirPath string, build compose.Build) error {
+	for _, pattern := range getK8sBuildCheckoutPatterns(build) {
+		_, err := common.GIT.SparseCheckout(filepath.Dir(applicationRootDirPath), gitActualRepoUrl, pattern, branch)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 构建上下文以及 Dockerfile 对应的 sparse-checkout 规则，路径相对于 git 库的根目录；绝对路径不在 git 库中，不需要检出
+func getK8sBuildCheckoutPatterns(build compose.Build) (patterns []string) {
+	contextDir := path.Clean(filepath.ToSlash(build.Context))
+	if path.IsAbs(contextDir) || contextDir == ".." || strings.HasPrefix(contextDir, "../") {
+		return patterns
+	}
+	if contextDir == "." { // 整个 git 库
+		return []string{"/*"}
+	}
+	patterns = append(patterns, "/"+contextDir+"/")
+
+	dockerfile := build.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	dockerfile = filepath.ToSlash(dockerfile)
+	if !path.IsAbs(dockerfile) {
+		dockerfile = path.Join(contextDir, dockerfile)
+		if !strings.HasPrefix(dockerfile, contextDir+"/") && dockerfile != ".." && !strings.HasPrefix(dockerfile, "../") { // 在构建上下文之外
+			patterns = append(patterns, "/"+dockerfile)
+		}
+	}
+	return patterns
+}
+
+// 构建镜像使用的 docker 客户端，host 为 smartide host list 中的主机 id，为空时使用本机的 docker
+func newK8sBuildDockerClient(ctx context.Context, host string) (cli *client.Client, closeCli func(), err error) {
+	//1. 本机
+	if host == "" {
+		cli, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return nil, nil, err
+		}
+		return cli, func() { cli.Close() }, nil
+	}
+
+	//2. 远程主机，通过 ssh 访问主机上的 docker api
+	remoteInfo, err := getK8sBuildRemote(host)
+	if err != nil {
+		return nil, nil, err
+	}
+	common.SmartIDELog.InfoF(i18nInstance.Start.Info_k8s_build_on_host, remoteInfo.Addr)
+	sshRemote, err := common.NewSSHRemote(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName, remoteInfo.Password,
+		remoteInfo.SSHKey, remoteInfo.IdentityFile, remoteInfo.JumpHosts)
+	if err != nil {
+		return nil, nil, err
+	}
+	cli, err = sshRemote.NewDockerClient(ctx)
+	if err != nil {
+		sshRemote.Close()
+		return nil, nil, err
+	}
+	return cli, func() {
+		cli.Close()
+		sshRemote.Close()
+	}, nil
+}
+
+// 根据主机 id 从数据库中加载构建主机的信息
+func getK8sBuildRemote(host string) (*workspace.RemoteInfo, error) {
+	if !common.IsNumber(host) {
+		return nil, fmt.Errorf(i18nInstance.Start.Err_k8s_build_host_not_exist, host)
+	}
+	remoteId, err := strconv.Atoi(host)
+	if err != nil {
+		return nil, err
+	}
+	remoteInfo, err := dal.GetRemoteById(remoteId)
+	if err != nil {
+		return nil, err
+	}
+	if remoteInfo == nil {
+		return nil, fmt.Errorf(i18nInstance.Start.Err_k8s_build_host_not_exist, host)
+	}
+	return remoteInfo, nil
+}
+
+// 镜像id的前12位，与 docker images 的输出一致
+func getShortImageId(imageId string) string {
+	imageId = strings.TrimPrefix(imageId, "sha256:")
+	if len(imageId) > 12 {
+		return imageId[:12]
+	}
+	return imageId
+}
+
+// k8s 配置中开发容器使用的镜像，没有找到时返回空
+func getK8sDevContainerImage(k8sConfig config.SmartIdeK8SConfig) string {
+	image := ""
+	forEachK8sDevContainer(&k8sConfig, func(container *coreV1.Container) {
+		image = container.Image
+	})
+	return image
+}
+
+// 设置 k8s 配置中开发容器使用的镜像，本地构建的镜像不需要从镜像仓库拉取
+func setK8sDevContainerImage(k8sConfig *config.SmartIdeK8SConfig, imageName string) {
+	forEachK8sDevContainer(k8sConfig, func(container *coreV1.Container) {
+		container.Image = imageName
+		container.ImagePullPolicy = coreV1.PullIfNotPresent
+	})
+}
+
+// 遍历 deployment 以及 pod 中的开发容器
+func forEachK8sDevContainer(k8sConfig *config.SmartIdeK8SConfig, action func(container *coreV1.Container)) {
+	devContainerName := k8sConfig.Workspace.DevContainer.ServiceName
+	forEachContainer := func(containers []coreV1.Container) {
+		for index := range containers {
+			if containers[index].Name == devContainerName {
+				action(&containers[index])
+			}
+		}
+	}
+
+	for _, deployment := range k8sConfig.Workspace.Deployments {
+		forEachContainer(deployment.Spec.Template.Spec.Containers)
+	}
+	for index, other := range k8sConfig.Workspace.Others {
+		switch pod := other.(type) {
+		case *coreV1.Pod:
+			forEachContainer(pod.Spec.Containers)
+		case coreV1.Pod:
+			forEachContainer(pod.Spec.Containers)
+			k8sConfig.Workspace.Others[index] = pod
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package start
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+)
+
+func TestGetK8sBuildCheckoutPatterns(t *testing.T) {
+	tests := []struct {
+		name  string
+		build compose.Build
+		want  []string
+	}{
+		{"repo root", compose.Build{Context: "."}, []string{"/*"}},
+		{"sub dir", compose.Build{Context: "docker"}, []string{"/docker/"}},
+		{"dockerfile outside context", compose.Build{Context: "src", Dockerfile: "../docker/dev.Dockerfile"}, []string{"/src/", "/docker/dev.Dockerfile"}},
+		{"absolute", compose.Build{Context: "/tmp/build"}, nil},
+		{"outside repo", compose.Build{Context: "../build"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getK8sBuildCheckoutPatterns(tt.build); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("getK8sBuildCheckoutPatterns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// git 库中的 k8s 工作区，先稀疏检出配置文件，构建前检出构建上下文以及 Dockerfile
+func TestCheckoutK8sBuildContext(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	common.SmartIDELog.InitLogger("")
+
+	//1. 准备 git 库
+	repoDir := filepath.Join(t.TempDir(), "boathouse")
+	files := map[string]string{
+		".ide/.k8s.ide.yaml":     "workspace:\n  dev-container:\n    service-name: dev\n    build:\n      context: ../src\n      dockerfile: ../docker/Dockerfile\n",
+		".ide/k8s/deploy.yaml":   "kind: Deployment\n",
+		"docker/Dockerfile":      "FROM alpine\nCOPY . /app\n",
+		"src/main.go":            "package main\n",
+		"src/.dockerignore":      "*.log\n",
+		"docs/readme.md":         "# boathouse\n",
+		"docker/unused/file.txt": "unused\n",
+	}
+	for filePath, content := range files {
+		fullPath := filepath.Join(repoDir, filePath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "init"},
+	} {
+		command := exec.Command("git", args...)
+		command.Dir = repoDir
+		if output, err := command.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v %s", args, err, output)
+		}
+	}
+	gitRepoUrl := "file://" + filepath.ToSlash(repoDir) + ".git"
+	if err := os.Rename(repoDir, repoDir+".git"); err != nil {
+		t.Fatal(err)
+	}
+
+	//2. 与 downloadConfigAndLinkFiles 一致，只检出配置文件
+	workingRootDir := t.TempDir()
+	applicationRootDirPath := filepath.Join(workingRootDir, common.GetRepoName(gitRepoUrl))
+	if _, err := common.GIT.SparseCheckout(workingRootDir, gitRepoUrl, ".ide/.k8s.ide.yaml", "main"); err != nil {
+		t.Fatal(err)
+	}
+	configFileRelativePath := filepath.Join(".ide", ".k8s.ide.yaml")
+	if !common.IsExist(filepath.Join(applicationRootDirPath, configFileRelativePath)) {
+		t.Fatalf("config file is not checked out")
+	}
+	if common.IsExist(filepath.Join(applicationRootDirPath, "docker", "Dockerfile")) {
+		t.Fatalf("Dockerfile should not be checked out before building")
+	}
+
+	//3. 检出构建上下文
+	devContainer := config.DevContainerConfig{
+		ServiceName: "dev",
+		Build:       &config.DevContainerBuildConfig{Context: "../src", Dockerfile: "../docker/Dockerfile"},
+	}
+	build, isBuild := devContainer.GetBuild(configFileRelativePath)
+	if !isBuild {
+		t.Fatalf("GetBuild() isBuild = false")
+	}
+	if err := checkoutK8sBuildContext(gitRepoUrl, "main", applicationRootDirPath, build); err != nil {
+		t.Fatal(err)
+	}
+	for _, filePath := range []string{".ide/.k8s.ide.yaml", "docker/Dockerfile", "src/main.go", "src/.dockerignore"} {
+		if !common.IsExist(filepath.Join(applicationRootDirPath, filePath)) {
+			t.Errorf("%v is not checked out", filePath)
+		}
+	}
+	for _, filePath := range []string{"docs/readme.md", "docker/unused/file.txt"} {
+		if common.IsExist(filepath.Join(applicationRootDirPath, filePath)) {
+			t.Errorf("%v should not be checked out", filePath)
+		}
+	}
+}
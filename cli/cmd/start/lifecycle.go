@@ -0,0 +1,125 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package start
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/k8s"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+// 生命周期钩子是否有需要执行的命令
+func hasLifecycleHooks(hooks config.LifecycleHooks) bool {
+	return len(hooks.OnCreate) > 0 || len(hooks.UpdateContent) > 0 || len(hooks.PostStart) > 0 ||
+		len(hooks.PostAttach) > 0 || len(hooks.PreStop) > 0
+}
+
+// 本地模式，通过 docker api 在容器中执行
+func newLocalLifecycleExec(docker common.Docker, containerName string) workspace.LifecycleExecFunc {
+	return func(command string) (string, error) {
+		output, exitCode, err := docker.ExecWithExitCode(context.Background(), containerName, []string{"sh", "-c", command})
+		if err == nil && exitCode != 0 {
+			err = fmt.Errorf("exit code %v", exitCode)
+		}
+		return output, err
+	}
+}
+
+// 远程主机模式，通过 ssh 在远程主机上执行 docker exec，退出码不为 0 时 ssh 会返回错误
+func newRemoteLifecycleExec(sshRemote common.SSHRemote, containerId string) workspace.LifecycleExecFunc {
+	return func(command string) (string, error) {
+		command = strings.ReplaceAll(command, "'", `'\''`)
+		return sshRemote.ExeSSHCommand(fmt.Sprintf(`docker exec %v sh -c '%v'`, containerId, command))
+	}
+}
+
+// k8s 模式，通过 kubectl exec 在 pod 中执行，命令作为单独的参数传递，不需要再转义
+func newK8sLifecycleExec(k8sUtil *k8s.KubernetesUtil, pod coreV1.Pod, containerName string, runAsUser string) workspace.LifecycleExecFunc {
+	return func(command string) (string, error) {
+		var output bytes.Buffer
+		exitCode, err := k8sUtil.ExecuteCommandStreamInPod(pod, containerName, []string{"sh", "-c", command}, runAsUser,
+			false, false, nil, &output, &output)
+		if err == nil && exitCode != 0 {
+			err = fmt.Errorf("exit code %v", exitCode)
+		}
+		return output.String(), err
+	}
+}
+
+// 停止本地工作区前执行 pre-stop，失败时只提示，不影响停止
+func RunLocalPreStopHooks(ctx context.Context, cli *client.Client, workspaceInfo workspace.WorkspaceInfo) {
+	hooks := workspaceInfo.ConfigYaml.Workspace.DevContainer.Hooks
+	if len(hooks.PreStop) == 0 {
+		return
+	}
+	serviceName := workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName
+	containers := GetLocalContainersWithServices(ctx, cli, workspaceInfo.WorkingDirectoryPath, []string{serviceName})
+	containerName := strings.ReplaceAll(getDevContainerName(containers, serviceName), "/", "")
+	if containerName == "" {
+		return
+	}
+	exec := newLocalLifecycleExec(*common.NewDocker(cli), containerName)
+	if err := workspace.RunLifecycleStage(hooks, workspace.LifecycleStageEnum_PreStop, exec); err != nil {
+		common.SmartIDELog.Warning(err.Error())
+	}
+}
+
+// 停止远程主机工作区前执行 pre-stop，失败时只提示，不影响停止
+func RunRemotePreStopHooks(sshRemote common.SSHRemote, workspaceInfo workspace.WorkspaceInfo) {
+	hooks := workspaceInfo.ConfigYaml.Workspace.DevContainer.Hooks
+	if len(hooks.PreStop) == 0 {
+		return
+	}
+	containers, err := GetRemoteContainersWithServices(sshRemote,
+		workspaceInfo.WorkingDirectoryPath, []string{workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName})
+	if err != nil || len(containers) == 0 {
+		return
+	}
+	containerName := strings.ReplaceAll(containers[len(containers)-1].ContainerName, "/", "")
+	exec := newRemoteLifecycleExec(sshRemote, containerName)
+	if err := workspace.RunLifecycleStage(hooks, workspace.LifecycleStageEnum_PreStop, exec); err != nil {
+		common.SmartIDELog.Warning(err.Error())
+	}
+}
+
+// 删除 k8s 工作区前执行 pre-stop，失败时只提示，不影响删除
+func RunK8sPreStopHooks(k8sUtil k8s.KubernetesUtil, workspaceInfo workspace.WorkspaceInfo) {
+	hooks := workspaceInfo.K8sInfo.OriginK8sYaml.Workspace.DevContainer.Hooks
+	if len(hooks.PreStop) == 0 {
+		return
+	}
+	tempK8sConfig := workspaceInfo.K8sInfo.TempK8sConfig
+	devContainerPod, _, err := GetDevContainerPod(k8sUtil, tempK8sConfig)
+	if err != nil || devContainerPod == nil {
+		common.SmartIDELog.Debug(fmt.Sprintf("dev container pod not found, skip pre-stop: %v", err))
+		return
+	}
+	exec := newK8sLifecycleExec(&k8sUtil, *devContainerPod, tempK8sConfig.Workspace.DevContainer.ServiceName, "smartide") // 与启动时的用户一致
+	if err := workspace.RunLifecycleStage(hooks, workspace.LifecycleStageEnum_PreStop, exec); err != nil {
+		common.SmartIDELog.Warning(err.Error())
+	}
+}
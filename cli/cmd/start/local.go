@@ -39,7 +39,6 @@ import (
 	"github.com/leansoftX/smartide-cli/pkg/k8s"
 	"github.com/leansoftX/smartide-cli/pkg/tunnel"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 )
 
@@ -124,33 +123,15 @@ func ExecuteStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 	isDockerComposeRunning := isDockerComposeRunning(ctx, cli, workspaceInfo.WorkingDirectoryPath, currentConfig.GetServiceNames())
 
 	//3.2. 运行容器
-	if !isDockerComposeRunning || hasChanged { // 容器没有运行 或者 有改变，重新创建容器
+	// 开发容器的镜像通过 Dockerfile 构建时，每次都需要检查构建上下文是否改变，没有改变的镜像和容器不会重新创建
+	isBuild := currentConfig.Workspace.DevContainer.Build != nil
+	if !isDockerComposeRunning || hasChanged || isBuild { // 容器没有运行 或者 有改变，重新创建容器
 		// print
 		common.SmartIDELog.InfoF(i18nInstance.Start.Info_ssh_tunnel, sshBindingPort) // 提示用户ssh端口绑定到了本地的某个端口
 
-		// 创建网络（docker-compose 创建的网络会增加文件夹名，导致无法匹配）
-		for network := range tempDockerCompose.Networks {
-			networkList, _ := cli.NetworkList(ctx, types.NetworkListOptions{})
-			isContain := false
-			for _, item := range networkList {
-				if item.Name == network {
-					isContain = true
-					break
-				}
-			}
-			if !isContain {
-				_, err = cli.NetworkCreate(ctx, network, types.NetworkCreate{})
-				common.CheckError(err)
-				common.SmartIDELog.InfoF(i18nInstance.Start.Info_create_network, network)
-			}
-		}
-
-		// 运行docker-compose命令
-		// e.g. docker-compose -f {docker-compose文件路径} --project-directory {工作目录} up -d
+		// 创建网络、挂载卷，启动容器（docker compose up -d）
 		pwd, _ := os.Getwd()
-		commands := []string{"docker-compose", "-f", workspaceInfo.TempYamlFileAbsolutePath, "--project-directory", pwd, "up", "-d"}
-		common.SmartIDELog.Debug(strings.Join(commands, " "))
-		err := common.EXEC.Realtime(strings.Join(commands, " "), "")
+		err := ExecuteLocalCompose(ctx, cli, tempDockerCompose, workspaceInfo.TempYamlFileAbsolutePath, pwd, ComposeActionEnum_Up)
 		common.CheckError(err)
 	}
 
@@ -168,6 +149,9 @@ func ExecuteStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 	dockerContainerName := strings.ReplaceAll(devContainerName, "/", "")
 	config.LocalContainerGitSet(docker, dockerContainerName)                  //git 设置
 	localContainerCredentialCache(docker, dockerContainerName, workspaceInfo) // 缓存git 用户名、密码
+	lifecycleExec := newLocalLifecycleExec(docker, dockerContainerName)
+	err = workspace.RunLifecycleStartStages(currentConfig.Workspace.DevContainer.Hooks, lifecycleExec) // 生命周期：on-create、update-content、post-start
+	common.CheckError(err)
 
 	//5. 保存 workspace
 	//5.1.
@@ -206,6 +190,8 @@ func ExecuteStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 
 	//6. 执行函数内容
 	endPostExecuteFun(dockerContainerName, docker)
+	err = workspace.RunLifecycleStage(currentConfig.Workspace.DevContainer.Hooks, workspace.LifecycleStageEnum_PostAttach, lifecycleExec)
+	common.CheckError(err)
 
 	//7. 使用浏览器打开web ide
 	if currentConfig.Workspace.DevContainer.IdeType != config.IdeTypeEnum_SDKOnly {
@@ -244,14 +230,17 @@ func ExecuteStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 
 	//9. tunnel
 	sshPassword := workspaceInfo.TempDockerCompose.GetSSHPassword(currentConfig.Workspace.DevContainer.ServiceName)
-	sshRemote, err := common.NewSSHRemote("localhost", sshBindingPort, model.CONST_DEV_CONTAINER_CUSTOM_USER, sshPassword, "")
+	sshRemote, err := common.NewDevContainerSSHRemote("localhost", sshBindingPort, model.CONST_DEV_CONTAINER_CUSTOM_USER, sshPassword)
 	common.CheckError(err)
 	options := tunnel.AutoTunnelMultipleOptions{}
 	for _, portMap := range workspaceInfo.Extend.Ports {
 		options.AppendPortMapping(tunnel.PortMapTypeEnum(portMap.PortMapType), portMap.OriginHostPort, portMap.CurrentHostPort,
 			portMap.HostPortDesc, portMap.ContainerPort)
 	}
-	tunnel.AutoTunnel(sshRemote.Connection, options)
+	tunnel.AutoTunnel(sshRemote.GetSSHClient(), options)
+	if len(currentConfig.Workspace.DevContainer.ReversePorts) > 0 { // 远程端口转发
+		reverseTunnel4Container(sshRemote.GetSSHClient(), currentConfig.Workspace.DevContainer.ReversePorts)
+	}
 
 	return workspaceInfo, nil
 }
@@ -0,0 +1,60 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package start
+
+import (
+	"fmt"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/internal/model"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/tunnel"
+	"golang.org/x/crypto/ssh"
+)
+
+// 配置文件中的远程端口转发，本机的端口在开发容器中可以通过 localhost 访问
+// client 为开发容器的 ssh 连接，远程端口在开发容器中监听
+func reverseTunnel4Container(client *common.ReconnectingSSHClient, reversePorts map[string]int) {
+	mapping := map[string]string{}
+	for label, port := range reversePorts {
+		addr := fmt.Sprintf("localhost:%v", port)
+		mapping[addr] = addr
+		common.SmartIDELog.InfoF(i18nInstance.Common.Info_port_reverse_binding, port, port, label)
+	}
+	tunnel.ReverseMultiple(client, mapping)
+}
+
+// 远程主机模式，通过远程主机的 ssh 连接，再连接到开发容器的 ssh 端口
+func reverseTunnel4RemoteContainer(sshRemote common.SSHRemote, workspaceInfo workspace.WorkspaceInfo, reversePorts map[string]int) {
+	if len(reversePorts) == 0 {
+		return
+	}
+	sshPortMap, err := workspaceInfo.Extend.Ports.Find(model.CONST_DevContainer_PortDesc_SSH)
+	if err != nil {
+		common.SmartIDELog.Warning(err.Error())
+		return
+	}
+
+	addr := fmt.Sprintf("localhost:%v", sshPortMap.CurrentHostPort)
+	password := workspaceInfo.TempDockerCompose.GetSSHPassword(workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName)
+	client := common.NewReconnectingSSHClient(nil, func() (*ssh.Client, error) {
+		return common.DialSSHThrough(sshRemote.GetSSHClient().Dial, addr, model.CONST_DEV_CONTAINER_CUSTOM_USER, password)
+	}, common.SSHKeepAliveInterval, false)
+	reverseTunnel4Container(client, reversePorts)
+}
@@ -36,7 +36,6 @@ import (
 	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
 	"github.com/leansoftX/smartide-cli/pkg/tunnel"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 )
 
 // 远程服务器执行 start 命令
@@ -63,15 +62,27 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 	//0. 连接到远程主机
 	msg := fmt.Sprintf(" %v@%v:%v ...", workspaceInfo.Remote.UserName, workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort)
 	common.SmartIDELog.Info(i18nInstance.VmStart.Info_connect_remote + msg)
-	sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey)
+	sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey, workspaceInfo.Remote.IdentityFile, workspaceInfo.Remote.JumpHosts)
 	common.CheckErrorFunc(err, serverFeedback)
 
 	//1. 检查远程主机是否有docker、docker-compose、git
 	err = sshRemote.CheckRemoteEnv()
 	common.CheckErrorFunc(err, serverFeedback)
 
-	//2. git clone & checkout
-	if !disableClone { // 是否禁止clone
+	//2. 同步本地文件夹 或者 git clone & checkout
+	syncDir, _ := cmd.Flags().GetString("sync")
+	// 之前通过 --sync 创建的工作区
+	syncedDir := workspaceInfo.Extend.SyncDirectory
+	if syncDir != "" { // 使用本地文件夹代替 git 库
+		syncer, err := syncLocalDir4Remote(sshRemote, workspaceInfo, syncDir)
+		common.CheckErrorFunc(err, serverFeedback)
+		watchLocalDir4Remote(syncer)
+		syncedDir = syncer.LocalDir()
+
+	} else if syncedDir != "" { // 远程目录中没有 .git，不能判断是否 clone，也没有可以 clone 的地址
+		common.SmartIDELog.Warning(fmt.Sprintf(i18nInstance.VmStart.Warn_sync_not_watching, syncedDir))
+
+	} else if !disableClone { // 是否禁止clone
 		//2.1. 是否已 clone
 		common.SmartIDELog.Info(i18nInstance.VmStart.Info_git_clone)
 		isCloned := sshRemote.IsCloned(workspaceInfo.WorkingDirectoryPath)
@@ -91,6 +102,7 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 			common.CheckErrorFunc(err, serverFeedback)
 		}
 	}
+	isSyncChanged := syncedDir != workspaceInfo.Extend.SyncDirectory // 同步的文件夹有变化时需要保存
 	sshRemote.AddPublicKeyIntoAuthorizedkeys()
 
 	//3. 获取配置文件的内容
@@ -98,7 +110,8 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 	var tempDockerCompose compose.DockerComposeYml
 	ideYamlFilePath := common.FilePahtJoin4Linux(workspaceInfo.WorkingDirectoryPath, workspaceInfo.ConfigFileRelativePath) //fmt.Sprintf(`%v/.ide/.ide.yaml`, repoWorkspace)
 	common.SmartIDELog.Info(fmt.Sprintf(i18nInstance.VmStart.Info_read_config, ideYamlFilePath))
-	if !sshRemote.IsFileExist(ideYamlFilePath) {
+	if !sshRemote.IsFileExist(ideYamlFilePath) &&
+		config.GetRemoteDevContainerJsonRelativeFilePath(&sshRemote, workspaceInfo.WorkingDirectoryPath) == "" { // 没有 devcontainer.json 时才使用模板
 		argsTemplateTypeName := ""
 		argsTemplateSubTypeName := ""
 		if len(args) > 0 {
@@ -121,7 +134,7 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 
 	}
 	currentConfig, err := config.NewRemoteConfig(&sshRemote,
-		workspaceInfo.WorkingDirectoryPath, workspaceInfo.ConfigFileRelativePath)
+		workspaceInfo.WorkingDirectoryPath, workspaceInfo.ConfigFileRelativePath, workspaceInfo.GitCloneRepoUrl)
 	common.CheckError(err)
 
 	// addonEnable()
@@ -180,6 +193,7 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 
 	//3.2. 扩展信息
 	workspaceInfo.Extend = workspaceInfo.GetWorkspaceExtend()
+	workspaceInfo.Extend.SyncDirectory = syncedDir
 
 	//4.1 agent cp to remote
 	workspace.InstallSmartideAgent(sshRemote)
@@ -191,44 +205,27 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 	common.CheckErrorFunc(err, serverFeedback)
 
 	//5.2. docker
-	if !isDockerComposeRunning || hasChanged { // 容器没有运行 或者 有改变，重新创建容器
-		// 创建网络
-		common.SmartIDELog.Info(i18nInstance.VmStart.Info_create_network)
-		networkCreateCommand := ""
-		for network := range tempDockerCompose.Networks {
-			cmd := fmt.Sprintf("docker network ls|grep %v > /dev/null || docker network create %v\n", network, network) // 不存在才创建
-			networkCreateCommand += cmd
-		}
-		sshRemote.ExeSSHCommand(networkCreateCommand)
-
-		// 在远程vm上生成docker-compose文件，运行docker-compose up
+	// 开发容器的镜像通过 Dockerfile 构建时，每次都需要检查构建上下文是否改变，没有改变的镜像和容器不会重新创建
+	isBuild := currentConfig.Workspace.DevContainer.Build != nil
+	if !isDockerComposeRunning || hasChanged || isBuild { // 容器没有运行 或者 有改变，重新创建容器
+		// 在远程vm上创建网络、挂载卷，启动容器（docker compose up -d）
 		common.SmartIDELog.Info(i18nInstance.VmStart.Info_compose_up) // 提示文本：compose up
-		bytesDockerComposeContent, err := yaml.Marshal(&tempDockerCompose)
-		printServices(tempDockerCompose.Services) // 打印services
-		common.CheckError(err, string(bytesDockerComposeContent))
+		printServices(tempDockerCompose.Services)                     // 打印services
 		if workspaceInfo.TempYamlFileAbsolutePath == "" {
 			common.SmartIDELog.Error("compose 文件路径为空！")
 		}
-		commandCreateDockerComposeFile := fmt.Sprintf("docker-compose -f %v --project-directory %v up -d",
-			workspaceInfo.TempYamlFileAbsolutePath, workspaceInfo.WorkingDirectoryPath)
-		fmt.Println() // 避免向前覆盖
-		fun1 := func(output string) error {
-			output = strings.ToLower(output)
-			if strings.Contains(output, ":error") || strings.Contains(output, ":fatal") {
-				common.SmartIDELog.Error(output)
-
-			} else {
-				if strings.Contains(output, "Pulling") {
-					fmt.Println()
-				}
-			}
+		err = ExecuteRemoteCompose(sshRemote, tempDockerCompose,
+			workspaceInfo.TempYamlFileAbsolutePath, workspaceInfo.WorkingDirectoryPath, ComposeActionEnum_Up)
+		common.CheckErrorFunc(err, serverFeedback)
 
-			return nil
-		}
-		err = sshRemote.ExecSSHCommandRealTimeFunc(commandCreateDockerComposeFile, fun1)
-		fmt.Println()
-		common.CheckError(err, commandCreateDockerComposeFile)
+	}
 
+	//5.3. 生命周期：on-create、update-content、post-start
+	var lifecycleExec workspace.LifecycleExecFunc
+	if hasLifecycleHooks(currentConfig.Workspace.DevContainer.Hooks) {
+		lifecycleExec = newRemoteLifecycleExec(sshRemote, getRemoteWorkspaceContainerId(sshRemote, workspaceInfo, cmd))
+		err = workspace.RunLifecycleStartStages(currentConfig.Workspace.DevContainer.Hooks, lifecycleExec)
+		common.CheckErrorFunc(err, serverFeedback)
 	}
 
 	//6. 当前主机绑定到远程端口
@@ -236,11 +233,17 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 	unusedLocalPort4IdeBindingPort := ideBindingPort // 未使用的本地端口，与ide端口对应
 	//6.1. 查找所有远程主机的端口
 	for serviceName, service := range tempDockerCompose.Services {
-		for _, portBinding := range service.Ports {
-			ports := strings.Split(portBinding, ":")
-			remoteBindingPort, containerPort := ports[0], ports[1]
+		portConfigs, err := service.Ports.ToComplex()
+		if err != nil {
+			common.SmartIDELog.Warning(err.Error())
+		}
+		for _, portConfig := range portConfigs {
+			remoteBindingPortInt, containerPortInt := portConfig.GetPublishedPort(), portConfig.Target
+			if remoteBindingPortInt <= 0 { // 随机分配的端口
+				continue
+			}
+			remoteBindingPort := strconv.Itoa(remoteBindingPortInt)
 
-			remoteBindingPortInt, _ := strconv.Atoi(remoteBindingPort)
 			unusedLocalPort, err := common.CheckAndGetAvailableLocalPort(remoteBindingPortInt, 100) // 得到一个未被占用的本地端口
 			if err != nil {
 				common.SmartIDELog.Warning(err.Error())
@@ -252,7 +255,6 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 
 			// 日志
 			// 【注意】这里非常的绕！！！ 远程主机的docker-compose才保存了端口的label信息，所以只能使用远程主机的端口
-			containerPortInt, _ := strconv.Atoi(containerPort)
 			label := currentConfig.GetLabelWithPort(0, remoteBindingPortInt, containerPortInt)
 
 			for i, port := range workspaceInfo.Extend.Ports {
@@ -268,7 +270,7 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 	}
 
 	//7. 保存数据
-	if hasChanged {
+	if hasChanged || isSyncChanged {
 		common.SmartIDELog.InfoF(i18nInstance.Start.Info_workspace_saving) // log
 
 		remoteDockerComposeContainers, err := GetRemoteContainersWithServices(sshRemote,
@@ -345,8 +347,15 @@ func ExecuteVmStartCmd(workspaceInfo workspace.WorkspaceInfo, isUnforward bool,
 
 	}
 	//8.1. 执行绑定
-	tunnel.TunnelMultiple(sshRemote.Connection, addrMapping) // 端口转发
-	//8.2. 打开浏览器
+	tunnel.TunnelMultiple(sshRemote.GetSSHClient(), addrMapping) // 端口转发
+	//8.2. 远程端口转发
+	reverseTunnel4RemoteContainer(sshRemote, workspaceInfo, currentConfig.Workspace.DevContainer.ReversePorts)
+	//8.3. 生命周期：post-attach
+	if lifecycleExec != nil {
+		err = workspace.RunLifecycleStage(currentConfig.Workspace.DevContainer.Hooks, workspace.LifecycleStageEnum_PostAttach, lifecycleExec)
+		common.CheckErrorFunc(err, serverFeedback)
+	}
+	//8.4. 打开浏览器
 	if currentConfig.Workspace.DevContainer.IdeType != config.IdeTypeEnum_SDKOnly {
 		var url string
 		//vscode启动时候默认打开文件夹处理
@@ -454,7 +463,7 @@ func printServices(services map[string]compose.Service) {
 	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
 	fmt.Fprintln(w, "Service\tImage\tPorts\t")
 	for serviceName, service := range services {
-		line := fmt.Sprintf("%v\t%v\t%v\t", serviceName, service.Image, strings.Join(service.Ports, ";"))
+		line := fmt.Sprintf("%v\t%v\t%v\t", serviceName, service.Image, strings.Join(service.Ports.Strings(), ";"))
 		fmt.Fprintln(w, line)
 	}
 	w.Flush()
@@ -496,7 +505,7 @@ func GitCloneAndCheckoutBranch(sshRemote common.SSHRemote, workspaceInfo workspa
 	isSSHClone := strings.Index(workspaceInfo.GitCloneRepoUrl, "git@") == 0
 	fflags := cmd.Flags()
 	userName, _ := fflags.GetString("serverusername")
-	GIT_SSH_COMMAND := fmt.Sprintf(`GIT_SSH_COMMAND='ssh -i ~/.ssh/id_rsa_%s_%s -o IdentitiesOnly=yes'`,
+	GIT_SSH_COMMAND := fmt.Sprintf(`GIT_SSH_COMMAND='ssh -i ~/.ssh/id_rsa_%s_%s -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new'`,
 		userName, common.SmartIDELog.Ws_id)
 
 	// git checkout
@@ -77,7 +77,7 @@ func ExecuteServerVmStartByClientEnvCmd(workspaceInfo workspace.WorkspaceInfo,
 		return workspaceInfo, errors.New("关联 远程主机 信息为空！")
 	}
 
-	sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey)
+	sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey, workspaceInfo.Remote.IdentityFile, workspaceInfo.Remote.JumpHosts)
 	if err != nil {
 		return workspaceInfo, err
 	}
@@ -129,7 +129,7 @@ func ExecuteServerVmStartByClientEnvCmd(workspaceInfo workspace.WorkspaceInfo,
 	}
 	workspaceInfo.UpdateSSHConfig()
 	//6.2. 执行绑定
-	tunnel.TunnelMultiple(sshRemote.Connection, addrMapping)
+	tunnel.TunnelMultiple(sshRemote.GetSSHClient(), addrMapping)
 
 	//8. 打开浏览器
 	var checkUrl string
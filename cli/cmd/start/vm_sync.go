@@ -0,0 +1,88 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package start
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/internal/model"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/filesync"
+)
+
+// 检查远程主机上修改的间隔
+const syncPollInterval = time.Second * 3
+
+// 首次同步本地文件夹到远程工作区，代替 git clone
+func syncLocalDir4Remote(sshRemote common.SSHRemote, workspaceInfo workspace.WorkspaceInfo, localDir string) (*filesync.Syncer, error) {
+	//1. 远程主机上的路径需要是绝对路径，~ 在引号中不会展开
+	remoteDir := sshRemote.ConvertFilePath(workspaceInfo.WorkingDirectoryPath)
+	extraPatterns := []string{model.CONST_GlobalTempDirPath + "/"} // 临时文件只在远程主机上使用
+	syncer, err := filesync.NewSyncer(filesync.NewSSHCommandRemote(&sshRemote), localDir, remoteDir, extraPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	//2. 上传
+	common.SmartIDELog.Info(fmt.Sprintf(i18nInstance.VmStart.Info_sync_start, syncer.LocalDir(), remoteDir))
+	result, err := syncer.InitialSync()
+	if err != nil {
+		return nil, err
+	}
+	common.SmartIDELog.Info(fmt.Sprintf(i18nInstance.VmStart.Info_sync_initial_done, len(result.Pushed), result.Unchanged))
+	reportSyncResult(filesync.SyncResult{Conflicts: result.Conflicts}, nil) // 上传的文件较多，只提示冲突
+	return syncer, nil
+}
+
+// 在后台监听本地以及远程的修改，直到进程退出
+func watchLocalDir4Remote(syncer *filesync.Syncer) {
+	common.SmartIDELog.Info(fmt.Sprintf(i18nInstance.VmStart.Info_sync_watching, syncer.LocalDir()))
+	go func() {
+		if err := syncer.Watch(context.Background(), syncPollInterval, reportSyncResult); err != nil {
+			common.SmartIDELog.Warning(fmt.Sprintf(i18nInstance.VmStart.Err_sync, err))
+		}
+	}()
+}
+
+// 输出同步的结果，冲突时提示，不中断同步
+func reportSyncResult(result filesync.SyncResult, err error) {
+	if err != nil {
+		common.SmartIDELog.Warning(fmt.Sprintf(i18nInstance.VmStart.Err_sync, err))
+	}
+	for _, item := range []struct {
+		format string
+		files  []string
+	}{
+		{i18nInstance.VmStart.Info_sync_pushed, result.Pushed},
+		{i18nInstance.VmStart.Info_sync_removed_remote, result.RemovedRemote},
+		{i18nInstance.VmStart.Info_sync_pulled, result.Pulled},
+		{i18nInstance.VmStart.Info_sync_removed_local, result.RemovedLocal},
+	} {
+		if len(item.files) > 0 {
+			common.SmartIDELog.Info(fmt.Sprintf(item.format, strings.Join(item.files, ", ")))
+		}
+	}
+	for _, file := range result.Conflicts {
+		common.SmartIDELog.Warning(fmt.Sprintf(i18nInstance.VmStart.Warn_sync_conflict, file))
+	}
+}
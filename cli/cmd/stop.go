@@ -19,14 +19,15 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
-	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/docker/docker/client"
 	cmdCommon "github.com/leansoftX/smartide-cli/cmd/common"
+	"github.com/leansoftX/smartide-cli/cmd/start"
 
 	"github.com/leansoftX/smartide-cli/cmd/server"
 	"github.com/leansoftX/smartide-cli/internal/apk/appinsight"
@@ -146,13 +147,15 @@ func stopLocal(workspace workspace.WorkspaceInfo) {
 	err := common.CheckLocalEnv()
 	common.CheckError(err)
 
-	// 本地执行docker-compose
-	composeCmd := exec.Command("docker-compose", "-f", workspace.TempYamlFileAbsolutePath,
-		"--project-directory", workspace.WorkingDirectoryPath, "stop")
-	composeCmd.Stdout = os.Stdout
-	composeCmd.Stderr = os.Stderr
-	if composeCmdErr := composeCmd.Run(); composeCmdErr != nil {
-		common.SmartIDELog.Fatal(composeCmdErr)
+	// 本地停止 compose 对应的容器
+	ctx := context.Background()
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	common.CheckError(err)
+	start.RunLocalPreStopHooks(ctx, cli, workspace) // 生命周期：pre-stop
+	err = start.ExecuteLocalCompose(ctx, cli, workspace.TempDockerCompose,
+		workspace.TempYamlFileAbsolutePath, workspace.WorkingDirectoryPath, start.ComposeActionEnum_Stop)
+	if err != nil {
+		common.SmartIDELog.Fatal(err)
 	}
 }
 
@@ -161,7 +164,7 @@ func stopRemote(workspaceInfo workspace.WorkspaceInfo) error {
 	// ssh 连接
 	common.SmartIDELog.Info(i18nInstance.Stop.Info_sshremote_connection_creating)
 
-	sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey)
+	sshRemote, err := common.NewSSHRemote(workspaceInfo.Remote.Addr, workspaceInfo.Remote.SSHPort, workspaceInfo.Remote.UserName, workspaceInfo.Remote.Password, workspaceInfo.Remote.SSHKey, workspaceInfo.Remote.IdentityFile, workspaceInfo.Remote.JumpHosts)
 	if err != nil {
 		return err
 	}
@@ -183,11 +186,13 @@ func stopRemote(workspaceInfo workspace.WorkspaceInfo) error {
 		return err
 	}
 
+	// 生命周期：pre-stop
+	start.RunRemotePreStopHooks(sshRemote, workspaceInfo)
+
 	// 停止容器
 	common.SmartIDELog.Info(i18nInstance.Stop.Info_docker_stopping)
-	command := fmt.Sprintf("docker-compose -f %v --project-directory %v stop",
-		common.FilePahtJoin4Linux(workspaceInfo.TempYamlFileAbsolutePath), common.FilePahtJoin4Linux(workspaceInfo.WorkingDirectoryPath))
-	err = sshRemote.ExecSSHCommandRealTime(command)
+	err = start.ExecuteRemoteCompose(sshRemote, workspaceInfo.TempDockerCompose,
+		workspaceInfo.TempYamlFileAbsolutePath, workspaceInfo.WorkingDirectoryPath, start.ComposeActionEnum_Stop)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,49 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"github.com/leansoftX/smartide-cli/cmd/tunnel"
+	"github.com/spf13/cobra"
+)
+
+// initCmd represents the init command
+var tunnelCmd = &cobra.Command{
+	Use:   "tunnel",
+	Short: i18nInstance.Tunnel.Info_help_short,
+	Long:  i18nInstance.Tunnel.Info_help_long,
+	Example: `  smartide tunnel add <workspaceid>
+  smartide tunnel add <workspaceid> --port 8080:3000
+  smartide tunnel reverse <workspaceid> 5432
+  smartide tunnel ls
+  smartide tunnel rm <workspaceid>
+  smartide tunnel stop`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+func init() {
+	tunnelCmd.AddCommand(tunnel.TunnelDaemonCmd)
+	tunnelCmd.AddCommand(tunnel.TunnelListCmd)
+	tunnelCmd.AddCommand(tunnel.TunnelAddCmd)
+	tunnelCmd.AddCommand(tunnel.TunnelReverseCmd)
+	tunnelCmd.AddCommand(tunnel.TunnelRemoveCmd)
+	tunnelCmd.AddCommand(tunnel.TunnelStopCmd)
+}
@@ -0,0 +1,58 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/tunnel"
+	"github.com/spf13/cobra"
+)
+
+const flag_port = "port"
+
+// initCmd represents the init command
+var TunnelAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: i18nInstance.Tunnel.Info_help_add_short,
+	Long:  i18nInstance.Tunnel.Info_help_add_short,
+	Example: `  smartide tunnel add <workspaceid>
+  smartide tunnel add <workspaceid> --port 8080:3000`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ports, err := cmd.Flags().GetStringArray(flag_port)
+		common.CheckError(err)
+		for _, port := range ports { // 提前校验，避免启动守护进程后才报错
+			_, err := tunnel.ParseDaemonForward(port)
+			common.CheckError(err)
+		}
+
+		common.CheckError(ensureDaemonRunning())
+		response, err := tunnel.SendDaemonRequest(tunnel.GetDaemonSocketPath(), tunnel.DaemonRequest{
+			Action:      tunnel.DaemonActionEnum_Add,
+			WorkspaceID: args[0],
+			Ports:       ports,
+		})
+		common.CheckError(err)
+		printForwards(response.Forwards)
+	},
+}
+
+func init() {
+	TunnelAddCmd.Flags().StringArrayP(flag_port, "p", []string{}, i18nInstance.Tunnel.Info_help_flag_port)
+}
@@ -0,0 +1,185 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/internal/apk/i18n"
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/internal/dal"
+	"github.com/leansoftX/smartide-cli/internal/model"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/tunnel"
+	"github.com/spf13/cobra"
+)
+
+var i18nInstance = i18n.GetInstance()
+
+// initCmd represents the init command
+var TunnelDaemonCmd = &cobra.Command{
+	Use:     "daemon",
+	Short:   i18nInstance.Tunnel.Info_help_daemon_short,
+	Long:    i18nInstance.Tunnel.Info_help_long,
+	Example: `  smartide tunnel daemon`,
+	Run: func(cmd *cobra.Command, args []string) {
+		daemon := tunnel.NewDaemon(tunnel.GetDaemonSocketPath(), resolveWorkspace)
+
+		// 关闭终端时不退出，收到中断信号时停止所有的端口转发
+		signal.Ignore(syscall.SIGHUP)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			daemon.Stop()
+		}()
+
+		common.CheckError(daemon.Run())
+		common.SmartIDELog.Info(i18nInstance.Tunnel.Info_daemon_stopped)
+	},
+}
+
+// 获取工作区的 ssh 连接信息，以及需要转发的端口
+func resolveWorkspace(workspaceId string) (target tunnel.DaemonTarget, forwards []tunnel.DaemonForward, err error) {
+	id, err := strconv.Atoi(workspaceId)
+	if err != nil {
+		return target, forwards, fmt.Errorf("invalid workspace id %v", workspaceId)
+	}
+	workspaceInfo, err := dal.GetSingleWorkspace(id)
+	if err != nil {
+		return target, forwards, err
+	}
+	if workspaceInfo.IsNil() {
+		return target, forwards, errors.New(i18nInstance.Common.Warn_dal_record_not_exit)
+	}
+
+	switch workspaceInfo.Mode {
+	case workspace.WorkingMode_Remote:
+		// 连接到远程主机，转发远程主机上绑定的端口
+		target = tunnel.DaemonTarget{
+			Host:         workspaceInfo.Remote.Addr,
+			Port:         workspaceInfo.Remote.SSHPort,
+			UserName:     workspaceInfo.Remote.UserName,
+			Password:     workspaceInfo.Remote.Password,
+			SSHKey:       workspaceInfo.Remote.SSHKey,
+			IdentityFile: workspaceInfo.Remote.IdentityFile,
+			JumpHosts:    workspaceInfo.Remote.JumpHosts,
+		}
+		// 远程端口转发时，通过远程主机连接到开发容器的 ssh 端口
+		if sshPortMap, err := workspaceInfo.Extend.Ports.Find(model.CONST_DevContainer_PortDesc_SSH); err == nil {
+			target.ContainerSSHAddr = fmt.Sprintf("localhost:%v", sshPortMap.CurrentHostPort)
+			target.ContainerUserName = model.CONST_DEV_CONTAINER_CUSTOM_USER
+			target.ContainerPassword = workspaceInfo.TempDockerCompose.GetSSHPassword(workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName)
+		}
+		for _, portMap := range workspaceInfo.Extend.Ports {
+			localPort := portMap.ClientPort
+			if localPort <= 0 {
+				localPort = portMap.CurrentHostPort
+			}
+			forwards = append(forwards, tunnel.DaemonForward{
+				WorkspaceID: workspaceId,
+				Label:       portMap.HostPortDesc,
+				LocalPort:   localPort,
+				RemoteAddr:  fmt.Sprintf("localhost:%v", portMap.CurrentHostPort),
+			})
+		}
+
+	case workspace.WorkingMode_Local:
+		// 通过开发容器的 ssh 端口连接，compose 中的端口已经绑定到本地，只转发指定的端口
+		sshPortMap, err := workspaceInfo.Extend.Ports.Find(model.CONST_DevContainer_PortDesc_SSH)
+		if err != nil {
+			return target, forwards, err
+		}
+		target = tunnel.DaemonTarget{
+			Host:     "localhost",
+			Port:     sshPortMap.CurrentHostPort,
+			UserName: model.CONST_DEV_CONTAINER_CUSTOM_USER,
+			Password: workspaceInfo.TempDockerCompose.GetSSHPassword(workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName),
+		}
+
+	default:
+		return target, forwards, fmt.Errorf(i18nInstance.Tunnel.Err_workspace_mode_not_supported, workspaceInfo.Mode)
+	}
+
+	// 配置文件中的远程端口转发
+	for label, port := range workspaceInfo.ConfigYaml.Workspace.DevContainer.ReversePorts {
+		forwards = append(forwards, tunnel.DaemonForward{
+			WorkspaceID: workspaceId,
+			Label:       label,
+			LocalPort:   port,
+			RemoteAddr:  fmt.Sprintf("localhost:%v", port),
+			IsReverse:   true,
+		})
+	}
+
+	return target, forwards, nil
+}
+
+// 守护进程的日志文件
+func getDaemonLogFilePath() string {
+	return filepath.Join(filepath.Dir(tunnel.GetDaemonSocketPath()), "tunnel.log")
+}
+
+// 在后台启动守护进程，已经运行时直接返回
+func ensureDaemonRunning() error {
+	socketPath := tunnel.GetDaemonSocketPath()
+	if tunnel.IsDaemonRunning(socketPath) {
+		return nil
+	}
+
+	//1. 启动子进程，输出到日志文件
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	logFilePath := getDaemonLogFilePath()
+	if err := os.MkdirAll(filepath.Dir(logFilePath), 0700); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	daemonCmd := exec.Command(executable, "tunnel", "daemon")
+	daemonCmd.Stdout = logFile
+	daemonCmd.Stderr = logFile
+	if err := daemonCmd.Start(); err != nil {
+		return err
+	}
+	daemonCmd.Process.Release()
+
+	//2. 等待控制 socket 可用
+	for i := 0; i < 50; i++ {
+		if tunnel.IsDaemonRunning(socketPath) {
+			common.SmartIDELog.InfoF(i18nInstance.Tunnel.Info_daemon_started, logFilePath)
+			return nil
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+	return fmt.Errorf(i18nInstance.Tunnel.Err_daemon_start_timeout, logFilePath)
+}
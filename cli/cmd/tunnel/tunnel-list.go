@@ -0,0 +1,92 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	cmdCommon "github.com/leansoftX/smartide-cli/cmd/common"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/tunnel"
+	"github.com/spf13/cobra"
+)
+
+// initCmd represents the init command
+var TunnelListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   i18nInstance.Tunnel.Info_help_list_short,
+	Long:    i18nInstance.Tunnel.Info_help_list_short,
+	Aliases: []string{"ls"},
+	Example: `  smartide tunnel ls
+  smartide tunnel ls -o json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		outputFormat, err := cmdCommon.GetOutputFormat(cmd)
+		common.CheckError(err)
+
+		socketPath := tunnel.GetDaemonSocketPath()
+		forwards := []tunnel.DaemonForward{}
+		if tunnel.IsDaemonRunning(socketPath) {
+			response, err := tunnel.SendDaemonRequest(socketPath, tunnel.DaemonRequest{Action: tunnel.DaemonActionEnum_List})
+			common.CheckError(err)
+			forwards = response.Forwards
+		} else if !outputFormat.IsStructured() {
+			common.SmartIDELog.Info(i18nInstance.Tunnel.Info_daemon_not_running)
+			return
+		}
+
+		if outputFormat.IsStructured() {
+			common.CheckError(cmdCommon.PrintStructured(outputFormat, forwards))
+			return
+		}
+		printForwards(forwards)
+	},
+}
+
+// 打印端口转发列表
+func printForwards(forwards []tunnel.DaemonForward) {
+	if len(forwards) <= 0 {
+		common.SmartIDELog.Info(i18nInstance.Tunnel.Info_forward_none)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	fmt.Fprintln(w, i18nInstance.Tunnel.Info_forward_table_header)
+	for _, forward := range forwards {
+		label := forward.Label
+		if label == "" {
+			label = "-"
+		}
+		lastError := forward.LastError
+		if lastError == "" {
+			lastError = "-"
+		}
+		direction := "->"
+		if forward.IsReverse {
+			direction = "<-"
+		}
+		line := fmt.Sprintf("%v\t%v\t%v\t%v\t%v\t%v\t%v", forward.WorkspaceID, label, forward.LocalPort, direction, forward.RemoteAddr, forward.IsConnected, lastError)
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+}
+
+func init() {
+	cmdCommon.AddOutputFlag(TunnelListCmd, i18nInstance.Main.Info_help_flag_output)
+}
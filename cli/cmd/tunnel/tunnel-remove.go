@@ -0,0 +1,67 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/tunnel"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flag_local_port = "local-port"
+	flag_reverse    = "reverse"
+)
+
+// initCmd represents the init command
+var TunnelRemoveCmd = &cobra.Command{
+	Use:     "remove",
+	Short:   i18nInstance.Tunnel.Info_help_remove_short,
+	Long:    i18nInstance.Tunnel.Info_help_remove_short,
+	Aliases: []string{"rm"},
+	Example: `  smartide tunnel rm <workspaceid>
+  smartide tunnel rm <workspaceid> --local-port 8080
+  smartide tunnel rm <workspaceid> --local-port 5432 --reverse`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		socketPath := tunnel.GetDaemonSocketPath()
+		if !tunnel.IsDaemonRunning(socketPath) {
+			common.SmartIDELog.Info(i18nInstance.Tunnel.Info_daemon_not_running)
+			return
+		}
+
+		localPorts, err := cmd.Flags().GetIntSlice(flag_local_port)
+		common.CheckError(err)
+		isReverse, err := cmd.Flags().GetBool(flag_reverse)
+		common.CheckError(err)
+		response, err := tunnel.SendDaemonRequest(socketPath, tunnel.DaemonRequest{
+			Action:      tunnel.DaemonActionEnum_Remove,
+			WorkspaceID: args[0],
+			LocalPorts:  localPorts,
+			IsReverse:   isReverse,
+		})
+		common.CheckError(err)
+		printForwards(response.Forwards)
+	},
+}
+
+func init() {
+	TunnelRemoveCmd.Flags().IntSliceP(flag_local_port, "l", []int{}, i18nInstance.Tunnel.Info_help_flag_local_port)
+	TunnelRemoveCmd.Flags().BoolP(flag_reverse, "r", false, i18nInstance.Tunnel.Info_help_flag_reverse)
+}
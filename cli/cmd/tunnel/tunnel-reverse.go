@@ -0,0 +1,52 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/tunnel"
+	"github.com/spf13/cobra"
+)
+
+// initCmd represents the init command
+var TunnelReverseCmd = &cobra.Command{
+	Use:   "reverse",
+	Short: i18nInstance.Tunnel.Info_help_reverse_short,
+	Long:  i18nInstance.Tunnel.Info_help_reverse_short,
+	Example: `  smartide tunnel reverse <workspaceid>
+  smartide tunnel reverse <workspaceid> 5432
+  smartide tunnel reverse <workspaceid> 15432:5432`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ports := args[1:]
+		for _, port := range ports { // 提前校验，避免启动守护进程后才报错
+			_, err := tunnel.ParseReverseForward(port)
+			common.CheckError(err)
+		}
+
+		common.CheckError(ensureDaemonRunning())
+		response, err := tunnel.SendDaemonRequest(tunnel.GetDaemonSocketPath(), tunnel.DaemonRequest{
+			Action:      tunnel.DaemonActionEnum_Reverse,
+			WorkspaceID: args[0],
+			Ports:       ports,
+		})
+		common.CheckError(err)
+		printForwards(response.Forwards)
+	},
+}
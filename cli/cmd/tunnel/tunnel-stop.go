@@ -0,0 +1,48 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/tunnel"
+	"github.com/spf13/cobra"
+)
+
+// initCmd represents the init command
+var TunnelStopCmd = &cobra.Command{
+	Use:     "stop",
+	Short:   i18nInstance.Tunnel.Info_help_stop_short,
+	Long:    i18nInstance.Tunnel.Info_help_stop_short,
+	Example: `  smartide tunnel stop`,
+	Run: func(cmd *cobra.Command, args []string) {
+		socketPath := tunnel.GetDaemonSocketPath()
+		if !tunnel.IsDaemonRunning(socketPath) {
+			common.SmartIDELog.Info(i18nInstance.Tunnel.Info_daemon_not_running)
+			return
+		}
+
+		_, err := tunnel.SendDaemonRequest(socketPath, tunnel.DaemonRequest{Action: tunnel.DaemonActionEnum_Stop})
+		common.CheckError(err)
+		common.SmartIDELog.Info(i18nInstance.Tunnel.Info_daemon_stopped)
+	},
+}
+
+func init() {
+
+}
@@ -25,6 +25,7 @@ import (
 	"strings"
 	"time"
 
+	cmdCommon "github.com/leansoftX/smartide-cli/cmd/common"
 	"github.com/leansoftX/smartide-cli/pkg/common"
 	"github.com/spf13/cobra"
 	"golang.org/x/text/encoding/simplifiedchinese"
@@ -36,12 +37,30 @@ var versionCmd = &cobra.Command{
 	Short:   i18nInstance.Version.Info_help_short,
 	Long:    i18nInstance.Version.Info_help_long,
 	Aliases: []string{"v"},
+	Example: `  smartide version
+  smartide version -o json`,
 	Run: func(cmd *cobra.Command, args []string) {
+		outputFormat, err := cmdCommon.GetOutputFormat(cmd)
+		common.CheckError(err)
+		if outputFormat.IsStructured() {
+			common.CheckError(cmdCommon.PrintStructured(outputFormat, Version.ToView()))
+			return
+		}
 
 		common.SmartIDELog.Console(Version.ConvertToJson())
 	},
 }
 
+// version 命令使用 --output json|yaml 时的输出格式，字段名称固定
+type SmartVersionView struct {
+	Version     string `json:"version" yaml:"version"`
+	BuildNumber string `json:"buildNumber" yaml:"buildNumber"`
+	BuildTime   string `json:"buildTime" yaml:"buildTime"`
+	Commit      string `json:"commit" yaml:"commit"`
+	Company     string `json:"company" yaml:"company"`
+	OS          string `json:"os" yaml:"os"`
+}
+
 type SmartVersion struct {
 	VersionNumber        string
 	TagName              string `json:"tag_name"`
@@ -64,8 +83,20 @@ func (smartVersion *SmartVersion) ConvertToJson() string {
 	return json
 }
 
-func init() {
+// 转换为输出格式
+func (smartVersion *SmartVersion) ToView() SmartVersionView {
+	return SmartVersionView{
+		Version:     smartVersion.VersionNumber,
+		BuildNumber: smartVersion.BuildNumber,
+		BuildTime:   common.LocalTimeStr(smartVersion.BuildTime),
+		Commit:      smartVersion.TargetCommitish,
+		Company:     smartVersion.Company,
+		OS:          getOsInformation(),
+	}
+}
 
+func init() {
+	cmdCommon.AddOutputFlag(versionCmd, i18nInstance.Main.Info_help_flag_output)
 }
 
 // 获取系统版本
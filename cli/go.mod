@@ -25,7 +25,7 @@ require (
 	github.com/docker/docker v20.10.14+incompatible
 	github.com/docker/go-connections v0.4.0
 	github.com/docker/go-units v0.4.0 // indirect
-	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gogo/protobuf v1.3.2 // indirect
 	github.com/gohouse/e v0.0.3-rc.0.20200727024801-fe7d4c2e0680 // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
@@ -53,11 +53,9 @@ require (
 	google.golang.org/grpc v1.40.0 // indirect
 	google.golang.org/protobuf v1.27.1 // indirect
 	gopkg.in/ini.v1 v1.62.0 // indirect
-
 )
 
 require (
-	github.com/bramvdbogaerde/go-scp v1.1.0
 	github.com/howeyc/gopass v0.0.0-20210920133722-c8aef6fb66ef
 	github.com/jeandeaual/go-locale v0.0.0-20210323163322-5cf4ff553a8d
 	github.com/leansoftX/i18n v0.0.0-20210903074237-61e743e338d1
@@ -126,7 +124,7 @@ require (
 	github.com/opencontainers/runc v1.0.1 // indirect
 	go.opencensus.io v0.23.0 // indirect
 	go.uber.org/zap v1.19.1
-	golang.org/x/term v0.1.0 // indirect
+	golang.org/x/term v0.1.0
 )
 
 require (
@@ -144,6 +142,7 @@ require (
 	github.com/pkg/sftp v1.13.4
 	github.com/thedevsaddam/gojsonq v2.3.0+incompatible
 	golang.org/x/sync v0.0.0-20220722155255-886fb9371eb4
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 	gorm.io/gorm v1.23.10
 	k8s.io/api v0.23.5
 	k8s.io/apimachinery v0.23.5
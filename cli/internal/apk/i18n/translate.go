@@ -40,7 +40,16 @@ type I18nSource struct {
 		Err_read_config         string `json:"err_read_config"`
 		Err_set_config          string `json:"err_set_config"`
 
+		Info_help_validate_short string `json:"info_help_validate_short"`
+		Info_help_validate_long  string `json:"info_help_validate_long"`
+		Info_help_show_short     string `json:"info_help_show_short"`
+		Info_help_show_long      string `json:"info_help_show_long"`
+		Info_help_flag_merged    string `json:"info_help_flag_merged"`
+
+		Info_config_overlay_merged string `json:"info_config_overlay_merged"`
+
 		Info_read_docker_compose      string `json:"info_read_docker_compose"`
+		Warn_compose_variable_not_set string `json:"warn_compose_variable_not_set"`
 		Err_services_not_exit         string `json:"err_services_not_exit"`
 		Err_file_not_exit             string `json:"err_file_not_exit"`
 		Err_devcontainer_not_contains string `json:"err_devcontainer_not_contains"`
@@ -58,13 +67,17 @@ type I18nSource struct {
 	} `json:"config"`
 
 	Main struct {
-		Info_help_short        string `json:"info_help_short"`
-		Info_help_long         string `json:"info_help_long"`
-		Info_help_flag_debug   string `json:"info_help_flag_debug"`
-		Info_Usage_template    string `json:"info_usage_template"`
-		Info_workspace_loading string `json:"info_workspace_loading"`
-		Info_ssh_connect_check string `json:"info_ssh_connect_check"`
-		Info_version_local     string `json:"info_version_local"`
+		Info_help_short                     string `json:"info_help_short"`
+		Info_help_long                      string `json:"info_help_long"`
+		Info_help_flag_debug                string `json:"info_help_flag_debug"`
+		Info_help_flag_output               string `json:"info_help_flag_output"`
+		Info_help_flag_host_key_fingerprint string `json:"info_help_flag_host_key_fingerprint"`
+		Info_help_flag_ssh_timeout          string `json:"info_help_flag_ssh_timeout"`
+		Info_help_flag_config_overlay       string `json:"info_help_flag_config_overlay"`
+		Info_Usage_template                 string `json:"info_usage_template"`
+		Info_workspace_loading              string `json:"info_workspace_loading"`
+		Info_ssh_connect_check              string `json:"info_ssh_connect_check"`
+		Info_version_local                  string `json:"info_version_local"`
 
 		Info_help_flag_mode                string `json:"info_help_flag_mode"`
 		Info_help_flag_server_workspace_id string `json:"info_help_flag_server_workspace_id"`
@@ -112,6 +125,16 @@ type I18nSource struct {
 		Info_open_brower           string `json:"info_open_brower"`
 		Info_git_cloned            string `json:"info_git_cloned"`
 		Info_tunnel_waiting        string `json:"info_tunnel_waiting"`
+		Info_sync_start            string `json:"info_sync_start"`
+		Info_sync_initial_done     string `json:"info_sync_initial_done"`
+		Info_sync_watching         string `json:"info_sync_watching"`
+		Info_sync_pushed           string `json:"info_sync_pushed"`
+		Info_sync_removed_remote   string `json:"info_sync_removed_remote"`
+		Info_sync_pulled           string `json:"info_sync_pulled"`
+		Info_sync_removed_local    string `json:"info_sync_removed_local"`
+		Warn_sync_conflict         string `json:"warn_sync_conflict"`
+		Err_sync                   string `json:"err_sync"`
+		Warn_sync_not_watching     string `json:"warn_sync_not_watching"`
 		Info_callback_msg          string `json:"info_callback_msg"`
 	} `json:"vm_start"`
 
@@ -135,6 +158,8 @@ type I18nSource struct {
 		Info_help_short                     string `json:"info_help_short"`
 		Info_help_long                      string `json:"info_help_long"`
 		Info_help_flag_host                 string `json:"info_help_flag_host"`
+		Info_help_flag_sync                 string `json:"info_help_flag_sync"`
+		Err_sync_remote_only                string `json:"err_sync_remote_only"`
 		Info_help_flag_port                 string `json:"info_help_flag_port"`
 		Info_help_flag_username             string `json:"info_help_flag_username"`
 		Info_help_flag_password             string `json:"info_help_flag_pasword"`
@@ -172,6 +197,11 @@ type I18nSource struct {
 
 		Warn_docker_container_started string `json:"warn_docker_container_started"`
 		Warn_docker_container_getnone string `json:"warn_docker_container_getnone"`
+
+		Info_k8s_build_image_exist      string `json:"info_k8s_build_image_exist"`
+		Err_k8s_build_context_not_exist string `json:"err_k8s_build_context_not_exist"`
+		Info_k8s_build_on_host          string `json:"info_k8s_build_on_host"`
+		Err_k8s_build_host_not_exist    string `json:"err_k8s_build_host_not_exist"`
 	} `json:"start"`
 
 	List struct {
@@ -277,10 +307,12 @@ type I18nSource struct {
 
 		Err_host_data_not_exit string `json:"err_host_data_not_exit"`
 
-		Info_help_host_add_short    string `json:"info_help_host_add_short"`
-		Info_help_host_add_long     string `json:"info_help_host_add_long"`
-		Info_help_host_remove_short string `json:"info_help_host_remove_short"`
-		Info_help_host_remove_long  string `json:"info_help_host_remove_long"`
+		Info_help_host_add_short     string `json:"info_help_host_add_short"`
+		Info_help_host_add_long      string `json:"info_help_host_add_long"`
+		Info_help_host_remove_short  string `json:"info_help_host_remove_short"`
+		Info_help_host_remove_long   string `json:"info_help_host_remove_long"`
+		Info_help_flag_identity_file string `json:"info_help_flag_identity_file"`
+		Info_help_flag_jump          string `json:"info_help_flag_jump"`
 
 		Err_host_add_addr_required     string `json:"err_host_add_addr_required"`
 		Err_host_add_username_required string `json:"err_host_add_username_required"`
@@ -288,6 +320,19 @@ type I18nSource struct {
 		Add_start                      string `json:"add_start"`
 		Remove_start                   string `json:"remove_start"`
 		Info_host_remove_success       string `json:"info_host_remove_success"`
+
+		Info_help_host_import_short       string `json:"info_help_host_import_short"`
+		Info_help_host_import_long        string `json:"info_help_host_import_long"`
+		Info_help_flag_pattern            string `json:"info_help_flag_pattern"`
+		Info_help_flag_dry_run            string `json:"info_help_flag_dry_run"`
+		Import_start                      string `json:"import_start"`
+		Info_host_import_none             string `json:"info_host_import_none"`
+		Info_host_import_table_header     string `json:"info_host_import_table_header"`
+		Info_host_import_status_duplicate string `json:"info_host_import_status_duplicate"`
+		Info_host_import_status_dry_run   string `json:"info_host_import_status_dry_run"`
+		Info_host_import_status_failed    string `json:"info_host_import_status_failed"`
+		Info_host_import_status_imported  string `json:"info_host_import_status_imported"`
+		Info_host_import_success          string `json:"info_host_import_success"`
 	} `json:"host"`
 
 	Common struct {
@@ -295,6 +340,8 @@ type I18nSource struct {
 		Debug_same_not_overwrite   string `json:"debug_same_not_overwrite"`
 		Debug_auto_connect_gitrepo string `json:"debug_auto_connect_gitrepo"`
 		Debug_empty_error          string `json:"debug_empty_error"`
+		Debug_dal_migrated         string `json:"debug_dal_migrated"`
+		Debug_dal_initialized      string `json:"debug_dal_initialized"`
 
 		Err_sshremote_param_repourl_none      string `json:"err_sshremote_param_repourl_none"`
 		Err_password_none                     string `json:"err_password_none"`
@@ -304,6 +351,20 @@ type I18nSource struct {
 		Err_enum_error                        string `json:"err_ernum_error"`
 		Err_ssh_password_required             string `json:"err_ssh_password_required"`
 		Err_ssh_dial_none                     string `json:"err_ssh_dial_none"`
+		Err_ssh_host_key_changed              string `json:"err_ssh_host_key_changed"`
+		Err_ssh_host_key_unknown              string `json:"err_ssh_host_key_unknown"`
+		Err_ssh_host_key_mismatch             string `json:"err_ssh_host_key_mismatch"`
+		Err_ssh_host_key_rejected             string `json:"err_ssh_host_key_rejected"`
+		Info_ssh_host_key_confirm             string `json:"info_ssh_host_key_confirm"`
+		Info_ssh_host_key_added               string `json:"info_ssh_host_key_added"`
+		Err_ssh_identity_file_read            string `json:"err_ssh_identity_file_read"`
+		Err_ssh_identity_passphrase_required  string `json:"err_ssh_identity_passphrase_required"`
+		Err_ssh_no_identity                   string `json:"err_ssh_no_identity"`
+		Info_ssh_identity_passphrase          string `json:"info_ssh_identity_passphrase"`
+		Err_ssh_jump_host_invalid             string `json:"err_ssh_jump_host_invalid"`
+		Err_ssh_jump_host_connect             string `json:"err_ssh_jump_host_connect"`
+		Err_ssh_command_timeout               string `json:"err_ssh_command_timeout"`
+		Err_ssh_command_canceled              string `json:"err_ssh_command_canceled"`
 		Err_dal_remote_reference_by_workspace string `json:"err_dal_remote_reference_by_workspace"`
 
 		Info_privatekey_is_overwrite       string `json:"info_privatekey_is_overwrite"`
@@ -316,6 +377,12 @@ type I18nSource struct {
 		Info_port_binding_result2          string `json:"info_port_binding_result2"`
 		Info_port_binding_result           string `json:"info_port_binding_result"`
 		Info_find_new_port                 string `json:"info_find_new_port"`
+		Info_port_unbinding                string `json:"info_port_unbinding"`
+		Info_port_reverse_binding          string `json:"info_port_reverse_binding"`
+		Info_upload_progress               string `json:"info_upload_progress"`
+		Info_lifecycle_running             string `json:"info_lifecycle_running"`
+		Info_lifecycle_skipped             string `json:"info_lifecycle_skipped"`
+		Err_lifecycle_failed               string `json:"err_lifecycle_failed"`
 		Info_ssh_webide_host_port          string `json:"info_ssh_webide_host_port"`
 		Info_ssh_host_port                 string `json:"info_ssh_host_port"`
 		Info_temp_create_directory         string `json:"info_temp_create_directory"`
@@ -348,6 +415,79 @@ type I18nSource struct {
 		Warn_confirm_all_remove string `json:"warn_confirm_all_remove"`
 	} `json:"reset"`
 
+	Export struct {
+		Info_help_short                string `json:"info_help_short"`
+		Info_help_long                 string `json:"info_help_long"`
+		Info_help_flag_output          string `json:"info_help_flag_output"`
+		Info_help_flag_include_project string `json:"info_help_flag_include_project"`
+		Info_export_success            string `json:"info_export_success"`
+		Warn_project_not_supported     string `json:"warn_project_not_supported"`
+	} `json:"export"`
+
+	Import struct {
+		Info_help_short         string `json:"info_help_short"`
+		Info_help_long          string `json:"info_help_long"`
+		Info_help_flag_dir      string `json:"info_help_flag_dir"`
+		Info_import_success     string `json:"info_import_success"`
+		Warn_project_none       string `json:"warn_project_none"`
+		Err_host_not_exist      string `json:"err_host_not_exist"`
+		Err_directory_not_empty string `json:"err_directory_not_empty"`
+		Err_bundle_name_invalid string `json:"err_bundle_name_invalid"`
+	} `json:"import"`
+
+	Db struct {
+		Info_help_short         string `json:"info_help_short"`
+		Info_help_long          string `json:"info_help_long"`
+		Info_help_migrate_short string `json:"info_help_migrate_short"`
+		Info_help_status_short  string `json:"info_help_status_short"`
+		Info_migrate_success    string `json:"info_migrate_success"`
+		Info_migrate_latest     string `json:"info_migrate_latest"`
+		Info_migrate_backup     string `json:"info_migrate_backup"`
+	} `json:"db"`
+
+	Tunnel struct {
+		Info_help_short                  string `json:"info_help_short"`
+		Info_help_long                   string `json:"info_help_long"`
+		Info_help_daemon_short           string `json:"info_help_daemon_short"`
+		Info_help_list_short             string `json:"info_help_list_short"`
+		Info_help_add_short              string `json:"info_help_add_short"`
+		Info_help_remove_short           string `json:"info_help_remove_short"`
+		Info_help_reverse_short          string `json:"info_help_reverse_short"`
+		Info_help_stop_short             string `json:"info_help_stop_short"`
+		Info_help_flag_port              string `json:"info_help_flag_port"`
+		Info_help_flag_local_port        string `json:"info_help_flag_local_port"`
+		Info_help_flag_reverse           string `json:"info_help_flag_reverse"`
+		Info_daemon_not_running          string `json:"info_daemon_not_running"`
+		Info_daemon_started              string `json:"info_daemon_started"`
+		Info_daemon_stopped              string `json:"info_daemon_stopped"`
+		Info_forward_none                string `json:"info_forward_none"`
+		Info_forward_table_header        string `json:"info_forward_table_header"`
+		Err_daemon_start_timeout         string `json:"err_daemon_start_timeout"`
+		Err_workspace_mode_not_supported string `json:"err_workspace_mode_not_supported"`
+	} `json:"tunnel"`
+
+	Ssh struct {
+		Info_help_short                  string `json:"info_help_short"`
+		Info_help_long                   string `json:"info_help_long"`
+		Info_help_flag_tty               string `json:"info_help_flag_tty"`
+		Info_help_flag_forward_agent     string `json:"info_help_flag_forward_agent"`
+		Info_connecting                  string `json:"info_connecting"`
+		Err_ssh_port_none                string `json:"err_ssh_port_none"`
+		Err_workspace_mode_not_supported string `json:"err_workspace_mode_not_supported"`
+	} `json:"ssh"`
+
+	Exec struct {
+		Info_help_short                  string `json:"info_help_short"`
+		Info_help_long                   string `json:"info_help_long"`
+		Info_help_flag_service           string `json:"info_help_flag_service"`
+		Info_help_flag_user              string `json:"info_help_flag_user"`
+		Info_help_flag_interactive       string `json:"info_help_flag_interactive"`
+		Info_help_flag_tty               string `json:"info_help_flag_tty"`
+		Err_command_none                 string `json:"err_command_none"`
+		Err_container_none               string `json:"err_container_none"`
+		Err_workspace_mode_not_supported string `json:"err_workspace_mode_not_supported"`
+	} `json:"exec"`
+
 	Login struct {
 		Info_help_short         string `json:"info_help_short"`
 		Info_help_long          string `json:"info_help_long"`
@@ -32,8 +32,8 @@ func GetWebTerminalCompose(contaninerName, pwd string) compose.Service {
 	webTerminalService.ContainerName = contaninerName
 	webTerminalService.Image = fmt.Sprintf("%v/smartide/smartide-webterminal", GlobalSmartIdeConfig.ImagesRegistry)
 	webTerminalService.Restart = "always"
-	webTerminalService.AppendPort("6860:6860")
-	webTerminalService.Volumes = append(webTerminalService.Volumes, "/var/run/docker.sock:/var/run/docker.sock")
+	webTerminalService.AppendPort(compose.NewPortSimpleSame(6860))
+	webTerminalService.AppendVolume(compose.NewVolumeMapSimple("/var/run/docker.sock", "/var/run/docker.sock"))
 	webTerminalService.Networks = append(webTerminalService.Networks, "smartide-network")
 	webTerminalService.Environment = map[string]string{
 		"LOCAL_USER_GID":        "1000",
@@ -21,7 +21,7 @@ package config
 import (
 	"fmt"
 	"os"
-	"runtime"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -91,28 +91,17 @@ func (yamlFileConfig *SmartIdeConfig) LoadDockerComposeFromTempFile(sshRemote co
 			if serviceName != yamlFileConfig.Workspace.DevContainer.ServiceName {
 				continue
 			}
-			for _, port := range service.Ports {
-				containerWebIDEPort := yamlFileConfig.GetContainerWebIDEPort()
-				if containerWebIDEPort != nil && strings.Contains(port, ":"+strconv.Itoa(*containerWebIDEPort)) { // webide 端口
-					index := strings.Index(port, ":")
-					if index > 0 {
-						ideBindingPort, _ = strconv.Atoi(port[:index])
-						if ideBindingPort > 0 {
-							common.SmartIDELog.DebugF(i18nInstance.Common.Info_ssh_webide_host_port, ideBindingPort)
-							continue
-						}
-					}
-				} else if strings.Contains(port, ":"+strconv.Itoa(model.CONST_Container_SSHPort)) { // ssh 端口
-					index := strings.Index(port, ":")
-					if index > 0 {
-						sshBindingPort, _ = strconv.Atoi(port[:index])
-						if sshBindingPort > 0 {
-							common.SmartIDELog.DebugF(i18nInstance.Common.Info_ssh_host_port, sshBindingPort)
-							continue
-						}
-					}
+			containerWebIDEPort := yamlFileConfig.GetContainerWebIDEPort()
+			if containerWebIDEPort != nil { // webide 端口
+				if port := service.GetPublishedPort(*containerWebIDEPort); port > 0 {
+					ideBindingPort = port
+					common.SmartIDELog.DebugF(i18nInstance.Common.Info_ssh_webide_host_port, ideBindingPort)
 				}
 			}
+			if port := service.GetPublishedPort(model.CONST_Container_SSHPort); port > 0 { // ssh 端口
+				sshBindingPort = port
+				common.SmartIDELog.DebugF(i18nInstance.Common.Info_ssh_host_port, sshBindingPort)
+			}
 		}
 	}
 	//TODO: 在k8s 的yaml文件中查找端口是否申明
@@ -164,12 +153,18 @@ func (yamlFileConfig *SmartIdeConfig) ConvertToDockerCompose(sshRemote common.SS
 	dockerCompose, err := yamlFileConfig.getDockerCompose(sshRemote, remoteWorkingDir)
 	common.CheckError(err)
 
-	//2.2. 检查devContainer中定义的service时候存在于services中
+	//2.2. 根据 profiles 过滤服务，开发容器始终启动
+	dockerCompose.ApplyProfiles(yamlFileConfig.Workspace.Profiles, yamlFileConfig.Workspace.DevContainer.ServiceName)
+
+	//2.3. 检查devContainer中定义的service时候存在于services中
 	if _, ok := dockerCompose.Services[yamlFileConfig.Workspace.DevContainer.ServiceName]; !ok { // 是否定义了devContainer节点对应的service
 		err := fmt.Sprintf(i18nInstance.Config.Err_devcontainer_not_contains, yamlFileConfig.Workspace.DevContainer.ServiceName)
 		common.SmartIDELog.Error(err)
 	}
 
+	//2.4. 开发容器的镜像通过 Dockerfile 构建
+	yamlFileConfig.applyDevContainerBuild(&dockerCompose, projectName)
+
 	//3. 转换为docker compose - 端口绑定
 	//3.1. 端口映射
 	for serviceName, service := range dockerCompose.Services {
@@ -183,37 +178,58 @@ func (yamlFileConfig *SmartIdeConfig) ConvertToDockerCompose(sshRemote common.SS
 		// 绑定端口被占用的问题
 		if isCheckUnuesedPorts {
 			hasChange := false
-			for index, portMapStr := range service.Ports {
-				binding := strings.Split(portMapStr, ":")
-				bindingPortOld, err := strconv.Atoi(binding[0])
+			ports := compose.Ports{}
+			for _, port := range service.Ports {
+				portConfigs, err := port.ToComplex()
 				common.CheckError(err)
 
-				containerPort, err := strconv.Atoi(binding[1])
-				common.CheckError(err)
+				isPortChanged := false
+				for index, portConfig := range portConfigs {
+					containerPort := portConfig.Target
+					bindingPortOld := portConfig.GetPublishedPort()
+					if bindingPortOld <= 0 || strings.Contains(portConfig.Published, "-") { // 随机端口、端口范围由 docker 分配
+						continue
+					}
 
-				bindingPortNew, err := checkAndGetAvailableRemotePort(sshRemote, bindingPortOld, 10) // 检测端口是否被占用
-				common.CheckError(err)
-				if bindingPortOld != bindingPortNew {
-					service.Ports[index] = fmt.Sprintf("%v:%v", bindingPortNew, containerPort)
-
-					common.SmartIDELog.DebugF("localhost:%v (%v 被占用) -> container:%v", bindingPortNew, bindingPortOld, containerPort)
-					hasChange = true
-
-					// ide、ssh端口更新
-					if serviceName == yamlFileConfig.Workspace.DevContainer.ServiceName {
-						containerWebIDEPort := yamlFileConfig.GetContainerWebIDEPort()
-						if containerWebIDEPort != nil && containerPort == *containerWebIDEPort {
-							ideBindingPort = bindingPortNew
-						} else if containerPort == model.CONST_Container_SSHPort {
-							sshBindingPort = bindingPortNew
+					bindingPortNew, err := checkAndGetAvailableRemotePort(sshRemote, bindingPortOld, 10) // 检测端口是否被占用
+					common.CheckError(err)
+					if bindingPortOld != bindingPortNew {
+						portConfigs[index].Published = strconv.Itoa(bindingPortNew)
+						isPortChanged = true
+
+						common.SmartIDELog.DebugF("localhost:%v (%v 被占用) -> container:%v", bindingPortNew, bindingPortOld, containerPort)
+
+						// ide、ssh端口更新
+						if serviceName == yamlFileConfig.Workspace.DevContainer.ServiceName {
+							containerWebIDEPort := yamlFileConfig.GetContainerWebIDEPort()
+							if containerWebIDEPort != nil && containerPort == *containerWebIDEPort {
+								ideBindingPort = bindingPortNew
+							} else if containerPort == model.CONST_Container_SSHPort {
+								sshBindingPort = bindingPortNew
+							}
 						}
+					} else {
+						common.SmartIDELog.DebugF("localhost:%v -> container:%v", bindingPortOld, containerPort)
+					}
+					yamlFileConfig.setPort4Label(containerPort, bindingPortOld, bindingPortNew, serviceName)
+				}
+
+				// 修改后保持原来的格式，端口范围展开为多个映射
+				if !isPortChanged {
+					ports = append(ports, port)
+					continue
+				}
+				hasChange = true
+				for _, portConfig := range portConfigs {
+					if _, isSimple := port.(compose.PortSimple); isSimple {
+						ports = append(ports, portConfig.ToSimple())
+					} else {
+						ports = append(ports, portConfig)
 					}
-				} else {
-					common.SmartIDELog.DebugF("localhost:%v -> container:%v", bindingPortOld, containerPort)
 				}
-				yamlFileConfig.setPort4Label(containerPort, bindingPortOld, bindingPortNew, serviceName)
 			}
 			if hasChange {
+				service.Ports = ports
 				dockerCompose.Services[serviceName] = service
 			}
 		}
@@ -241,7 +257,7 @@ func (yamlFileConfig *SmartIdeConfig) ConvertToDockerCompose(sshRemote common.SS
 				}
 
 				//
-				service.AppendPort(strconv.Itoa(ideBindingPort) + ":" + strconv.Itoa(*containerWebIDEPort))
+				service.AppendPort(compose.NewPortSimple(ideBindingPort, *containerWebIDEPort))
 			}
 
 			// ssh port
@@ -261,7 +277,7 @@ func (yamlFileConfig *SmartIdeConfig) ConvertToDockerCompose(sshRemote common.SS
 				}
 
 				//
-				service.AppendPort(strconv.Itoa(sshBindingPort) + ":" + strconv.Itoa(model.CONST_Container_SSHPort))
+				service.AppendPort(compose.NewPortSimple(sshBindingPort, model.CONST_Container_SSHPort))
 			}
 
 		}
@@ -298,7 +314,7 @@ func (yamlFileConfig *SmartIdeConfig) ConvertToDockerCompose(sshRemote common.SS
 				if service.ContainContainerPort(int(port)) {
 					common.SmartIDELog.Importance(fmt.Sprintf("端口 %v 映射已存在，将被覆盖", port)) //TODO 注明原端口的label 和 映射信息，以及 新端口的label、映射信息
 				}
-				service.AppendPort(fmt.Sprintf("%v:%v", availablePort, port))
+				service.AppendPort(compose.NewPortSimple(availablePort, int(port)))
 
 			}
 
@@ -329,11 +345,9 @@ func (yamlFileConfig *SmartIdeConfig) ConvertToDockerCompose(sshRemote common.SS
 
 			// 查找目录映射的volume
 			indexProjectVolume := -1
-			volumeProject := ""
 			for indexVolume, volume := range service.Volumes {
-				if strings.Contains(volume, "/home/project") {
+				if volumeConfig, err := volume.ToComplex(); err == nil && strings.Contains(volumeConfig.Target, "/home/project") {
 					indexProjectVolume = indexVolume
-					volumeProject = volume
 				}
 			}
 
@@ -345,26 +359,25 @@ func (yamlFileConfig *SmartIdeConfig) ConvertToDockerCompose(sshRemote common.SS
 			common.CheckError(err)
 
 			// 设置目录映射值
-			isWindows := runtime.GOOS == "windows"
 			if indexProjectVolume > -1 { // 当存在配置时，需要吧把 “.” 替换为当前目录
-				// 本地模式下，把“.”替换为当前目录
-				if strings.Index(volumeProject, ".") == 0 {
-					if isWindows && !isRemoteMode {
-						service.Volumes[indexProjectVolume] = "\\'" + twd + volumeProject[1:] + "\\'"
-					} else {
-						service.Volumes[indexProjectVolume] = twd + volumeProject[1:]
+				switch volume := service.Volumes[indexProjectVolume].(type) {
+				case compose.VolumeMapSimple:
+					if strings.Index(volume.Host, ".") == 0 {
+						volume.Host = twd + volume.Host[1:]
+						service.Volumes[indexProjectVolume] = volume
+					}
+				case compose.VolumeMapComplex:
+					if strings.Index(volume.Source, ".") == 0 {
+						volume.Source = twd + volume.Source[1:]
+						service.Volumes[indexProjectVolume] = volume
 					}
-
-					// 重置
-					dockerCompose.Services[serviceName] = service
 				}
 
+				// 重置
+				dockerCompose.Services[serviceName] = service
+
 			} else { // insert default project volume
-				if isWindows && !isRemoteMode {
-					service.Volumes = append(service.Volumes, fmt.Sprintf("\\'%v:%v\\'", twd, "/home/project/"+projectName))
-				} else {
-					service.Volumes = append(service.Volumes, fmt.Sprintf("%v:%v", twd, "/home/project/"+projectName))
-				}
+				service.AppendVolume(compose.NewVolumeMapSimple(twd, "/home/project/"+projectName))
 
 				// 重置
 				dockerCompose.Services[serviceName] = service
@@ -459,7 +472,8 @@ func (yamlFileConfig SmartIdeConfig) getDockerCompose(sshRemote common.SSHRemote
 
 		if isRemoteMode {
 			// 获取docker-compose文件在远程主机上的路径
-			remoteDockerComposeFilePath := common.FilePahtJoin4Linux(remoteWorkingDir, ".ide", yamlFileConfig.Workspace.DockerComposeFile)
+			configFileDir := filepath.Dir(yamlFileConfig.Workspace.DevContainer.configRelativeFilePath) // 链接文件是相对于配置文件所在目录的
+			remoteDockerComposeFilePath := common.FilePahtJoin4Linux(remoteWorkingDir, configFileDir, yamlFileConfig.Workspace.DockerComposeFile)
 			common.SmartIDELog.InfoF(i18nInstance.Config.Info_read_docker_compose, remoteDockerComposeFilePath)
 
 			// 在远程主机上加载docker-compose文件
@@ -468,6 +482,10 @@ func (yamlFileConfig SmartIdeConfig) getDockerCompose(sshRemote common.SSHRemote
 			if err != nil {
 				return dockerCompose, err
 			}
+			output, err = interpolateLinkComposeWithWarning(&sshRemote, remoteDockerComposeFilePath, output)
+			if err != nil {
+				return dockerCompose, err
+			}
 			dockerComposeFileBytes = []byte(output)
 
 		} else {
@@ -478,6 +496,11 @@ func (yamlFileConfig SmartIdeConfig) getDockerCompose(sshRemote common.SSHRemote
 			if err != nil {
 				return dockerCompose, err
 			}
+			content, err := interpolateLinkComposeWithWarning(nil, linkDockerComposeFilePath, string(dockerComposeFileBytes))
+			if err != nil {
+				return dockerCompose, err
+			}
+			dockerComposeFileBytes = []byte(content)
 
 		}
 
@@ -505,6 +528,7 @@ func (yamlFileConfig SmartIdeConfig) getDockerCompose(sshRemote common.SSHRemote
 		dockerCompose.Networks = notReferenceConfig.Workspace.Networks
 		dockerCompose.Volumes = notReferenceConfig.Workspace.Volumes
 		dockerCompose.Secrets = notReferenceConfig.Workspace.Secrets
+		dockerCompose.Configs = notReferenceConfig.Workspace.Configs
 	}
 
 	return dockerCompose, err
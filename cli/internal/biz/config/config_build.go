@@ -0,0 +1,86 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+)
+
+// 开发容器镜像的构建配置转换为 docker-compose 中的 build 节点，没有设置时返回 false
+// 构建上下文相对于配置文件（configRelativeFilePath）所在的目录，转换后相对于工作目录；dockerfile 相对于构建上下文
+func (d DevContainerConfig) GetBuild(configRelativeFilePath string) (build compose.Build, isBuild bool) {
+	buildConfig := d.Build
+	if buildConfig == nil {
+		return build, false
+	}
+
+	configFileDir := filepath.ToSlash(filepath.Dir(configRelativeFilePath))
+	context := buildConfig.Context
+	if context == "" {
+		context = "."
+	}
+	if !path.IsAbs(context) {
+		context = path.Join(configFileDir, context)
+	}
+	build = compose.Build{
+		Context:    context,
+		Dockerfile: buildConfig.Dockerfile,
+		Target:     buildConfig.Target,
+	}
+	if len(buildConfig.Args) > 0 {
+		build.Args = map[string]interface{}{}
+		for key, value := range buildConfig.Args {
+			build.Args[key] = value
+		}
+	}
+	for _, image := range buildConfig.CacheFrom {
+		build.CacheFrom = append(build.CacheFrom, parseImage(image))
+	}
+	return build, true
+}
+
+// 构建后开发容器镜像的名称，没有设置镜像仓库时只在本地使用
+func (d DevContainerConfig) GetBuildImageName(projectName string) string {
+	imageName := fmt.Sprintf("smartide-%v-%v", sanitizeImageName(projectName), sanitizeImageName(d.ServiceName))
+	if d.Build != nil && d.Build.Registry != "" {
+		imageName = strings.TrimSuffix(d.Build.Registry, "/") + "/" + imageName
+	}
+	return imageName
+}
+
+// 在开发容器对应的服务中设置构建配置，镜像名称始终使用构建的镜像名称，不覆盖服务中 image 指定的镜像
+func (c *SmartIdeConfig) applyDevContainerBuild(dockerCompose *compose.DockerComposeYml, projectName string) {
+	build, isBuild := c.Workspace.DevContainer.GetBuild(c.Workspace.DevContainer.configRelativeFilePath)
+	if !isBuild {
+		return
+	}
+	serviceName := c.Workspace.DevContainer.ServiceName
+	service, ok := dockerCompose.Services[serviceName]
+	if !ok {
+		return
+	}
+	service.Build = build
+	service.Image = c.Workspace.DevContainer.GetBuildImageName(projectName)
+	dockerCompose.Services[serviceName] = service
+}
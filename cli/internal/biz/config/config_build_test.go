@@ -0,0 +1,97 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+)
+
+func TestApplyDevContainerBuild(t *testing.T) {
+	tests := []struct {
+		name      string
+		build     *DevContainerBuildConfig
+		image     string
+		wantBuild compose.Build
+		wantImage string
+	}{
+		{
+			name:      "no build",
+			image:     "node:16",
+			wantImage: "node:16",
+		},
+		{
+			name:      "default context",
+			build:     &DevContainerBuildConfig{},
+			wantBuild: compose.Build{Context: ".ide"},
+			wantImage: "smartide-my-project-dev",
+		},
+		{
+			name: "all options",
+			build: &DevContainerBuildConfig{
+				Context:    "..",
+				Dockerfile: "docker/dev.Dockerfile",
+				Args:       map[string]string{"NODE_VERSION": "16"},
+				Target:     "dev",
+				CacheFrom:  []string{"localhost:5000/smartide/node:16"},
+				Registry:   "registry.example.com/smartide/",
+			},
+			wantBuild: compose.Build{
+				Context:    ".",
+				Dockerfile: "docker/dev.Dockerfile",
+				Args:       map[string]interface{}{"NODE_VERSION": "16"},
+				Target:     "dev",
+				CacheFrom:  []compose.Image{compose.NewImage("localhost:5000/smartide/node", "16")},
+			},
+			wantImage: "registry.example.com/smartide/smartide-my-project-dev",
+		},
+		{
+			name:      "replace image",
+			build:     &DevContainerBuildConfig{Context: "/src"},
+			image:     "smartide/dev:latest",
+			wantBuild: compose.Build{Context: "/src"},
+			wantImage: "smartide-my-project-dev",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			smartideConfig := &SmartIdeConfig{}
+			smartideConfig.Workspace.DevContainer.ServiceName = "dev"
+			smartideConfig.Workspace.DevContainer.Build = tt.build
+			smartideConfig.Workspace.DevContainer.configRelativeFilePath = ".ide/.ide.yaml"
+			dockerCompose := compose.DockerComposeYml{
+				Services: map[string]compose.Service{"dev": {Image: tt.image}, "db": {Image: "mysql"}},
+			}
+
+			smartideConfig.applyDevContainerBuild(&dockerCompose, "My Project")
+			service := dockerCompose.Services["dev"]
+			if !reflect.DeepEqual(service.Build, tt.wantBuild) {
+				t.Errorf("applyDevContainerBuild() build = %+v, want %+v", service.Build, tt.wantBuild)
+			}
+			if service.Image != tt.wantImage {
+				t.Errorf("applyDevContainerBuild() image = %v, want %v", service.Image, tt.wantImage)
+			}
+			if dockerCompose.Services["db"].Build.Context != "" {
+				t.Errorf("applyDevContainerBuild() should only change the dev container service")
+			}
+		})
+	}
+}
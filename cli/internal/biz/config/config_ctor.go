@@ -41,21 +41,48 @@ import (
 // 国际化
 var i18nInstance = i18n.GetInstance()
 
-// 远程主机模式的配置文件
-func NewRemoteConfig(sshRemote *common.SSHRemote, workingDir string, relativeConfigFilePath string) (
+// 远程主机模式的配置文件，gitRepoUrl 用于查找当前用户对项目的配置覆盖文件
+func NewRemoteConfig(sshRemote *common.SSHRemote, workingDir string, relativeConfigFilePath string, gitRepoUrl string) (
 	result *SmartIdeConfig, err error) {
 
 	if sshRemote != nil { // 从vm上加载配置文件
 		// 配置文件路径
 		ideYamlFilePath := common.FilePahtJoin4Linux(workingDir, relativeConfigFilePath)
 
+		// 没有 .ide.yaml 时，使用 devcontainer.json
+		isDevContainerJson := IsDevContainerJsonFile(relativeConfigFilePath)
+		if !isDevContainerJson && isDefaultConfigFilePath(relativeConfigFilePath) && !sshRemote.IsFileExist(ideYamlFilePath) {
+			if devContainerJsonFilePath := GetRemoteDevContainerJsonRelativeFilePath(sshRemote, workingDir); devContainerJsonFilePath != "" {
+				relativeConfigFilePath = devContainerJsonFilePath
+				ideYamlFilePath = common.FilePahtJoin4Linux(workingDir, relativeConfigFilePath)
+				isDevContainerJson = true
+			}
+		}
+
 		// 从远程服务器上加载配置文件
 		catCommand := fmt.Sprintf(`cat %v`, ideYamlFilePath)
 		configYamlContent, err := sshRemote.ExeSSHCommand(catCommand)
 		if err != nil {
 			return nil, err
 		}
-		result = newConfig(workingDir, relativeConfigFilePath, configYamlContent, OrchestratorTypeEnum_Compose, true)
+		if !isDevContainerJson { // 合并远程主机上的 .ide.local.yaml，以及本地的配置覆盖文件
+			configYamlContent, err = mergeRemoteLocalConfigOverlay(sshRemote, ideYamlFilePath, configYamlContent)
+			if err != nil {
+				return nil, err
+			}
+			configYamlContent, err = mergeUserConfigOverlays(configYamlContent, workingDir, relativeConfigFilePath, gitRepoUrl)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if isDevContainerJson {
+			result, err = newConfigFromDevContainerJson(workingDir, relativeConfigFilePath, configYamlContent)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			result = newConfig(workingDir, relativeConfigFilePath, configYamlContent, OrchestratorTypeEnum_Compose, true)
+		}
 
 		// 如果有链接文件也需要加载
 		if result.IsLinkDockerComposeFile() {
@@ -84,8 +111,27 @@ func NewRemoteConfig(sshRemote *common.SSHRemote, workingDir string, relativeCon
 // 本地主机 模式的配置文件
 func NewLocalConfig(localWorkingDir string, relativeConfigFilePath string) (
 	result *SmartIdeConfig, err error) {
+	// 没有 .ide.yaml 时，使用 devcontainer.json
+	if !IsDevContainerJsonFile(relativeConfigFilePath) && isDefaultConfigFilePath(relativeConfigFilePath) &&
+		!common.IsExist(filepath.Join(localWorkingDir, model.CONST_Default_ConfigRelativeFilePath)) {
+		if devContainerJsonFilePath := GetLocalDevContainerJsonRelativeFilePath(localWorkingDir); devContainerJsonFilePath != "" {
+			relativeConfigFilePath = devContainerJsonFilePath
+		}
+	}
+
 	// 从本地加载配置文件
-	result = newConfig(localWorkingDir, relativeConfigFilePath, "", OrchestratorTypeEnum_Compose, false)
+	if IsDevContainerJsonFile(relativeConfigFilePath) {
+		contentBytes, err := os.ReadFile(filepath.Join(localWorkingDir, relativeConfigFilePath))
+		if err != nil {
+			return nil, err
+		}
+		result, err = newConfigFromDevContainerJson(localWorkingDir, relativeConfigFilePath, string(contentBytes))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		result = newConfig(localWorkingDir, relativeConfigFilePath, "", OrchestratorTypeEnum_Compose, false)
+	}
 
 	// 如果有链接文件也需要加载
 	if result.IsLinkDockerComposeFile() {
@@ -120,41 +166,10 @@ func newK8sConfig(localWorkingDir string, configFileRelativePath string, linkK8s
 	result = config.ConvertToSmartIdeK8SConfig()
 
 	parseYamlFunc := func(yamlFileContent string) error {
-		re, _ := regexp.Compile(fmt.Sprintf("---%v|---%v", "\n", "\r\n"))
-		array := re.Split(yamlFileContent, -1)
-		for _, subYamlFileContent := range array { // 分割符
-			subYamlFileContent = strings.TrimSpace(subYamlFileContent)
-			if subYamlFileContent == "" {
-				continue
-			}
-
-			// 遍历k8s的yaml文件
-			decode := k8sScheme.Codecs.UniversalDeserializer().Decode
-			obj, groupKindVersion, err := decode([]byte(subYamlFileContent), nil, nil)
-			if err != nil {
+		for _, document := range splitK8sYamlDocuments(yamlFileContent) { // 分割符
+			if err := result.appendK8sYamlDocument(document.Content); err != nil {
 				return err
 			}
-			if obj == nil || groupKindVersion == nil {
-				return errors.New("k8s yaml 文件解析失败！")
-			}
-
-			// example: https://developers.redhat.com/blog/2020/12/16/create-a-kubernetes-operator-in-golang-to-automatically-manage-a-simple-stateful-application#set_the_controller
-			switch groupKindVersion.Kind {
-			case "Deployment":
-				deployment := obj.(*appV1.Deployment)
-				result.Workspace.Deployments = append(result.Workspace.Deployments, *deployment)
-			case "Service":
-				service := obj.(*coreV1.Service)
-				result.Workspace.Services = append(result.Workspace.Services, *service)
-			case "PersistentVolumeClaim":
-				pvc := obj.(*coreV1.PersistentVolumeClaim)
-				result.Workspace.PVCS = append(result.Workspace.PVCS, *pvc)
-			case "NetworkPolicy":
-				networkPolicy := obj.(*networkingV1.NetworkPolicy)
-				result.Workspace.Networks = append(result.Workspace.Networks, *networkPolicy)
-			default:
-				result.Workspace.Others = append(result.Workspace.Others, obj)
-			}
 		}
 
 		return nil
@@ -261,6 +276,13 @@ func newConfig(localWorkingDir string, configFilePath string, configContent stri
 	}
 
 	// 私有成员赋值
+	result.initPrivateMembers(localWorkingDir, configFilePath)
+
+	return result
+}
+
+// 私有成员赋值
+func (result *SmartIdeConfig) initPrivateMembers(localWorkingDir string, configFilePath string) {
 	result.Workspace.DevContainer.configRelativeFilePath = configFilePath
 	result.Workspace.DevContainer.workingDirectoryPath = localWorkingDir
 
@@ -270,8 +292,73 @@ func newConfig(localWorkingDir string, configFilePath string, configContent stri
 
 	// 置为空
 	result.Workspace.DevContainer.bindingPorts = []PortMapInfo{}
+}
 
-	return result
+// k8s yaml 文件中的单个文档
+type k8sYamlDocument struct {
+	// 文档内容
+	Content string
+	// 文档在文件中的起始行号
+	Line int
+}
+
+// 按照 “---” 分割 k8s yaml 文件，空文档会被忽略
+func splitK8sYamlDocuments(yamlFileContent string) (documents []k8sYamlDocument) {
+	line := 1
+	re, _ := regexp.Compile(fmt.Sprintf("---%v|---%v", "\n", "\r\n"))
+	for _, subYamlFileContent := range re.Split(yamlFileContent, -1) {
+		startLine := line
+		line += strings.Count(subYamlFileContent, "\n") + 1
+
+		// 跳过开头的空行，保证行号指向文档的第一行
+		trimmed := strings.TrimLeft(subYamlFileContent, "\r\n\t ")
+		startLine += strings.Count(subYamlFileContent[:len(subYamlFileContent)-len(trimmed)], "\n")
+		trimmed = strings.TrimSpace(trimmed)
+		if trimmed == "" {
+			continue
+		}
+		documents = append(documents, k8sYamlDocument{Content: trimmed, Line: startLine})
+	}
+	return documents
+}
+
+// 解析单个 k8s yaml 文档，并添加到配置中
+func (result *SmartIdeK8SConfig) appendK8sYamlDocument(subYamlFileContent string) error {
+	// 遍历k8s的yaml文件
+	decode := k8sScheme.Codecs.UniversalDeserializer().Decode
+	obj, groupKindVersion, err := decode([]byte(subYamlFileContent), nil, nil)
+	if err != nil {
+		return err
+	}
+	if obj == nil || groupKindVersion == nil {
+		return errors.New("k8s yaml 文件解析失败！")
+	}
+
+	// example: https://developers.redhat.com/blog/2020/12/16/create-a-kubernetes-operator-in-golang-to-automatically-manage-a-simple-stateful-application#set_the_controller
+	switch groupKindVersion.Kind {
+	case "Deployment":
+		deployment := obj.(*appV1.Deployment)
+		result.Workspace.Deployments = append(result.Workspace.Deployments, *deployment)
+	case "Service":
+		service := obj.(*coreV1.Service)
+		result.Workspace.Services = append(result.Workspace.Services, *service)
+	case "PersistentVolumeClaim":
+		pvc := obj.(*coreV1.PersistentVolumeClaim)
+		result.Workspace.PVCS = append(result.Workspace.PVCS, *pvc)
+	case "NetworkPolicy":
+		networkPolicy := obj.(*networkingV1.NetworkPolicy)
+		result.Workspace.Networks = append(result.Workspace.Networks, *networkPolicy)
+	default:
+		result.Workspace.Others = append(result.Workspace.Others, obj)
+	}
+
+	return nil
+}
+
+// 是否为默认的配置文件路径
+func isDefaultConfigFilePath(relativeConfigFilePath string) bool {
+	return relativeConfigFilePath == "" ||
+		filepath.ToSlash(filepath.Clean(relativeConfigFilePath)) == model.CONST_Default_ConfigRelativeFilePath
 }
 
 // 从yaml文件中获取配置
@@ -285,9 +372,10 @@ func loadConfigWithYamlFile(workingDirectoryPath, configRelativeFilePath string)
 		common.SmartIDELog.Error(err, i18nInstance.Main.Err_file_not_exit, configFilePath)
 	}
 
-	// read
-	yamlFile, err := os.ReadFile(configFilePath)
+	// read，并合并配置覆盖文件
+	yamlFile, mergedFilePaths, err := LoadConfigContentWithOverlays(workingDirectoryPath, configRelativeFilePath)
 	common.CheckError(err)
+	logConfigOverlays(mergedFilePaths)
 
 	// parse
 	err = yaml.Unmarshal(yamlFile, &result)
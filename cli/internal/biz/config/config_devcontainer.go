@@ -0,0 +1,670 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/leansoftX/smartide-cli/internal/model"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+)
+
+// devcontainer.json 可能存放的位置（相对于工作目录），按优先级排列
+var DevContainerJsonRelativeFilePaths = []string{
+	model.CONST_Default_DevContainerJsonRelativeFilePath,
+	".devcontainer.json",
+}
+
+// devcontainer.json 转换时，dev container 默认的服务名称
+const devContainerJsonDefaultServiceName = "devcontainer"
+
+// devcontainer.json 中的 build 节点
+type devContainerJsonBuild struct {
+	Dockerfile string            `json:"dockerfile"`
+	Context    string            `json:"context"`
+	Args       map[string]string `json:"args"`
+	Target     string            `json:"target"`
+	CacheFrom  interface{}       `json:"cacheFrom"` // string 或者 []string
+}
+
+// devcontainer.json 的结构，只包含支持转换的字段
+// https://containers.dev/implementors/json_reference/
+type devContainerJson struct {
+	Name              string                 `json:"name"`
+	Image             string                 `json:"image"`
+	DockerFile        string                 `json:"dockerFile"`
+	Context           string                 `json:"context"`
+	Build             *devContainerJsonBuild `json:"build"`
+	DockerComposeFile interface{}            `json:"dockerComposeFile"` // string 或者 []string
+	Service           string                 `json:"service"`
+	ForwardPorts      []interface{}          `json:"forwardPorts"` // number 或者 "host:port"
+	AppPort           interface{}            `json:"appPort"`      // number、string 或者数组
+	PortsAttributes   map[string]struct {
+		Label string `json:"label"`
+	} `json:"portsAttributes"`
+	PostCreateCommand    interface{}                `json:"postCreateCommand"` // string、[]string 或者 object
+	OnCreateCommand      interface{}                `json:"onCreateCommand"`
+	UpdateContentCommand interface{}                `json:"updateContentCommand"`
+	PostStartCommand     interface{}                `json:"postStartCommand"`
+	PostAttachCommand    interface{}                `json:"postAttachCommand"`
+	Mounts               []interface{}              `json:"mounts"` // string 或者 object
+	RemoteUser           string                     `json:"remoteUser"`
+	ContainerUser        string                     `json:"containerUser"`
+	ContainerEnv         map[string]string          `json:"containerEnv"`
+	Customizations       map[string]json.RawMessage `json:"customizations"`
+}
+
+// devcontainer.json 中已经支持转换的一级节点
+var devContainerJsonSupportedKeys = []string{
+	"$schema", "name",
+	"image", "dockerFile", "context", "build",
+	"dockerComposeFile", "service",
+	"forwardPorts", "appPort", "portsAttributes",
+	"postCreateCommand", "onCreateCommand", "updateContentCommand", "postStartCommand", "postAttachCommand",
+	"mounts",
+	"remoteUser", "containerUser", "containerEnv",
+	"customizations",
+}
+
+// 在本地工作目录中查找 devcontainer.json，返回相对路径，找不到时返回空
+func GetLocalDevContainerJsonRelativeFilePath(localWorkingDir string) string {
+	for _, relativeFilePath := range DevContainerJsonRelativeFilePaths {
+		if common.IsExist(filepath.Join(localWorkingDir, relativeFilePath)) {
+			return relativeFilePath
+		}
+	}
+	return ""
+}
+
+// 在远程主机的工作目录中查找 devcontainer.json，返回相对路径，找不到时返回空
+func GetRemoteDevContainerJsonRelativeFilePath(sshRemote *common.SSHRemote, remoteWorkingDir string) string {
+	for _, relativeFilePath := range DevContainerJsonRelativeFilePaths {
+		if sshRemote.IsFileExist(common.FilePahtJoin4Linux(remoteWorkingDir, relativeFilePath)) {
+			return relativeFilePath
+		}
+	}
+	return ""
+}
+
+// 把 devcontainer.json 的路径拆分为工作目录以及相对路径，.devcontainer 目录中的文件以上一级目录作为工作目录
+func splitDevContainerJsonFilePath(filePath string) (workingDir string, relativeFilePath string) {
+	dir := filepath.Dir(filePath)
+	if filepath.Base(dir) == filepath.Dir(model.CONST_Default_DevContainerJsonRelativeFilePath) {
+		return filepath.Dir(dir), path.Join(filepath.Base(dir), filepath.Base(filePath))
+	}
+	return dir, filepath.Base(filePath)
+}
+
+// 配置文件是否为 devcontainer.json
+func IsDevContainerJsonFile(relativeFilePath string) bool {
+	return strings.HasSuffix(strings.ToLower(relativeFilePath), ".json")
+}
+
+// 从 devcontainer.json 的内容创建配置
+func newConfigFromDevContainerJson(localWorkingDir string, relativeFilePath string, content string) (
+	result *SmartIdeConfig, err error) {
+	common.SmartIDELog.InfoF("加载 devcontainer.json 配置文件: %v", relativeFilePath)
+
+	projectName := filepath.Base(localWorkingDir)
+	if localWorkingDir == "" {
+		dirName, _ := os.Getwd()
+		projectName = filepath.Base(dirName)
+	}
+
+	result, warnings, err := ConvertDevContainerJsonToConfig(content, relativeFilePath, projectName)
+	if err != nil {
+		return nil, fmt.Errorf("%v 解析失败: %v", relativeFilePath, err)
+	}
+	for _, warning := range warnings {
+		common.SmartIDELog.Warning(fmt.Sprintf("%v: %v", relativeFilePath, warning))
+	}
+
+	// 验证
+	if err = result.Valid(); err != nil {
+		return nil, fmt.Errorf("%v 转换后的配置无效: %v", relativeFilePath, err)
+	}
+
+	result.initPrivateMembers(localWorkingDir, relativeFilePath)
+	return result, nil
+}
+
+// 把 devcontainer.json 的内容转换为 smartide 的配置，不支持的节点会在 warnings 中返回
+// relativeFilePath 为 devcontainer.json 相对于工作目录的路径，其中的相对路径以它所在的目录为基准
+func ConvertDevContainerJsonToConfig(content string, relativeFilePath string, projectName string) (
+	result *SmartIdeConfig, warnings []string, err error) {
+	contentBytes := []byte(stripJsonComments(content))
+
+	//1. 解析
+	var devContainer devContainerJson
+	if err = json.Unmarshal(contentBytes, &devContainer); err != nil {
+		return nil, nil, err
+	}
+	var rawNodes map[string]json.RawMessage
+	if err = json.Unmarshal(contentBytes, &rawNodes); err != nil {
+		return nil, nil, err
+	}
+	for key := range rawNodes {
+		if !common.Contains(devContainerJsonSupportedKeys, key) {
+			warnings = append(warnings, fmt.Sprintf("不支持的配置项 %v，已忽略", key))
+		}
+	}
+
+	//2. 基本信息
+	result = &SmartIdeConfig{}
+	result.Version = "smartide/v0.3"
+	result.Orchestrator.Type = OrchestratorTypeEnum_Compose
+	result.Orchestrator.Version = "3"
+	result.Workspace.DevContainer.IdeType = IdeTypeEnum_SDKOnly
+	result.Workspace.DevContainer.RemoteUser = devContainer.RemoteUser
+	result.Workspace.DevContainer.Ports = map[string]int{}
+
+	//2.1. customizations，只处理 smartide 节点
+	for key, value := range devContainer.Customizations {
+		if key != "smartide" {
+			warnings = append(warnings, fmt.Sprintf("不支持的配置项 customizations.%v，已忽略", key))
+			continue
+		}
+		var smartideCustomization struct {
+			IdeType IdeTypeEnum `json:"ide-type"`
+		}
+		if err = json.Unmarshal(value, &smartideCustomization); err != nil {
+			return nil, nil, fmt.Errorf("customizations.smartide: %v", err)
+		}
+		if smartideCustomization.IdeType != "" {
+			result.Workspace.DevContainer.IdeType = smartideCustomization.IdeType
+		}
+	}
+
+	//2.2. postCreateCommand
+	commands, err := parseDevContainerCommand(devContainer.PostCreateCommand)
+	if err != nil {
+		return nil, nil, fmt.Errorf("postCreateCommand: %v", err)
+	}
+	result.Workspace.DevContainer.Command = commands
+
+	//2.3. 生命周期命令
+	hooks := &result.Workspace.DevContainer.Hooks
+	for name, item := range map[string]struct {
+		command interface{}
+		target  *[]string
+	}{
+		"onCreateCommand":      {devContainer.OnCreateCommand, &hooks.OnCreate},
+		"updateContentCommand": {devContainer.UpdateContentCommand, &hooks.UpdateContent},
+		"postStartCommand":     {devContainer.PostStartCommand, &hooks.PostStart},
+		"postAttachCommand":    {devContainer.PostAttachCommand, &hooks.PostAttach},
+	} {
+		*item.target, err = parseDevContainerCommand(item.command)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v: %v", name, err)
+		}
+	}
+
+	//3. 链接 docker-compose 文件
+	if devContainer.DockerComposeFile != nil {
+		composeFiles, err := parseStringOrArray(devContainer.DockerComposeFile)
+		if err != nil || len(composeFiles) == 0 {
+			return nil, nil, fmt.Errorf("dockerComposeFile 格式错误")
+		}
+		if len(composeFiles) > 1 {
+			warnings = append(warnings, fmt.Sprintf("dockerComposeFile 只支持一个文件，将使用 %v", composeFiles[0]))
+		}
+		if devContainer.Service == "" {
+			return nil, nil, fmt.Errorf("使用 dockerComposeFile 时，service 不能为空")
+		}
+		for _, key := range []string{"image", "dockerFile", "build", "appPort", "containerUser", "mounts", "containerEnv"} {
+			if _, ok := rawNodes[key]; ok {
+				warnings = append(warnings, fmt.Sprintf("使用 dockerComposeFile 时，配置项 %v 无效，请在 docker-compose 文件中设置", key))
+			}
+		}
+
+		result.Workspace.DockerComposeFile = composeFiles[0] // 相对于 devcontainer.json 所在的目录
+		result.Workspace.DevContainer.ServiceName = devContainer.Service
+
+		for _, item := range devContainer.ForwardPorts {
+			_, port, err := parseDevContainerForwardPort(item)
+			if err != nil {
+				return nil, nil, err
+			}
+			result.Workspace.DevContainer.Ports[devContainer.getPortLabel(port)] = port
+		}
+
+		sort.Strings(warnings)
+		return result, warnings, nil
+	}
+
+	//4. 单容器，image 或者 dockerfile
+	serviceName := devContainerJsonDefaultServiceName
+	service := compose.Service{
+		Image:       devContainer.Image,
+		User:        devContainer.ContainerUser,
+		Environment: devContainer.ContainerEnv,
+	}
+	if _, ok := rawNodes["service"]; ok {
+		warnings = append(warnings, "没有设置 dockerComposeFile，配置项 service 无效")
+	}
+
+	//4.1. 构建
+	dockerfile, context := devContainer.DockerFile, devContainer.Context
+	if devContainer.Build != nil {
+		if devContainer.Build.Dockerfile != "" {
+			dockerfile = devContainer.Build.Dockerfile
+		}
+		if devContainer.Build.Context != "" {
+			context = devContainer.Build.Context
+		}
+	}
+	if dockerfile != "" {
+		if context == "" {
+			context = "."
+		}
+		// devcontainer.json 中的路径都相对于 devcontainer.json 所在的目录，docker-compose 中 dockerfile 相对于 context
+		configFileDir := path.Dir(filepath.ToSlash(relativeFilePath))
+		contextPath := path.Join(configFileDir, context)
+		dockerfilePath := path.Join(configFileDir, dockerfile)
+		relativeDockerfilePath, err := filepath.Rel(contextPath, dockerfilePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		service.Build = compose.Build{
+			Context:    contextPath,
+			Dockerfile: filepath.ToSlash(relativeDockerfilePath),
+		}
+		if devContainer.Build != nil {
+			service.Build.Target = devContainer.Build.Target
+			if len(devContainer.Build.Args) > 0 {
+				service.Build.Args = map[string]interface{}{}
+				for key, value := range devContainer.Build.Args {
+					service.Build.Args[key] = value
+				}
+			}
+			cacheFrom, err := parseStringOrArray(devContainer.Build.CacheFrom)
+			if err != nil {
+				return nil, nil, fmt.Errorf("build.cacheFrom: %v", err)
+			}
+			for _, image := range cacheFrom {
+				service.Build.CacheFrom = append(service.Build.CacheFrom, parseImage(image))
+			}
+		}
+		if service.Image == "" { // 构建后镜像的名称
+			service.Image = fmt.Sprintf("smartide-%v-%v", sanitizeImageName(projectName), serviceName)
+		}
+	}
+	if service.Image == "" {
+		return nil, nil, fmt.Errorf("image、dockerFile、dockerComposeFile 至少需要设置一个")
+	}
+
+	//4.2. 端口
+	for _, item := range devContainer.ForwardPorts {
+		host, port, err := parseDevContainerForwardPort(item)
+		if err != nil {
+			return nil, nil, err
+		}
+		if host != "" && host != "localhost" && host != serviceName {
+			warnings = append(warnings, fmt.Sprintf("forwardPorts 中的 %v:%v 不属于当前容器，已忽略", host, port))
+			continue
+		}
+		result.Workspace.DevContainer.Ports[devContainer.getPortLabel(port)] = port
+		service.AppendPort(compose.NewPortSimpleSame(port))
+	}
+	appPorts, err := parseDevContainerAppPorts(devContainer.AppPort)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, appPort := range appPorts {
+		port, err := compose.ParsePort(appPort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("appPort 格式错误: %v", appPort)
+		}
+		if !service.Ports.Contains(port) {
+			service.AppendPort(port)
+		}
+	}
+
+	//4.3. 挂载
+	for _, item := range devContainer.Mounts {
+		mount, err := parseDevContainerMount(item, projectName)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch mount.Type {
+		case "bind":
+			service.AppendVolume(mount.toVolumeMap())
+		case "volume":
+			if result.Workspace.Volumes == nil {
+				result.Workspace.Volumes = map[string]compose.Volume{}
+			}
+			result.Workspace.Volumes[mount.Source] = compose.Volume{}
+			service.AppendVolume(mount.toVolumeMap())
+		default:
+			warnings = append(warnings, fmt.Sprintf("不支持 %v 类型的挂载 %v，已忽略", mount.Type, mount.Target))
+		}
+	}
+
+	result.Workspace.DevContainer.ServiceName = serviceName
+	result.Workspace.Servcies = map[string]compose.Service{serviceName: service}
+
+	sort.Strings(warnings)
+	return result, warnings, nil
+}
+
+// 获取端口的描述，优先使用 portsAttributes 中的 label
+func (devContainer devContainerJson) getPortLabel(port int) string {
+	if attribute, ok := devContainer.PortsAttributes[strconv.Itoa(port)]; ok && attribute.Label != "" {
+		return attribute.Label
+	}
+	return fmt.Sprintf("port-%v", port)
+}
+
+// 解析 forwardPorts 中的一项，比如 3000 或者 "db:5432"
+func parseDevContainerForwardPort(item interface{}) (host string, port int, err error) {
+	switch value := item.(type) {
+	case float64:
+		port = int(value)
+	case string:
+		portStr := value
+		if index := strings.LastIndex(value, ":"); index >= 0 {
+			host, portStr = value[:index], value[index+1:]
+		}
+		port, err = strconv.Atoi(portStr)
+	default:
+		err = fmt.Errorf("forwardPorts 格式错误: %v", item)
+	}
+	if err == nil && (port <= 0 || port > 65535) {
+		err = fmt.Errorf("forwardPorts 中的端口 %v 无效", item)
+	}
+	return host, port, err
+}
+
+// 解析 appPort，返回 docker-compose 格式的端口映射
+func parseDevContainerAppPorts(appPort interface{}) (ports []string, err error) {
+	var items []interface{}
+	switch value := appPort.(type) {
+	case nil:
+		return ports, nil
+	case []interface{}:
+		items = value
+	default:
+		items = []interface{}{value}
+	}
+
+	for _, item := range items {
+		switch value := item.(type) {
+		case float64:
+			ports = append(ports, fmt.Sprintf("%v:%v", int(value), int(value)))
+		case string:
+			if !strings.Contains(value, ":") {
+				value = fmt.Sprintf("%v:%v", value, value)
+			}
+			ports = append(ports, value)
+		default:
+			return nil, fmt.Errorf("appPort 格式错误: %v", item)
+		}
+	}
+	return ports, nil
+}
+
+// 解析 postCreateCommand 等命令，支持 string、[]string 以及 object 三种格式
+func parseDevContainerCommand(command interface{}) (commands []string, err error) {
+	switch value := command.(type) {
+	case nil:
+	case string:
+		commands = append(commands, value)
+	case []interface{}:
+		args, err := parseStringOrArray(value)
+		if err != nil {
+			return nil, err
+		}
+		for index, arg := range args {
+			if strings.ContainsAny(arg, " \t\"'") {
+				args[index] = strconv.Quote(arg)
+			}
+		}
+		commands = append(commands, strings.Join(args, " "))
+	case map[string]interface{}: // object 格式下并行执行，这里按名称顺序依次执行
+		var names []string
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			subCommands, err := parseDevContainerCommand(value[name])
+			if err != nil {
+				return nil, err
+			}
+			commands = append(commands, subCommands...)
+		}
+	default:
+		err = fmt.Errorf("格式错误: %v", command)
+	}
+	return commands, err
+}
+
+// devcontainer.json 中的挂载
+type devContainerMount struct {
+	Type     string
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// 转换为 docker-compose 中的 volume
+func (mount devContainerMount) toVolumeMap() compose.VolumeMapSimple {
+	volume := compose.NewVolumeMapSimple(mount.Source, mount.Target)
+	if mount.ReadOnly {
+		volume.Mode = compose.VolumeReadOnly
+	}
+	return volume
+}
+
+// 解析 mounts 中的一项，比如 "source=${localWorkspaceFolder}/.cache,target=/root/.cache,type=bind"
+func parseDevContainerMount(item interface{}, projectName string) (mount devContainerMount, err error) {
+	options := map[string]string{}
+	switch value := item.(type) {
+	case string:
+		for _, option := range strings.Split(value, ",") {
+			keyValue := strings.SplitN(strings.TrimSpace(option), "=", 2)
+			if len(keyValue) == 2 {
+				options[strings.ToLower(keyValue[0])] = keyValue[1]
+			} else {
+				options[strings.ToLower(keyValue[0])] = "true"
+			}
+		}
+	case map[string]interface{}:
+		for key, option := range value {
+			options[strings.ToLower(key)] = fmt.Sprint(option)
+		}
+	default:
+		return mount, fmt.Errorf("mounts 格式错误: %v", item)
+	}
+
+	getOption := func(keys ...string) string {
+		for _, key := range keys {
+			if option, ok := options[key]; ok {
+				return option
+			}
+		}
+		return ""
+	}
+	mount.Type = getOption("type")
+	if mount.Type == "" {
+		mount.Type = "volume"
+	}
+	mount.Source = replaceDevContainerVariables(getOption("source", "src"), projectName)
+	mount.Target = replaceDevContainerVariables(getOption("target", "destination", "dst"), projectName)
+	readOnly := getOption("readonly", "ro")
+	mount.ReadOnly = readOnly == "true" || readOnly == "1"
+
+	if mount.Target == "" {
+		return mount, fmt.Errorf("mounts 中 %v 没有设置 target", item)
+	}
+	if mount.Source == "" && mount.Type != "tmpfs" {
+		return mount, fmt.Errorf("mounts 中 %v 没有设置 source", item)
+	}
+	return mount, nil
+}
+
+var devContainerVariableRegexp = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// 替换 devcontainer.json 中的变量，比如 ${localWorkspaceFolder}、${localEnv:HOME}
+func replaceDevContainerVariables(value string, projectName string) string {
+	return devContainerVariableRegexp.ReplaceAllStringFunc(value, func(variable string) string {
+		name := variable[2 : len(variable)-1]
+		switch {
+		case name == "localWorkspaceFolder":
+			return "."
+		case name == "localWorkspaceFolderBasename":
+			return projectName
+		case name == "containerWorkspaceFolder":
+			return "/home/project/" + projectName
+		case name == "containerWorkspaceFolderBasename":
+			return projectName
+		case strings.HasPrefix(name, "localEnv:"):
+			array := strings.SplitN(strings.TrimPrefix(name, "localEnv:"), ":", 2)
+			if envValue := os.Getenv(array[0]); envValue != "" || len(array) == 1 {
+				return envValue
+			}
+			return array[1]
+		}
+		return variable
+	})
+}
+
+// 解析 string 或者 []string
+func parseStringOrArray(value interface{}) (result []string, err error) {
+	switch tmp := value.(type) {
+	case nil:
+	case string:
+		result = append(result, tmp)
+	case []interface{}:
+		for _, item := range tmp {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%v 不是字符串", item)
+			}
+			result = append(result, str)
+		}
+	default:
+		err = fmt.Errorf("格式错误: %v", value)
+	}
+	return result, err
+}
+
+// 解析镜像名称，兼容带端口的镜像仓库地址
+func parseImage(image string) compose.Image {
+	index := strings.LastIndex(image, ":")
+	if index > strings.LastIndex(image, "/") {
+		return compose.NewImage(image[:index], image[index+1:])
+	}
+	return compose.NewImage(image, "")
+}
+
+// 镜像名称只能包含小写字母、数字以及 . _ -
+func sanitizeImageName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLower(r) || unicode.IsDigit(r) || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, name)
+	return strings.Trim(name, ".-_")
+}
+
+// 去掉 json 中的注释以及末尾多余的逗号（devcontainer.json 使用的是 jsonc 格式）
+func stripJsonComments(content string) string {
+	//1. 去掉注释，保留换行
+	content = scanJsonOutsideString(content, func(runes []rune, index int, builder *strings.Builder) int {
+		next := rune(0)
+		if index+1 < len(runes) {
+			next = runes[index+1]
+		}
+		switch {
+		case runes[index] == '/' && next == '/': // 单行注释
+			for index < len(runes) && runes[index] != '\n' {
+				index++
+			}
+			return index - 1
+		case runes[index] == '/' && next == '*': // 多行注释
+			index += 2
+			for index < len(runes) && !(runes[index] == '*' && index+1 < len(runes) && runes[index+1] == '/') {
+				if runes[index] == '\n' {
+					builder.WriteRune('\n')
+				}
+				index++
+			}
+			return index + 1
+		}
+		builder.WriteRune(runes[index])
+		return index
+	})
+
+	//2. 去掉末尾多余的逗号
+	return scanJsonOutsideString(content, func(runes []rune, index int, builder *strings.Builder) int {
+		if runes[index] == ',' {
+			nextIndex := index + 1
+			for nextIndex < len(runes) && unicode.IsSpace(runes[nextIndex]) {
+				nextIndex++
+			}
+			if nextIndex < len(runes) && (runes[nextIndex] == '}' || runes[nextIndex] == ']') {
+				return index
+			}
+		}
+		builder.WriteRune(runes[index])
+		return index
+	})
+}
+
+// 遍历 json 文本，字符串内的内容原样输出，字符串外的字符交给 handle 处理，handle 返回最后处理的位置
+func scanJsonOutsideString(content string, handle func(runes []rune, index int, builder *strings.Builder) int) string {
+	runes := []rune(content)
+	var builder strings.Builder
+	inString, isEscaped := false, false
+	for index := 0; index < len(runes); index++ {
+		current := runes[index]
+		if inString {
+			builder.WriteRune(current)
+			if isEscaped {
+				isEscaped = false
+			} else if current == '\\' {
+				isEscaped = true
+			} else if current == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		if current == '"' {
+			inString = true
+			builder.WriteRune(current)
+			continue
+		}
+		index = handle(runes, index, &builder)
+	}
+	return builder.String()
+}
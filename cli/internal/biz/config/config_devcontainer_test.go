@@ -0,0 +1,195 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestConvertDevContainerJsonToConfig(t *testing.T) {
+	tests := []struct {
+		filePath        string // 为空时为 .devcontainer/devcontainer.json
+		content         string
+		wantServiceName string
+		wantImage       string
+		wantBuild       [2]string // context, dockerfile
+		wantPorts       map[string]int
+		wantBindings    []string
+		wantVolumes     []string
+		wantCommand     []string
+		wantHooks       LifecycleHooks
+		wantComposeFile string
+		wantRemoteUser  string
+		wantWarnings    int
+		wantErr         bool
+	}{
+		{ // image + jsonc
+			content: `{
+				// 注释
+				"name": "go",
+				"image": "mcr.microsoft.com/devcontainers/go:1", /* 镜像 */
+				"forwardPorts": [3000, "localhost:8080",],
+				"portsAttributes": { "3000": { "label": "web" } },
+				"postCreateCommand": "go mod download",
+				"mounts": ["source=${localWorkspaceFolder}/.cache,target=/root/.cache,type=bind", "source=gomod,target=/go/pkg,type=volume"],
+				"remoteUser": "vscode",
+				"features": {},
+			}`,
+			wantServiceName: "devcontainer",
+			wantImage:       "mcr.microsoft.com/devcontainers/go:1",
+			wantPorts:       map[string]int{"web": 3000, "port-8080": 8080},
+			wantBindings:    []string{"3000:3000", "8080:8080"},
+			wantVolumes:     []string{"./.cache:/root/.cache", "gomod:/go/pkg"},
+			wantCommand:     []string{"go mod download"},
+			wantRemoteUser:  "vscode",
+			wantWarnings:    1,
+		},
+		{ // dockerfile
+			content: `{
+				"build": { "dockerfile": "Dockerfile", "context": "..", "target": "dev" },
+				"postCreateCommand": ["npm", "run", "a b"],
+				"onCreateCommand": "npm ci",
+				"postStartCommand": ["npm", "start"],
+				"appPort": "6000:80"
+			}`,
+			wantServiceName: "devcontainer",
+			wantImage:       "smartide-demo-devcontainer",
+			wantBuild:       [2]string{".", ".devcontainer/Dockerfile"},
+			wantPorts:       map[string]int{},
+			wantBindings:    []string{"6000:80"},
+			wantCommand:     []string{`npm run "a b"`},
+			wantHooks:       LifecycleHooks{OnCreate: []string{"npm ci"}, PostStart: []string{"npm start"}},
+		},
+		{ // 工作目录中的 .devcontainer.json
+			filePath: ".devcontainer.json",
+			content: `{
+				"build": { "dockerfile": "docker/Dockerfile", "context": "." }
+			}`,
+			wantServiceName: "devcontainer",
+			wantImage:       "smartide-demo-devcontainer",
+			wantBuild:       [2]string{".", "docker/Dockerfile"},
+			wantPorts:       map[string]int{},
+		},
+		{ // docker compose
+			content: `{
+				"dockerComposeFile": ["docker-compose.yml", "docker-compose.extend.yml"],
+				"service": "app",
+				"forwardPorts": ["db:5432"],
+				"postCreateCommand": { "b": "echo b", "a": "echo a" },
+				"runServices": ["app"]
+			}`,
+			wantServiceName: "app",
+			wantPorts:       map[string]int{"port-5432": 5432},
+			wantCommand:     []string{"echo a", "echo b"},
+			wantComposeFile: "docker-compose.yml",
+			wantWarnings:    2,
+		},
+		{ // compose 缺少 service
+			content: `{ "dockerComposeFile": "docker-compose.yml" }`,
+			wantErr: true,
+		},
+		{ // 没有镜像
+			content: `{ "forwardPorts": [3000] }`,
+			wantErr: true,
+		},
+		{ // 格式错误
+			content: `{ "image": }`,
+			wantErr: true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			filePath := tt.filePath
+			if filePath == "" {
+				filePath = ".devcontainer/devcontainer.json"
+			}
+			result, warnings, err := ConvertDevContainerJsonToConfig(tt.content, filePath, "demo")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ConvertDevContainerJsonToConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("ConvertDevContainerJsonToConfig() warnings = %v, wantWarnings %v", warnings, tt.wantWarnings)
+			}
+			devContainer := result.Workspace.DevContainer
+			if devContainer.ServiceName != tt.wantServiceName {
+				t.Errorf("ConvertDevContainerJsonToConfig() service name = %v, want %v", devContainer.ServiceName, tt.wantServiceName)
+			}
+			if !reflect.DeepEqual(devContainer.Ports, tt.wantPorts) {
+				t.Errorf("ConvertDevContainerJsonToConfig() ports = %v, want %v", devContainer.Ports, tt.wantPorts)
+			}
+			if !reflect.DeepEqual(devContainer.Command, tt.wantCommand) {
+				t.Errorf("ConvertDevContainerJsonToConfig() command = %v, want %v", devContainer.Command, tt.wantCommand)
+			}
+			if !reflect.DeepEqual(devContainer.Hooks, tt.wantHooks) {
+				t.Errorf("ConvertDevContainerJsonToConfig() hooks = %v, want %v", devContainer.Hooks, tt.wantHooks)
+			}
+			if devContainer.RemoteUser != tt.wantRemoteUser {
+				t.Errorf("ConvertDevContainerJsonToConfig() remote user = %v, want %v", devContainer.RemoteUser, tt.wantRemoteUser)
+			}
+			if result.Workspace.DockerComposeFile != tt.wantComposeFile {
+				t.Errorf("ConvertDevContainerJsonToConfig() compose file = %v, want %v", result.Workspace.DockerComposeFile, tt.wantComposeFile)
+			}
+			if err := result.Valid(); err != nil {
+				t.Errorf("ConvertDevContainerJsonToConfig() valid error = %v", err)
+			}
+			if tt.wantComposeFile != "" {
+				return
+			}
+
+			service := result.Workspace.Servcies[tt.wantServiceName]
+			if service.Image != tt.wantImage {
+				t.Errorf("ConvertDevContainerJsonToConfig() image = %v, want %v", service.Image, tt.wantImage)
+			}
+			if service.Build.Context != tt.wantBuild[0] || service.Build.Dockerfile != tt.wantBuild[1] {
+				t.Errorf("ConvertDevContainerJsonToConfig() build = %v, want %v", service.Build, tt.wantBuild)
+			}
+			if !reflect.DeepEqual(service.Ports.Strings(), tt.wantBindings) {
+				t.Errorf("ConvertDevContainerJsonToConfig() bindings = %v, want %v", service.Ports, tt.wantBindings)
+			}
+			if len(service.Volumes) != len(tt.wantVolumes) || (len(tt.wantVolumes) > 0 && !reflect.DeepEqual(service.Volumes.Strings(), tt.wantVolumes)) {
+				t.Errorf("ConvertDevContainerJsonToConfig() volumes = %v, want %v", service.Volumes, tt.wantVolumes)
+			}
+		})
+	}
+}
+
+func TestSplitDevContainerJsonFilePath(t *testing.T) {
+	tests := []struct {
+		filePath             string
+		wantWorkingDir       string
+		wantRelativeFilePath string
+	}{
+		{filePath: "/home/smartide/demo/.devcontainer/devcontainer.json", wantWorkingDir: "/home/smartide/demo", wantRelativeFilePath: ".devcontainer/devcontainer.json"},
+		{filePath: "/home/smartide/demo/.devcontainer.json", wantWorkingDir: "/home/smartide/demo", wantRelativeFilePath: ".devcontainer.json"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			workingDir, relativeFilePath := splitDevContainerJsonFilePath(tt.filePath)
+			if workingDir != tt.wantWorkingDir || relativeFilePath != tt.wantRelativeFilePath {
+				t.Errorf("splitDevContainerJsonFilePath() = %v, %v, want %v, %v", workingDir, relativeFilePath, tt.wantWorkingDir, tt.wantRelativeFilePath)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+)
+
+// 替换链接的 docker-compose 文件中的变量，e.g. ${POSTGRES_PASSWORD:-dev}
+// 变量来自 docker-compose 文件所在目录的 .env 文件以及当前进程的环境变量，后者优先；远程主机模式下读取远程主机上的 .env 文件
+func interpolateLinkCompose(sshRemote *common.SSHRemote, composeFilePath string, content string) (
+	result string, unsetNames []string, err error) {
+	if !strings.Contains(content, "$") {
+		return content, nil, nil
+	}
+
+	//1. .env 文件
+	isRemoteMode := sshRemote != nil && *sshRemote != (common.SSHRemote{})
+	environment := map[string]string{}
+	if isRemoteMode {
+		envFilePath := path.Join(path.Dir(composeFilePath), ".env")
+		if sshRemote.IsFileExist(envFilePath) {
+			environment = compose.ParseEnvFile(sshRemote.GetContent(envFilePath))
+		}
+	} else {
+		envFileBytes, err := os.ReadFile(filepath.Join(filepath.Dir(composeFilePath), ".env"))
+		if err != nil && !os.IsNotExist(err) {
+			return "", nil, err
+		}
+		environment = compose.ParseEnvFile(string(envFileBytes))
+	}
+
+	//2. 环境变量
+	for _, item := range os.Environ() {
+		if index := strings.Index(item, "="); index > 0 {
+			environment[item[:index]] = item[index+1:]
+		}
+	}
+	if isRemoteMode { // 与远程主机上执行 docker compose 时一致
+		homeDir, err := sshRemote.GetRemoteHome()
+		if err != nil {
+			return "", nil, err
+		}
+		environment["HOME"] = strings.TrimSpace(homeDir)
+		environment["USER"] = sshRemote.SSHUserName
+	}
+
+	//3. 替换
+	return compose.InterpolateYaml(content, func(name string) (string, bool) {
+		value, ok := environment[name]
+		return value, ok
+	})
+}
+
+// 替换变量，没有设置的变量输出警告
+func interpolateLinkComposeWithWarning(sshRemote *common.SSHRemote, composeFilePath string, content string) (string, error) {
+	result, unsetNames, err := interpolateLinkCompose(sshRemote, composeFilePath, content)
+	for _, name := range unsetNames {
+		common.SmartIDELog.WarningF(i18nInstance.Config.Warn_compose_variable_not_set, name)
+	}
+	return result, err
+}
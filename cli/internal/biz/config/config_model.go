@@ -94,6 +94,8 @@ type DevContainerConfig struct {
 	ServiceName string `yaml:"service-name"`
 	// 端口申明
 	Ports map[string]int `yaml:"ports"`
+	// 远程端口转发，本机的端口在开发容器中可以通过 localhost 访问，key 为端口描述，value 为端口
+	ReversePorts map[string]int `yaml:"reverse-ports,omitempty"`
 	// 容器运行起来后，在webide的terminal中执行的shell命令
 	Command []string `yaml:"command"`
 	// ide-type web ide类型
@@ -103,6 +105,12 @@ type DevContainerConfig struct {
 		HasGitConfig CustomBool `yaml:"git-config"`
 		HasSshKey    CustomBool `yaml:"ssh-key"`
 	} `yaml:"volumes"`
+	// 连接到容器时使用的用户，为空时使用容器的默认用户
+	RemoteUser string `yaml:"remote-user,omitempty"`
+	// 容器生命周期中执行的 shell 命令
+	Hooks LifecycleHooks `yaml:"hooks,omitempty"`
+	// 通过 Dockerfile 构建开发容器的镜像，构建配置没有变化时使用已经构建的镜像
+	Build *DevContainerBuildConfig `yaml:"build,omitempty"`
 
 	// 绑定的端口列表
 	bindingPorts []PortMapInfo
@@ -117,6 +125,38 @@ type DevContainerConfig struct {
 	// WebidePort string `yaml:"webide-port"`
 } //`yaml:"dev-container"`
 
+// 开发容器的生命周期钩子，每个阶段的命令按顺序在开发容器中执行，任意命令失败时停止
+type LifecycleHooks struct {
+	// 容器创建后执行，每个容器只执行一次
+	OnCreate []string `yaml:"on-create,omitempty"`
+	// 每次启动时，在代码更新后执行
+	UpdateContent []string `yaml:"update-content,omitempty"`
+	// 每次启动时执行
+	PostStart []string `yaml:"post-start,omitempty"`
+	// 客户端连接到工作区后执行
+	PostAttach []string `yaml:"post-attach,omitempty"`
+	// 容器停止前执行
+	PreStop []string `yaml:"pre-stop,omitempty"`
+}
+
+// 开发容器镜像的构建配置
+type DevContainerBuildConfig struct {
+	// 构建上下文，相对于配置文件所在的目录
+	Context string `yaml:"context,omitempty"`
+	// Dockerfile 的路径，相对于构建上下文，默认为 Dockerfile
+	Dockerfile string `yaml:"dockerfile,omitempty"`
+	// Dockerfile 中定义的 ARG 参数的值
+	Args map[string]string `yaml:"args,omitempty"`
+	// 构建 Dockerfile 中指定的 stage
+	Target string `yaml:"target,omitempty"`
+	// 作为构建缓存的镜像列表
+	CacheFrom []string `yaml:"cache-from,omitempty"`
+	// k8s 模式下推送镜像的镜像仓库（包括命名空间），比如 registry.cn-hangzhou.aliyuncs.com/smartide，为空时只在本地构建
+	Registry string `yaml:"registry,omitempty"`
+	// k8s 模式下构建镜像的主机，对应 smartide host list 中的主机 id，为空时使用本机的 docker
+	Host string `yaml:"host,omitempty"`
+}
+
 // smartide 的配置
 // docker-compose.yaml https://docs.docker.com/compose/compose-file/
 type SmartIdeConfig struct {
@@ -152,6 +192,11 @@ type SmartIdeConfig struct {
 		Volumes map[string]compose.Volume `yaml:"volumes,omitempty"`
 		// 密钥，docker-compose 中的 Secrets 节点
 		Secrets map[string]compose.YmlSecret `yaml:"secrets,omitempty"`
+		// 配置，docker-compose 中的 Configs 节点
+		Configs map[string]compose.YmlConfig `yaml:"configs,omitempty"`
+
+		// 激活的 compose profiles，没有设置 profiles 的服务始终启动
+		Profiles []string `yaml:"profiles,omitempty"`
 
 		// 链接的compose配置
 		LinkCompose *compose.DockerComposeYml
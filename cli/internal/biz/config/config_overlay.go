@@ -0,0 +1,225 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+配置文件的覆盖（overlay），按优先级从低到高依次合并：
+ 1. .ide/.ide.yaml             团队共享的配置
+ 2. .ide/.ide.local.yaml       个人的配置，不需要提交到 git
+ 3. ~/.ide/overrides/<repo>.yaml  当前用户对某个项目的配置，<repo> 为 git 库的名称，没有 git 库时为项目目录的名称
+ 4. --config-overlay 指定的文件    可以指定多个，按顺序合并
+
+合并规则：
+  - 对象（map）按照 key 递归合并
+  - 数组（list）以及其他值，后面的覆盖前面的
+  - key 以 + 结尾时（比如 ports+），数组追加到原来的数组后面
+  - 值为 null 时，删除对应的 key
+*/
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"gopkg.in/yaml.v2"
+)
+
+// 命令行指定的配置覆盖文件，通过 --config-overlay 设置
+var ConfigOverlayFiles []string
+
+// 配置覆盖文件
+type configOverlayFile struct {
+	FilePath   string
+	IsRequired bool // 文件不存在时是否报错
+}
+
+// 项目中个人的配置覆盖文件，e.g. .ide/.ide.yaml -> .ide/.ide.local.yaml
+func GetLocalConfigOverlayFilePath(configFilePath string) string {
+	ext := filepath.Ext(configFilePath)
+	return strings.TrimSuffix(configFilePath, ext) + ".local" + ext
+}
+
+// 当前用户对某个项目的配置覆盖文件，e.g. ~/.ide/overrides/smartide-cli.yaml
+func GetUserConfigOverlayFilePath(projectName string) string {
+	return common.PathJoin(SmartIdeHome, "overrides", projectName+".yaml")
+}
+
+// 配置覆盖文件对应的项目名称，优先使用 git 库的名称，远程主机上的工作目录名称包含随机的后缀（比如 ~/project/boathouse-abc）
+func GetConfigOverlayProjectName(workingDir string, gitRepoUrl string) string {
+	if gitRepoUrl != "" {
+		return common.GetRepoName(strings.TrimRight(gitRepoUrl, "/"))
+	}
+	return path.Base(strings.TrimRight(filepath.ToSlash(workingDir), "/"))
+}
+
+// 需要合并的配置覆盖文件，按优先级从低到高排序
+// isIncludeLocal 为 false 时不包括项目中的 .ide.local.yaml（远程主机模式下需要从远程主机上读取）
+func getConfigOverlayFiles(workingDir string, configRelativeFilePath string, projectName string, isIncludeLocal bool) (result []configOverlayFile) {
+	if isIncludeLocal {
+		configFilePath := common.PathJoin(workingDir, configRelativeFilePath)
+		result = append(result, configOverlayFile{FilePath: GetLocalConfigOverlayFilePath(configFilePath)})
+	}
+	result = append(result, configOverlayFile{FilePath: GetUserConfigOverlayFilePath(projectName)})
+	for _, filePath := range ConfigOverlayFiles {
+		result = append(result, configOverlayFile{FilePath: filePath, IsRequired: true})
+	}
+	return result
+}
+
+// 读取本地的配置文件，并合并所有的配置覆盖文件；mergedFilePaths 为实际合并的覆盖文件
+func LoadConfigContentWithOverlays(workingDir string, configRelativeFilePath string) (
+	content []byte, mergedFilePaths []string, err error) {
+	content, err = os.ReadFile(common.PathJoin(workingDir, configRelativeFilePath))
+	if err != nil {
+		return nil, nil, err
+	}
+	return mergeConfigOverlayFiles(content,
+		getConfigOverlayFiles(workingDir, configRelativeFilePath, GetConfigOverlayProjectName(workingDir, ""), true), os.ReadFile)
+}
+
+// 合并远程主机上的 .ide.local.yaml
+func mergeRemoteLocalConfigOverlay(sshRemote *common.SSHRemote, configFilePath string, content string) (string, error) {
+	overlayFile := configOverlayFile{FilePath: GetLocalConfigOverlayFilePath(configFilePath)}
+	result, mergedFilePaths, err := mergeConfigOverlayFiles([]byte(content), []configOverlayFile{overlayFile},
+		func(filePath string) ([]byte, error) {
+			if !sshRemote.IsFileExist(filePath) {
+				return nil, os.ErrNotExist
+			}
+			return []byte(sshRemote.GetContent(filePath)), nil
+		})
+	if err != nil {
+		return "", err
+	}
+	logConfigOverlays(mergedFilePaths)
+	return string(result), nil
+}
+
+// 远程主机模式下，合并本地的配置覆盖文件（~/.ide/overrides/<repo>.yaml 以及 --config-overlay）
+func mergeUserConfigOverlays(content string, workingDir string, configRelativeFilePath string, gitRepoUrl string) (string, error) {
+	projectName := GetConfigOverlayProjectName(workingDir, gitRepoUrl)
+	result, mergedFilePaths, err := mergeConfigOverlayFiles([]byte(content),
+		getConfigOverlayFiles(workingDir, configRelativeFilePath, projectName, false), os.ReadFile)
+	if err != nil {
+		return "", err
+	}
+	logConfigOverlays(mergedFilePaths)
+	return string(result), nil
+}
+
+// 输出合并的配置覆盖文件
+func logConfigOverlays(mergedFilePaths []string) {
+	for _, filePath := range mergedFilePaths {
+		common.SmartIDELog.InfoF(i18nInstance.Config.Info_config_overlay_merged, filePath)
+	}
+}
+
+// 依次合并配置覆盖文件，可选的文件不存在时跳过
+func mergeConfigOverlayFiles(content []byte, overlayFiles []configOverlayFile, readFile func(filePath string) ([]byte, error)) (
+	result []byte, mergedFilePaths []string, err error) {
+	var overlays [][]byte
+	for _, overlayFile := range overlayFiles {
+		overlayContent, err := readFile(overlayFile.FilePath)
+		if err != nil {
+			if !overlayFile.IsRequired && errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, nil, err
+		}
+		overlays = append(overlays, overlayContent)
+		mergedFilePaths = append(mergedFilePaths, overlayFile.FilePath)
+	}
+
+	result, err = MergeConfigOverlays(content, overlays...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, mergedFilePaths, nil
+}
+
+// 把覆盖的内容合并到配置中，没有覆盖时返回原内容
+func MergeConfigOverlays(content []byte, overlays ...[]byte) ([]byte, error) {
+	if len(overlays) == 0 {
+		return content, nil
+	}
+
+	var result yaml.MapSlice
+	if err := yaml.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+	for index, overlay := range overlays {
+		var overlayMap yaml.MapSlice
+		if err := yaml.Unmarshal(overlay, &overlayMap); err != nil {
+			return nil, fmt.Errorf("config overlay %v: %w", index+1, err)
+		}
+		result = mergeYamlMap(result, overlayMap)
+	}
+	return yaml.Marshal(result)
+}
+
+// 按照 key 递归合并，保持原来的顺序，新增的 key 添加到最后
+func mergeYamlMap(base yaml.MapSlice, overlay yaml.MapSlice) yaml.MapSlice {
+	result := append(yaml.MapSlice{}, base...)
+	for _, item := range overlay {
+		key, isAppend := item.Key, false
+		if keyString, ok := item.Key.(string); ok && len(keyString) > 1 && strings.HasSuffix(keyString, "+") {
+			key, isAppend = strings.TrimSuffix(keyString, "+"), true
+		}
+
+		index := indexOfYamlKey(result, key)
+		switch {
+		case item.Value == nil: // 删除
+			if index >= 0 {
+				result = append(result[:index], result[index+1:]...)
+			}
+		case index < 0:
+			result = append(result, yaml.MapItem{Key: key, Value: item.Value})
+		default:
+			result[index].Value = mergeYamlValue(result[index].Value, item.Value, isAppend)
+		}
+	}
+	return result
+}
+
+func mergeYamlValue(base interface{}, overlay interface{}, isAppend bool) interface{} {
+	switch overlayValue := overlay.(type) {
+	case yaml.MapSlice:
+		if baseValue, ok := base.(yaml.MapSlice); ok {
+			return mergeYamlMap(baseValue, overlayValue)
+		}
+	case []interface{}:
+		if baseValue, ok := base.([]interface{}); ok && isAppend {
+			return append(append([]interface{}{}, baseValue...), overlayValue...)
+		}
+	}
+	return overlay
+}
+
+// key 的类型可能不同（比如端口号），按照字符串比较
+func indexOfYamlKey(items yaml.MapSlice, key interface{}) int {
+	for index, item := range items {
+		if fmt.Sprint(item.Key) == fmt.Sprint(key) {
+			return index
+		}
+	}
+	return -1
+}
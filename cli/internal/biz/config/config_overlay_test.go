@@ -0,0 +1,205 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"gopkg.in/yaml.v2"
+)
+
+func TestMergeConfigOverlays(t *testing.T) {
+	base := `orchestrator:
+  type: docker-compose
+workspace:
+  dev-container:
+    service-name: web
+    ports:
+      webide: 6800
+      api: 6801
+    ide-type: vscode
+  services:
+    web:
+      image: nginx
+      ports:
+        - 6800:3000
+      volumes:
+        - ./:/home/project
+`
+	overlay1 := `workspace:
+  dev-container:
+    ports:
+      webide: 7800
+      api: ~
+      debug: 9229
+    ide-type: jb-projector
+  services:
+    web:
+      ports:
+        - 7800:3000
+      volumes+:
+        - ~/.m2:/root/.m2
+    redis:
+      image: redis
+`
+	overlay2 := `workspace:
+  dev-container:
+    ide-type: opensumi
+`
+	content, err := MergeConfigOverlays([]byte(base), []byte(overlay1), []byte(overlay2))
+	if err != nil {
+		t.Fatalf("MergeConfigOverlays() error = %v", err)
+	}
+	var got SmartIdeConfig
+	if err := yaml.Unmarshal(content, &got); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+
+	devContainer := got.Workspace.DevContainer
+	if devContainer.ServiceName != "web" || devContainer.IdeType != IdeTypeEnum_Opensumi {
+		t.Errorf("MergeConfigOverlays() dev-container = %+v", devContainer)
+	}
+	if wantPorts := map[string]int{"webide": 7800, "debug": 9229}; !reflect.DeepEqual(devContainer.Ports, wantPorts) {
+		t.Errorf("MergeConfigOverlays() ports = %v, want %v", devContainer.Ports, wantPorts)
+	}
+	web := got.Workspace.Servcies["web"]
+	if ports := web.Ports.Strings(); !reflect.DeepEqual(ports, []string{"7800:3000"}) {
+		t.Errorf("MergeConfigOverlays() service ports = %v", ports)
+	}
+	if volumes := web.Volumes.Strings(); !reflect.DeepEqual(volumes, []string{"./:/home/project", "~/.m2:/root/.m2"}) {
+		t.Errorf("MergeConfigOverlays() service volumes = %v", volumes)
+	}
+	if _, ok := got.Workspace.Servcies["redis"]; !ok || got.Orchestrator.Type != OrchestratorTypeEnum_Compose {
+		t.Errorf("MergeConfigOverlays() = %+v", got)
+	}
+
+	// 没有覆盖时保持原内容
+	if content, err := MergeConfigOverlays([]byte(base)); err != nil || string(content) != base {
+		t.Errorf("MergeConfigOverlays() without overlays = %v, error = %v", string(content), err)
+	}
+	if _, err := MergeConfigOverlays([]byte(base), []byte("- abc\n")); err == nil {
+		t.Errorf("MergeConfigOverlays() should return error for invalid overlay")
+	}
+}
+
+func TestLoadConfigContentWithOverlays(t *testing.T) {
+	originHome, originFiles := SmartIdeHome, ConfigOverlayFiles
+	defer func() {
+		SmartIdeHome, ConfigOverlayFiles = originHome, originFiles
+	}()
+
+	tempDir := t.TempDir()
+	SmartIdeHome = filepath.Join(tempDir, "home")
+	workingDir := filepath.Join(tempDir, "demo")
+	writeFile := func(filePath string, content string) {
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(workingDir, ".ide", ".ide.yaml"), "version: smartide/v0.3\nworkspace:\n  dev-container:\n    ide-type: vscode\n")
+	writeFile(filepath.Join(workingDir, ".ide", ".ide.local.yaml"), "workspace:\n  dev-container:\n    ide-type: jb-projector\n")
+	writeFile(filepath.Join(SmartIdeHome, "overrides", "demo.yaml"), "workspace:\n  dev-container:\n    service-name: web\n")
+	cliOverlayFilePath := filepath.Join(tempDir, "debug.yaml")
+	writeFile(cliOverlayFilePath, "workspace:\n  dev-container:\n    ide-type: opensumi\n")
+	ConfigOverlayFiles = []string{cliOverlayFilePath}
+
+	content, mergedFilePaths, err := LoadConfigContentWithOverlays(workingDir, filepath.Join(".ide", ".ide.yaml"))
+	if err != nil {
+		t.Fatalf("LoadConfigContentWithOverlays() error = %v", err)
+	}
+	wantFilePaths := []string{
+		filepath.Join(workingDir, ".ide", ".ide.local.yaml"),
+		filepath.Join(SmartIdeHome, "overrides", "demo.yaml"),
+		cliOverlayFilePath,
+	}
+	if !reflect.DeepEqual(mergedFilePaths, wantFilePaths) {
+		t.Errorf("LoadConfigContentWithOverlays() files = %v, want %v", mergedFilePaths, wantFilePaths)
+	}
+	var got SmartIdeConfig
+	if err := yaml.Unmarshal(content, &got); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	if got.Workspace.DevContainer.IdeType != IdeTypeEnum_Opensumi || got.Workspace.DevContainer.ServiceName != "web" {
+		t.Errorf("LoadConfigContentWithOverlays() = %v", string(content))
+	}
+
+	// --config-overlay 指定的文件必须存在，其他覆盖文件可选
+	ConfigOverlayFiles = []string{filepath.Join(tempDir, "not-exist.yaml")}
+	if _, _, err := LoadConfigContentWithOverlays(workingDir, filepath.Join(".ide", ".ide.yaml")); err == nil {
+		t.Errorf("LoadConfigContentWithOverlays() should return error for missing --config-overlay file")
+	}
+	ConfigOverlayFiles = nil
+	os.Remove(filepath.Join(workingDir, ".ide", ".ide.local.yaml"))
+	if _, mergedFilePaths, err := LoadConfigContentWithOverlays(workingDir, filepath.Join(".ide", ".ide.yaml")); err != nil || len(mergedFilePaths) != 1 {
+		t.Errorf("LoadConfigContentWithOverlays() files = %v, error = %v", mergedFilePaths, err)
+	}
+}
+
+// 远程主机模式下，工作目录名称包含随机的后缀，使用 git 库的名称查找 ~/.ide/overrides/<repo>.yaml
+func TestMergeUserConfigOverlays_Remote(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+	originHome, originFiles := SmartIdeHome, ConfigOverlayFiles
+	defer func() {
+		SmartIdeHome, ConfigOverlayFiles = originHome, originFiles
+	}()
+	SmartIdeHome = t.TempDir()
+	ConfigOverlayFiles = nil
+	overlayFilePath := filepath.Join(SmartIdeHome, "overrides", "boathouse.yaml")
+	if err := os.MkdirAll(filepath.Dir(overlayFilePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlayFilePath, []byte("workspace:\n  dev-container:\n    service-name: web\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "version: smartide/v0.3\nworkspace:\n  dev-container:\n    service-name: dev\n"
+	tests := []struct {
+		name        string
+		workingDir  string
+		gitRepoUrl  string
+		serviceName string
+	}{
+		{"git repo", "~/project/boathouse-x1y", "https://github.com/idcf-boat-house/boathouse.git", "web"},
+		{"git repo with ssh url", "~/project/boathouse-x1y", "git@github.com:idcf-boat-house/boathouse.git", "web"},
+		{"local folder", "~/project/boathouse", "", "web"},
+		{"other repo", "~/project/boathouse-x1y", "https://github.com/idcf-boat-house/calculator.git", "dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := mergeUserConfigOverlays(content, tt.workingDir, ".ide/.ide.yaml", tt.gitRepoUrl)
+			if err != nil {
+				t.Fatalf("mergeUserConfigOverlays() error = %v", err)
+			}
+			var got SmartIdeConfig
+			if err := yaml.Unmarshal([]byte(result), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Workspace.DevContainer.ServiceName != tt.serviceName {
+				t.Errorf("mergeUserConfigOverlays() service-name = %v, want %v", got.Workspace.DevContainer.ServiceName, tt.serviceName)
+			}
+		})
+	}
+}
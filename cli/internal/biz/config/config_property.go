@@ -87,7 +87,9 @@ func (instance SmartIdeConfig) getLinkDockerComposeFile(sshRemote *common.SSHRem
 		common.SmartIDELog.Error("link compose file is empty")
 	}
 
-	//
+	// 变量替换
+	localLinkDockerComposeFileContent, _, err := interpolateLinkCompose(sshRemote, localLinkDockerComposeFilePath, localLinkDockerComposeFileContent)
+	common.CheckError(err)
 
 	return localLinkDockerComposeFilePath, localLinkDockerComposeFileContent
 }
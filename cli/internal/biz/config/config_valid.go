@@ -19,20 +19,50 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package config
 
 import (
-	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
 )
 
+// 配置文件的验证错误
+type ConfigValidError struct {
+	// 出错节点在yaml中的路径，比如 workspace.dev-container.service-name
+	Path string
+	// 错误信息
+	Message string
+}
+
+func (e ConfigValidError) Error() string {
+	return e.Message
+}
+
+// 新建验证错误
+func newConfigValidError(path string, format string, args ...interface{}) ConfigValidError {
+	message := format
+	if len(args) > 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+	return ConfigValidError{Path: path, Message: message}
+}
+
 // 验证配置文件格式是否正确
 func (c SmartIdeK8SConfig) Valid() error {
+	if errs := c.ValidErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// 验证配置文件，返回所有的错误
+func (c SmartIdeK8SConfig) ValidErrors() (errs []ConfigValidError) {
 	// Workspace.KubeDeployFiles 节点
 	if c.Workspace.KubeDeployFileExpression == "" {
-		return errors.New("Workspace.KubeDeployFiles 未在配置文件中定义！")
+		return append(errs, newConfigValidError("workspace.kube-deploy-files", "Workspace.KubeDeployFiles 未在配置文件中定义！"))
 	}
 
 	// service name 必须在 k8s 部署文件中申明
@@ -59,11 +89,13 @@ func (c SmartIdeK8SConfig) Valid() error {
 		}
 	}
 	if !isContainServiceName {
-		return fmt.Errorf("service (%v) 未在关联 k8s yaml 中定义！", c.Workspace.DevContainer.ServiceName)
+		errs = append(errs, newConfigValidError("workspace.dev-container.service-name",
+			"service (%v) 未在关联 k8s yaml 中定义！", c.Workspace.DevContainer.ServiceName))
 	}
 
 	// 申明的端口是否在service中存在
-	for portLabel, port := range c.Workspace.DevContainer.Ports {
+	for _, portLabel := range getSortedPortLabels(c.Workspace.DevContainer.Ports) {
+		port := c.Workspace.DevContainer.Ports[portLabel]
 		isContain := false
 		for _, service := range c.Workspace.Services {
 			for _, specPort := range service.Spec.Ports {
@@ -74,36 +106,46 @@ func (c SmartIdeK8SConfig) Valid() error {
 			}
 		}
 		if !isContain {
-			return fmt.Errorf("端口 (%v:%v) 没有在k8s yaml文件中申明！", portLabel, port)
+			errs = append(errs, newConfigValidError("workspace.dev-container.ports."+portLabel,
+				"端口 (%v:%v) 没有在k8s yaml文件中申明！", portLabel, port))
 		}
 	}
 
-	return nil
+	return errs
 }
 
 // 验证配置文件格式是否正确
 func (c SmartIdeConfig) Valid() error {
+	if errs := c.ValidErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// 验证配置文件，返回所有的错误
+func (c SmartIdeConfig) ValidErrors() (errs []ConfigValidError) {
 	// 格式不能为空
 	if c.Orchestrator.Type == "" {
-		return errors.New(i18nInstance.Config.Err_config_orchestrator_type_none)
+		errs = append(errs, newConfigValidError("orchestrator.type", i18nInstance.Config.Err_config_orchestrator_type_none))
 
 	} else {
 		if c.Orchestrator.Type != OrchestratorTypeEnum_Compose &&
 			c.Orchestrator.Type != OrchestratorTypeEnum_K8S &&
 			c.Orchestrator.Type != OrchestratorTypeEnum_Allinone {
-			return errors.New(i18nInstance.Config.Err_config_orchestrator_type_valid)
+			errs = append(errs, newConfigValidError("orchestrator.type", i18nInstance.Config.Err_config_orchestrator_type_valid))
 		}
 	}
 
 	// 格式对应的版本
 	if c.Orchestrator.Version == "" {
-		msg := fmt.Sprintf(i18nInstance.Config.Err_config_orchestrator_version_none, c.Orchestrator.Type)
-		return errors.New(msg)
+		errs = append(errs, newConfigValidError("orchestrator.version",
+			i18nInstance.Config.Err_config_orchestrator_version_none, c.Orchestrator.Type))
 	}
 
 	// service name 不能为空
 	if c.Workspace.DevContainer.ServiceName == "" {
-		return errors.New(i18nInstance.Config.Err_config_devcontainer_servicename_none)
+		errs = append(errs, newConfigValidError("workspace.dev-container.service-name",
+			i18nInstance.Config.Err_config_devcontainer_servicename_none))
 
 	} else {
 
@@ -116,72 +158,99 @@ func (c SmartIdeConfig) Valid() error {
 				}
 			}
 			if !hasService {
-				msg := fmt.Sprintf(i18nInstance.Config.Err_config_devcontainer_services_not_exit, c.Workspace.DevContainer.ServiceName)
-				return errors.New(msg)
+				errs = append(errs, newConfigValidError("workspace.dev-container.service-name",
+					i18nInstance.Config.Err_config_devcontainer_services_not_exit, c.Workspace.DevContainer.ServiceName))
 			}
 		}
 
-		//TODO 如果是关联了docker-compose 文件
+		// 关联了docker-compose 文件时，由 ValidLinkCompose 验证
 	}
 
 	// web ide的类型不能为空
 	if c.Workspace.DevContainer.IdeType == "" {
-		return errors.New(i18nInstance.Config.Err_config_devcontainer_idetype_none)
+		errs = append(errs, newConfigValidError("workspace.dev-container.ide-type",
+			i18nInstance.Config.Err_config_devcontainer_idetype_none))
 
 	} else {
 		switch c.Workspace.DevContainer.IdeType {
 		case IdeTypeEnum_JbProjector, IdeTypeEnum_Opensumi, IdeTypeEnum_Theia, IdeTypeEnum_VsCode, IdeTypeEnum_SDKOnly:
 			break
 		default:
-			return errors.New(i18nInstance.Config.Err_config_devcontainer_idetype_valid)
+			errs = append(errs, newConfigValidError("workspace.dev-container.ide-type",
+				i18nInstance.Config.Err_config_devcontainer_idetype_valid))
 		}
 	}
 
 	// ports 中的端口 & 描述不能重复
 	if len(c.Workspace.DevContainer.Ports) > 0 {
 		var ports []int
-		var labels []string
-		for label, port := range c.Workspace.DevContainer.Ports {
-
+		for _, label := range getSortedPortLabels(c.Workspace.DevContainer.Ports) {
+			port := c.Workspace.DevContainer.Ports[label]
 			if common.Contains4Int(ports, port) {
-				msg := fmt.Sprintf(i18nInstance.Config.Err_config_devcontainer_ports_port_reqeat, port)
-				return errors.New(msg)
+				errs = append(errs, newConfigValidError("workspace.dev-container.ports."+label,
+					i18nInstance.Config.Err_config_devcontainer_ports_port_reqeat, port))
 			} else {
 				ports = append(ports, port)
 			}
+		}
+	}
 
-			if common.Contains(labels, label) {
-				msg := fmt.Sprintf(i18nInstance.Config.Err_config_devcontainer_ports_label_reqeat, label)
-				return errors.New(msg)
-			} else {
-				labels = append(labels, label)
-			}
+	// 定义了ports时，必services中有且仅有一个（关联了docker-compose 文件时，由 ValidLinkCompose 验证）
+	if c.Orchestrator.Type == OrchestratorTypeEnum_Compose && !c.IsLinkDockerComposeFile() {
+		errs = append(errs, c.validComposePorts(c.Workspace.Servcies)...)
+	}
+
+	return errs
+}
 
+// 验证关联的 docker-compose 文件
+func (c SmartIdeConfig) ValidLinkCompose(linkCompose compose.DockerComposeYml) (errs []ConfigValidError) {
+	// dev container 对应的 service 必须存在
+	if c.Workspace.DevContainer.ServiceName != "" {
+		if _, ok := linkCompose.Services[c.Workspace.DevContainer.ServiceName]; !ok {
+			errs = append(errs, newConfigValidError("workspace.dev-container.service-name",
+				i18nInstance.Config.Err_devcontainer_not_contains, c.Workspace.DevContainer.ServiceName))
 		}
 	}
 
-	// 定义了ports时，必services中有且仅有一个
-	if c.Orchestrator.Type == OrchestratorTypeEnum_Compose {
-		for label, port := range c.Workspace.DevContainer.Ports {
-			count := 0
-			for _, service := range c.Workspace.Servcies {
-				for _, portStr := range service.Ports {
-					array := strings.Split(portStr, ":")
-					if array[0] == strconv.Itoa(port) {
-						count++
-					}
+	// 申明的端口必须在 services 中绑定
+	errs = append(errs, c.validComposePorts(linkCompose.Services)...)
+
+	return errs
+}
+
+// 申明的端口在services中有且仅有一个绑定
+func (c SmartIdeConfig) validComposePorts(services map[string]compose.Service) (errs []ConfigValidError) {
+	for _, label := range getSortedPortLabels(c.Workspace.DevContainer.Ports) {
+		port := c.Workspace.DevContainer.Ports[label]
+		count := 0
+		for _, service := range services {
+			portConfigs, _ := service.Ports.ToComplex() // 格式错误在解析时已经返回
+			for _, portConfig := range portConfigs {
+				if portConfig.Published == strconv.Itoa(port) {
+					count++
 				}
 			}
+		}
 
-			if count == 0 {
-				return fmt.Errorf("没有找到 %v:%v 的端口绑定信息", label, port)
-			} else if count > 1 {
-				return fmt.Errorf("%v:%v 被多个service重复绑定", label, port)
-			}
+		if count == 0 {
+			errs = append(errs, newConfigValidError("workspace.dev-container.ports."+label,
+				"没有找到 %v:%v 的端口绑定信息", label, port))
+		} else if count > 1 {
+			errs = append(errs, newConfigValidError("workspace.dev-container.ports."+label,
+				"%v:%v 被多个service重复绑定", label, port))
 		}
 	}
+	return errs
+}
 
-	return nil
+// 按照描述排序，保证验证结果的顺序是固定的
+func getSortedPortLabels(ports map[string]int) (labels []string) {
+	for label := range ports {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
 }
 
 func (c SmartIdeConfig) IsNil() bool {
@@ -0,0 +1,299 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+	"gopkg.in/yaml.v2"
+	yamlV3 "gopkg.in/yaml.v3"
+)
+
+// .ide.yaml 的 json schema，可以在编辑器中使用
+//
+//go:embed schema/ide.schema.json
+var ConfigJsonSchema string
+
+// 配置文件验证时发现的问题
+type ConfigFileIssue struct {
+	// 文件路径
+	File string
+	// 行号，从1开始，0 表示未知
+	Line int
+	// 列号，从1开始，0 表示未知
+	Column int
+	// 问题描述
+	Message string
+	// 是否为警告，警告不会导致验证失败
+	IsWarning bool
+}
+
+// 格式为 file:line:column: message
+func (issue ConfigFileIssue) String() string {
+	position := issue.File
+	if issue.Line > 0 {
+		position += fmt.Sprintf(":%v", issue.Line)
+		if issue.Column > 0 {
+			position += fmt.Sprintf(":%v", issue.Column)
+		}
+	}
+	level := "error"
+	if issue.IsWarning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%v: %v: %v", position, level, issue.Message)
+}
+
+// 离线验证配置文件，以及关联的 docker-compose 文件或者 k8s 部署文件，返回发现的所有问题
+func ValidateConfigFile(configFilePath string) (issues []ConfigFileIssue, err error) {
+	contentBytes, err := os.ReadFile(configFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsDevContainerJsonFile(configFilePath) {
+		issues = validateDevContainerJsonFile(configFilePath, string(contentBytes))
+	} else {
+		issues = validateIdeYamlFile(configFilePath, contentBytes)
+	}
+
+	// 按照文件、行号排序
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// 验证 devcontainer.json，转换后的配置没有行号信息
+func validateDevContainerJsonFile(configFilePath string, content string) (issues []ConfigFileIssue) {
+	workingDir, relativeFilePath := splitDevContainerJsonFilePath(configFilePath)
+	result, warnings, err := ConvertDevContainerJsonToConfig(content, relativeFilePath, filepath.Base(workingDir))
+	if err != nil {
+		return append(issues, newIssueFromError(configFilePath, err, false))
+	}
+	for _, warning := range warnings {
+		issues = append(issues, ConfigFileIssue{File: configFilePath, Message: warning, IsWarning: true})
+	}
+	for _, validErr := range result.ValidErrors() {
+		issues = append(issues, ConfigFileIssue{File: configFilePath, Message: validErr.Message})
+	}
+	if result.IsLinkDockerComposeFile() {
+		issues = append(issues, validateLinkComposeFile(configFilePath, nil, *result)...)
+	}
+	return issues
+}
+
+// 验证 .ide.yaml
+func validateIdeYamlFile(configFilePath string, contentBytes []byte) (issues []ConfigFileIssue) {
+	//1. yaml 语法
+	var root yamlV3.Node
+	if err := yamlV3.Unmarshal(contentBytes, &root); err != nil {
+		return append(issues, newIssueFromError(configFilePath, err, false))
+	}
+
+	//2. 字段类型，未定义的字段作为警告
+	var smartideConfig SmartIdeConfig
+	if err := yaml.UnmarshalStrict(contentBytes, &smartideConfig); err != nil {
+		var typeError *yaml.TypeError
+		if !errors.As(err, &typeError) {
+			return append(issues, newIssueFromError(configFilePath, err, false))
+		}
+		for _, message := range typeError.Errors {
+			isWarning := strings.Contains(message, "not found in type")
+			issues = append(issues, newIssueFromError(configFilePath, errors.New(message), isWarning))
+		}
+		if err := yaml.Unmarshal(contentBytes, &smartideConfig); err != nil && smartideConfig.IsNil() {
+			return issues
+		}
+	}
+
+	//3. 配置规则
+	for _, validErr := range smartideConfig.ValidErrors() {
+		issues = append(issues, newIssueFromValidError(configFilePath, &root, validErr))
+	}
+
+	//4. 关联的文件
+	switch smartideConfig.Orchestrator.Type {
+	case OrchestratorTypeEnum_Compose:
+		if smartideConfig.IsLinkDockerComposeFile() {
+			issues = append(issues, validateLinkComposeFile(configFilePath, &root, smartideConfig)...)
+		}
+	case OrchestratorTypeEnum_K8S:
+		issues = append(issues, validateLinkK8sFiles(configFilePath, &root, smartideConfig, true)...)
+	case OrchestratorTypeEnum_Allinone:
+		if smartideConfig.IsLinkDockerComposeFile() {
+			issues = append(issues, validateLinkComposeFile(configFilePath, &root, smartideConfig)...)
+		}
+		issues = append(issues, validateLinkK8sFiles(configFilePath, &root, smartideConfig, false)...)
+	}
+
+	return issues
+}
+
+// 验证关联的 docker-compose 文件
+func validateLinkComposeFile(configFilePath string, root *yamlV3.Node, smartideConfig SmartIdeConfig) (issues []ConfigFileIssue) {
+	composeFilePath := filepath.Join(filepath.Dir(configFilePath), smartideConfig.Workspace.DockerComposeFile)
+	composeBytes, err := os.ReadFile(composeFilePath)
+	if err != nil {
+		validErr := newConfigValidError("workspace.docker-compose-file", i18nInstance.Config.Err_file_not_exit, composeFilePath)
+		return append(issues, newIssueFromValidError(configFilePath, root, validErr))
+	}
+
+	// 变量替换，没有设置的变量只提示警告
+	content, unsetNames, err := interpolateLinkCompose(nil, composeFilePath, string(composeBytes))
+	if err != nil {
+		return append(issues, newIssueFromError(composeFilePath, err, false))
+	}
+	for _, name := range unsetNames {
+		message := fmt.Sprintf(i18nInstance.Config.Warn_compose_variable_not_set, name)
+		issues = append(issues, newIssueFromError(composeFilePath, errors.New(message), true))
+	}
+
+	var linkCompose compose.DockerComposeYml
+	if err := yaml.Unmarshal([]byte(content), &linkCompose); err != nil {
+		var typeError *yaml.TypeError
+		if !errors.As(err, &typeError) {
+			return append(issues, newIssueFromError(composeFilePath, err, false))
+		}
+		for _, message := range typeError.Errors {
+			issues = append(issues, newIssueFromError(composeFilePath, errors.New(message), false))
+		}
+	}
+
+	for _, validErr := range smartideConfig.ValidLinkCompose(linkCompose) {
+		issues = append(issues, newIssueFromValidError(configFilePath, root, validErr))
+	}
+	return issues
+}
+
+// 验证关联的 k8s 部署文件，isRequired 为 false 时，没有匹配的文件不会报错
+func validateLinkK8sFiles(configFilePath string, root *yamlV3.Node, smartideConfig SmartIdeConfig, isRequired bool) (issues []ConfigFileIssue) {
+	var files []string
+	if smartideConfig.Workspace.KubeDeployFileExpression != "" {
+		expression := filepath.Join(filepath.Dir(configFilePath), smartideConfig.Workspace.KubeDeployFileExpression) // 链接文件是相对于.ide.yaml文件所在目录的
+		matches, err := filepath.Glob(expression)
+		if err != nil {
+			validErr := newConfigValidError("workspace.kube-deploy-files", "kube-deploy-files 格式错误: %v", err)
+			return append(issues, newIssueFromValidError(configFilePath, root, validErr))
+		}
+		files = matches
+	}
+	if len(files) == 0 {
+		if !isRequired {
+			return issues
+		}
+		if smartideConfig.Workspace.KubeDeployFileExpression != "" {
+			validErr := newConfigValidError("workspace.kube-deploy-files",
+				"没有找到与 %v 匹配的 k8s 部署文件", smartideConfig.Workspace.KubeDeployFileExpression)
+			return append(issues, newIssueFromValidError(configFilePath, root, validErr))
+		}
+	}
+
+	k8sConfig := smartideConfig.ConvertToSmartIdeK8SConfig()
+	for _, file := range files {
+		contentBytes, err := os.ReadFile(file)
+		if err != nil {
+			issues = append(issues, newIssueFromError(file, err, false))
+			continue
+		}
+		for _, document := range splitK8sYamlDocuments(string(contentBytes)) {
+			if err := k8sConfig.appendK8sYamlDocument(document.Content); err != nil {
+				issue := newIssueFromError(file, err, false)
+				if issue.Line > 0 { // 文档内的行号转换为文件内的行号
+					issue.Line += document.Line - 1
+				} else {
+					issue.Line = document.Line
+				}
+				issues = append(issues, issue)
+			}
+		}
+	}
+
+	for _, validErr := range k8sConfig.ValidErrors() {
+		issues = append(issues, newIssueFromValidError(configFilePath, root, validErr))
+	}
+	return issues
+}
+
+// 错误信息中的行号，比如 “yaml: line 3: ...” 、“line 3: cannot unmarshal ...”
+var yamlErrorLineRegexp = regexp.MustCompile(`^(?:yaml: )?line (\d+): `)
+
+// 从 yaml 的错误信息中解析行号
+func newIssueFromError(file string, err error, isWarning bool) ConfigFileIssue {
+	issue := ConfigFileIssue{File: file, Message: err.Error(), IsWarning: isWarning}
+	if matches := yamlErrorLineRegexp.FindStringSubmatch(issue.Message); len(matches) == 2 {
+		issue.Line, _ = strconv.Atoi(matches[1])
+		issue.Message = strings.TrimPrefix(issue.Message, matches[0])
+	}
+	return issue
+}
+
+// 根据节点路径定位到配置文件中的行号
+func newIssueFromValidError(file string, root *yamlV3.Node, validErr ConfigValidError) ConfigFileIssue {
+	issue := ConfigFileIssue{File: file, Message: validErr.Message}
+	if node := findYamlNode(root, validErr.Path); node != nil {
+		issue.Line, issue.Column = node.Line, node.Column
+	}
+	return issue
+}
+
+// 查找路径对应的节点（键所在的位置），找不到时返回最接近的上级节点
+func findYamlNode(root *yamlV3.Node, path string) *yamlV3.Node {
+	if root == nil {
+		return nil
+	}
+	current := root
+	if current.Kind == yamlV3.DocumentNode && len(current.Content) > 0 {
+		current = current.Content[0]
+	}
+	result := current
+	if path == "" {
+		return result
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		if current.Kind != yamlV3.MappingNode {
+			break
+		}
+		found := false
+		for index := 0; index+1 < len(current.Content); index += 2 {
+			if current.Content[index].Value == key {
+				result, current = current.Content[index], current.Content[index+1]
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return result
+}
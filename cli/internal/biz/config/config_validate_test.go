@@ -0,0 +1,189 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	tests := []struct {
+		config    string
+		linkFile  string // 关联文件的内容，文件名为 link.yaml
+		envFile   string // .env 文件的内容
+		wantLines []int  // 错误所在的行号
+		wantWarns int
+	}{
+		{ // 正确
+			config: `orchestrator:
+  type: docker-compose
+  version: 3
+workspace:
+  dev-container:
+    service-name: web
+    ports:
+      webide: 6800
+    ide-type: vscode
+  docker-compose-file: link.yaml
+`,
+			linkFile: `services:
+  web:
+    image: nginx
+    ports:
+      - 6800:3000
+`,
+		},
+		{ // 所有的错误都需要返回
+			config: `orchestrator:
+  type: docker-compose
+workspace:
+  dev-container:
+    service-name: api
+    ports:
+      webide: 6800
+      api: 6801
+    ide-type: abc
+    unknown: 1
+  docker-compose-file: link.yaml
+`,
+			linkFile: `services:
+  web:
+    image: nginx
+    ports:
+      - 6800:3000
+`,
+			wantLines: []int{1, 5, 8, 9},
+			wantWarns: 1,
+		},
+		{ // 变量替换，没有设置的变量提示警告
+			config: `orchestrator:
+  type: docker-compose
+  version: 3
+workspace:
+  dev-container:
+    service-name: web
+    ports:
+      webide: 6800
+    ide-type: vscode
+  docker-compose-file: link.yaml
+`,
+			linkFile: `services:
+  web:
+    image: nginx:${SMARTIDE_TEST_UNSET_TAG}
+    ports:
+      - ${WEB_PORT:-80}:3000
+`,
+			envFile:   "WEB_PORT=6800\n",
+			wantWarns: 1,
+		},
+		{ // yaml 语法错误
+			config:    "orchestrator:\n  type: [\n",
+			wantLines: []int{2},
+		},
+		{ // k8s 部署文件
+			config: `orchestrator:
+  type: k8s
+  version: 1
+workspace:
+  dev-container:
+    service-name: web
+    ports:
+      webide: 6800
+    ide-type: vscode
+  kube-deploy-files: link.yaml
+`,
+			linkFile: `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+    - port: 6800
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: api
+          image: nginx
+`,
+			wantLines: []int{6},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			dir := t.TempDir()
+			configFilePath := filepath.Join(dir, ".ide.yaml")
+			if err := os.WriteFile(configFilePath, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.linkFile != "" {
+				if err := os.WriteFile(filepath.Join(dir, "link.yaml"), []byte(tt.linkFile), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.envFile != "" {
+				if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(tt.envFile), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			issues, err := ValidateConfigFile(configFilePath)
+			if err != nil {
+				t.Errorf("ValidateConfigFile() error = %v", err)
+				return
+			}
+			var lines []int
+			warns := 0
+			for _, issue := range issues {
+				if issue.IsWarning {
+					warns++
+				} else {
+					lines = append(lines, issue.Line)
+				}
+			}
+			if fmt.Sprint(lines) != fmt.Sprint(tt.wantLines) && !(len(lines) == 0 && len(tt.wantLines) == 0) {
+				t.Errorf("ValidateConfigFile() lines = %v, wantLines %v, issues %v", lines, tt.wantLines, issues)
+			}
+			if warns != tt.wantWarns {
+				t.Errorf("ValidateConfigFile() warnings = %v, wantWarns %v", warns, tt.wantWarns)
+			}
+		})
+	}
+}
+
+func TestSplitK8sYamlDocuments(t *testing.T) {
+	documents := splitK8sYamlDocuments("a: 1\n---\n\nb: 2\n---\n---\nc: 3\n")
+	if len(documents) != 3 {
+		t.Fatalf("splitK8sYamlDocuments() count = %v, want 3", len(documents))
+	}
+	for index, wantLine := range []int{1, 4, 7} {
+		if documents[index].Line != wantLine {
+			t.Errorf("splitK8sYamlDocuments() line = %v, wantLine %v", documents[index].Line, wantLine)
+		}
+	}
+}
@@ -107,8 +107,10 @@ func SSHVolumesConfig(isVmCommand bool, service *compose.Service, sshRemote comm
 		}
 
 	}
-	if configPaths != nil {
-		service.Volumes = append(service.Volumes, configPaths...)
+	for _, configPath := range configPaths {
+		volume, err := compose.ParseVolumeMap(configPath)
+		common.CheckError(err)
+		service.AppendVolume(volume)
 	}
 
 	// return
@@ -180,8 +182,10 @@ func GitConfig(isVmCommand bool, containerName string, cli *client.Client,
 	}
 	if isConfig {
 		configPaths := []string{"$HOME/.gitconfig:/home/smartide/.gitconfig"}
-		if configPaths != nil {
-			service.Volumes = append(service.Volumes, configPaths...)
+		for _, configPath := range configPaths {
+			volume, err := compose.ParseVolumeMap(configPath)
+			common.CheckError(err)
+			service.AppendVolume(volume)
 		}
 	}
 
@@ -47,6 +47,8 @@ type GlobalConfig struct {
 	DefaultLoginUrl       string               `yaml:"default-login-url" json:"default-login-url"`
 	Auths                 []model.Auth         `yaml:"auths" json:"auths"`
 	IsInsightEnabled      IsInsightEnabledEnum `yaml:"isInsight" json:"isInsight"`
+	// 密码等敏感信息的存储方式，db | file | secret-service | pass | env
+	SecretBackend string `yaml:"secret-backend,omitempty" json:"secret-backend,omitempty"`
 }
 
 func GetCurrentAuth(auths []model.Auth) model.Auth {
@@ -0,0 +1,398 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package workspace
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/leansoftX/smartide-cli/internal/model"
+	"gopkg.in/yaml.v2"
+)
+
+// 工作区导出文件的格式版本
+const CONST_WorkspaceBundle_Version = 1
+
+const (
+	// 导出文件中工作区信息的文件名
+	bundleWorkspaceFileName = "workspace.json"
+	// 导出文件中项目文件所在的目录
+	bundleProjectDirName = "project"
+)
+
+// 导出的工作区，不包含任何密码、密钥等敏感信息
+type WorkspaceBundle struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exportedAt"`
+
+	Name                   string          `json:"name"`
+	Mode                   WorkingModeEnum `json:"mode"`
+	WorkingDirectoryPath   string          `json:"workingDirectoryPath"`
+	ConfigFileRelativePath string          `json:"configFileRelativePath"`
+
+	GitCloneRepoUrl string          `json:"gitCloneRepoUrl,omitempty"`
+	GitRepoAuthType GitRepoAuthType `json:"gitRepoAuthType,omitempty"`
+	GitBranch       string          `json:"gitBranch,omitempty"`
+
+	// 远程主机，只包含连接地址
+	Remote *WorkspaceBundleRemote `json:"remote,omitempty"`
+
+	ConfigContent      string          `json:"configContent"`
+	LinkComposeContent string          `json:"linkComposeContent,omitempty"`
+	TempComposeContent string          `json:"tempComposeContent"`
+	Extend             WorkspaceExtend `json:"extend"`
+
+	// 是否包含项目文件
+	IsIncludeProject bool `json:"isIncludeProject"`
+}
+
+// 导出的远程主机信息
+type WorkspaceBundleRemote struct {
+	Addr     string         `json:"addr"`
+	SSHPort  int            `json:"sshPort"`
+	UserName string         `json:"userName"`
+	AuthType RemoteAuthType `json:"authType"`
+}
+
+// 从工作区信息创建
+func NewWorkspaceBundle(workspaceInfo WorkspaceInfo) (*WorkspaceBundle, error) {
+	if workspaceInfo.Mode == WorkingMode_K8s {
+		return nil, errors.New("k8s 模式的工作区不支持导出")
+	}
+
+	bundle := &WorkspaceBundle{
+		Version:                CONST_WorkspaceBundle_Version,
+		ExportedAt:             time.Now(),
+		Name:                   workspaceInfo.Name,
+		Mode:                   workspaceInfo.Mode,
+		WorkingDirectoryPath:   workspaceInfo.WorkingDirectoryPath,
+		ConfigFileRelativePath: workspaceInfo.ConfigFileRelativePath,
+		GitCloneRepoUrl:        workspaceInfo.GitCloneRepoUrl,
+		GitRepoAuthType:        workspaceInfo.GitRepoAuthType,
+		GitBranch:              workspaceInfo.GitBranch,
+		Extend:                 workspaceInfo.Extend,
+	}
+	if workspaceInfo.Mode == WorkingMode_Remote {
+		bundle.Remote = &WorkspaceBundleRemote{
+			Addr:     workspaceInfo.Remote.Addr,
+			SSHPort:  workspaceInfo.Remote.SSHPort,
+			UserName: workspaceInfo.Remote.UserName,
+			AuthType: workspaceInfo.Remote.AuthType,
+		}
+	}
+
+	var err error
+	bundle.ConfigContent, err = workspaceInfo.ConfigYaml.ToYaml()
+	if err != nil {
+		return nil, err
+	}
+	if workspaceInfo.ConfigYaml.IsLinkDockerComposeFile() {
+		bundle.LinkComposeContent, err = workspaceInfo.ConfigYaml.Workspace.LinkCompose.ToYaml()
+		if err != nil {
+			return nil, err
+		}
+	}
+	bundle.TempComposeContent, err = workspaceInfo.TempDockerCompose.ToYaml()
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// 转换为工作区信息，workingDirectoryPath 为空时使用导出时的工作目录
+func (bundle WorkspaceBundle) ToWorkspaceInfo(workingDirectoryPath string) (WorkspaceInfo, error) {
+	workspaceInfo := WorkspaceInfo{
+		Name:                   bundle.Name,
+		Mode:                   bundle.Mode,
+		WorkingDirectoryPath:   bundle.WorkingDirectoryPath,
+		ConfigFileRelativePath: bundle.ConfigFileRelativePath,
+		GitCloneRepoUrl:        bundle.GitCloneRepoUrl,
+		GitRepoAuthType:        bundle.GitRepoAuthType,
+		GitBranch:              bundle.GitBranch,
+		Extend:                 bundle.Extend,
+		CacheEnv:               CacheEnvEnum_Local,
+		CliRunningEnv:          CliRunningEnvEnum_Client,
+	}
+	if workingDirectoryPath != "" {
+		workspaceInfo.WorkingDirectoryPath = workingDirectoryPath
+	}
+	if workspaceInfo.GitRepoAuthType == "" {
+		workspaceInfo.GitRepoAuthType = GitRepoAuthType_Public
+	}
+	if bundle.Remote != nil {
+		workspaceInfo.Remote = RemoteInfo{
+			Addr:     bundle.Remote.Addr,
+			SSHPort:  bundle.Remote.SSHPort,
+			UserName: bundle.Remote.UserName,
+			AuthType: bundle.Remote.AuthType,
+		}
+	}
+
+	// 配置文件 及 关联的 docker-compose 文件
+	configYaml, _, err := config.NewComposeConfigFromContent(bundle.ConfigContent, bundle.LinkComposeContent)
+	if err != nil {
+		return workspaceInfo, err
+	}
+	workspaceInfo.ConfigYaml = *configYaml
+	if bundle.TempComposeContent != "" {
+		err = yaml.Unmarshal([]byte(bundle.TempComposeContent), &workspaceInfo.TempDockerCompose)
+		if err != nil {
+			return workspaceInfo, err
+		}
+	}
+	workspaceInfo.TempYamlFileAbsolutePath = workspaceInfo.GetTempDockerComposeFilePath()
+
+	return workspaceInfo, nil
+}
+
+// 导出到 tar.gz 文件，projectDirPath 不为空时同时打包项目文件（不包含临时文件夹）
+func (bundle WorkspaceBundle) Export(outputFilePath string, projectDirPath string) (err error) {
+	bundle.IsIncludeProject = projectDirPath != ""
+
+	file, err := os.Create(outputFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(outputFilePath)
+		}
+	}()
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	//1. 工作区信息
+	content, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = tarWriter.WriteHeader(&tar.Header{Name: bundleWorkspaceFileName, Mode: 0600, Size: int64(len(content)), ModTime: bundle.ExportedAt})
+	if err != nil {
+		return err
+	}
+	if _, err = tarWriter.Write(content); err != nil {
+		return err
+	}
+
+	//2. 项目文件
+	if bundle.IsIncludeProject {
+		if err = addDirToTar(tarWriter, projectDirPath, bundleProjectDirName, outputFilePath); err != nil {
+			return err
+		}
+	}
+
+	if err = tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// 读取导出的文件，projectDirPath 不为空并且包含项目文件时，解压到该目录下
+func ReadWorkspaceBundle(bundleFilePath string, projectDirPath string) (*WorkspaceBundle, error) {
+	file, err := os.Open(bundleFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+
+	var bundle *WorkspaceBundle
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		//1. 工作区信息
+		if header.Name == bundleWorkspaceFileName {
+			bundle = &WorkspaceBundle{}
+			if err := json.NewDecoder(tarReader).Decode(bundle); err != nil {
+				return nil, err
+			}
+			if bundle.Version > CONST_WorkspaceBundle_Version {
+				return nil, fmt.Errorf("不支持的导出文件版本 %v，请升级 smartide", bundle.Version)
+			}
+			continue
+		}
+
+		//2. 项目文件
+		if projectDirPath == "" || !strings.HasPrefix(header.Name, bundleProjectDirName+"/") {
+			continue
+		}
+		relativePath := strings.TrimPrefix(header.Name, bundleProjectDirName+"/")
+		if relativePath == "" {
+			continue
+		}
+		if err := extractTarEntry(tarReader, header, projectDirPath, relativePath); err != nil {
+			return nil, err
+		}
+	}
+
+	if bundle == nil {
+		return nil, fmt.Errorf("%v 中没有找到 %v，不是有效的工作区导出文件", bundleFilePath, bundleWorkspaceFileName)
+	}
+	return bundle, nil
+}
+
+// 把目录添加到 tar 中
+func addDirToTar(tarWriter *tar.Writer, dirPath string, prefix string, excludeFilePath string) error {
+	tempDirPath := filepath.Join(dirPath, model.CONST_GlobalTempDirPath)
+	excludeFilePath, _ = filepath.Abs(excludeFilePath)
+
+	return filepath.Walk(dirPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == tempDirPath { // 临时文件不需要导出，导入时会重新生成
+			return filepath.SkipDir
+		}
+		if absPath, _ := filepath.Abs(filePath); absPath == excludeFilePath { // 导出文件保存在项目目录下时
+			return nil
+		}
+		relativePath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return err
+		}
+		if relativePath == "." {
+			return nil
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(filePath); err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() { // socket、管道等文件忽略
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(prefix, filepath.ToSlash(relativePath))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+}
+
+// 解压单个文件，不允许解压到目标目录之外
+// 符号链接只能指向目标目录中的相对路径，并且不会通过已有的符号链接写入文件
+func extractTarEntry(tarReader *tar.Reader, header *tar.Header, targetDirPath string, relativePath string) error {
+	targetFilePath := filepath.Join(targetDirPath, filepath.FromSlash(relativePath))
+	if !isPathInDir(targetDirPath, targetFilePath) || targetFilePath == filepath.Clean(targetDirPath) {
+		return fmt.Errorf("导出文件中包含非法的路径 %v", header.Name)
+	}
+	if err := checkNoSymlinkInPath(targetDirPath, targetFilePath); err != nil {
+		return fmt.Errorf("导出文件中包含非法的路径 %v: %v", header.Name, err)
+	}
+
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(targetFilePath, os.FileMode(header.Mode)|0700)
+	case tar.TypeSymlink:
+		linkTargetPath := filepath.Join(filepath.Dir(targetFilePath), filepath.FromSlash(header.Linkname))
+		if filepath.IsAbs(header.Linkname) || strings.HasPrefix(header.Linkname, "/") || !isPathInDir(targetDirPath, linkTargetPath) {
+			return fmt.Errorf("导出文件中的符号链接 %v -> %v 指向了项目目录之外", header.Name, header.Linkname)
+		}
+		if err := os.MkdirAll(filepath.Dir(targetFilePath), os.ModePerm); err != nil {
+			return err
+		}
+		return os.Symlink(header.Linkname, targetFilePath)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(targetFilePath), os.ModePerm); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(targetFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)|0600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, tarReader); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+	return nil
+}
+
+// 路径是否在目录中（包括目录本身）
+func isPathInDir(dirPath string, filePath string) bool {
+	rel, err := filepath.Rel(dirPath, filePath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// 检查目标目录到文件之间（包括文件本身）已经存在的路径，不允许是符号链接，避免写入到链接指向的位置
+func checkNoSymlinkInPath(dirPath string, filePath string) error {
+	rel, err := filepath.Rel(dirPath, filePath)
+	if err != nil {
+		return err
+	}
+	currentPath := filepath.Clean(dirPath)
+	for _, item := range strings.Split(rel, string(filepath.Separator)) {
+		currentPath = filepath.Join(currentPath, item)
+		fileInfo, err := os.Lstat(currentPath)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fileInfo.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%v 是符号链接", currentPath)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package workspace
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
+)
+
+const testBundleConfig = `version: smartide/v0.3
+orchestrator:
+  type: docker-compose
+  version: 3
+workspace:
+  dev-container:
+    service-name: web
+    ports:
+      webide: 6800
+    ide-type: vscode
+  services:
+    web:
+      image: nginx
+      ports:
+        - 6800:3000
+`
+
+func newTestBundleWorkspace(t *testing.T, workingDir string) WorkspaceInfo {
+	configYaml, _, err := config.NewComposeConfigFromContent(testBundleConfig, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return WorkspaceInfo{
+		ID:                     "1",
+		Name:                   "boathouse",
+		Mode:                   WorkingMode_Local,
+		WorkingDirectoryPath:   workingDir,
+		ConfigFileRelativePath: ".ide/.ide.yaml",
+		GitCloneRepoUrl:        "https://github.com/idcf-boat-house/boathouse-calculator.git",
+		GitRepoAuthType:        GitRepoAuthType_Basic,
+		GitUserName:            "smartide",
+		GitPassword:            "secret-password",
+		GitBranch:              "main",
+		ConfigYaml:             *configYaml,
+		TempDockerCompose: compose.DockerComposeYml{
+			Version:  "3",
+			Services: map[string]compose.Service{"web": {Image: "nginx", Ports: compose.Ports{compose.NewPortSimple(6800, 3000)}}},
+		},
+	}
+}
+
+func TestWorkspaceBundle_ExportAndRead(t *testing.T) {
+	projectDir := t.TempDir()
+	os.MkdirAll(filepath.Join(projectDir, "src"), os.ModePerm)
+	os.WriteFile(filepath.Join(projectDir, "src", "main.go"), []byte("package main"), 0644)
+	os.MkdirAll(filepath.Join(projectDir, ".ide", ".temp"), os.ModePerm)
+	os.WriteFile(filepath.Join(projectDir, ".ide", ".temp", "docker-compose-boathouse.yaml"), []byte("temp"), 0644)
+
+	bundle, err := NewWorkspaceBundle(newTestBundleWorkspace(t, projectDir))
+	if err != nil {
+		t.Fatalf("NewWorkspaceBundle() error = %v", err)
+	}
+	bundleFilePath := filepath.Join(t.TempDir(), "ws.tar.gz")
+	if err := bundle.Export(bundleFilePath, projectDir); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// 不能包含密码
+	content := readTestBundleFile(t, bundleFilePath, bundleWorkspaceFileName)
+	if strings.Contains(content, "secret-password") || strings.Contains(content, `"smartide"`) {
+		t.Errorf("Export() should not contain git credentials, got %v", content)
+	}
+
+	// 解压项目文件，临时文件不需要导出
+	targetDir := t.TempDir()
+	got, err := ReadWorkspaceBundle(bundleFilePath, targetDir)
+	if err != nil {
+		t.Fatalf("ReadWorkspaceBundle() error = %v", err)
+	}
+	if !got.IsIncludeProject || got.Name != "boathouse" || got.GitBranch != "main" {
+		t.Errorf("ReadWorkspaceBundle() = %+v", got)
+	}
+	if data, err := os.ReadFile(filepath.Join(targetDir, "src", "main.go")); err != nil || string(data) != "package main" {
+		t.Errorf("ReadWorkspaceBundle() project file = %v, %v", string(data), err)
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, ".ide", ".temp")); !os.IsNotExist(err) {
+		t.Errorf("ReadWorkspaceBundle() should not extract temp directory")
+	}
+
+	// 还原工作区
+	workspaceInfo, err := got.ToWorkspaceInfo(targetDir)
+	if err != nil {
+		t.Fatalf("ToWorkspaceInfo() error = %v", err)
+	}
+	if workspaceInfo.ID != "" || workspaceInfo.WorkingDirectoryPath != targetDir || workspaceInfo.GitPassword != "" {
+		t.Errorf("ToWorkspaceInfo() = %v, %v, %v", workspaceInfo.ID, workspaceInfo.WorkingDirectoryPath, workspaceInfo.GitPassword)
+	}
+	if workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName != "web" || workspaceInfo.TempDockerCompose.Services["web"].Image != "nginx" {
+		t.Errorf("ToWorkspaceInfo() config = %+v", workspaceInfo.ConfigYaml.Workspace.DevContainer)
+	}
+}
+
+func TestReadWorkspaceBundle_IllegalPath(t *testing.T) {
+	bundleFilePath := filepath.Join(t.TempDir(), "ws.tar.gz")
+	file, _ := os.Create(bundleFilePath)
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range map[string]string{bundleWorkspaceFileName: `{"version":1,"name":"demo"}`, "project/../../evil": "evil"} {
+		tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tarWriter.Write([]byte(content))
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+	file.Close()
+
+	if _, err := ReadWorkspaceBundle(bundleFilePath, t.TempDir()); err == nil {
+		t.Errorf("ReadWorkspaceBundle() should return error for illegal path")
+	}
+}
+
+func TestReadWorkspaceBundle_IllegalSymlink(t *testing.T) {
+	type entry struct {
+		name     string
+		linkname string
+		content  string
+	}
+	tests := []struct {
+		name    string
+		entries []entry
+		wantErr bool
+	}{
+		{name: "absolute link", entries: []entry{{name: "project/x", linkname: "/etc"}}, wantErr: true},
+		{name: "relative link outside", entries: []entry{{name: "project/a/x", linkname: "../../../etc"}}, wantErr: true},
+		{name: "write through link", entries: []entry{{name: "project/x", linkname: "a"}, {name: "project/x/passwd", content: "evil"}}, wantErr: true},
+		{name: "overwrite link", entries: []entry{{name: "project/x", linkname: "a"}, {name: "project/x", content: "evil"}}, wantErr: true},
+		{name: "link inside", entries: []entry{{name: "project/a/b", content: "b"}, {name: "project/x", linkname: "a/b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundleFilePath := filepath.Join(t.TempDir(), "ws.tar.gz")
+			file, _ := os.Create(bundleFilePath)
+			gzipWriter := gzip.NewWriter(file)
+			tarWriter := tar.NewWriter(gzipWriter)
+			content := `{"version":1,"name":"demo"}`
+			tarWriter.WriteHeader(&tar.Header{Name: bundleWorkspaceFileName, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+			tarWriter.Write([]byte(content))
+			for _, item := range tt.entries {
+				if item.linkname != "" {
+					tarWriter.WriteHeader(&tar.Header{Name: item.name, Linkname: item.linkname, Mode: 0777, Typeflag: tar.TypeSymlink})
+					continue
+				}
+				tarWriter.WriteHeader(&tar.Header{Name: item.name, Mode: 0600, Size: int64(len(item.content)), Typeflag: tar.TypeReg})
+				tarWriter.Write([]byte(item.content))
+			}
+			tarWriter.Close()
+			gzipWriter.Close()
+			file.Close()
+
+			projectDirPath := filepath.Join(t.TempDir(), "project")
+			os.MkdirAll(filepath.Join(projectDirPath, "a"), 0755)
+			if _, err := ReadWorkspaceBundle(bundleFilePath, projectDirPath); (err != nil) != tt.wantErr {
+				t.Errorf("ReadWorkspaceBundle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func readTestBundleFile(t *testing.T, bundleFilePath string, name string) string {
+	file, err := os.Open(bundleFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			t.Fatalf("%v not found: %v", name, err)
+		}
+		if header.Name == name {
+			data, err := io.ReadAll(tarReader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(data)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+// 生命周期阶段，名称与 .ide.yaml 中的节点一致
+type LifecycleStageEnum string
+
+const (
+	LifecycleStageEnum_OnCreate      LifecycleStageEnum = "on-create"
+	LifecycleStageEnum_UpdateContent LifecycleStageEnum = "update-content"
+	LifecycleStageEnum_PostStart     LifecycleStageEnum = "post-start"
+	LifecycleStageEnum_PostAttach    LifecycleStageEnum = "post-attach"
+	LifecycleStageEnum_PreStop       LifecycleStageEnum = "pre-stop"
+)
+
+// 容器中记录 on-create 已经执行的文件，容器重建后文件不存在，会再次执行
+const CONST_LifecycleOnCreateMarkerFilePath = "/var/tmp/.smartide-on-create"
+
+// 在开发容器中通过 sh -c 执行命令，返回输出；命令的退出码不为 0 时需要返回错误
+type LifecycleExecFunc func(command string) (output string, err error)
+
+// 获取阶段对应的命令
+func getLifecycleCommands(hooks config.LifecycleHooks, stage LifecycleStageEnum) []string {
+	switch stage {
+	case LifecycleStageEnum_OnCreate:
+		return hooks.OnCreate
+	case LifecycleStageEnum_UpdateContent:
+		return hooks.UpdateContent
+	case LifecycleStageEnum_PostStart:
+		return hooks.PostStart
+	case LifecycleStageEnum_PostAttach:
+		return hooks.PostAttach
+	case LifecycleStageEnum_PreStop:
+		return hooks.PreStop
+	}
+	return nil
+}
+
+// 依次执行某个阶段的命令，输出记录到工作区日志中，任意命令失败时停止并返回错误
+func RunLifecycleStage(hooks config.LifecycleHooks, stage LifecycleStageEnum, exec LifecycleExecFunc) error {
+	for _, command := range getLifecycleCommands(hooks, stage) {
+		if strings.TrimSpace(command) == "" {
+			continue
+		}
+		common.SmartIDELog.InfoF(i18nInstance.Common.Info_lifecycle_running, stage, command)
+		output, err := exec(command)
+		if output = strings.TrimSpace(output); output != "" {
+			common.SmartIDELog.Info(output)
+		}
+		if err != nil {
+			return fmt.Errorf(i18nInstance.Common.Err_lifecycle_failed, stage, command, err)
+		}
+	}
+	return nil
+}
+
+// 启动时执行的生命周期：on-create（每个容器一次）、update-content、post-start
+func RunLifecycleStartStages(hooks config.LifecycleHooks, exec LifecycleExecFunc) error {
+	//1. on-create，通过容器中的标记文件判断是否已经执行
+	if len(hooks.OnCreate) > 0 {
+		if _, err := exec(fmt.Sprintf("test -f %v", CONST_LifecycleOnCreateMarkerFilePath)); err == nil {
+			common.SmartIDELog.InfoF(i18nInstance.Common.Info_lifecycle_skipped, LifecycleStageEnum_OnCreate)
+		} else {
+			if err := RunLifecycleStage(hooks, LifecycleStageEnum_OnCreate, exec); err != nil {
+				return err
+			}
+			if _, err := exec(fmt.Sprintf("touch %v", CONST_LifecycleOnCreateMarkerFilePath)); err != nil {
+				common.SmartIDELog.Warning(err.Error())
+			}
+		}
+	}
+
+	//2. update-content & post-start
+	for _, stage := range []LifecycleStageEnum{LifecycleStageEnum_UpdateContent, LifecycleStageEnum_PostStart} {
+		if err := RunLifecycleStage(hooks, stage, exec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
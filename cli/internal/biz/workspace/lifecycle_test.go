@@ -0,0 +1,100 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package workspace
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+// 模拟容器，记录执行过的命令以及标记文件
+type fakeLifecycleContainer struct {
+	files    map[string]bool
+	commands []string
+	failOn   string
+}
+
+func (c *fakeLifecycleContainer) exec(command string) (string, error) {
+	switch {
+	case strings.HasPrefix(command, "test -f "):
+		if !c.files[strings.TrimPrefix(command, "test -f ")] {
+			return "", errors.New("exit code 1")
+		}
+		return "", nil
+	case strings.HasPrefix(command, "touch "):
+		c.files[strings.TrimPrefix(command, "touch ")] = true
+		return "", nil
+	}
+	c.commands = append(c.commands, command)
+	if command == c.failOn {
+		return "not found", errors.New("exit code 127")
+	}
+	return "ok", nil
+}
+
+func TestRunLifecycleStartStages(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+
+	hooks := config.LifecycleHooks{
+		OnCreate:      []string{"npm ci"},
+		UpdateContent: []string{"npm run build"},
+		PostStart:     []string{"npm start &"},
+	}
+	container := &fakeLifecycleContainer{files: map[string]bool{}}
+
+	// 第一次启动执行所有阶段，第二次启动跳过 on-create
+	for i := 0; i < 2; i++ {
+		if err := RunLifecycleStartStages(hooks, container.exec); err != nil {
+			t.Fatalf("RunLifecycleStartStages() error = %v", err)
+		}
+	}
+	want := []string{"npm ci", "npm run build", "npm start &", "npm run build", "npm start &"}
+	if !reflect.DeepEqual(container.commands, want) {
+		t.Errorf("commands = %v, want %v", container.commands, want)
+	}
+	if !container.files[CONST_LifecycleOnCreateMarkerFilePath] {
+		t.Errorf("on-create marker should be created")
+	}
+}
+
+func TestRunLifecycleStartStages_Failed(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+
+	hooks := config.LifecycleHooks{
+		OnCreate:  []string{"npm ci", "npm run seed"},
+		PostStart: []string{"npm start &"},
+	}
+	container := &fakeLifecycleContainer{files: map[string]bool{}, failOn: "npm ci"}
+
+	err := RunLifecycleStartStages(hooks, container.exec)
+	if err == nil || !strings.Contains(err.Error(), "exit code 127") {
+		t.Fatalf("RunLifecycleStartStages() error = %v, want exit code error", err)
+	}
+	if !reflect.DeepEqual(container.commands, []string{"npm ci"}) {
+		t.Errorf("commands = %v, should stop after the failed command", container.commands)
+	}
+	if container.files[CONST_LifecycleOnCreateMarkerFilePath] {
+		t.Errorf("on-create marker should not be created when failed")
+	}
+}
@@ -66,8 +66,11 @@ const (
 type RemoteAuthType string
 
 const (
+	// 私钥，默认为 ~/.ssh 下的私钥，也可以指定私钥文件
 	RemoteAuthType_SSH      RemoteAuthType = "ssh"
 	RemoteAuthType_Password RemoteAuthType = "password"
+	// ssh-agent（SSH_AUTH_SOCK）中的私钥
+	RemoteAuthType_Agent RemoteAuthType = "agent"
 )
 
 // git库的连接方式
@@ -438,10 +441,8 @@ func (c *WorkspaceInfo) GetContainerWorkingPathWithVolumes() string {
 
 	service := c.TempDockerCompose.Services[c.ConfigYaml.Workspace.DevContainer.ServiceName]
 	for _, volume := range service.Volumes {
-		if strings.Contains(volume, ":/home/project") {
-			tmp := strings.ReplaceAll(volume, "\\'", "")
-			index := strings.Index(tmp, ":/home/project")
-			projectPath = tmp[index+1:]
+		if volumeConfig, err := volume.ToComplex(); err == nil && strings.HasPrefix(volumeConfig.Target, "/home/project") {
+			projectPath = volumeConfig.Target
 			break
 		}
 	}
@@ -539,6 +540,8 @@ type ExtendPorts []config.PortMapInfo
 type WorkspaceExtend struct {
 	// 端口映射情况
 	Ports ExtendPorts `json:"Ports"`
+	// 通过 --sync 同步的本地文件夹，远程目录中没有 .git，不能通过 git clone 恢复
+	SyncDirectory string `json:"SyncDirectory,omitempty"`
 }
 
 // 在扩展的端口列表中查找
@@ -624,6 +627,11 @@ type RemoteInfo struct {
 	SSHPort     int
 	SSHKey      string
 	CreatedTime time.Time
+
+	// 本机上的私钥文件路径，为空时使用 ~/.ssh 下默认的私钥
+	IdentityFile string
+	// 跳板机，格式与 ssh 的 ProxyJump 一致，比如 user@bastion:22，多个跳板机用逗号分隔
+	JumpHosts string
 }
 
 type K8sInfo struct {
@@ -0,0 +1,121 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package workspace
+
+import (
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/ssh_config"
+)
+
+// ~/.ssh/config 中可以导入的主机
+type SSHConfigHost struct {
+	// Host 指令中的别名
+	Alias  string
+	Remote RemoteInfo
+}
+
+// 读取 ssh config 中的主机，忽略带有通配符的 Host、指向本机的 Host（比如 SmartIDE 工作区生成的 SmartIDE-xxx）
+// pattern 不为空时，只返回别名匹配的主机，支持 * 和 ? 通配符
+func LoadSSHConfigHosts(reader io.Reader, pattern string) (hosts []SSHConfigHost, err error) {
+	cfg, err := ssh_config.Decode(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := []string{}
+	for _, host := range cfg.Hosts {
+		for _, hostPattern := range host.Patterns {
+			alias := hostPattern.String()
+			if strings.ContainsAny(alias, "*?!") || common.Contains(aliases, alias) {
+				continue
+			}
+			if pattern != "" {
+				if isMatch, err := filepath.Match(pattern, alias); err != nil {
+					return nil, err
+				} else if !isMatch {
+					continue
+				}
+			}
+			aliases = append(aliases, alias)
+		}
+	}
+
+	for _, alias := range aliases {
+		remoteInfo := RemoteInfo{}
+
+		//1. 地址、端口、用户名，未配置的值与 ssh 的默认值一致
+		remoteInfo.Addr = alias
+		if hostName, _ := cfg.Get(alias, "HostName"); hostName != "" {
+			remoteInfo.Addr = strings.ReplaceAll(hostName, "%h", alias) // %h 代表别名
+		}
+		if ip := net.ParseIP(remoteInfo.Addr); remoteInfo.Addr == "localhost" || (ip != nil && ip.IsLoopback()) {
+			continue
+		}
+		remoteInfo.SSHPort = 22
+		if port, _ := cfg.Get(alias, "Port"); port != "" {
+			remoteInfo.SSHPort, err = strconv.Atoi(port)
+			if err != nil {
+				return nil, err
+			}
+		}
+		remoteInfo.UserName, _ = cfg.Get(alias, "User")
+		if remoteInfo.UserName == "" {
+			if current, err := user.Current(); err == nil {
+				remoteInfo.UserName = current.Username
+			}
+		}
+
+		//2. 认证方式
+		identityFile, _ := cfg.Get(alias, "IdentityFile")
+		identityFile = strings.Trim(identityFile, `"`)
+		if identityFile != "" {
+			remoteInfo.IdentityFile, err = filepath.Abs(common.ExpandIdentityFilePath(identityFile))
+			if err != nil {
+				return nil, err
+			}
+			remoteInfo.AuthType = RemoteAuthType_SSH
+		} else if os.Getenv("SSH_AUTH_SOCK") != "" {
+			remoteInfo.AuthType = RemoteAuthType_Agent
+		} else {
+			remoteInfo.AuthType = RemoteAuthType_SSH
+		}
+
+		//3. 跳板机
+		if proxyJump, _ := cfg.Get(alias, "ProxyJump"); strings.ToLower(proxyJump) != "none" {
+			remoteInfo.JumpHosts = proxyJump
+		}
+
+		hosts = append(hosts, SSHConfigHost{Alias: alias, Remote: remoteInfo})
+	}
+
+	return hosts, nil
+}
+
+// 默认的 ssh config 文件
+func GetSSHConfigFilePath() (string, error) {
+	return getSSHConfigPath()
+}
@@ -0,0 +1,82 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSSHConfigHosts(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	home, _ := os.UserHomeDir()
+	content := `
+Host *
+    ServerAliveInterval 30
+
+Host build-1 build-2
+    HostName %h.internal
+    User builder
+    Port 2222
+    IdentityFile ~/.ssh/id_build
+    ProxyJump ops@bastion:22
+
+Host bastion
+    HostName bastion.example.com
+    User ops
+
+Host SmartIDE-abc
+    HostName localhost
+    User smartide
+    Port 6822
+
+Host !prod-* dev-?
+    User dev
+`
+	hosts, err := LoadSSHConfigHosts(strings.NewReader(content), "")
+	if err != nil {
+		t.Fatalf("LoadSSHConfigHosts() error = %v", err)
+	}
+	aliases := []string{}
+	for _, host := range hosts {
+		aliases = append(aliases, host.Alias)
+	}
+	if strings.Join(aliases, ",") != "build-1,build-2,bastion" {
+		t.Fatalf("LoadSSHConfigHosts() aliases = %v", aliases)
+	}
+
+	build := hosts[0].Remote
+	if build.Addr != "build-1.internal" || build.SSHPort != 2222 || build.UserName != "builder" ||
+		build.IdentityFile != filepath.Join(home, ".ssh", "id_build") || build.JumpHosts != "ops@bastion:22" ||
+		build.AuthType != RemoteAuthType_SSH {
+		t.Errorf("LoadSSHConfigHosts() build-1 = %+v", build)
+	}
+	bastion := hosts[2].Remote
+	if bastion.Addr != "bastion.example.com" || bastion.SSHPort != 22 || bastion.UserName != "ops" || bastion.JumpHosts != "" {
+		t.Errorf("LoadSSHConfigHosts() bastion = %+v", bastion)
+	}
+
+	// 按照别名过滤
+	hosts, err = LoadSSHConfigHosts(strings.NewReader(content), "build-*")
+	if err != nil || len(hosts) != 2 {
+		t.Errorf("LoadSSHConfigHosts() with pattern = %v, %v", len(hosts), err)
+	}
+}
@@ -156,10 +156,8 @@ func (workspace WorkspaceInfo) SaveTempFilesForRemote(sshRemote common.SSHRemote
 	if err != nil {
 		common.SmartIDELog.Fatal(err)
 	}
-	tmpComposeContent := strings.ReplaceAll(string(dCompose), "\"", "\\\"") // 防止在远程主机上双引号被剔除
-	command = fmt.Sprintf(`echo "%v" >> %v`, tmpComposeContent, tempRemoteDockerComposeFilePath)
-	output, err = sshRemote.ExeSSHCommand(command)
-	common.CheckError(err, output)
+	err = sshRemote.WriteFile(tempRemoteDockerComposeFilePath, []byte(dCompose), 0644)
+	common.CheckError(err)
 
 	// create config file
 	common.SmartIDELog.InfoF(i18nInstance.Common.Info_temp_created_config, tempRemoteConfigFilePath)
@@ -167,9 +165,8 @@ func (workspace WorkspaceInfo) SaveTempFilesForRemote(sshRemote common.SSHRemote
 	if err != nil {
 		common.SmartIDELog.Fatal(err)
 	}
-	command = fmt.Sprintf(`echo "%v" >> %v`, string(dConfig), tempRemoteConfigFilePath)
-	output, err = sshRemote.ExeSSHCommand(command)
-	common.CheckError(err, output)
+	err = sshRemote.WriteFile(tempRemoteConfigFilePath, dConfig, 0644)
+	common.CheckError(err)
 
 	return err
 }
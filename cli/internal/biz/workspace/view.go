@@ -0,0 +1,210 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package workspace
+
+import (
+	"time"
+
+	"github.com/leansoftX/smartide-cli/internal/model/response"
+)
+
+// 输出时代替密码、密钥等敏感信息
+const CONST_MaskedSecret = "******"
+
+/*
+list、get、host list 等命令使用 --output json|yaml 时的输出格式
+字段名称是固定的，不随语言变化，新增字段只能追加，不能修改或者删除已有的字段
+
+	{
+	  "id": "1",
+	  "name": "boathouse",
+	  "mode": "local | remote | k8s",
+	  "source": "local | server",
+	  "status": "",
+	  "workingDirectory": "/home/smartide/boathouse",
+	  "configFile": ".ide/.ide.yaml",
+	  "tempFile": "/home/smartide/boathouse/.ide/.temp/docker-compose-boathouse.yaml",
+	  "git": { "repoUrl": "", "branch": "", "authType": "public | basic | ssh", "userName": "", "password": "******" },
+	  "remote": { ... },
+	  "k8s": { ... },
+	  "ports": [ { "service": "", "label": "", "localPort": 6800, "originLocalPort": 6800, "containerPort": 3000 } ],
+	  "createdTime": "2023-01-01T12:00:00+08:00"
+	}
+*/
+type WorkspaceView struct {
+	ID               string      `json:"id" yaml:"id"`
+	Name             string      `json:"name" yaml:"name"`
+	Mode             string      `json:"mode" yaml:"mode"`
+	Source           string      `json:"source" yaml:"source"`
+	Status           string      `json:"status,omitempty" yaml:"status,omitempty"`
+	WorkingDirectory string      `json:"workingDirectory" yaml:"workingDirectory"`
+	ConfigFile       string      `json:"configFile" yaml:"configFile"`
+	TempFile         string      `json:"tempFile,omitempty" yaml:"tempFile,omitempty"`
+	Git              GitView     `json:"git" yaml:"git"`
+	Remote           *RemoteView `json:"remote,omitempty" yaml:"remote,omitempty"`
+	K8s              *K8sView    `json:"k8s,omitempty" yaml:"k8s,omitempty"`
+	Ports            []PortView  `json:"ports" yaml:"ports"`
+	CreatedTime      time.Time   `json:"createdTime" yaml:"createdTime"`
+}
+
+// git 库信息
+type GitView struct {
+	RepoUrl  string `json:"repoUrl" yaml:"repoUrl"`
+	Branch   string `json:"branch" yaml:"branch"`
+	AuthType string `json:"authType" yaml:"authType"`
+	UserName string `json:"userName,omitempty" yaml:"userName,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// 远程主机信息
+type RemoteView struct {
+	ID          int       `json:"id" yaml:"id"`
+	Addr        string    `json:"addr" yaml:"addr"`
+	Port        int       `json:"port" yaml:"port"`
+	UserName    string    `json:"userName" yaml:"userName"`
+	AuthType    string    `json:"authType" yaml:"authType"`
+	Password    string    `json:"password,omitempty" yaml:"password,omitempty"`
+	SSHKey      string    `json:"sshKey,omitempty" yaml:"sshKey,omitempty"`
+	CreatedTime time.Time `json:"createdTime" yaml:"createdTime"`
+
+	IdentityFile string `json:"identityFile,omitempty" yaml:"identityFile,omitempty"`
+	JumpHosts    string `json:"jumpHosts,omitempty" yaml:"jumpHosts,omitempty"`
+}
+
+// k8s 信息
+type K8sView struct {
+	ID                   int    `json:"id" yaml:"id"`
+	Context              string `json:"context" yaml:"context"`
+	Namespace            string `json:"namespace" yaml:"namespace"`
+	Deployment           string `json:"deployment,omitempty" yaml:"deployment,omitempty"`
+	PVC                  string `json:"pvc,omitempty" yaml:"pvc,omitempty"`
+	KubeConfigFile       string `json:"kubeConfigFile,omitempty" yaml:"kubeConfigFile,omitempty"`
+	IngressBaseDnsName   string `json:"ingressBaseDnsName,omitempty" yaml:"ingressBaseDnsName,omitempty"`
+	IngressName          string `json:"ingressName,omitempty" yaml:"ingressName,omitempty"`
+	IngressAuthType      string `json:"ingressAuthType,omitempty" yaml:"ingressAuthType,omitempty"`
+	IngressLoginUserName string `json:"ingressLoginUserName,omitempty" yaml:"ingressLoginUserName,omitempty"`
+	IngressLoginPassword string `json:"ingressLoginPassword,omitempty" yaml:"ingressLoginPassword,omitempty"`
+}
+
+// 端口绑定信息
+type PortView struct {
+	Service         string `json:"service" yaml:"service"`
+	Label           string `json:"label" yaml:"label"`
+	LocalPort       int    `json:"localPort" yaml:"localPort"`
+	OriginLocalPort int    `json:"originLocalPort" yaml:"originLocalPort"`
+	ContainerPort   int    `json:"containerPort" yaml:"containerPort"`
+}
+
+// 敏感信息不为空时替换为 ******
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return CONST_MaskedSecret
+}
+
+// 转换为输出格式，敏感信息会被替换
+func (w WorkspaceInfo) ToView() WorkspaceView {
+	view := WorkspaceView{
+		ID:               w.ID,
+		Name:             w.Name,
+		Mode:             string(w.Mode),
+		Source:           string(w.CacheEnv),
+		WorkingDirectory: w.WorkingDirectoryPath,
+		ConfigFile:       w.ConfigFileRelativePath,
+		TempFile:         w.TempYamlFileAbsolutePath,
+		Git: GitView{
+			RepoUrl:  w.GitCloneRepoUrl,
+			Branch:   w.GitBranch,
+			AuthType: string(w.GitRepoAuthType),
+			UserName: w.GitUserName,
+			Password: maskSecret(w.GitPassword),
+		},
+		Ports:       []PortView{},
+		CreatedTime: w.CreatedTime,
+	}
+	if w.ServerWorkSpace != nil {
+		view.Status = w.ServerWorkSpace.Status.GetDesc()
+	}
+	if w.Mode == WorkingMode_Remote && w.Remote != (RemoteInfo{}) {
+		remote := w.Remote.ToView()
+		view.Remote = &remote
+	}
+	if w.Mode == WorkingMode_K8s {
+		k8s := w.K8sInfo.ToView()
+		view.K8s = &k8s
+	}
+	for _, port := range w.Extend.Ports {
+		view.Ports = append(view.Ports, PortView{
+			Service:         port.ServiceName,
+			Label:           port.HostPortDesc,
+			LocalPort:       port.CurrentHostPort,
+			OriginLocalPort: port.OriginHostPort,
+			ContainerPort:   port.ContainerPort,
+		})
+	}
+	return view
+}
+
+// 转换为输出格式，敏感信息会被替换
+func (r RemoteInfo) ToView() RemoteView {
+	return RemoteView{
+		ID:          r.ID,
+		Addr:        r.Addr,
+		Port:        r.SSHPort,
+		UserName:    r.UserName,
+		AuthType:    string(r.AuthType),
+		Password:    maskSecret(r.Password),
+		SSHKey:      maskSecret(r.SSHKey),
+		CreatedTime: r.CreatedTime,
+
+		IdentityFile: r.IdentityFile,
+		JumpHosts:    r.JumpHosts,
+	}
+}
+
+// 转换为输出格式，敏感信息会被替换
+func (k K8sInfo) ToView() K8sView {
+	return K8sView{
+		ID:                   k.ID,
+		Context:              k.Context,
+		Namespace:            k.Namespace,
+		Deployment:           k.DeploymentName,
+		PVC:                  k.PVCName,
+		KubeConfigFile:       k.KubeConfigFilePath,
+		IngressBaseDnsName:   k.IngressBaseDnsName,
+		IngressName:          k.IngressName,
+		IngressAuthType:      getIngressAuthTypeName(k.IngressAuthType),
+		IngressLoginUserName: k.IngressLoginUserName,
+		IngressLoginPassword: maskSecret(k.IngressLoginPassword),
+	}
+}
+
+// ingress 认证方式的名称
+func getIngressAuthTypeName(authType response.KubeIngressAuthenticationTypeEnum) string {
+	switch authType {
+	case response.KubeAuthenticationTypeEnum_None:
+		return "none"
+	case response.KubeAuthenticationTypeEnum_Basic:
+		return "basic"
+	case response.KubeAuthenticationTypeEnum_Oauth:
+		return "oauth"
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package workspace
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWorkspaceInfo_ToView(t *testing.T) {
+	workspaceInfo := WorkspaceInfo{
+		ID:              "1",
+		Name:            "boathouse",
+		Mode:            WorkingMode_Remote,
+		GitCloneRepoUrl: "https://github.com/idcf-boat-house/boathouse-calculator.git",
+		GitRepoAuthType: GitRepoAuthType_Basic,
+		GitUserName:     "smartide",
+		GitPassword:     "git-p@ssw0rd",
+		Remote: RemoteInfo{
+			ID:       2,
+			Addr:     "192.168.1.2",
+			SSHPort:  22,
+			UserName: "root",
+			AuthType: RemoteAuthType_Password,
+			Password: "p@ssw0rd",
+		},
+	}
+
+	view := workspaceInfo.ToView()
+	if view.Git.Password != CONST_MaskedSecret || view.Remote == nil || view.Remote.Password != CONST_MaskedSecret {
+		t.Errorf("ToView() secrets should be masked, got %+v", view)
+	}
+	if view.Remote.SSHKey != "" || view.K8s != nil {
+		t.Errorf("ToView() empty fields should be omitted, got %+v", view)
+	}
+
+	content, err := json.Marshal(view)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	for _, secret := range []string{"git-p@ssw0rd", "p@ssw0rd"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("json.Marshal() = %v, should not contain %v", string(content), secret)
+		}
+	}
+	for _, key := range []string{`"workingDirectory"`, `"ports":[]`, `"remote":{`} {
+		if !strings.Contains(string(content), key) {
+			t.Errorf("json.Marshal() = %v, want contains %v", string(content), key)
+		}
+	}
+}
@@ -20,12 +20,11 @@ package workspace
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/leansoftX/smartide-cli/internal/biz/config"
 	"github.com/leansoftX/smartide-cli/internal/model"
-	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/docker/compose"
 )
 
 // 从生成docker-compose 和 配置文件中获取扩展信息（端口绑定）
@@ -48,21 +47,21 @@ func (workspaceInfo *WorkspaceInfo) GetWorkspaceExtend() WorkspaceExtend {
 	for serviceName, originService := range composeServices {
 		isDevService := serviceName == workspaceInfo.ConfigYaml.Workspace.DevContainer.ServiceName // 是否开发容器
 		isWebTerminal := serviceName == fmt.Sprintf("%v_smartide-webterminal", workspaceInfo.Name)
-		originServicePorts := originService.Ports // 原始端口
+		originServicePorts := append(compose.Ports{}, originService.Ports...) // 原始端口
 
 		//1.1. 开发容器时
 		if isDevService {
 			// ssh 端口
-			portSSH := fmt.Sprintf("%v:%v", model.CONST_Local_Default_BindingPort_SSH, model.CONST_Container_SSHPort)
-			if !common.Contains(originServicePorts, portSSH) { // 是否包含
+			portSSH := compose.NewPortSimple(model.CONST_Local_Default_BindingPort_SSH, model.CONST_Container_SSHPort)
+			if !originServicePorts.Contains(portSSH) { // 是否包含
 				originServicePorts = append(originServicePorts, portSSH)
 			}
 
 			// webide 端口
 			containerWebIDEPort := workspaceInfo.ConfigYaml.GetContainerWebIDEPort()
 			if containerWebIDEPort != nil { // 判断是否获取到webide的端口，在sdk-only模式下没有webide
-				portWebide := fmt.Sprintf("%v:%v", model.CONST_Local_Default_BindingPort_WebIDE, *containerWebIDEPort)
-				if !common.Contains(originServicePorts, portWebide) {
+				portWebide := compose.NewPortSimple(model.CONST_Local_Default_BindingPort_WebIDE, *containerWebIDEPort)
+				if !originServicePorts.Contains(portWebide) {
 					originServicePorts = append(originServicePorts, portWebide)
 				}
 			}
@@ -70,10 +69,15 @@ func (workspaceInfo *WorkspaceInfo) GetWorkspaceExtend() WorkspaceExtend {
 		}
 
 		//1.2. 遍历原始端口
-		for _, temp := range originServicePorts {
-			ports := strings.Split(temp, ":")
-			originLocalPort, _ := strconv.Atoi(ports[0])
-			containerPort, _ := strconv.Atoi(ports[1])
+		var originPortConfigs []compose.PortComplex
+		for _, port := range originServicePorts {
+			if portConfigs, err := port.ToComplex(); err == nil {
+				originPortConfigs = append(originPortConfigs, portConfigs...)
+			}
+		}
+		for _, portConfig := range originPortConfigs {
+			originLocalPort := portConfig.GetPublishedPort()
+			containerPort := portConfig.Target
 			label := ""
 
 			//1.2.1 从端口描述信息中查找
@@ -108,18 +112,9 @@ func (workspaceInfo *WorkspaceInfo) GetWorkspaceExtend() WorkspaceExtend {
 			if label != "" {
 				// 查找当前的绑定端口
 				currentLocalPort := -1
-				for currentServiceName, currentService := range workspaceInfo.TempDockerCompose.Services {
-					if currentServiceName == serviceName {
-						for _, str := range currentService.Ports {
-							currentPorts := strings.Split(str, ":")
-							tmpCurrentLocalPort, _ := strconv.Atoi(currentPorts[0])
-							tmpCurrentContainerPort, _ := strconv.Atoi(currentPorts[1])
-							if tmpCurrentContainerPort == containerPort {
-								currentLocalPort = tmpCurrentLocalPort
-								break
-							}
-
-						}
+				if currentService, ok := workspaceInfo.TempDockerCompose.Services[serviceName]; ok {
+					if port := currentService.GetPublishedPort(containerPort); port > 0 {
+						currentLocalPort = port
 					}
 				}
 
@@ -22,6 +22,7 @@ import (
 	"database/sql"
 	"log"
 	"os"
+	"path/filepath"
 
 	"github.com/leansoftX/smartide-cli/pkg/common"
 )
@@ -35,107 +36,53 @@ func getDb() *sql.DB {
 	return db
 }
 
-// 创建数据库表
-func createDataTables() {
-	sql_table := `
-CREATE TABLE IF NOT EXISTS "remote" (
-   "r_id" INTEGER PRIMARY KEY AUTOINCREMENT,
-   "r_addr" VARCHAR(256) NULL,
-   "r_port" int default (22) NOT NULL,
-   "r_username" VARCHAR(100) NULL,
-   "r_auth_type" VARCHAR(25) NULL,
-   "r_password" VARCHAR(100) NULL,
-   "r_json" TEXT NULL,
-   "r_is_del" BIT default (0),
-   "r_created" TIMESTAMP default (datetime('now', 'localtime')) 
-);
-CREATE TABLE IF NOT EXISTS "workspace" (
-	"w_id" INTEGER PRIMARY KEY AUTOINCREMENT,
-	"w_name" VARCHAR(256) NULL,
-	"w_workingdir" VARCHAR(256) NULL,
-	"w_config_file" VARCHAR(256) NULL,
-	"w_docker_compose_file_path" VARCHAR(256) NULL,
-	"w_mode" VARCHAR(10) NULL,
-    "w_git_clone_repo_url" VARCHAR(200) NULL,
-    "w_git_auth_type" VARCHAR(10) NULL,
-	"w_git_username" VARCHAR(100) NULL,
-	"w_git_password" VARCHAR(60) NULL,
-    "w_git_auth_pat" VARCHAR(10) NULL,
-
-    "w_branch" VARCHAR(50) NULL,
-	"w_json" TEXT NULL,
-	"w_config_content" text NULL,
-	"w_link_compose_content" text NULL,
-	"w_temp_compose_content" text NULL,
-
-	"r_id" INTEGER NULL,
-	"w_is_del" BIT default (0),
-	"w_created" TIMESTAMP default (datetime('now', 'localtime')),
-	FOREIGN KEY (r_id) REFERENCES remote(r_id)
- );
- CREATE TABLE IF NOT EXISTS "k8s" (
-	"k_id" INTEGER PRIMARY KEY AUTOINCREMENT,
-	"k_kubeconfig" VARCHAR(500) NULL,
-	"k_context" VARCHAR(50) NULL,
-	"k_namespace" VARCHAR(50) NULL,
-	"k_deployment" VARCHAR(50) NULL,
-	"k_pvc" VARCHAR(50) NULL,
-	"k_is_del" BIT default (0),
-	"k_created" TIMESTAMP default (datetime('now', 'localtime')) 
- );
-
- `
-
-	db, err := connection()
-	if err != nil {
-		db.Close()
-		common.CheckError(err)
-	}
-	defer db.Close()
-
-	_, err = db.Exec(sql_table)
-	common.CheckError(err)
-
-	// 新增的列
-	db.Exec("ALTER TABLE remote ADD r_port int default (22);")
-	db.Exec("ALTER TABLE remote ADD COLUMN r_json text;")
-
-	db.Exec("ALTER TABLE workspace ADD COLUMN w_json text;")
-	db.Exec("ALTER TABLE workspace ADD COLUMN w_config_file VARCHAR(256) NULL;")
-	db.Exec("ALTER TABLE workspace ADD COLUMN w_config_content text NULL;")
-	db.Exec("ALTER TABLE workspace ADD COLUMN w_link_compose_content text NULL;")
-	db.Exec("ALTER TABLE workspace ADD COLUMN w_temp_compose_content text NULL;")
-	db.Exec("ALTER TABLE workspace ADD COLUMN k_id INTEGER NULL;")
-	db.Exec("ALTER TABLE workspace ADD COLUMN w_git_username VARCHAR(100) NULL;")
-	db.Exec("ALTER TABLE workspace ADD COLUMN w_git_password VARCHAR(60) NULL;")
-
-	db.Exec("ALTER TABLE k8s ADD COLUMN k_kubeconfig VARCHAR(500) NULL;")
-}
-
 // sqlite 数据库文件所在路径
 var SqliteFilePath string = ".ide/.ide.db"
 
 var isInit bool = false
 
+// 第一次访问数据库时，升级到最新版本
 func dbInit() {
 	if !isInit {
 
-		createDataTables()
+		result, err := Migrate()
+		common.CheckError(err)
 		isInit = true
+		// 只输出调试信息，避免混入 -o json 等格式化的输出
+		if result.FromVersion != result.ToVersion {
+			if result.BackupFilePath != "" {
+				common.SmartIDELog.DebugF(i18nInstance.Common.Debug_dal_migrated, result.FromVersion, result.ToVersion, result.BackupFilePath)
+			} else {
+				common.SmartIDELog.DebugF(i18nInstance.Common.Debug_dal_initialized, result.ToVersion)
+			}
+		}
+
+	}
+}
 
+// 数据库文件的完整路径
+func getSqliteFilePath() (string, error) {
+	dirname, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
 	}
+	return common.PathJoin(dirname, SqliteFilePath), nil
 }
 
 func connection() (*sql.DB, error) {
 
-	dirname, err := os.UserHomeDir()
+	sqliteFilePath, err := getSqliteFilePath()
 	if err != nil {
 		log.Fatal(err)
 	}
-	sqliteFilePath := common.PathJoin(dirname, SqliteFilePath)
 
+	return openSqliteFile(sqliteFilePath)
+}
+
+// 打开数据库文件，不存在时创建
+func openSqliteFile(sqliteFilePath string) (*sql.DB, error) {
 	if !common.IsExist(sqliteFilePath) {
-		os.MkdirAll(common.PathJoin(dirname, ".ide"), os.ModePerm) // create dir
+		os.MkdirAll(filepath.Dir(sqliteFilePath), os.ModePerm) // create dir
 		os.Create(sqliteFilePath)
 	}
 
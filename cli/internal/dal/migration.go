@@ -0,0 +1,386 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package dal
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// 数据库版本的迁移
+type migration struct {
+	Version     int
+	Description string
+	Up          func(tx *sql.Tx) error
+}
+
+// 所有的迁移，按照版本号顺序排列，已经发布的迁移不能再修改，只能追加新的迁移
+var migrations = []migration{
+	{Version: 1, Description: "创建 remote、workspace、k8s 表", Up: migrateCreateTables},
+	{Version: 2, Description: "补充旧版本数据库中缺少的列", Up: migrateLegacyColumns},
+	{Version: 3, Description: "修正 workspace 表中错位的 git 认证信息", Up: migrateGitAuthColumns},
+	{Version: 4, Description: "remote 表增加私钥文件路径", Up: migrateRemoteIdentityFile},
+	{Version: 5, Description: "remote 表增加跳板机", Up: migrateRemoteJumpHosts},
+}
+
+// 迁移的状态
+type MigrationStatus struct {
+	Version     int
+	Description string
+	IsApplied   bool
+	AppliedAt   string
+}
+
+// 迁移的结果
+type MigrateResult struct {
+	FromVersion    int
+	ToVersion      int
+	BackupFilePath string // 升级前的备份文件，没有升级时为空
+}
+
+// 当前程序支持的最新数据库版本
+func GetLatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// 将数据库升级到最新版本
+func Migrate() (MigrateResult, error) {
+	sqliteFilePath, err := getSqliteFilePath()
+	if err != nil {
+		return MigrateResult{}, err
+	}
+	result, err := migrateDatabase(sqliteFilePath)
+	if err == nil {
+		isInit = true
+	}
+	return result, err
+}
+
+// 获取数据库中每个迁移的状态
+func GetMigrationStatus() ([]MigrationStatus, error) {
+	db, err := connection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	return getMigrationStatus(db)
+}
+
+// 升级指定的数据库文件
+func migrateDatabase(sqliteFilePath string) (result MigrateResult, err error) {
+	db, err := openSqliteFile(sqliteFilePath)
+	if err != nil {
+		return result, err
+	}
+	defer db.Close()
+
+	//1. 当前版本
+	if err = createSchemaVersionTable(db); err != nil {
+		return result, err
+	}
+	result.FromVersion, err = getSchemaVersion(db)
+	if err != nil {
+		return result, err
+	}
+	result.ToVersion = result.FromVersion
+	if result.FromVersion > GetLatestSchemaVersion() { // 数据库由更新版本的 cli 创建
+		return result, fmt.Errorf("数据库版本 (%v) 高于当前程序支持的版本 (%v)，请升级 smartide", result.FromVersion, GetLatestSchemaVersion())
+	}
+	pending := getPendingMigrations(result.FromVersion)
+	if len(pending) == 0 {
+		return result, nil
+	}
+
+	//2. 升级前备份，新建的数据库不需要备份
+	isEmpty, err := isEmptyDatabase(db)
+	if err != nil {
+		return result, err
+	}
+	if !isEmpty {
+		result.BackupFilePath, err = backupSqliteFile(sqliteFilePath, result.FromVersion)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	//3. 在同一个事务中执行所有的迁移，任何一个失败都会回滚到升级前的状态
+	tx, err := db.Begin()
+	if err != nil {
+		return result, err
+	}
+	for _, item := range pending {
+		if err = item.Up(tx); err != nil {
+			tx.Rollback()
+			return result, fmt.Errorf("数据库迁移 %v (%v) 失败: %v", item.Version, item.Description, err)
+		}
+		if _, err = tx.Exec(`INSERT INTO schema_version (version, description) VALUES (?, ?)`, item.Version, item.Description); err != nil {
+			tx.Rollback()
+			return result, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return result, err
+	}
+	result.ToVersion = pending[len(pending)-1].Version
+
+	return result, nil
+}
+
+// 版本记录表
+func createSchemaVersionTable(db *sql.DB) error {
+	_, err := db.Exec(`
+CREATE TABLE IF NOT EXISTS "schema_version" (
+	"version" INTEGER PRIMARY KEY,
+	"description" VARCHAR(256) NULL,
+	"applied" TIMESTAMP default (datetime('now', 'localtime'))
+);`)
+	return err
+}
+
+// 数据库当前的版本，没有执行过迁移时为 0
+func getSchemaVersion(db *sql.DB) (int, error) {
+	var version sql.NullInt64
+	err := db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// 版本号大于当前版本的迁移
+func getPendingMigrations(currentVersion int) []migration {
+	var pending []migration
+	for _, item := range migrations {
+		if item.Version > currentVersion {
+			pending = append(pending, item)
+		}
+	}
+	return pending
+}
+
+// 所有迁移的状态
+func getMigrationStatus(db *sql.DB) ([]MigrationStatus, error) {
+	applied := map[int]string{}
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'`).Scan(&count)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		rows, err := db.Query(`SELECT version, applied FROM schema_version`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var version int
+			var appliedAt sql.NullString
+			if err := rows.Scan(&version, &appliedAt); err != nil {
+				return nil, err
+			}
+			applied[version] = appliedAt.String
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []MigrationStatus
+	for _, item := range migrations {
+		appliedAt, ok := applied[item.Version]
+		result = append(result, MigrationStatus{Version: item.Version, Description: item.Description, IsApplied: ok, AppliedAt: appliedAt})
+	}
+	return result, nil
+}
+
+// 数据库中是否还没有任何业务表
+func isEmptyDatabase(db *sql.DB) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_version', 'sqlite_sequence')`).Scan(&count)
+	return count == 0, err
+}
+
+// 复制数据库文件，e.g. ~/.ide/.ide.db.v2.20230101120000.bak
+func backupSqliteFile(sqliteFilePath string, version int) (string, error) {
+	backupFilePath := fmt.Sprintf("%v.v%v.%v.bak", sqliteFilePath, version, time.Now().Format("20060102150405"))
+
+	source, err := os.Open(sqliteFilePath)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(backupFilePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(target, source); err != nil {
+		target.Close()
+		return "", err
+	}
+	return backupFilePath, target.Close()
+}
+
+// 表中是否存在指定的列
+func isColumnExist(tx *sql.Tx, table string, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf(`PRAGMA table_info("%v")`, table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		for i := range values {
+			values[i] = new(interface{})
+		}
+		if err := rows.Scan(values...); err != nil {
+			return false, err
+		}
+		for i, name := range columns {
+			if name != "name" {
+				continue
+			}
+			if value := *(values[i].(*interface{})); strings.EqualFold(fmt.Sprint(value), column) {
+				return true, nil
+			}
+		}
+	}
+	return false, rows.Err()
+}
+
+// 列不存在时添加
+func addColumnIfNotExist(tx *sql.Tx, table string, column string, definition string) error {
+	isExist, err := isColumnExist(tx, table, column)
+	if err != nil || isExist {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE "%v" ADD COLUMN "%v" %v;`, table, column, definition))
+	return err
+}
+
+// 1. 创建表
+func migrateCreateTables(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+CREATE TABLE IF NOT EXISTS "remote" (
+   "r_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+   "r_addr" VARCHAR(256) NULL,
+   "r_port" int default (22) NOT NULL,
+   "r_username" VARCHAR(100) NULL,
+   "r_auth_type" VARCHAR(25) NULL,
+   "r_password" VARCHAR(100) NULL,
+   "r_json" TEXT NULL,
+   "r_is_del" BIT default (0),
+   "r_created" TIMESTAMP default (datetime('now', 'localtime'))
+);
+CREATE TABLE IF NOT EXISTS "workspace" (
+	"w_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"w_name" VARCHAR(256) NULL,
+	"w_workingdir" VARCHAR(256) NULL,
+	"w_config_file" VARCHAR(256) NULL,
+	"w_docker_compose_file_path" VARCHAR(256) NULL,
+	"w_mode" VARCHAR(10) NULL,
+	"w_git_clone_repo_url" VARCHAR(200) NULL,
+	"w_git_auth_type" VARCHAR(10) NULL,
+	"w_git_username" VARCHAR(100) NULL,
+	"w_git_password" VARCHAR(60) NULL,
+	"w_git_auth_pat" VARCHAR(10) NULL,
+
+	"w_branch" VARCHAR(50) NULL,
+	"w_json" TEXT NULL,
+	"w_config_content" text NULL,
+	"w_link_compose_content" text NULL,
+	"w_temp_compose_content" text NULL,
+
+	"r_id" INTEGER NULL,
+	"k_id" INTEGER NULL,
+	"w_is_del" BIT default (0),
+	"w_created" TIMESTAMP default (datetime('now', 'localtime')),
+	FOREIGN KEY (r_id) REFERENCES remote(r_id)
+);
+CREATE TABLE IF NOT EXISTS "k8s" (
+	"k_id" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"k_kubeconfig" VARCHAR(500) NULL,
+	"k_context" VARCHAR(50) NULL,
+	"k_namespace" VARCHAR(50) NULL,
+	"k_deployment" VARCHAR(50) NULL,
+	"k_pvc" VARCHAR(50) NULL,
+	"k_is_del" BIT default (0),
+	"k_created" TIMESTAMP default (datetime('now', 'localtime'))
+);`)
+	return err
+}
+
+// 2. 旧版本的 cli 创建的表可能缺少部分列
+func migrateLegacyColumns(tx *sql.Tx) error {
+	columns := []struct {
+		Table      string
+		Column     string
+		Definition string
+	}{
+		{"remote", "r_port", "int default (22)"},
+		{"remote", "r_json", "text"},
+
+		{"workspace", "w_json", "text"},
+		{"workspace", "w_config_file", "VARCHAR(256) NULL"},
+		{"workspace", "w_config_content", "text NULL"},
+		{"workspace", "w_link_compose_content", "text NULL"},
+		{"workspace", "w_temp_compose_content", "text NULL"},
+		{"workspace", "k_id", "INTEGER NULL"},
+		{"workspace", "w_git_username", "VARCHAR(100) NULL"},
+		{"workspace", "w_git_password", "VARCHAR(60) NULL"},
+
+		{"k8s", "k_kubeconfig", "VARCHAR(500) NULL"},
+	}
+	for _, item := range columns {
+		if err := addColumnIfNotExist(tx, item.Table, item.Column, item.Definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 3. 旧版本保存工作区时，git 的认证方式、用户名、密码依次错位保存到了 w_git_username、w_git_password、w_git_auth_type 中
+func migrateGitAuthColumns(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+UPDATE workspace
+SET w_git_auth_type = w_git_password, w_git_username = w_git_auth_type, w_git_password = w_git_username
+WHERE w_git_password IN ('ssh', 'basic', 'public')
+	AND (w_git_auth_type IS NULL OR w_git_auth_type NOT IN ('ssh', 'basic', 'public'));`)
+	return err
+}
+
+// 4. 远程主机可以指定本机上的私钥文件
+func migrateRemoteIdentityFile(tx *sql.Tx) error {
+	return addColumnIfNotExist(tx, "remote", "r_identity_file", "VARCHAR(256) NULL")
+}
+
+// 5. 远程主机可以通过跳板机连接
+func migrateRemoteJumpHosts(tx *sql.Tx) error {
+	return addColumnIfNotExist(tx, "remote", "r_jump_hosts", "VARCHAR(500) NULL")
+}
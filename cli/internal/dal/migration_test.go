@@ -0,0 +1,129 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package dal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+func TestMigrateDatabase_New(t *testing.T) {
+	sqliteFilePath := filepath.Join(t.TempDir(), ".ide.db")
+
+	result, err := migrateDatabase(sqliteFilePath)
+	if err != nil {
+		t.Fatalf("migrateDatabase() error = %v", err)
+	}
+	if result.FromVersion != 0 || result.ToVersion != GetLatestSchemaVersion() {
+		t.Errorf("migrateDatabase() version = %v -> %v, want 0 -> %v", result.FromVersion, result.ToVersion, GetLatestSchemaVersion())
+	}
+	if result.BackupFilePath != "" {
+		t.Errorf("migrateDatabase() should not backup new database, got %v", result.BackupFilePath)
+	}
+
+	// 再次执行不会有任何变化
+	result, err = migrateDatabase(sqliteFilePath)
+	if err != nil {
+		t.Fatalf("migrateDatabase() error = %v", err)
+	}
+	if result.FromVersion != result.ToVersion || result.BackupFilePath != "" {
+		t.Errorf("migrateDatabase() = %+v, want no change", result)
+	}
+}
+
+func TestMigrateDatabase_Legacy(t *testing.T) {
+	sqliteFilePath := filepath.Join(t.TempDir(), ".ide.db")
+
+	// 旧版本 cli 创建的数据库，缺少部分列，也没有版本记录
+	db, err := openSqliteFile(sqliteFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+CREATE TABLE "remote" ("r_id" INTEGER PRIMARY KEY AUTOINCREMENT, "r_addr" VARCHAR(256) NULL, "r_username" VARCHAR(100) NULL);
+CREATE TABLE "workspace" ("w_id" INTEGER PRIMARY KEY AUTOINCREMENT, "w_name" VARCHAR(256) NULL, "w_json" TEXT NULL, "r_id" INTEGER NULL,
+	"w_git_auth_type" VARCHAR(10) NULL, "w_git_username" VARCHAR(100) NULL, "w_git_password" VARCHAR(60) NULL);
+INSERT INTO remote (r_addr, r_username) VALUES ('192.168.1.2', 'smartide');
+INSERT INTO workspace (w_name, w_git_auth_type, w_git_username, w_git_password) VALUES ('demo', 'smartide', 'p@ssw0rd', 'basic');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := migrateDatabase(sqliteFilePath)
+	if err != nil {
+		t.Fatalf("migrateDatabase() error = %v", err)
+	}
+	if result.FromVersion != 0 || result.ToVersion != GetLatestSchemaVersion() {
+		t.Errorf("migrateDatabase() version = %v -> %v", result.FromVersion, result.ToVersion)
+	}
+	if result.BackupFilePath == "" || !common.IsExist(result.BackupFilePath) {
+		t.Errorf("migrateDatabase() backup file = %v not exist", result.BackupFilePath)
+	}
+
+	// 原有的数据保留，缺少的列已经补充
+	db, err = openSqliteFile(sqliteFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var addr string
+	var port int
+	if err := db.QueryRow(`SELECT r_addr, r_port FROM remote`).Scan(&addr, &port); err != nil {
+		t.Fatalf("query remote error = %v", err)
+	}
+	if addr != "192.168.1.2" || port != 22 {
+		t.Errorf("remote = %v:%v, want 192.168.1.2:22", addr, port)
+	}
+	if _, err := db.Exec(`SELECT w_config_content, k_id FROM workspace`); err != nil {
+		t.Errorf("workspace columns error = %v", err)
+	}
+	if _, err := db.Exec(`SELECT r_identity_file, r_jump_hosts FROM remote`); err != nil {
+		t.Errorf("remote columns error = %v", err)
+	}
+
+	// 错位的 git 认证信息已经修正
+	var authType, userName, password string
+	if err := db.QueryRow(`SELECT w_git_auth_type, w_git_username, w_git_password FROM workspace`).Scan(&authType, &userName, &password); err != nil {
+		t.Fatalf("query workspace error = %v", err)
+	}
+	if authType != "basic" || userName != "smartide" || password != "p@ssw0rd" {
+		t.Errorf("workspace git = %v, %v, %v, want basic, smartide, p@ssw0rd", authType, userName, password)
+	}
+
+	status, err := getMigrationStatus(db)
+	if err != nil {
+		t.Fatalf("getMigrationStatus() error = %v", err)
+	}
+	for _, item := range status {
+		if !item.IsApplied {
+			t.Errorf("getMigrationStatus() migration %v not applied", item.Version)
+		}
+	}
+}
+
+func TestMigrations_Order(t *testing.T) {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			t.Errorf("migration %v should be after %v", migrations[i].Version, migrations[i-1].Version)
+		}
+	}
+}
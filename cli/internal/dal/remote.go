@@ -24,8 +24,6 @@ import (
 	"fmt"
 	"time"
 
-	aes4go "github.com/leansoftX/smartide-cli/pkg/aes"
-
 	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
 	"github.com/leansoftX/smartide-cli/internal/model"
 	"github.com/leansoftX/smartide-cli/pkg/common"
@@ -33,12 +31,14 @@ import (
 
 // remote orm
 type remoteDO struct {
-	r_id        int
-	r_addr      string
-	r_port      sql.NullInt32
-	r_username  string
-	r_auth_type string
-	r_password  sql.NullString
+	r_id            int
+	r_addr          string
+	r_port          sql.NullInt32
+	r_username      string
+	r_auth_type     string
+	r_password      sql.NullString
+	r_identity_file sql.NullString
+	r_jump_hosts    sql.NullString
 	//r_is_del    bool
 	r_created time.Time
 }
@@ -85,11 +85,16 @@ func GetRemoteList() (remoteList []workspace.RemoteInfo, err error) {
 }
 
 func GetRemoteById(remoteId int) (remoteInfo *workspace.RemoteInfo, err error) {
-	return getRemote(remoteId, "", "")
+	return getRemote(remoteId, "", 0, "")
 }
 
 func GetRemoteByHost(host string, userName string) (remoteInfo *workspace.RemoteInfo, err error) {
-	return getRemote(0, host, userName)
+	return getRemote(0, host, 0, userName)
+}
+
+// 根据地址、端口、用户名获取主机，同一个地址上不同端口的 ssh 服务是不同的主机
+func GetRemoteByHostPort(host string, port int, userName string) (remoteInfo *workspace.RemoteInfo, err error) {
+	return getRemote(0, host, port, userName)
 }
 
 func RemoveRemote(remoteId int, host string, userName string) error {
@@ -126,6 +131,20 @@ func RemoveRemote(remoteId int, host string, userName string) error {
 		return errors.New(i18nInstance.Common.Err_dal_remote_reference_by_workspace)
 	}
 
+	// 删除密码等敏感信息
+	var password sql.NullString
+	if len(host) > 0 {
+		row = db.QueryRow("select r_password from remote where r_addr=? and r_username = ? and r_is_del = 0", host, userName)
+	} else {
+		row = db.QueryRow("select r_password from remote where r_id=? and r_is_del = 0", remoteId)
+	}
+	if err := row.Scan(&password); err != nil {
+		return err
+	}
+	if err := deleteSecret(password.String); err != nil {
+		return err
+	}
+
 	//
 	var stmt *sql.Stmt
 	var err error
@@ -168,7 +187,7 @@ func InsertOrUpdateRemote(remoteInfo workspace.RemoteInfo) (id int, err error) {
 			return id, err
 		}
 	} else {
-		single, err = GetRemoteByHost(remoteInfo.Addr, remoteInfo.UserName)
+		single, err = GetRemoteByHostPort(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName)
 		if err != nil {
 			return id, err
 		}
@@ -178,21 +197,21 @@ func InsertOrUpdateRemote(remoteInfo workspace.RemoteInfo) (id int, err error) {
 	db := getDb()
 	defer db.Close()
 
-	passwordEncrypt := ""
-	if len(remoteInfo.Password) > 0 {
-		passwordEncrypt = aes4go.Encrypt(remoteInfo.Password, aesDecryptKey)
+	passwordEncrypt, err := saveSecret(getRemotePasswordSecretKey(remoteInfo.UserName, remoteInfo.Addr, remoteInfo.SSHPort), remoteInfo.Password, encodeRemotePassword)
+	if err != nil {
+		return id, err
 	}
 
 	//2. insert or update
 	if single != nil { //2.1. update
 		stmt, err := db.Prepare(`update remote
-		set r_addr=?, r_port=?, r_username=?, r_auth_type=?, r_password=?  
-		where r_id=? or r_addr=?`)
+		set r_addr=?, r_port=?, r_username=?, r_auth_type=?, r_password=?, r_identity_file=?, r_jump_hosts=?  
+		where r_id=?`)
 		if err != nil {
 			return id, err
 		}
-		_, err = stmt.Exec(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName, remoteInfo.AuthType, passwordEncrypt,
-			remoteInfo.ID, remoteInfo.Addr)
+		_, err = stmt.Exec(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName, remoteInfo.AuthType, passwordEncrypt, remoteInfo.IdentityFile, remoteInfo.JumpHosts,
+			single.ID)
 		if err != nil {
 			return -1, err
 		}
@@ -200,12 +219,12 @@ func InsertOrUpdateRemote(remoteInfo workspace.RemoteInfo) (id int, err error) {
 		id = single.ID
 
 	} else { //2.2. insert
-		stmt, err := db.Prepare(`INSERT INTO remote(r_addr, r_port, r_username, r_auth_type, r_password)  
-                                        values(?, ?, ?, ?, ?)`)
+		stmt, err := db.Prepare(`INSERT INTO remote(r_addr, r_port, r_username, r_auth_type, r_password, r_identity_file, r_jump_hosts)  
+                                        values(?, ?, ?, ?, ?, ?, ?)`)
 		if err != nil {
 			return id, err
 		}
-		res, err := stmt.Exec(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName, remoteInfo.AuthType, passwordEncrypt)
+		res, err := stmt.Exec(remoteInfo.Addr, remoteInfo.SSHPort, remoteInfo.UserName, remoteInfo.AuthType, passwordEncrypt, remoteInfo.IdentityFile, remoteInfo.JumpHosts)
 		if err != nil {
 			return id, err
 		}
@@ -217,7 +236,8 @@ func InsertOrUpdateRemote(remoteInfo workspace.RemoteInfo) (id int, err error) {
 	return id, err
 }
 
-func getRemote(remoteId int, host string, userName string) (remoteInfo *workspace.RemoteInfo, err error) {
+// 获取主机，port 小于等于 0 时不限制端口
+func getRemote(remoteId int, host string, port int, userName string) (remoteInfo *workspace.RemoteInfo, err error) {
 
 	db := getDb()
 	defer db.Close()
@@ -225,18 +245,22 @@ func getRemote(remoteId int, host string, userName string) (remoteInfo *workspac
 	do := remoteDO{}
 
 	var row *sql.Row
-	if len(host) > 0 {
-		row = db.QueryRow(`select r_id, r_addr, r_port, r_username, r_auth_type, r_password, r_created 
+	if len(host) > 0 && port > 0 {
+		row = db.QueryRow(`select r_id, r_addr, r_port, r_username, r_auth_type, r_password, r_identity_file, r_jump_hosts, r_created 
+		from remote 
+		where r_addr=? and r_port=? and r_username = ? and r_is_del = 0`, host, port, userName)
+	} else if len(host) > 0 {
+		row = db.QueryRow(`select r_id, r_addr, r_port, r_username, r_auth_type, r_password, r_identity_file, r_jump_hosts, r_created 
 		from remote 
 		where r_addr=? and r_username = ? and r_is_del = 0`, host, userName)
 	} else if remoteId > 0 {
-		row = db.QueryRow(`select r_id, r_addr, r_port, r_username, r_auth_type, r_password, r_created 
+		row = db.QueryRow(`select r_id, r_addr, r_port, r_username, r_auth_type, r_password, r_identity_file, r_jump_hosts, r_created 
 		from remote where r_id=? and r_is_del = 0`, remoteId)
 	} else {
 		return
 	}
 
-	switch err := row.Scan(&do.r_id, &do.r_addr, &do.r_port, &do.r_username, &do.r_auth_type, &do.r_password, &do.r_created); err {
+	switch err := row.Scan(&do.r_id, &do.r_addr, &do.r_port, &do.r_username, &do.r_auth_type, &do.r_password, &do.r_identity_file, &do.r_jump_hosts, &do.r_created); err {
 	case sql.ErrNoRows:
 		msg := fmt.Sprintf("host (%v | %v)", host, remoteId)
 		common.SmartIDELog.WarningF(i18nInstance.Common.Warn_dal_record_not_exit_condition, msg) // 不存在
@@ -245,13 +269,14 @@ func getRemote(remoteId int, host string, userName string) (remoteInfo *workspac
 		remoteInfo.ID = do.r_id
 		remoteInfo.Addr = do.r_addr
 		remoteInfo.UserName = do.r_username
-		if do.r_auth_type == string(workspace.RemoteAuthType_SSH) {
-			remoteInfo.AuthType = workspace.RemoteAuthType_SSH
-		} else if do.r_auth_type == string(workspace.RemoteAuthType_Password) {
-			remoteInfo.AuthType = workspace.RemoteAuthType_Password
-		} else {
+		switch workspace.RemoteAuthType(do.r_auth_type) {
+		case workspace.RemoteAuthType_SSH, workspace.RemoteAuthType_Password, workspace.RemoteAuthType_Agent:
+			remoteInfo.AuthType = workspace.RemoteAuthType(do.r_auth_type)
+		default:
 			panic(do.r_auth_type + i18nInstance.Common.Err_enum_error) // 不能被识别
 		}
+		remoteInfo.IdentityFile = do.r_identity_file.String
+		remoteInfo.JumpHosts = do.r_jump_hosts.String
 
 		if int(do.r_port.Int32) > 0 {
 			remoteInfo.SSHPort = int(do.r_port.Int32)
@@ -260,8 +285,18 @@ func getRemote(remoteId int, host string, userName string) (remoteInfo *workspac
 		}
 
 		if do.r_password.Valid && len(do.r_password.String) > 0 {
-			passwordDecrypt := aes4go.Decrypt(do.r_password.String, aesDecryptKey)
-			remoteInfo.Password = passwordDecrypt
+			secretKey := getRemotePasswordSecretKey(do.r_username, do.r_addr, remoteInfo.SSHPort)
+			password, newValue, err := loadSecret(secretKey, do.r_password.String, encodeRemotePassword, decodeRemotePassword)
+			if err != nil {
+				return nil, err
+			}
+			remoteInfo.Password = password
+			if newValue != do.r_password.String { // 已经迁移到新的存储
+				_, err = db.Exec("update remote set r_password=? where r_id=?", newValue, do.r_id)
+				if err != nil {
+					return nil, err
+				}
+			}
 		}
 
 		remoteInfo.CreatedTime = do.r_created
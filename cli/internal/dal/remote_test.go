@@ -0,0 +1,69 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package dal
+
+import (
+	"testing"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+// 同一个地址上不同端口的 ssh 服务是不同的主机
+func TestInsertOrUpdateRemote_Port(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+	originIsInit := isInit
+	defer func() { isInit = originIsInit }()
+	t.Setenv("HOME", t.TempDir()) // 使用临时的数据库
+	isInit = false
+
+	newRemote := func(port int, identityFile string) workspace.RemoteInfo {
+		return workspace.RemoteInfo{Addr: "192.168.1.2", SSHPort: port, UserName: "root",
+			AuthType: workspace.RemoteAuthType_SSH, IdentityFile: identityFile}
+	}
+	firstId, err := InsertOrUpdateRemote(newRemote(22, "/root/.ssh/id_first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondId, err := InsertOrUpdateRemote(newRemote(2222, "/root/.ssh/id_second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if firstId == secondId {
+		t.Fatalf("InsertOrUpdateRemote() different ports should be different hosts, id = %v", firstId)
+	}
+
+	// 更新时只修改相同端口的主机
+	updatedId, err := InsertOrUpdateRemote(newRemote(22, "/root/.ssh/id_updated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updatedId != firstId {
+		t.Errorf("InsertOrUpdateRemote() id = %v, want %v", updatedId, firstId)
+	}
+	for id, want := range map[int]string{firstId: "/root/.ssh/id_updated", secondId: "/root/.ssh/id_second"} {
+		remoteInfo, err := GetRemoteById(id)
+		if err != nil || remoteInfo == nil {
+			t.Fatalf("GetRemoteById(%v) = %v, %v", id, remoteInfo, err)
+		}
+		if remoteInfo.IdentityFile != want {
+			t.Errorf("GetRemoteById(%v).IdentityFile = %v, want %v", id, remoteInfo.IdentityFile, want)
+		}
+	}
+}
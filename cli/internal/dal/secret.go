@@ -0,0 +1,164 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package dal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/config"
+	aes4go "github.com/leansoftX/smartide-cli/pkg/aes"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/secret"
+)
+
+// 已经创建的存储，加密文件的口令只需要输入一次
+var secretStores = map[secret.SecretBackendEnum]secret.SecretStore{}
+
+// 获取当前配置的存储方式
+var getSecretBackend = func() (secret.SecretBackendEnum, error) {
+	return secret.ParseSecretBackend(config.GlobalSmartIdeConfig.SecretBackend)
+}
+
+// 获取存储
+func getSecretStore(backend secret.SecretBackendEnum) (secret.SecretStore, error) {
+	if store, ok := secretStores[backend]; ok {
+		return store, nil
+	}
+	store, err := secret.NewSecretStore(backend)
+	if err != nil {
+		return nil, err
+	}
+	secretStores[backend] = store
+	return store, nil
+}
+
+// 敏感信息在存储中的 key
+// 同一个地址上不同端口的主机是不同的主机，key 中需要包含端口
+func getRemotePasswordSecretKey(userName string, addr string, port int) string {
+	if port <= 0 {
+		port = 22
+	}
+	return fmt.Sprintf("remote/%v@%v:%v/password", userName, addr, port)
+}
+func getWorkspaceGitPasswordSecretKey(workspaceId int64) string {
+	return fmt.Sprintf("workspace/%v/git-password", workspaceId)
+}
+
+// 旧版本中远程主机的密码使用内置的密钥加密
+func encodeRemotePassword(value string) string {
+	return aes4go.Encrypt(value, aesDecryptKey)
+}
+func decodeRemotePassword(value string) string {
+	return aes4go.Decrypt(value, aesDecryptKey)
+}
+
+// 旧版本中 git 密码直接保存在数据库中
+func encodeGitPassword(value string) string {
+	return value
+}
+func decodeGitPassword(value string) string {
+	return value
+}
+
+// 保存敏感信息，返回需要保存到数据库中的值
+// 使用数据库存储时返回加密后的值，否则返回引用，e.g. secret://file/remote/root@192.168.1.2:22/password
+// 环境变量是只读的，只有环境变量中已经是相同的值时才返回引用，否则返回错误
+func saveSecret(key string, value string, encode func(string) string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	backend, err := getSecretBackend()
+	if err != nil {
+		return "", err
+	}
+	if backend == secret.SecretBackendEnum_Database {
+		return encode(value), nil
+	}
+
+	store, err := getSecretStore(backend)
+	if err != nil {
+		return "", err
+	}
+	err = store.Set(key, value)
+	if errors.Is(err, secret.ErrSecretStoreReadOnly) { // 环境变量中的值需要用户自己设置
+		return "", fmt.Errorf("secret-backend 为 %v，请通过环境变量 %v 设置: %w", backend, secret.GetSecretEnvName(key), err)
+	} else if err != nil {
+		return "", err
+	}
+	return secret.GetReference(backend, key), nil
+}
+
+// 读取敏感信息，value 为数据库中保存的值
+// 保存的方式和当前配置的存储方式不一致时，迁移到当前的存储，此时返回的 newValue 与 value 不同，需要更新到数据库中
+func loadSecret(key string, value string, encode func(string) string, decode func(string) string) (result string, newValue string, err error) {
+	if value == "" {
+		return "", value, nil
+	}
+
+	//1. 读取
+	backend, refKey, isReference := secret.ParseReference(value)
+	if isReference {
+		store, err := getSecretStore(backend)
+		if err != nil {
+			return "", value, err
+		}
+		result, err = store.Get(refKey)
+		if errors.Is(err, secret.ErrSecretNotFound) && backend == secret.SecretBackendEnum_Env {
+			return "", value, fmt.Errorf("没有找到环境变量 %v", secret.GetSecretEnvName(refKey))
+		} else if err != nil {
+			return "", value, err
+		}
+	} else {
+		backend = secret.SecretBackendEnum_Database
+		result = decode(value)
+	}
+
+	//2. 迁移到当前的存储，失败时不影响使用
+	currentBackend, err := getSecretBackend()
+	if err != nil || currentBackend == backend {
+		return result, value, nil
+	}
+	newValue, err = saveSecret(key, result, encode)
+	if err != nil {
+		common.SmartIDELog.WarningF("无法将 %v 迁移到 %v: %v", key, currentBackend, err)
+		return result, value, nil
+	}
+	if isReference { // 从原来的存储中删除
+		if store, err := getSecretStore(backend); err == nil {
+			store.Delete(refKey)
+		}
+	}
+	common.SmartIDELog.DebugF("%v 已经从 %v 迁移到 %v", key, backend, currentBackend)
+
+	return result, newValue, nil
+}
+
+// 删除数据库中的值引用的敏感信息，保存在数据库中的值随记录一起删除
+func deleteSecret(value string) error {
+	backend, refKey, isReference := secret.ParseReference(value)
+	if !isReference {
+		return nil
+	}
+	store, err := getSecretStore(backend)
+	if err != nil {
+		return err
+	}
+	return store.Delete(refKey)
+}
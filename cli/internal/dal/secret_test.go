@@ -0,0 +1,182 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package dal
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/leansoftX/smartide-cli/internal/biz/workspace"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"github.com/leansoftX/smartide-cli/pkg/secret"
+)
+
+func TestLoadSecret_Migrate(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+	originGetSecretBackend, originSecretStores := getSecretBackend, secretStores
+	defer func() { getSecretBackend, secretStores = originGetSecretBackend, originSecretStores }()
+
+	fileStore := secret.NewFileSecretStore(filepath.Join(t.TempDir(), ".secrets"), func(bool) (string, error) { return "passphrase", nil })
+	secretStores = map[secret.SecretBackendEnum]secret.SecretStore{secret.SecretBackendEnum_File: fileStore}
+	backend := secret.SecretBackendEnum_Database
+	getSecretBackend = func() (secret.SecretBackendEnum, error) { return backend, nil }
+
+	// 旧版本，使用内置密钥加密
+	key := getRemotePasswordSecretKey("root", "192.168.1.2", 22)
+	legacy, err := saveSecret(key, "p@ssw0rd", encodeRemotePassword)
+	if err != nil || legacy == "p@ssw0rd" {
+		t.Fatalf("saveSecret() = %v, %v", legacy, err)
+	}
+	got, newValue, err := loadSecret(key, legacy, encodeRemotePassword, decodeRemotePassword)
+	if err != nil || got != "p@ssw0rd" || newValue != legacy {
+		t.Errorf("loadSecret() = %v, %v, %v", got, newValue, err)
+	}
+
+	// 切换到加密文件后，第一次读取时迁移
+	backend = secret.SecretBackendEnum_File
+	got, newValue, err = loadSecret(key, legacy, encodeRemotePassword, decodeRemotePassword)
+	if err != nil || got != "p@ssw0rd" {
+		t.Fatalf("loadSecret() = %v, %v", got, err)
+	}
+	if newValue != secret.GetReference(secret.SecretBackendEnum_File, key) {
+		t.Errorf("loadSecret() newValue = %v", newValue)
+	}
+	if value, err := fileStore.Get(key); err != nil || value != "p@ssw0rd" {
+		t.Errorf("fileStore.Get() = %v, %v", value, err)
+	}
+
+	// 已经迁移后不再变化
+	got, again, err := loadSecret(key, newValue, encodeRemotePassword, decodeRemotePassword)
+	if err != nil || got != "p@ssw0rd" || again != newValue {
+		t.Errorf("loadSecret() = %v, %v, %v", got, again, err)
+	}
+
+	// 切换回数据库
+	backend = secret.SecretBackendEnum_Database
+	got, newValue, err = loadSecret(key, newValue, encodeRemotePassword, decodeRemotePassword)
+	if err != nil || got != "p@ssw0rd" || decodeRemotePassword(newValue) != "p@ssw0rd" {
+		t.Errorf("loadSecret() = %v, %v, %v", got, newValue, err)
+	}
+	if _, err := fileStore.Get(key); err != secret.ErrSecretNotFound {
+		t.Errorf("fileStore.Get() after migrate error = %v", err)
+	}
+}
+
+func TestLoadSecret_MigrateToEnv(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+	originGetSecretBackend, originSecretStores := getSecretBackend, secretStores
+	defer func() { getSecretBackend, secretStores = originGetSecretBackend, originSecretStores }()
+
+	fileStore := secret.NewFileSecretStore(filepath.Join(t.TempDir(), ".secrets"), func(bool) (string, error) { return "passphrase", nil })
+	secretStores = map[secret.SecretBackendEnum]secret.SecretStore{secret.SecretBackendEnum_File: fileStore}
+	backend := secret.SecretBackendEnum_File
+	getSecretBackend = func() (secret.SecretBackendEnum, error) { return backend, nil }
+
+	key := getRemotePasswordSecretKey("root", "192.168.1.2", 2222)
+	value, err := saveSecret(key, "p@ssw0rd", encodeRemotePassword)
+	if err != nil {
+		t.Fatalf("saveSecret() error = %v", err)
+	}
+
+	// 环境变量中没有设置时，不能保存，也不迁移
+	backend = secret.SecretBackendEnum_Env
+	if got, err := saveSecret(key, "p@ssw0rd", encodeRemotePassword); !errors.Is(err, secret.ErrSecretStoreReadOnly) {
+		t.Errorf("saveSecret() = %v, %v", got, err)
+	}
+	got, newValue, err := loadSecret(key, value, encodeRemotePassword, decodeRemotePassword)
+	if err != nil || got != "p@ssw0rd" || newValue != value {
+		t.Errorf("loadSecret() = %v, %v, %v", got, newValue, err)
+	}
+	if stored, err := fileStore.Get(key); err != nil || stored != "p@ssw0rd" {
+		t.Errorf("fileStore.Get() = %v, %v", stored, err)
+	}
+
+	// 环境变量中已经是相同的值时迁移
+	t.Setenv(secret.GetSecretEnvName(key), "p@ssw0rd")
+	got, newValue, err = loadSecret(key, value, encodeRemotePassword, decodeRemotePassword)
+	if err != nil || got != "p@ssw0rd" || newValue != secret.GetReference(secret.SecretBackendEnum_Env, key) {
+		t.Errorf("loadSecret() = %v, %v, %v", got, newValue, err)
+	}
+}
+
+func TestGetRemotePasswordSecretKey(t *testing.T) {
+	if a, b := getRemotePasswordSecretKey("root", "192.168.1.2", 22), getRemotePasswordSecretKey("root", "192.168.1.2", 2222); a == b {
+		t.Errorf("getRemotePasswordSecretKey() with different port = %v", a)
+	}
+	if a, b := getRemotePasswordSecretKey("root", "192.168.1.2", 0), getRemotePasswordSecretKey("root", "192.168.1.2", 22); a != b {
+		t.Errorf("getRemotePasswordSecretKey() default port = %v, want %v", a, b)
+	}
+}
+
+// 删除主机以及工作区时，同时删除存储中的敏感信息
+func TestRemoveSecret(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+	originGetSecretBackend, originSecretStores, originIsInit := getSecretBackend, secretStores, isInit
+	defer func() {
+		getSecretBackend, secretStores, isInit = originGetSecretBackend, originSecretStores, originIsInit
+	}()
+	t.Setenv("HOME", t.TempDir()) // 使用临时的数据库
+	isInit = false
+
+	fileStore := secret.NewFileSecretStore(filepath.Join(t.TempDir(), ".secrets"), func(bool) (string, error) { return "passphrase", nil })
+	secretStores = map[secret.SecretBackendEnum]secret.SecretStore{secret.SecretBackendEnum_File: fileStore}
+	getSecretBackend = func() (secret.SecretBackendEnum, error) { return secret.SecretBackendEnum_File, nil }
+
+	//1. 主机
+	remoteId, err := InsertOrUpdateRemote(workspace.RemoteInfo{Addr: "192.168.1.2", SSHPort: 2222, UserName: "root",
+		AuthType: workspace.RemoteAuthType_Password, Password: "p@ssw0rd"})
+	if err != nil {
+		t.Fatalf("InsertOrUpdateRemote() error = %v", err)
+	}
+	remoteKey := getRemotePasswordSecretKey("root", "192.168.1.2", 2222)
+	if value, err := fileStore.Get(remoteKey); err != nil || value != "p@ssw0rd" {
+		t.Fatalf("fileStore.Get() = %v, %v", value, err)
+	}
+	if err := RemoveRemote(remoteId, "", ""); err != nil {
+		t.Fatalf("RemoveRemote() error = %v", err)
+	}
+	if _, err := fileStore.Get(remoteKey); !errors.Is(err, secret.ErrSecretNotFound) {
+		t.Errorf("fileStore.Get() after RemoveRemote error = %v, want ErrSecretNotFound", err)
+	}
+
+	//2. 工作区
+	db := getDb()
+	result, err := db.Exec("insert into workspace(w_name, w_is_del) values(?, 0)", "boathouse")
+	if err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	workspaceId, _ := result.LastInsertId()
+	gitKey := getWorkspaceGitPasswordSecretKey(workspaceId)
+	gitPassword, err := saveSecret(gitKey, "git-p@ssw0rd", encodeGitPassword)
+	if err == nil {
+		_, err = db.Exec("update workspace set w_git_password=? where w_id=?", gitPassword, workspaceId)
+	}
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveWorkspace(int(workspaceId)); err != nil {
+		t.Fatalf("RemoveWorkspace() error = %v", err)
+	}
+	if _, err := fileStore.Get(gitKey); !errors.Is(err, secret.ErrSecretNotFound) {
+		t.Errorf("fileStore.Get() after RemoveWorkspace error = %v, want ErrSecretNotFound", err)
+	}
+}
@@ -155,12 +155,27 @@ func InsertOrUpdateWorkspace(workspaceInfo workspace.WorkspaceInfo) (affectId in
 		}
 
 		res, err := stmt.Exec(workspaceInfo.Name, workspaceInfo.WorkingDirectoryPath, workspaceInfo.TempYamlFileAbsolutePath, workspaceInfo.ConfigFileRelativePath, remoteId, k8sId,
-			workspaceInfo.Mode, workspaceInfo.GitCloneRepoUrl, workspaceInfo.GitUserName, workspaceInfo.GitPassword, workspaceInfo.GitRepoAuthType, workspaceInfo.GitBranch,
+			workspaceInfo.Mode, workspaceInfo.GitCloneRepoUrl, workspaceInfo.GitRepoAuthType, workspaceInfo.GitUserName, "", workspaceInfo.GitBranch,
 			string(jsonBytes), configStr, linkComposeStr, tempComposeStr)
 		if err != nil {
 			return -1, err
 		}
-		return res.LastInsertId()
+		affectId, err = res.LastInsertId()
+		if err != nil {
+			return -1, err
+		}
+
+		// git 密码的 key 包含工作区 id，插入后再保存
+		if workspaceInfo.GitPassword != "" {
+			gitPassword, err := saveSecret(getWorkspaceGitPasswordSecretKey(affectId), workspaceInfo.GitPassword, encodeGitPassword)
+			if err != nil {
+				return -1, err
+			}
+			if _, err = db.Exec("update workspace set w_git_password=? where w_id=?", gitPassword, affectId); err != nil {
+				return -1, err
+			}
+		}
+		return affectId, nil
 	} else { //5.2.2. update
 		// exec
 		stmt, err := db.Prepare(`update workspace 
@@ -171,8 +186,12 @@ func InsertOrUpdateWorkspace(workspaceInfo workspace.WorkspaceInfo) (affectId in
 		if err != nil {
 			return -1, err
 		}
+		gitPassword, err := saveSecret(getWorkspaceGitPasswordSecretKey(affectId), workspaceInfo.GitPassword, encodeGitPassword)
+		if err != nil {
+			return -1, err
+		}
 		_, err = stmt.Exec(workspaceInfo.Name, workspaceInfo.WorkingDirectoryPath, workspaceInfo.TempYamlFileAbsolutePath, workspaceInfo.ConfigFileRelativePath,
-			workspaceInfo.Mode, workspaceInfo.GitCloneRepoUrl, workspaceInfo.GitUserName, workspaceInfo.GitPassword, workspaceInfo.GitRepoAuthType, workspaceInfo.GitBranch,
+			workspaceInfo.Mode, workspaceInfo.GitCloneRepoUrl, workspaceInfo.GitRepoAuthType, workspaceInfo.GitUserName, gitPassword, workspaceInfo.GitBranch,
 			string(jsonBytes), configStr, linkComposeStr, tempComposeStr,
 			affectId)
 		if err != nil {
@@ -195,6 +214,10 @@ func GetWorkspaceList() (workspaces []workspace.WorkspaceInfo, err error) {
 							from workspace 
 							where w_is_del = 0
 							order by w_created desc`)
+	if err != nil {
+		return nil, err
+	}
+	var dos []workspaceDo
 	for rows.Next() {
 		do := workspaceDo{}
 		switch errSql := rows.Scan(&do.w_id, &do.w_name, &do.w_workingdir, &do.w_docker_compose_file_path, &do.w_mode, &do.w_config_file,
@@ -205,16 +228,21 @@ func GetWorkspaceList() (workspaces []workspace.WorkspaceInfo, err error) {
 		/* case sql.ErrNoRows:
 		common.SmartIDELog.Warning() //TODO */
 		case nil:
-
-			workspaceInfo := workspace.WorkspaceInfo{}
-			err = workspaceDataMap(&workspaceInfo, do)
-
-			workspaces = append(workspaces, workspaceInfo)
+			dos = append(dos, do)
 
 		default:
 			err = errSql
 		}
 	}
+	rows.Close() // 转换时可能需要更新数据（敏感信息迁移），需要先释放读锁
+
+	for _, do := range dos {
+		workspaceInfo := workspace.WorkspaceInfo{}
+		if errMap := workspaceDataMap(&workspaceInfo, do); errMap != nil {
+			err = errMap
+		}
+		workspaces = append(workspaces, workspaceInfo)
+	}
 
 	return workspaces, err
 }
@@ -232,7 +260,7 @@ func GetSingleWorkspaceByParams(workingMode workspace.WorkingModeEnum,
 	params.w_mode = string(workingMode)
 
 	if remoteId <= 0 {
-		remoteInfo, err := getRemote(remoteId, remoteHost, remoteUserName)
+		remoteInfo, err := getRemote(remoteId, remoteHost, 0, remoteUserName)
 		common.CheckError(err)
 		if remoteInfo != nil && remoteInfo.ID > 0 {
 			params.r_id = sql.NullInt32{Int32: int32(remoteInfo.ID), Valid: true}
@@ -384,7 +412,19 @@ func workspaceDataMap(workspaceInfo *workspace.WorkspaceInfo, do workspaceDo) er
 	workspaceInfo.GitCloneRepoUrl = do.w_git_clone_repo_url.String
 	workspaceInfo.GitBranch = do.w_branch
 	workspaceInfo.GitUserName = do.w_git_username.String
-	workspaceInfo.GitPassword = do.w_git_password.String
+	if do.w_git_password.Valid && do.w_git_password.String != "" {
+		secretKey := getWorkspaceGitPasswordSecretKey(int64(do.w_id))
+		gitPassword, newValue, err := loadSecret(secretKey, do.w_git_password.String, encodeGitPassword, decodeGitPassword)
+		if err != nil {
+			return err
+		}
+		workspaceInfo.GitPassword = gitPassword
+		if newValue != do.w_git_password.String { // 已经迁移到新的存储
+			if err := updateWorkspaceGitPassword(do.w_id, newValue); err != nil {
+				return err
+			}
+		}
+	}
 
 	// 远程主机信息
 	if workspaceInfo.Mode == workspace.WorkingMode_Remote {
@@ -472,6 +512,16 @@ func RemoveWorkspace(workspaceId int) error {
 		panic(err)
 	}
 
+	// 删除 git 密码等敏感信息
+	var gitPassword sql.NullString
+	row = db.QueryRow("select w_git_password from workspace where w_id=? and w_is_del = 0", workspaceId)
+	if err := row.Scan(&gitPassword); err != nil {
+		return err
+	}
+	if err := deleteSecret(gitPassword.String); err != nil {
+		return err
+	}
+
 	//
 	stmt, err := db.Prepare("update workspace set w_is_del=1 where (w_id=?) and w_is_del = 0")
 	if err != nil {
@@ -497,3 +547,12 @@ func RemoveWorkspace(workspaceId int) error {
 
 	return nil
 }
+
+// 更新 git 密码在数据库中保存的值
+func updateWorkspaceGitPassword(workspaceId int, value string) error {
+	db := getDb()
+	defer db.Close()
+
+	_, err := db.Exec("update workspace set w_git_password=? where w_id=?", value, workspaceId)
+	return err
+}
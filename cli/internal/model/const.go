@@ -59,6 +59,9 @@ const CONST_Local_Default_BindingPort_WebIDE int = 6800
 // 默认的配置文件相对路径
 const CONST_Default_ConfigRelativeFilePath = ".ide/.ide.yaml"
 
+// 默认的 devcontainer.json 相对路径，没有 .ide.yaml 时使用
+const CONST_Default_DevContainerJsonRelativeFilePath = ".devcontainer/devcontainer.json"
+
 //const CONST_Default_K8S_ConfigRelativeFilePath = ".ide/.k8s.ide.yaml"
 
 // 环境变量名称，映射到容器里面，当前用户uid,windows默认1000
@@ -61,3 +61,8 @@ func RemoveWhiteLines(filePath string) {
 	content += newLine
 	os.WriteFile(filePath, []byte(content), 0700)
 }
+
+// 转义为 shell 中的单引号字符串
+func ShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
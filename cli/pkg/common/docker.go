@@ -23,7 +23,9 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"time"
@@ -32,8 +34,10 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/pkg/system"
 	"github.com/docker/go-connections/nat"
+	"golang.org/x/term"
 )
 
 type Docker struct {
@@ -199,6 +203,99 @@ func (d Docker) Exec(ctx context.Context, container string, chdir string, cmd []
 	return buf.String(), err
 }
 
+// 在容器中执行命令，返回输出以及退出码
+func (d Docker) ExecWithExitCode(ctx context.Context, container string, cmd []string) (output string, exitCode int, err error) {
+	id, err := d.client.ContainerExecCreate(ctx, container, types.ExecConfig{Tty: true, Cmd: cmd, AttachStderr: true, AttachStdout: true})
+	if err != nil {
+		return "", -1, err
+	}
+	resp, err := d.client.ContainerExecAttach(ctx, id.ID, types.ExecStartCheck{Tty: true})
+	if err != nil {
+		return "", -1, err
+	}
+	defer resp.Close()
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(resp.Reader)
+
+	exitCode, err = d.waitExecExitCode(ctx, id.ID)
+	return buf.String(), exitCode, err
+}
+
+// 等待命令结束并返回退出码，输出流关闭时命令可能还没有结束（Running 为 true，ExitCode 为 0）
+func (d Docker) waitExecExitCode(ctx context.Context, execId string) (int, error) {
+	for {
+		inspect, err := d.client.ContainerExecInspect(ctx, execId)
+		if err != nil {
+			return -1, err
+		}
+		if !inspect.Running {
+			return inspect.ExitCode, nil
+		}
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		case <-time.After(time.Millisecond * 100):
+		}
+	}
+}
+
+// 在容器中执行命令，标准输出、标准错误分开输出（分配终端时合并），返回退出码
+// stdin 为空时不发送输入；isTTY 为 true 并且 stdin 为终端时，本地终端切换到 raw 模式
+func (d Docker) ExecStream(ctx context.Context, container string, user string, cmd []string, isTTY bool,
+	stdin io.Reader, stdout io.Writer, stderr io.Writer) (exitCode int, err error) {
+	//1. 创建并连接
+	id, err := d.client.ContainerExecCreate(ctx, container, types.ExecConfig{
+		User: user, Tty: isTTY, Cmd: cmd, AttachStdin: stdin != nil, AttachStdout: true, AttachStderr: true,
+	})
+	if err != nil {
+		return -1, err
+	}
+	resp, err := d.client.ContainerExecAttach(ctx, id.ID, types.ExecStartCheck{Tty: isTTY})
+	if err != nil {
+		return -1, err
+	}
+	defer resp.Close()
+
+	//2. 终端
+	if isTTY {
+		stdoutFd, _ := getTerminalFd(stdout)
+		width, height := getTerminalSize(stdoutFd)
+		resize := func(width int, height int) {
+			d.client.ContainerExecResize(ctx, id.ID, types.ResizeOptions{Width: uint(width), Height: uint(height)})
+		}
+		resize(width, height)
+		if stdinFd, isStdinTerminal := getTerminalFd(stdin); isStdinTerminal {
+			state, err := term.MakeRaw(stdinFd)
+			if err != nil {
+				return -1, err
+			}
+			defer term.Restore(stdinFd, state)
+		}
+		done := make(chan struct{})
+		defer close(done)
+		go watchTerminalResize(stdoutFd, width, height, done, resize)
+	}
+
+	//3. 输入输出，没有终端时标准输出、标准错误使用同一个连接，需要拆分
+	if stdin != nil {
+		go func() {
+			io.Copy(resp.Conn, stdin)
+			resp.CloseWrite()
+		}()
+	}
+	if isTTY {
+		_, err = io.Copy(stdout, resp.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, resp.Reader)
+	}
+	if err != nil {
+		return -1, err
+	}
+
+	//4. 退出码
+	return d.waitExecExitCode(ctx, id.ID)
+}
+
 func (d Docker) Restart(ctx context.Context, container string) error {
 	_ = d.Stop(ctx, container)
 	return d.Start(ctx, container)
@@ -214,3 +311,31 @@ func (d Docker) IsRun(ctx context.Context, container string) bool {
 	}
 	return true
 }
+
+// 远程主机上 docker 的 unix socket
+const remoteDockerSocketPath = "/var/run/docker.sock"
+
+// 通过已有的 ssh 连接访问远程主机上的 docker api，当前用户需要有 docker.sock 的访问权限
+func (instance *SSHRemote) NewDockerClient(ctx context.Context) (*client.Client, error) {
+	sshClient := instance.GetSSHClient()
+	if sshClient == nil {
+		return nil, errors.New("ssh connection is nil")
+	}
+
+	// 连接断开时自动重连
+	dialContext := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return sshClient.Dial("unix", remoteDockerSocketPath)
+	}
+	cli, err := client.NewClientWithOpts(client.WithHost("unix://"+remoteDockerSocketPath),
+		client.WithDialContext(dialContext), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+
+	// 验证是否可以访问
+	if _, err := cli.Ping(ctx); err != nil {
+		cli.Close()
+		return nil, err
+	}
+	return cli, nil
+}
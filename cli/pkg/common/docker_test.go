@@ -0,0 +1,63 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+func TestDocker_WaitExecExitCode(t *testing.T) {
+	// 输出流关闭后，命令仍在运行
+	inspectCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/exec/exec-id/json") {
+			http.NotFound(w, r)
+			return
+		}
+		inspectCount++
+		inspect := types.ContainerExecInspect{ExecID: "exec-id", Running: inspectCount < 3}
+		if !inspect.Running {
+			inspect.ExitCode = 3
+		}
+		json.NewEncoder(w).Encode(inspect)
+	}))
+	defer server.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+server.Listener.Addr().String()), client.WithVersion("1.41"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	exitCode, err := NewDocker(cli).waitExecExitCode(context.Background(), "exec-id")
+	if err != nil || exitCode != 3 {
+		t.Errorf("waitExecExitCode() = %v, %v, want 3", exitCode, err)
+	}
+	if inspectCount != 3 {
+		t.Errorf("inspect count = %v, want 3", inspectCount)
+	}
+}
@@ -70,18 +70,22 @@ func (fs *fileOperation) SkipStrictHostKeyChecking(sshDirectory string, isReset
 		return err
 	}
 
-	// 是否重置
+	// 是否重置，首次连接时自动记录主机公钥，公钥改变时依然会报错
 	lines := `## smartide StrictHostKeyChecking ##
 HOST *
-StrictHostKeyChecking no`
+StrictHostKeyChecking accept-new`
+	legacyLines := `## smartide StrictHostKeyChecking ##
+HOST *
+StrictHostKeyChecking no` // 旧版本写入的配置，不校验主机公钥
 	if isReset {
 		fileContentBytes, err := os.ReadFile(sshConfigPath)
 		if err != nil {
 			return err
 		}
 		fileContent := string(fileContentBytes)
-		if strings.Contains(fileContent, lines) {
+		if strings.Contains(fileContent, lines) || strings.Contains(fileContent, legacyLines) {
 			fileContent = strings.Replace(fileContent, lines, "", -1)
+			fileContent = strings.Replace(fileContent, legacyLines, "", -1)
 			return os.WriteFile(sshConfigPath, []byte(fileContent), 0644)
 		}
 
@@ -0,0 +1,229 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// 远程主机公钥的指纹（比如 SHA256:xxx），server、pipeline 模式下无法交互确认，通过 --host-key-fingerprint 指定
+// 只有指纹时用于目标主机，跳板机等其他主机使用 host=fingerprint 的形式指定，多个之间用逗号分隔
+var SSHHostKeyFingerprint string
+
+// SmartIDE 自己维护的 known_hosts，首次连接确认后的公钥记录在这里，不修改 ~/.ssh/known_hosts
+func GetSmartIDEKnownHostsFilePath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ide", "known_hosts")
+}
+
+// 校验主机公钥时读取的 known_hosts 文件
+func getKnownHostsFilePaths() []string {
+	home, _ := os.UserHomeDir()
+	return []string{filepath.Join(home, ".ssh", "known_hosts"), GetSmartIDEKnownHostsFilePath()}
+}
+
+// known_hosts 中记录的主机公钥对应的算法，设置到 ssh.ClientConfig.HostKeyAlgorithms
+// 否则服务端可能优先返回没有记录的公钥类型（比如只记录了 ed25519，协商出 ECDSA），被误认为公钥已经改变
+// 没有记录时返回 nil，使用默认的算法
+func GetKnownHostKeyAlgorithms(addr string) []string {
+	return getKnownHostKeyAlgorithms(getKnownHostsFilePaths(), addr)
+}
+
+func getKnownHostKeyAlgorithms(knownHostsFilePaths []string, addr string) []string {
+	existFilePaths := []string{}
+	for _, filePath := range knownHostsFilePaths {
+		if IsExist(filePath) {
+			existFilePaths = append(existFilePaths, filePath)
+		}
+	}
+	if len(existFilePaths) == 0 {
+		return nil
+	}
+	callback, err := knownhosts.New(existFilePaths...)
+	if err != nil {
+		return nil
+	}
+
+	//1. 使用一个随机的公钥校验，返回的错误中包含记录的所有公钥
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil
+	}
+	probeKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	var keyErr *knownhosts.KeyError
+	if err := callback(addr, &net.TCPAddr{IP: net.IPv4zero}, probeKey); !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	//2. 公钥类型转换为算法，rsa 公钥支持多种签名算法
+	algorithms := []string{}
+	for _, want := range keyErr.Want {
+		switch keyType := want.Key.Type(); keyType {
+		case ssh.KeyAlgoRSA:
+			algorithms = append(algorithms, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA)
+		case ssh.CertAlgoRSAv01:
+			algorithms = append(algorithms, ssh.CertAlgoRSASHA512v01, ssh.CertAlgoRSASHA256v01, ssh.CertAlgoRSAv01)
+		default:
+			algorithms = append(algorithms, keyType)
+		}
+	}
+	if len(algorithms) == 0 {
+		return nil
+	}
+	return algorithms
+}
+
+// 确认是否信任首次连接的主机，返回 nil 代表信任
+type HostKeyConfirmFunc func(hostname string, key ssh.PublicKey) error
+
+// 使用 ~/.ssh/known_hosts 以及 SmartIDE 的 known_hosts 校验目标主机的公钥
+func NewHostKeyCallback() ssh.HostKeyCallback {
+	return newHostKeyCallback(getKnownHostsFilePaths(), GetSmartIDEKnownHostsFilePath(), SSHHostKeyFingerprint, true, confirmHostKey)
+}
+
+// 校验跳板机的公钥，--host-key-fingerprint 中没有指定主机的指纹只用于目标主机
+func NewJumpHostKeyCallback() ssh.HostKeyCallback {
+	return newHostKeyCallback(getKnownHostsFilePaths(), GetSmartIDEKnownHostsFilePath(), SSHHostKeyFingerprint, false, confirmHostKey)
+}
+
+// SmartIDE 转发的开发容器 ssh 端口（DialSSHThrough），容器重建后公钥会改变，不校验
+func newDevContainerHostKeyCallback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return nil
+	}
+}
+
+func newHostKeyCallback(knownHostsFilePaths []string, writeFilePath string, fingerprints string, isTarget bool, confirm HostKeyConfirmFunc) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		//1. 在 known_hosts 中查找
+		existFilePaths := []string{}
+		for _, filePath := range knownHostsFilePaths {
+			if IsExist(filePath) {
+				existFilePaths = append(existFilePaths, filePath)
+			}
+		}
+		fingerprint := ssh.FingerprintSHA256(key)
+		if len(existFilePaths) > 0 {
+			callback, err := knownhosts.New(existFilePaths...)
+			if err != nil {
+				return err
+			}
+			err = callback(hostname, remote, key)
+			if err == nil {
+				return nil
+			}
+			var keyErr *knownhosts.KeyError
+			if !errors.As(err, &keyErr) {
+				return err
+			}
+			if len(keyErr.Want) > 0 { // 已经记录了其他公钥
+				want := keyErr.Want[0]
+				return fmt.Errorf(i18nInstance.Common.Err_ssh_host_key_changed, hostname, fingerprint, want.Filename, want.Line)
+			}
+		}
+
+		//2. 首次连接，通过参数指定的指纹或者用户确认
+		expectedFingerprint := getExpectedHostKeyFingerprint(fingerprints, hostname, isTarget)
+		if expectedFingerprint != "" {
+			if strings.TrimPrefix(expectedFingerprint, "SHA256:") != strings.TrimPrefix(fingerprint, "SHA256:") {
+				return fmt.Errorf(i18nInstance.Common.Err_ssh_host_key_mismatch, hostname, fingerprint, expectedFingerprint)
+			}
+		} else if err := confirm(hostname, key); err != nil {
+			return err
+		}
+
+		//3. 记录到 SmartIDE 的 known_hosts
+		if err := appendKnownHost(writeFilePath, hostname, key); err != nil {
+			return err
+		}
+		SmartIDELog.InfoF(i18nInstance.Common.Info_ssh_host_key_added, hostname, writeFilePath)
+		return nil
+	}
+}
+
+// 从 --host-key-fingerprint 中获取主机对应的指纹，格式为 fingerprint 或者 host[:port]=fingerprint，多个之间用逗号分隔
+// 指定了主机的优先，没有指定主机的指纹只用于目标主机
+func getExpectedHostKeyFingerprint(fingerprints string, hostname string, isTarget bool) string {
+	defaultFingerprint := ""
+	for _, item := range strings.Split(fingerprints, ",") {
+		item = strings.TrimSpace(item)
+		index := strings.LastIndex(item, "=")
+		if index < 0 {
+			if defaultFingerprint == "" {
+				defaultFingerprint = item
+			}
+			continue
+		}
+		host := strings.TrimSpace(item[:index])
+		if knownhosts.Normalize(host) == knownhosts.Normalize(hostname) {
+			return strings.TrimSpace(item[index+1:])
+		}
+	}
+	if isTarget {
+		return defaultFingerprint
+	}
+	return ""
+}
+
+// 客户端模式下在终端中确认，其他情况下返回错误，提示使用 --host-key-fingerprint
+func confirmHostKey(hostname string, key ssh.PublicKey) error {
+	fingerprint := ssh.FingerprintSHA256(key)
+	if !isInteractiveClient() {
+		return fmt.Errorf(i18nInstance.Common.Err_ssh_host_key_unknown, hostname, fingerprint)
+	}
+
+	var answer string
+	fmt.Printf(i18nInstance.Common.Info_ssh_host_key_confirm, hostname, key.Type(), fingerprint)
+	fmt.Scanln(&answer)
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "yes" && answer != "y" {
+		return fmt.Errorf(i18nInstance.Common.Err_ssh_host_key_rejected, hostname)
+	}
+	return nil
+}
+
+// 追加一行到 known_hosts 文件
+func appendKnownHost(filePath string, hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n")
+	return err
+}
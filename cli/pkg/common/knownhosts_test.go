@@ -0,0 +1,182 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestHostKeyCallback(t *testing.T) {
+	SmartIDELog.InitLogger("")
+
+	knownHostsFilePath := filepath.Join(t.TempDir(), "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("192.168.1.2"), Port: 22}
+	key, otherKey := newTestHostKey(t), newTestHostKey(t)
+
+	confirmCount := 0
+	accept := func(hostname string, key ssh.PublicKey) error {
+		confirmCount++
+		return nil
+	}
+	reject := func(hostname string, key ssh.PublicKey) error {
+		return errors.New("rejected")
+	}
+
+	//1. 首次连接，拒绝后不记录
+	if err := newHostKeyCallback([]string{knownHostsFilePath}, knownHostsFilePath, "", true, reject)("192.168.1.2:22", remote, key); err == nil {
+		t.Fatalf("rejected host should return error")
+	}
+
+	//2. 首次连接，确认后记录，再次连接不需要确认
+	callback := newHostKeyCallback([]string{knownHostsFilePath}, knownHostsFilePath, "", true, accept)
+	for i := 0; i < 2; i++ {
+		if err := callback("192.168.1.2:22", remote, key); err != nil {
+			t.Fatalf("callback() error = %v", err)
+		}
+	}
+	if confirmCount != 1 {
+		t.Errorf("confirm count = %v, want 1", confirmCount)
+	}
+
+	//3. 公钥改变
+	err := callback("192.168.1.2:22", remote, otherKey)
+	if err == nil || !strings.Contains(err.Error(), knownHostsFilePath) {
+		t.Errorf("changed key error = %v, should contain %v", err, knownHostsFilePath)
+	}
+
+	//4. 本机地址（比如通过端口转发连接的远程主机）同样需要校验
+	loopback := &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 2222}
+	if err := callback("127.0.0.1:2222", loopback, key); err != nil {
+		t.Fatalf("loopback host error = %v", err)
+	}
+	if err := callback("127.0.0.1:2222", loopback, otherKey); err == nil {
+		t.Errorf("changed key of loopback host should return error")
+	}
+	if confirmCount != 2 {
+		t.Errorf("confirm count = %v, want 2", confirmCount)
+	}
+}
+
+func TestHostKeyCallback_Fingerprint(t *testing.T) {
+	SmartIDELog.InitLogger("")
+
+	knownHostsFilePath := filepath.Join(t.TempDir(), "known_hosts")
+	remote := &net.TCPAddr{IP: net.ParseIP("192.168.1.3"), Port: 2222}
+	key := newTestHostKey(t)
+	reject := func(hostname string, key ssh.PublicKey) error {
+		return errors.New("should not confirm when fingerprint is specified")
+	}
+
+	wrongFingerprint := ssh.FingerprintSHA256(newTestHostKey(t))
+	if err := newHostKeyCallback(nil, knownHostsFilePath, wrongFingerprint, true, reject)("192.168.1.3:2222", remote, key); err == nil {
+		t.Errorf("mismatched fingerprint should return error")
+	}
+
+	fingerprint := strings.TrimPrefix(ssh.FingerprintSHA256(key), "SHA256:")
+	if err := newHostKeyCallback(nil, knownHostsFilePath, fingerprint, true, reject)("192.168.1.3:2222", remote, key); err != nil {
+		t.Fatalf("matched fingerprint error = %v", err)
+	}
+	if err := newHostKeyCallback([]string{knownHostsFilePath}, knownHostsFilePath, "", true, reject)("192.168.1.3:2222", remote, key); err != nil {
+		t.Errorf("trusted host error = %v", err)
+	}
+}
+
+func TestHostKeyCallback_JumpHostFingerprint(t *testing.T) {
+	SmartIDELog.InitLogger("")
+
+	knownHostsFilePath := filepath.Join(t.TempDir(), "known_hosts")
+	jumpKey, targetKey := newTestHostKey(t), newTestHostKey(t)
+	reject := func(hostname string, key ssh.PublicKey) error {
+		return errors.New("rejected")
+	}
+
+	//1. 没有指定主机的指纹只用于目标主机
+	fingerprints := ssh.FingerprintSHA256(targetKey)
+	if err := newHostKeyCallback(nil, knownHostsFilePath, fingerprints, false, reject)("192.168.1.5:22", nil, targetKey); err == nil {
+		t.Errorf("fingerprint without host should not be used for jump hosts")
+	}
+
+	//2. host=fingerprint 指定跳板机的指纹
+	fingerprints = ssh.FingerprintSHA256(targetKey) + ", bastion.example.com:2222=" + ssh.FingerprintSHA256(jumpKey)
+	if err := newHostKeyCallback(nil, knownHostsFilePath, fingerprints, false, reject)("bastion.example.com:2222", nil, jumpKey); err != nil {
+		t.Errorf("jump host error = %v", err)
+	}
+	if err := newHostKeyCallback(nil, knownHostsFilePath, fingerprints, true, reject)("192.168.1.6:22", nil, targetKey); err != nil {
+		t.Errorf("target host error = %v", err)
+	}
+	if err := newHostKeyCallback(nil, knownHostsFilePath, fingerprints, true, reject)("192.168.1.7:22", nil, jumpKey); err == nil {
+		t.Errorf("jump host fingerprint should not be used for other hosts")
+	}
+}
+
+func TestGetKnownHostKeyAlgorithms(t *testing.T) {
+	knownHostsFilePath := filepath.Join(t.TempDir(), "known_hosts")
+	if algorithms := getKnownHostKeyAlgorithms([]string{knownHostsFilePath}, "192.168.1.2:22"); algorithms != nil {
+		t.Errorf("not exist known_hosts = %v, want nil", algorithms)
+	}
+
+	// 只记录了 ed25519 公钥
+	if err := appendKnownHost(knownHostsFilePath, "192.168.1.2:22", newTestHostKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendKnownHost(knownHostsFilePath, "192.168.1.3:2222", newTestHostKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendKnownHost(knownHostsFilePath, "127.0.0.1:2222", newTestHostKey(t)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		addr string
+		want []string
+	}{
+		{"192.168.1.2:22", []string{ssh.KeyAlgoED25519}},
+		{"192.168.1.2", []string{ssh.KeyAlgoED25519}},
+		{"192.168.1.3:2222", []string{ssh.KeyAlgoED25519}},
+		{"192.168.1.3:22", nil},
+		{"192.168.1.4:22", nil},
+		{"localhost:6822", nil},
+		{"127.0.0.1:2222", []string{ssh.KeyAlgoED25519}},
+	}
+	for _, tt := range tests {
+		got := getKnownHostKeyAlgorithms([]string{knownHostsFilePath}, tt.addr)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") || (got == nil) != (tt.want == nil) {
+			t.Errorf("getKnownHostKeyAlgorithms(%v) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
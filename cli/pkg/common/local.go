@@ -27,7 +27,7 @@ import (
 	"github.com/leansoftX/smartide-cli/internal/apk/i18n"
 )
 
-// 检查本地环境，是否安装docker、docker-compose
+// 检查本地环境，是否安装docker
 func CheckLocalEnv() error {
 	var errMsgArray []string
 
@@ -45,12 +45,7 @@ func CheckLocalEnv() error {
 		errMsgArray = append(errMsgArray, i18n.GetInstance().Main.Err_env_DockerPs)
 	}
 
-	//0.2. 校验是否能正常执行 docker-compose
-	dockercomposeErr := exec.Command("docker-compose", "version").Run()
-	if dockercomposeErr != nil {
-		SmartIDELog.Debug(dockercomposeErr.Error())
-		errMsgArray = append(errMsgArray, i18n.GetInstance().Main.Err_env_Docker_Compose)
-	}
+	// compose 项目通过 docker api 运行，不再需要 docker-compose
 
 	// 错误判断
 	if len(errMsgArray) > 0 {
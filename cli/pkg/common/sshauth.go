@@ -0,0 +1,217 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/howeyc/gopass"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/term"
+)
+
+// 没有指定私钥文件时依次尝试的默认私钥，与 openssh 的查找顺序一致
+var defaultIdentityFileNames = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// 读取加密的私钥时，获取私钥的密码
+type PassphrasePromptFunc func(identityFile string) ([]byte, error)
+
+// 获取公钥认证使用的私钥，依次为 ssh-agent 中的私钥、指定的私钥（内容或者文件）、默认的私钥文件
+// 认证时按照顺序尝试，加密的私钥在需要时才输入密码
+// 返回的 io.Closer 为 ssh-agent 的连接，认证完成后需要关闭
+func getSSHSigners(idRsa string, identityFile string, prompt PassphrasePromptFunc) (signers []ssh.Signer, agentConn io.Closer, err error) {
+	//1. ssh-agent
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			SmartIDELog.Debug("connect to ssh-agent failed: " + err.Error())
+		} else {
+			agentSigners, err := agent.NewClient(conn).Signers()
+			if err != nil {
+				SmartIDELog.Debug("list ssh-agent keys failed: " + err.Error())
+			}
+			signers = append(signers, agentSigners...)
+			agentConn = conn
+		}
+	}
+
+	//2. 指定的私钥，读取失败时直接返回错误
+	if idRsa != "" || identityFile != "" {
+		key := []byte(idRsa)
+		if idRsa == "" {
+			identityFile = ExpandIdentityFilePath(identityFile)
+			key, err = os.ReadFile(identityFile)
+			if err != nil {
+				return signers, agentConn, fmt.Errorf(i18nInstance.Common.Err_ssh_identity_file_read, identityFile, err)
+			}
+		}
+		signer, err := parsePrivateKey(key, identityFile, signers, prompt)
+		if err != nil {
+			return signers, agentConn, err
+		}
+		if signer != nil {
+			signers = append(signers, signer)
+		}
+		return signers, agentConn, nil
+	}
+
+	//3. 默认的私钥文件，不存在或者无法解析的跳过
+	homePath, _ := os.UserHomeDir()
+	for _, fileName := range defaultIdentityFileNames {
+		filePath := filepath.Join(homePath, ".ssh", fileName)
+		key, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		signer, err := parsePrivateKey(key, filePath, signers, prompt)
+		if err != nil {
+			SmartIDELog.Debug(fmt.Sprintf("skip private key %v: %v", filePath, err))
+			continue
+		}
+		if signer != nil {
+			signers = append(signers, signer)
+		}
+	}
+	if len(signers) == 0 {
+		return signers, agentConn, errors.New(i18nInstance.Common.Err_ssh_no_identity)
+	}
+
+	return signers, agentConn, nil
+}
+
+// 已经解密的私钥，key 为私钥文件的内容，同一个进程中只需要输入一次密码
+var (
+	decryptedSignersMutex sync.Mutex
+	decryptedSigners      = map[string]ssh.Signer{}
+)
+
+// 解析私钥，加密的私钥如果已经加载到 ssh-agent 中，返回 nil
+// 能够获取到公钥时延迟输入密码，ssh-agent 中的私钥认证失败、服务端接受这个公钥后才需要输入
+func parsePrivateKey(key []byte, identityFile string, agentSigners []ssh.Signer, prompt PassphrasePromptFunc) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey(key)
+	var missingErr *ssh.PassphraseMissingError
+	if !errors.As(err, &missingErr) {
+		return signer, err
+	}
+
+	//1. 公钥，旧格式（PEM）的私钥中没有公钥，从 .pub 文件中读取
+	publicKey := missingErr.PublicKey
+	if publicKey == nil && identityFile != "" {
+		if content, err := os.ReadFile(identityFile + ".pub"); err == nil {
+			publicKey, _, _, _, _ = ssh.ParseAuthorizedKey(content)
+		}
+	}
+
+	//2. 已经加载到 ssh-agent 中的跳过，否则在签名时才输入密码
+	if publicKey != nil {
+		for _, agentSigner := range agentSigners {
+			if string(agentSigner.PublicKey().Marshal()) == string(publicKey.Marshal()) {
+				return nil, nil
+			}
+		}
+		return &passphraseSigner{publicKey: publicKey, key: key, identityFile: identityFile, prompt: prompt}, nil
+	}
+
+	//3. 没有公钥，只能现在输入密码
+	return decryptPrivateKey(key, identityFile, prompt)
+}
+
+// 解密私钥，解密后缓存
+func decryptPrivateKey(key []byte, identityFile string, prompt PassphrasePromptFunc) (ssh.Signer, error) {
+	decryptedSignersMutex.Lock()
+	defer decryptedSignersMutex.Unlock()
+
+	if signer, ok := decryptedSigners[string(key)]; ok {
+		return signer, nil
+	}
+	passphrase, err := prompt(identityFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKeyWithPassphrase(key, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	decryptedSigners[string(key)] = signer
+	return signer, nil
+}
+
+// 加密的私钥，签名时才解密
+type passphraseSigner struct {
+	publicKey    ssh.PublicKey
+	key          []byte
+	identityFile string
+	prompt       PassphrasePromptFunc
+}
+
+func (s *passphraseSigner) PublicKey() ssh.PublicKey {
+	return s.publicKey
+}
+
+func (s *passphraseSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	return s.SignWithAlgorithm(rand, data, "")
+}
+
+// rsa 私钥需要支持 rsa-sha2-256、rsa-sha2-512 签名
+func (s *passphraseSigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	signer, err := decryptPrivateKey(s.key, s.identityFile, s.prompt)
+	if err != nil {
+		return nil, err
+	}
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok {
+		return algorithmSigner.SignWithAlgorithm(rand, data, algorithm)
+	}
+	return signer.Sign(rand, data)
+}
+
+// 在终端中输入私钥的密码，server、pipeline 模式下无法交互，需要先把私钥加载到 ssh-agent 中
+func promptPassphrase(identityFile string) ([]byte, error) {
+	if identityFile == "" {
+		identityFile = "id_rsa"
+	}
+	if !isInteractiveClient() {
+		return nil, fmt.Errorf(i18nInstance.Common.Err_ssh_identity_passphrase_required, identityFile, identityFile)
+	}
+
+	fmt.Printf(i18nInstance.Common.Info_ssh_identity_passphrase, identityFile)
+	return gopass.GetPasswdMasked()
+}
+
+// 是否为可以交互的客户端模式
+func isInteractiveClient() bool {
+	isClientMode := Mode == "" || strings.ToLower(Mode) == "client"
+	return isClientMode && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// 私钥文件路径中的 ~ 替换为当前用户的 HOME 目录
+func ExpandIdentityFilePath(identityFile string) string {
+	if identityFile == "~" || strings.HasPrefix(identityFile, "~/") {
+		homePath, _ := os.UserHomeDir()
+		return filepath.Join(homePath, strings.TrimPrefix(identityFile, "~"))
+	}
+	return identityFile
+}
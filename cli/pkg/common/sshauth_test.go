@@ -0,0 +1,213 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// 生成 rsa 私钥文件，passphrase 不为空时加密
+func newTestIdentityFile(t *testing.T, dir string, fileName string, passphrase string) (*rsa.PrivateKey, string) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}
+	if passphrase != "" {
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte(passphrase), x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	filePath := filepath.Join(dir, fileName)
+	if err := os.WriteFile(filePath, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, filePath
+}
+
+func TestGetSSHSigners_IdentityFile(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	t.Setenv("SSH_AUTH_SOCK", "")
+	dir := t.TempDir()
+	_, plainFilePath := newTestIdentityFile(t, dir, "id_plain", "")
+	_, encryptedFilePath := newTestIdentityFile(t, dir, "id_encrypted", "p@ssw0rd")
+
+	promptCount := 0
+	prompt := func(passphrase string) PassphrasePromptFunc {
+		return func(identityFile string) ([]byte, error) {
+			promptCount++
+			if passphrase == "" {
+				return nil, errors.New("no passphrase")
+			}
+			return []byte(passphrase), nil
+		}
+	}
+
+	// 未加密的私钥不需要输入密码
+	signers, _, err := getSSHSigners("", plainFilePath, prompt(""))
+	if err != nil || len(signers) != 1 || promptCount != 0 {
+		t.Errorf("plain key: signers = %v, prompt = %v, err = %v", len(signers), promptCount, err)
+	}
+
+	// 加密的私钥，输入正确的密码
+	signers, _, err = getSSHSigners("", encryptedFilePath, prompt("p@ssw0rd"))
+	if err != nil || len(signers) != 1 || promptCount != 1 {
+		t.Errorf("encrypted key: signers = %v, prompt = %v, err = %v", len(signers), promptCount, err)
+	}
+
+	// 已经解密过，不需要再次输入密码
+	signers, _, err = getSSHSigners("", encryptedFilePath, prompt(""))
+	if err != nil || len(signers) != 1 || promptCount != 1 {
+		t.Errorf("decrypted key: signers = %v, prompt = %v, err = %v", len(signers), promptCount, err)
+	}
+
+	// 加密的私钥，无法输入密码
+	_, otherFilePath := newTestIdentityFile(t, dir, "id_other", "p@ssw0rd")
+	if _, _, err = getSSHSigners("", otherFilePath, prompt("")); err == nil {
+		t.Error("encrypted key without passphrase should fail")
+	}
+
+	// 密码错误
+	if _, _, err = getSSHSigners("", otherFilePath, prompt("wrong")); err == nil {
+		t.Error("encrypted key with wrong passphrase should fail")
+	}
+
+	// 指定的私钥文件不存在
+	if _, _, err = getSSHSigners("", filepath.Join(dir, "not_exist"), prompt("")); err == nil {
+		t.Error("missing identity file should fail")
+	}
+}
+
+func TestGetSSHSigners_Agent(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".ssh"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	noPrompt := func(identityFile string) ([]byte, error) {
+		return nil, errors.New("should not prompt")
+	}
+
+	// 没有 ssh-agent，也没有默认的私钥
+	t.Setenv("SSH_AUTH_SOCK", "")
+	if _, _, err := getSSHSigners("", "", noPrompt); err == nil {
+		t.Error("no identity should fail")
+	}
+
+	// ssh-agent 中的私钥
+	keyring := agent.NewKeyring()
+	privateKey, _ := newTestIdentityFile(t, t.TempDir(), "id_agent", "")
+	if err := keyring.Add(agent.AddedKey{PrivateKey: privateKey}); err != nil {
+		t.Fatal(err)
+	}
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", socketPath)
+
+	// 默认私钥中的 id_rsa 也会被加载
+	newTestIdentityFile(t, filepath.Join(home, ".ssh"), "id_rsa", "")
+	signers, agentConn, err := getSSHSigners("", "", noPrompt)
+	if err != nil {
+		t.Fatalf("getSSHSigners() error = %v", err)
+	}
+	if agentConn == nil {
+		t.Fatal("agent connection should not be nil")
+	}
+	agentConn.Close()
+	if len(signers) != 2 {
+		t.Errorf("signers = %v, want 2", len(signers))
+	}
+}
+
+func TestExpandIdentityFilePath(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	if got := ExpandIdentityFilePath("~/.ssh/id_ed25519"); got != filepath.Join(home, ".ssh", "id_ed25519") {
+		t.Errorf("ExpandIdentityFilePath() = %v", got)
+	}
+	if got := ExpandIdentityFilePath("/tmp/id_rsa"); got != "/tmp/id_rsa" {
+		t.Errorf("ExpandIdentityFilePath() = %v", got)
+	}
+}
+
+func TestGetSSHSigners_PromptWhenSign(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	t.Setenv("SSH_AUTH_SOCK", "")
+	privateKey, filePath := newTestIdentityFile(t, t.TempDir(), "id_rsa", "p@ssw0rd")
+	publicKey, err := ssh.NewPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath+".pub", ssh.MarshalAuthorizedKey(publicKey), 0644); err != nil {
+		t.Fatal(err)
+	}
+	promptCount := 0
+	prompt := func(identityFile string) ([]byte, error) {
+		promptCount++
+		return []byte("p@ssw0rd"), nil
+	}
+
+	// 有公钥时，获取私钥不需要输入密码
+	signers, _, err := getSSHSigners("", filePath, prompt)
+	if err != nil || len(signers) != 1 || promptCount != 0 {
+		t.Fatalf("signers = %v, prompt = %v, err = %v", len(signers), promptCount, err)
+	}
+	if string(signers[0].PublicKey().Marshal()) != string(publicKey.Marshal()) {
+		t.Errorf("public key not match")
+	}
+
+	// 签名时输入一次密码
+	for i := 0; i < 2; i++ {
+		signature, err := signers[0].(ssh.AlgorithmSigner).SignWithAlgorithm(rand.Reader, []byte("data"), ssh.KeyAlgoRSASHA256)
+		if err != nil {
+			t.Fatalf("SignWithAlgorithm() error = %v", err)
+		}
+		if err := publicKey.Verify([]byte("data"), signature); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+	}
+	if promptCount != 1 {
+		t.Errorf("prompt = %v, want 1", promptCount)
+	}
+}
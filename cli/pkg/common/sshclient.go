@@ -0,0 +1,407 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ssh 连接发送心跳的间隔，小于等于 0 时不发送
+var SSHKeepAliveInterval = time.Second * 15
+
+// ssh 命令的超时时间，小于等于 0 时不限制，通过 --ssh-timeout 设置
+var SSHCommandTimeout time.Duration
+
+// 取消命令后，等待远程命令退出的时间
+const sshCommandAbortTimeout = time.Second * 3
+
+// 收到 Ctrl-C 后不再执行新的 ssh 命令
+var isSSHCommandInterrupted int32
+
+// 建立 ssh 连接
+type SSHDialFunc func() (*ssh.Client, error)
+
+// 可以自动重连的 ssh 连接，SSHRemote 的副本以及端口转发共用同一个连接
+// 每个连接定时发送心跳，心跳失败时断开连接，下次使用时重新连接
+type ReconnectingSSHClient struct {
+	dial              SSHDialFunc
+	keepAliveInterval time.Duration
+	isAutoReconnect   bool // 断开后是否在后台定时重连，否则在下次使用时重连
+
+	mutex          sync.Mutex
+	client         *ssh.Client
+	lastError      string
+	dialing        *sshDialCall  // 正在进行的连接，同一时间只有一个
+	stopKeepAlive  chan struct{} // 停止当前连接的心跳
+	isReconnecting bool          // 是否正在后台重连
+	done           chan struct{}
+}
+
+// 正在进行的连接，其他请求等待连接的结果
+type sshDialCall struct {
+	done   chan struct{}
+	client *ssh.Client
+	err    error
+}
+
+// 创建自动重连的 ssh 连接，client 为空时在第一次使用时连接
+func NewReconnectingSSHClient(client *ssh.Client, dial SSHDialFunc, keepAliveInterval time.Duration, isAutoReconnect bool) *ReconnectingSSHClient {
+	instance := &ReconnectingSSHClient{
+		dial:              dial,
+		keepAliveInterval: keepAliveInterval,
+		isAutoReconnect:   isAutoReconnect,
+		done:              make(chan struct{}),
+	}
+	if client != nil {
+		instance.mutex.Lock()
+		instance.setClient(client)
+		instance.mutex.Unlock()
+	}
+	return instance
+}
+
+// 获取 ssh 连接，断开时重新连接
+// 同时只有一个请求连接，其他请求等待该连接的结果，避免建立多个连接以及重复的密码提示
+func (c *ReconnectingSSHClient) Client() (*ssh.Client, error) {
+	c.mutex.Lock()
+	if c.client != nil {
+		client := c.client
+		c.mutex.Unlock()
+		return client, nil
+	}
+	select {
+	case <-c.done:
+		c.mutex.Unlock()
+		return nil, errors.New("ssh connection is closed")
+	default:
+	}
+	if c.dial == nil {
+		c.mutex.Unlock()
+		return nil, errors.New(i18nInstance.Common.Err_ssh_dial_none)
+	}
+
+	//1. 其他请求正在连接
+	if call := c.dialing; call != nil {
+		c.mutex.Unlock()
+		<-call.done
+		return call.client, call.err
+	}
+
+	//2. 连接时不持有锁，IsConnected、LastError 等不需要等待
+	call := &sshDialCall{done: make(chan struct{})}
+	c.dialing = call
+	c.mutex.Unlock()
+	client, err := c.dial()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	defer close(call.done)
+	c.dialing = nil
+	if err == nil {
+		select {
+		case <-c.done: // 连接的过程中已经关闭
+			client.Close()
+			client, err = nil, errors.New("ssh connection is closed")
+		default:
+			c.setClient(client)
+		}
+	}
+	if err != nil {
+		c.lastError = err.Error()
+	}
+	call.client, call.err = client, err
+	return client, err
+}
+
+// 设置当前连接，停止之前连接的心跳，并开始当前连接的心跳；调用方需要持有锁
+func (c *ReconnectingSSHClient) setClient(client *ssh.Client) {
+	c.stopKeepAliveLocked()
+	c.client = client
+	c.lastError = ""
+	if c.keepAliveInterval > 0 {
+		c.stopKeepAlive = make(chan struct{})
+		go c.keepAlive(client, c.stopKeepAlive)
+	}
+}
+
+// 停止当前连接的心跳；调用方需要持有锁
+func (c *ReconnectingSSHClient) stopKeepAliveLocked() {
+	if c.stopKeepAlive != nil {
+		close(c.stopKeepAlive)
+		c.stopKeepAlive = nil
+	}
+}
+
+// 断开 ssh 连接，下次使用时重新连接；自动重连时在后台定时重连
+func (c *ReconnectingSSHClient) Reset(client *ssh.Client, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.client == client && client != nil {
+		c.stopKeepAliveLocked()
+		c.client.Close()
+		c.client = nil
+
+		if c.isAutoReconnect && c.keepAliveInterval > 0 && !c.isReconnecting {
+			select {
+			case <-c.done:
+			default:
+				c.isReconnecting = true
+				go c.reconnect()
+			}
+		}
+	}
+	if err != nil {
+		c.lastError = err.Error()
+	}
+}
+
+// 连接是否正常
+func (c *ReconnectingSSHClient) IsConnected() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.client != nil
+}
+
+// 最近一次的错误
+func (c *ReconnectingSSHClient) LastError() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lastError
+}
+
+// 创建会话，失败时重连一次
+func (c *ReconnectingSSHClient) NewSession() (session *ssh.Session, err error) {
+	for i := 0; i < 2; i++ {
+		var client *ssh.Client
+		client, err = c.Client()
+		if err != nil {
+			continue
+		}
+		session, err = client.NewSession()
+		if err == nil {
+			return session, nil
+		}
+
+		// 服务端拒绝（比如超过了 MaxSessions）时不需要重连
+		if _, isOpenChannelError := err.(*ssh.OpenChannelError); isOpenChannelError {
+			return nil, err
+		}
+		c.Reset(client, err)
+	}
+	return nil, err
+}
+
+// 通过 ssh 连接到远程地址，失败时重连一次
+func (c *ReconnectingSSHClient) Dial(network, addr string) (conn net.Conn, err error) {
+	for i := 0; i < 2; i++ {
+		var client *ssh.Client
+		client, err = c.Client()
+		if err != nil {
+			continue
+		}
+		conn, err = client.Dial(network, addr)
+		if err == nil {
+			return conn, nil
+		}
+
+		// 远程端口未开放时不需要重连
+		if _, isOpenChannelError := err.(*ssh.OpenChannelError); isOpenChannelError {
+			return nil, err
+		}
+		c.Reset(client, err)
+	}
+	return nil, err
+}
+
+// 关闭连接，并停止心跳以及后台重连
+func (c *ReconnectingSSHClient) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	select {
+	case <-c.done:
+	default:
+		close(c.done)
+	}
+	c.stopKeepAliveLocked()
+	if c.client != nil {
+		err := c.client.Close()
+		c.client = nil
+		return err
+	}
+	return nil
+}
+
+// 定时发送心跳，VPN 等链路断开后 tcp 连接可能不会报错，需要心跳检测
+// 每个连接一个心跳，连接断开、重连或者关闭时停止
+func (c *ReconnectingSSHClient) keepAlive(client *ssh.Client, stop chan struct{}) {
+	ticker := time.NewTicker(c.keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if err := sendSSHKeepAlive(client, c.keepAliveInterval); err != nil {
+			SmartIDELog.Debug(fmt.Sprintf("ssh keepalive failed, reset connection: %v", err))
+			c.Reset(client, err)
+			return
+		}
+	}
+}
+
+// 连接断开后在后台定时重连，直到重新连接或者关闭
+func (c *ReconnectingSSHClient) reconnect() {
+	ticker := time.NewTicker(c.keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			c.mutex.Lock()
+			c.isReconnecting = false
+			c.mutex.Unlock()
+			return
+		case <-ticker.C:
+		}
+
+		// 在锁内判断并清除状态，避免与 Reset 同时发生时没有重连
+		c.mutex.Lock()
+		if c.client != nil {
+			c.isReconnecting = false
+			c.mutex.Unlock()
+			return
+		}
+		c.mutex.Unlock()
+		if _, err := c.Client(); err != nil {
+			SmartIDELog.Debug(fmt.Sprintf("ssh reconnect failed: %v", err))
+		}
+	}
+}
+
+// 发送心跳，连接已经失效时 SendRequest 可能不会返回，所以需要超时
+func sendSSHKeepAlive(client *ssh.Client, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return errors.New("keepalive timeout")
+	}
+}
+
+// 通过已有的连接（比如远程主机的 ssh 连接）连接到开发容器中的 ssh 服务，使用密码认证
+// 开发容器的端口由 SmartIDE 绑定在 localhost 上，不需要校验主机公钥
+func DialSSHThrough(dial func(network, addr string) (net.Conn, error), addr string, userName string, password string) (*ssh.Client, error) {
+	conn, err := dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	clientConfig := &ssh.ClientConfig{
+		User:            userName,
+		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+		Timeout:         10 * time.Second,
+		HostKeyCallback: newDevContainerHostKeyCallback(),
+	}
+	c, chans, reqs, err := newSSHClientConn(conn, addr, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// 执行 ssh 命令使用的 context，超时时间为 SSHCommandTimeout，收到 Ctrl-C 时取消
+func newSSHCommandContext(parent context.Context) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if SSHCommandTimeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, SSHCommandTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+			atomic.StoreInt32(&isSSHCommandInterrupted, 1)
+			cancel()
+		case <-stop:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(stop)
+		cancel()
+	}
+}
+
+// 执行会话中的命令，ctx 取消时（超时、Ctrl-C）通知远程主机结束命令，并关闭会话
+func runSSHSessionContext(ctx context.Context, session *ssh.Session, command string, run func() error) error {
+	if atomic.LoadInt32(&isSSHCommandInterrupted) == 1 {
+		return fmt.Errorf("%v: %w", fmt.Sprintf(i18nInstance.Common.Err_ssh_command_canceled, command), context.Canceled)
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- run() }()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+	}
+
+	// 没有分配终端时，关闭会话不会结束远程命令，需要发送信号（OpenSSH 7.9 及以上版本支持）
+	SmartIDELog.Debug(fmt.Sprintf("ssh command `%v` aborted: %v", command, ctx.Err()))
+	session.Signal(ssh.SIGINT)
+	select {
+	case <-result:
+	case <-time.After(sshCommandAbortTimeout):
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		<-result // 会话关闭后 run 会立即返回
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%v: %w", fmt.Sprintf(i18nInstance.Common.Err_ssh_command_timeout, command), ctx.Err())
+	}
+	return fmt.Errorf("%v: %w", fmt.Sprintf(i18nInstance.Common.Err_ssh_command_canceled, command), ctx.Err())
+}
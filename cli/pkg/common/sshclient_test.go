@@ -0,0 +1,200 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestReconnectingSSHClient_Reconnect(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	addr := startTestSSHServer(t, make(chan string, 10))
+
+	// 远程的 echo 服务
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+
+	var dialCount int32
+	sshClient := NewReconnectingSSHClient(nil, func() (*ssh.Client, error) {
+		atomic.AddInt32(&dialCount, 1)
+		return ssh.Dial("tcp", addr, &ssh.ClientConfig{User: "smartide", Auth: []ssh.AuthMethod{ssh.Password("")}, HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+	}, 0, false)
+	defer sshClient.Close()
+
+	for i := 0; i < 2; i++ {
+		session, err := sshClient.NewSession()
+		if err != nil {
+			t.Fatalf("NewSession() error = %v", err)
+		}
+		output, err := session.Output("echo")
+		session.Close()
+		if err != nil || string(output) != "ok" {
+			t.Fatalf("Output() = %q, %v", output, err)
+		}
+
+		conn, err := sshClient.Dial("tcp", echo.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial() error = %v", err)
+		}
+		conn.Write([]byte("ping"))
+		buf := make([]byte, 4)
+		_, err = io.ReadFull(conn, buf)
+		conn.Close()
+		if err != nil || string(buf) != "ping" {
+			t.Fatalf("echo = %q, %v", buf, err)
+		}
+
+		// 模拟连接断开，下一次使用时重连
+		client, _ := sshClient.Client()
+		client.Close()
+	}
+	if got := atomic.LoadInt32(&dialCount); got != 2 {
+		t.Errorf("dial count = %v, want 2", got)
+	}
+
+	// 远程端口未开放时不重连
+	if _, err := sshClient.Dial("tcp", "127.0.0.1:1"); err == nil {
+		t.Errorf("Dial() closed port should fail")
+	}
+	if got := atomic.LoadInt32(&dialCount); got != 3 {
+		t.Errorf("dial count = %v, want 3", got)
+	}
+}
+
+func TestReconnectingSSHClient_KeepAlive(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	addr := startTestSSHServer(t, make(chan string, 10))
+
+	var dialCount int32
+	sshClient := NewReconnectingSSHClient(nil, func() (*ssh.Client, error) {
+		atomic.AddInt32(&dialCount, 1)
+		return ssh.Dial("tcp", addr, &ssh.ClientConfig{User: "smartide", Auth: []ssh.AuthMethod{ssh.Password("")}, HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+	}, time.Millisecond*50, true)
+	defer sshClient.Close()
+
+	client, err := sshClient.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+
+	// 心跳失败后断开，并在下一次心跳时主动重连
+	deadline := time.Now().Add(time.Second * 5)
+	for atomic.LoadInt32(&dialCount) < 2 || !sshClient.IsConnected() {
+		if time.Now().After(deadline) {
+			t.Fatalf("keepalive did not reconnect, dial count = %v, last error = %v", atomic.LoadInt32(&dialCount), sshClient.LastError())
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+
+	sshClient.Close()
+	if _, err := sshClient.Client(); err == nil {
+		t.Errorf("Client() after Close() should fail")
+	}
+}
+
+func TestReconnectingSSHClient_SingleDial(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	addr := startTestSSHServer(t, make(chan string, 10))
+
+	var dialCount int32
+	sshClient := NewReconnectingSSHClient(nil, func() (*ssh.Client, error) {
+		atomic.AddInt32(&dialCount, 1)
+		time.Sleep(time.Millisecond * 100) // 慢速连接，比如等待输入密码
+		return ssh.Dial("tcp", addr, &ssh.ClientConfig{User: "smartide", Auth: []ssh.AuthMethod{ssh.Password("")}, HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+	}, time.Millisecond*50, false)
+	defer sshClient.Close()
+
+	// 同时使用时只连接一次
+	clients := make(chan *ssh.Client, 5)
+	for i := 0; i < cap(clients); i++ {
+		go func() {
+			client, err := sshClient.Client()
+			if err != nil {
+				t.Error(err)
+			}
+			clients <- client
+		}()
+	}
+	first := <-clients
+	for i := 1; i < cap(clients); i++ {
+		if client := <-clients; client != first {
+			t.Errorf("Client() returned different connections")
+		}
+	}
+	if got := atomic.LoadInt32(&dialCount); got != 1 {
+		t.Errorf("dial count = %v, want 1", got)
+	}
+
+	// 关闭后停止心跳，不会重连
+	sshClient.Close()
+	time.Sleep(time.Millisecond * 200)
+	if got := atomic.LoadInt32(&dialCount); got != 1 || sshClient.IsConnected() {
+		t.Errorf("dial count = %v, connected = %v after Close()", got, sshClient.IsConnected())
+	}
+}
+
+func TestSSHRemote_ExeSSHCommandContext(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	sshRemote := newTestFileSSHRemote(t, false)
+
+	output, err := sshRemote.ExeSSHCommandContext(context.Background(), "echo hello")
+	if err != nil || output != "hello" {
+		t.Fatalf("ExeSSHCommandContext() = %q, %v", output, err)
+	}
+
+	// 超时后通知远程主机结束命令
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	start := time.Now()
+	_, err = sshRemote.ExeSSHCommandContext(ctx, "exec sleep 10")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExeSSHCommandContext() error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed >= sshCommandAbortTimeout {
+		t.Errorf("remote command was not interrupted, elapsed %v", elapsed)
+	}
+
+	// 使用 SSHCommandTimeout
+	SSHCommandTimeout = time.Millisecond * 200
+	defer func() { SSHCommandTimeout = 0 }()
+	if _, err = sshRemote.ExeSSHCommand("exec sleep 10"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ExeSSHCommand() error = %v, want deadline exceeded", err)
+	}
+}
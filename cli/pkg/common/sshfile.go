@@ -0,0 +1,246 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+)
+
+// 大于该值的文件上传时输出进度
+const remoteUploadProgressThreshold = 10 * 1024 * 1024
+
+// 文件传输的进度
+type TransferProgressFunc func(transferred int64, total int64)
+
+// 创建 sftp 客户端，远程主机禁用了 sftp 子系统时返回错误，调用方使用 tar 传输
+func (instance *SSHRemote) newSftpClient() (*sftp.Client, error) {
+	connection, err := instance.GetConnection()
+	if err != nil {
+		return nil, err
+	}
+	sftpClient, err := sftp.NewClient(connection)
+	if err != nil {
+		SmartIDELog.Debug(fmt.Sprintf("sftp is unavailable on %v, fallback to tar over ssh: %v", instance.SSHHost, err))
+	}
+	return sftpClient, err
+}
+
+// 读取远程主机上的文件
+func (instance *SSHRemote) ReadFile(remoteFilePath string) ([]byte, error) {
+	remoteFilePath = instance.ConvertFilePath(remoteFilePath)
+
+	sftpClient, err := instance.newSftpClient()
+	if err != nil {
+		return instance.readFileByTar(remoteFilePath)
+	}
+	defer sftpClient.Close()
+
+	file, err := sftpClient.Open(remoteFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", remoteFilePath, err)
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// 写入远程主机上的文件，先写入同一目录下的临时文件再重命名，写入失败时不会破坏原有的文件
+func (instance *SSHRemote) WriteFile(remoteFilePath string, content []byte, perm os.FileMode) error {
+	return instance.writeFile(remoteFilePath, bytes.NewReader(content), int64(len(content)), perm, nil)
+}
+
+// 上传本地文件到远程主机，保留文件的权限
+func (instance *SSHRemote) UploadFile(localFilePath string, remoteFilePath string, progress TransferProgressFunc) error {
+	file, err := os.Open(localFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	return instance.writeFile(remoteFilePath, file, fileInfo.Size(), fileInfo.Mode().Perm(), progress)
+}
+
+// 在远程主机上创建文件夹，包括上级文件夹
+func (instance *SSHRemote) MkdirAll(remoteDirPath string) error {
+	remoteDirPath = instance.ConvertFilePath(remoteDirPath)
+
+	sftpClient, err := instance.newSftpClient()
+	if err != nil {
+		_, err = instance.ExeSSHCommand("mkdir -p " + ShellQuote(remoteDirPath))
+		return err
+	}
+	defer sftpClient.Close()
+	return sftpClient.MkdirAll(remoteDirPath)
+}
+
+func (instance *SSHRemote) writeFile(remoteFilePath string, reader io.Reader, size int64, perm os.FileMode, progress TransferProgressFunc) error {
+	remoteFilePath = instance.ConvertFilePath(remoteFilePath)
+	dirPath, fileName := path.Split(remoteFilePath)
+	if dirPath == "" {
+		dirPath = "."
+	}
+	tempFileName := fmt.Sprintf(".%v.%v.tmp", fileName, RandLowStr(6))
+	tempFilePath := path.Join(dirPath, tempFileName)
+	if progress != nil {
+		reader = &progressReader{Reader: reader, total: size, progress: progress}
+	}
+
+	sftpClient, err := instance.newSftpClient()
+	if err != nil {
+		return instance.writeFileByTar(dirPath, tempFileName, fileName, reader, size, perm)
+	}
+	defer sftpClient.Close()
+
+	//1. 写入临时文件
+	if err = sftpClient.MkdirAll(dirPath); err != nil {
+		return fmt.Errorf("%v: %w", dirPath, err)
+	}
+	file, err := sftpClient.OpenFile(tempFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("%v: %w", tempFilePath, err)
+	}
+	_, err = io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = sftpClient.Chmod(tempFilePath, perm)
+	}
+
+	//2. 重命名为目标文件，不支持 posix-rename 扩展时先删除目标文件
+	if err == nil {
+		if err = sftpClient.PosixRename(tempFilePath, remoteFilePath); err != nil {
+			sftpClient.Remove(remoteFilePath)
+			err = sftpClient.Rename(tempFilePath, remoteFilePath)
+		}
+	}
+	if err != nil {
+		sftpClient.Remove(tempFilePath)
+		return fmt.Errorf("%v: %w", remoteFilePath, err)
+	}
+
+	SmartIDELog.Debug(fmt.Sprintf("write file %v (%v bytes) to %v by sftp", remoteFilePath, size, instance.SSHHost))
+	return nil
+}
+
+// 通过 tar 写入文件，解压到临时文件后重命名
+func (instance *SSHRemote) writeFileByTar(dirPath string, tempFileName string, fileName string, reader io.Reader, size int64, perm os.FileMode) error {
+	session, err := instance.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close() // 远程命令提前退出时，结束写入
+	session.Stdin = pipeReader
+	go func() {
+		tarWriter := tar.NewWriter(pipeWriter)
+		err := tarWriter.WriteHeader(&tar.Header{Name: tempFileName, Mode: int64(perm), Size: size, ModTime: time.Now(), Typeflag: tar.TypeReg})
+		if err == nil {
+			_, err = io.Copy(tarWriter, reader)
+		}
+		if err == nil {
+			err = tarWriter.Close()
+		}
+		pipeWriter.CloseWithError(err)
+	}()
+
+	tempFilePath := path.Join(dirPath, tempFileName)
+	command := fmt.Sprintf(`mkdir -p %v && tar -xf - -C %v && chmod %o %v && mv -f %v %v || { rm -f %v; exit 1; }`,
+		ShellQuote(dirPath), ShellQuote(dirPath), perm, ShellQuote(tempFilePath),
+		ShellQuote(tempFilePath), ShellQuote(path.Join(dirPath, fileName)), ShellQuote(tempFilePath))
+	output, err := session.CombinedOutput(command)
+	if err != nil {
+		return fmt.Errorf("%v: %v %v", path.Join(dirPath, fileName), err, strings.TrimSpace(string(output)))
+	}
+
+	SmartIDELog.Debug(fmt.Sprintf("write file %v (%v bytes) to %v by tar", path.Join(dirPath, fileName), size, instance.SSHHost))
+	return nil
+}
+
+// 通过 tar 读取文件
+func (instance *SSHRemote) readFileByTar(remoteFilePath string) ([]byte, error) {
+	session, err := instance.newSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	dirPath, fileName := path.Split(remoteFilePath)
+	if dirPath == "" {
+		dirPath = "."
+	}
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err = session.Run(fmt.Sprintf(`tar -cf - -C %v %v`, ShellQuote(dirPath), ShellQuote(fileName))); err != nil {
+		return nil, fmt.Errorf("%v: %v %v", remoteFilePath, err, strings.TrimSpace(stderr.String()))
+	}
+
+	tarReader := tar.NewReader(&stdout)
+	if _, err = tarReader.Next(); err != nil {
+		return nil, fmt.Errorf("%v: %w", remoteFilePath, err)
+	}
+	return io.ReadAll(tarReader)
+}
+
+// 统计已经读取的字节数
+type progressReader struct {
+	io.Reader
+	transferred int64
+	total       int64
+	progress    TransferProgressFunc
+}
+
+func (reader *progressReader) Read(p []byte) (int, error) {
+	n, err := reader.Reader.Read(p)
+	if n > 0 {
+		reader.transferred += int64(n)
+		reader.progress(reader.transferred, reader.total)
+	}
+	return n, err
+}
+
+// 每完成 10% 输出一次上传的进度
+func newUploadProgressLogger(filePath string) TransferProgressFunc {
+	lastPercent := int64(0)
+	return func(transferred int64, total int64) {
+		if total <= 0 {
+			return
+		}
+		percent := transferred * 100 / total
+		if percent/10 > lastPercent/10 {
+			lastPercent = percent
+			SmartIDELog.InfoF(i18nInstance.Common.Info_upload_progress, filePath, percent, transferred/1024, total/1024)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// 测试用的 ssh 服务，在本机执行命令，isSftpEnabled 为 false 时拒绝 sftp 子系统
+func newTestFileSSHRemote(t *testing.T, isSftpEnabled bool) *SSHRemote {
+	_, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, _ := ssh.NewSignerFromKey(privateKey)
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					channel, channelReqs, _ := newChannel.Accept()
+					go serveTestFileSession(channel, channelReqs, isSftpEnabled)
+				}
+			}()
+		}
+	}()
+
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{User: "smartide", HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return &SSHRemote{SSHHost: "127.0.0.1", Connection: client}
+}
+
+// 命令在后台执行，执行期间可以接收 signal 请求
+func serveTestFileSession(channel ssh.Channel, reqs <-chan *ssh.Request, isSftpEnabled bool) {
+	defer channel.Close()
+	var cmd *exec.Cmd
+	for req := range reqs {
+		var payload struct{ Value string }
+		ssh.Unmarshal(req.Payload, &payload)
+		switch {
+		case req.Type == "subsystem" && payload.Value == "sftp" && isSftpEnabled:
+			req.Reply(true, nil)
+			server, _ := sftp.NewServer(channel)
+			server.Serve()
+			return
+		case req.Type == "exec" && cmd == nil:
+			req.Reply(true, nil)
+			cmd = exec.Command("sh", "-c", payload.Value)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = channel, channel, channel.Stderr()
+			if err := cmd.Start(); err != nil {
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{127}))
+				return
+			}
+			go func(cmd *exec.Cmd) {
+				status := uint32(0)
+				if err := cmd.Wait(); err != nil {
+					status = 1
+					var exitErr *exec.ExitError
+					if errors.As(err, &exitErr) {
+						status = uint32(exitErr.ExitCode())
+					}
+				}
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				channel.Close()
+			}(cmd)
+		case req.Type == "signal" && cmd != nil:
+			cmd.Process.Signal(os.Interrupt)
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func TestSSHRemote_WriteAndReadFile(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	if _, err := exec.LookPath("tar"); err != nil {
+		t.Skip("tar is required")
+	}
+
+	for _, isSftpEnabled := range []bool{true, false} {
+		sshRemote := newTestFileSSHRemote(t, isSftpEnabled)
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "sub dir", "docker-compose.yaml")
+
+		// 内容中的引号、$ 等字符不会被 shell 处理
+		content := "command: \"echo '$HOME' && `pwd`\"\n"
+		if err := sshRemote.WriteFile(filePath, []byte(content), 0600); err != nil {
+			t.Fatalf("sftp=%v WriteFile() error = %v", isSftpEnabled, err)
+		}
+		if err := sshRemote.WriteFile(filePath, []byte(content+"# overwrite\n"), 0600); err != nil {
+			t.Fatalf("sftp=%v WriteFile() overwrite error = %v", isSftpEnabled, err)
+		}
+		got, err := sshRemote.ReadFile(filePath)
+		if err != nil || string(got) != content+"# overwrite\n" {
+			t.Errorf("sftp=%v ReadFile() = %q, %v", isSftpEnabled, got, err)
+		}
+		if fileInfo, err := os.Stat(filePath); err != nil || fileInfo.Mode().Perm() != 0600 {
+			t.Errorf("sftp=%v file mode = %v, %v", isSftpEnabled, fileInfo.Mode().Perm(), err)
+		}
+
+		// 临时文件已经被重命名
+		entries, _ := os.ReadDir(filepath.Dir(filePath))
+		if len(entries) != 1 {
+			t.Errorf("sftp=%v files = %v, want only docker-compose.yaml", isSftpEnabled, len(entries))
+		}
+
+		// 上传文件，并输出进度
+		localFilePath := filepath.Join(t.TempDir(), "agent")
+		os.WriteFile(localFilePath, []byte(strings.Repeat("a", 1024)), 0755)
+		var transferred int64
+		err = sshRemote.UploadFile(localFilePath, filepath.Join(dir, "bin", "agent"), func(n int64, total int64) { transferred = n })
+		if err != nil || transferred != 1024 {
+			t.Errorf("sftp=%v UploadFile() transferred = %v, %v", isSftpEnabled, transferred, err)
+		}
+		if fileInfo, err := os.Stat(filepath.Join(dir, "bin", "agent")); err != nil || fileInfo.Mode().Perm() != 0755 {
+			t.Errorf("sftp=%v uploaded file mode = %v, %v", isSftpEnabled, fileInfo.Mode().Perm(), err)
+		}
+
+		// 文件不存在
+		if _, err := sshRemote.ReadFile(filepath.Join(dir, "not_exist")); err == nil {
+			t.Errorf("sftp=%v ReadFile() not exist file should fail", isSftpEnabled)
+		}
+	}
+}
@@ -22,7 +22,6 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"net"
 	"strings"
 	"time"
 
@@ -61,14 +60,9 @@ func NewSshClientConfig(sshUser, sshPassword, sshType, sshKey, sshKeyPassword st
 		return nil, errors.New("ssh_user can not be empty")
 	}
 	config = &ssh.ClientConfig{
-		Timeout: time.Second * 3,
-		User:    sshUser,
-		//HostKeyCallback: ssh.InsecureIgnoreHostKey(), //这个可以， 但是不够安全
-		//HostKeyCallback: hostKeyCallBackFunc(h.Host),
-		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-			// use OpenSSH's known_hosts file if you care about host validation
-			return nil
-		},
+		Timeout:         time.Second * 3,
+		User:            sshUser,
+		HostKeyCallback: NewHostKeyCallback(), // 通过 known_hosts 校验主机公钥
 	}
 	switch sshType {
 	case "password":
@@ -89,5 +83,6 @@ func CreateSimpleSshClient(sshUser, sshPassword, sshAddr string) (*ssh.Client, e
 	if err != nil {
 		return nil, fmt.Errorf("cluster jumper proxy ssh config failed:%s", err)
 	}
+	targetConfig.HostKeyAlgorithms = GetKnownHostKeyAlgorithms(sshAddr)
 	return ssh.Dial("tcp", sshAddr, targetConfig)
 }
@@ -0,0 +1,261 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/pkg/ssh_config"
+	"golang.org/x/crypto/ssh"
+)
+
+// 跳板机
+type SSHJumpHost struct {
+	Host     string
+	Port     int
+	UserName string
+	// 本机上的私钥文件，来自 ~/.ssh/config 中的 IdentityFile
+	IdentityFile string
+}
+
+func (jumpHost SSHJumpHost) Addr() string {
+	return net.JoinHostPort(jumpHost.Host, strconv.Itoa(jumpHost.Port))
+}
+
+// 读取 ssh config 的方法，默认读取 ~/.ssh/config 以及 /etc/ssh/ssh_config
+type SSHConfigGetFunc func(alias string, key string) string
+
+// 解析跳板机，格式与 ssh 的 ProxyJump 一致，即 [user@]host[:port]，多个跳板机用逗号分隔，按顺序连接
+// host 可以是 ~/.ssh/config 中的别名，未指定的用户名、端口、私钥从 ~/.ssh/config 中读取
+func ParseSSHJumpHosts(value string, getConfig SSHConfigGetFunc) (jumpHosts []SSHJumpHost, err error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.ToLower(value) == "none" {
+		return nil, nil
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimPrefix(strings.TrimSpace(item), "ssh://")
+		if item == "" {
+			continue
+		}
+		jumpHost := SSHJumpHost{}
+
+		//1. 用户名
+		if index := strings.LastIndex(item, "@"); index >= 0 {
+			jumpHost.UserName = item[:index]
+			item = item[index+1:]
+		}
+
+		//2. 主机以及端口，ipv6 地址需要使用 [] 包含
+		alias := item
+		if strings.HasPrefix(item, "[") || strings.Count(item, ":") == 1 {
+			host, portStr, splitErr := net.SplitHostPort(item)
+			if splitErr != nil {
+				if !strings.HasPrefix(item, "[") || !strings.HasSuffix(item, "]") {
+					return nil, fmt.Errorf(i18nInstance.Common.Err_ssh_jump_host_invalid, item)
+				}
+				host = strings.Trim(item, "[]")
+			}
+			alias = host
+			if portStr != "" {
+				jumpHost.Port, err = strconv.Atoi(portStr)
+				if err != nil || jumpHost.Port <= 0 {
+					return nil, fmt.Errorf(i18nInstance.Common.Err_ssh_jump_host_invalid, item)
+				}
+			}
+		}
+		if alias == "" {
+			return nil, fmt.Errorf(i18nInstance.Common.Err_ssh_jump_host_invalid, item)
+		}
+
+		//3. ~/.ssh/config 中的配置
+		jumpHost.Host = alias
+		if getConfig != nil {
+			if hostName := getConfig(alias, "HostName"); hostName != "" {
+				jumpHost.Host = hostName
+			}
+			if jumpHost.Port <= 0 {
+				jumpHost.Port, _ = strconv.Atoi(getConfig(alias, "Port"))
+			}
+			if jumpHost.UserName == "" {
+				jumpHost.UserName = getConfig(alias, "User")
+			}
+			if identityFile := getConfig(alias, "IdentityFile"); identityFile != ssh_config.Default("IdentityFile") {
+				jumpHost.IdentityFile = identityFile
+			}
+		}
+		if jumpHost.Port <= 0 {
+			jumpHost.Port = 22
+		}
+		if jumpHost.UserName == "" { // 与 ssh 一致，默认使用本机的用户名
+			if current, err := user.Current(); err == nil {
+				jumpHost.UserName = current.Username
+			}
+		}
+
+		jumpHosts = append(jumpHosts, jumpHost)
+	}
+
+	return jumpHosts, nil
+}
+
+// 获取连接主机时使用的跳板机，没有指定时使用 ~/.ssh/config 中的 ProxyJump
+func getSSHJumpHosts(sshHost string, jumpHosts string) ([]SSHJumpHost, error) {
+	if jumpHosts == "" && !isLoopbackHost(sshHost) {
+		jumpHosts = ssh_config.Get(sshHost, "ProxyJump")
+	}
+	return ParseSSHJumpHosts(jumpHosts, ssh_config.Get)
+}
+
+// 依次通过跳板机连接到目标主机，跳板机使用公钥认证
+func dialWithJumpHosts(addr string, clientConfig *ssh.ClientConfig, jumpHosts []SSHJumpHost) (*ssh.Client, error) {
+	if len(jumpHosts) == 0 {
+		return ssh.Dial("tcp", addr, clientConfig)
+	}
+
+	//1. 依次连接跳板机，后一个跳板机通过前一个跳板机连接
+	jumpClients := []*ssh.Client{}
+	closeJumpClients := func() {
+		for i := len(jumpClients) - 1; i >= 0; i-- {
+			jumpClients[i].Close()
+		}
+	}
+	for _, jumpHost := range jumpHosts {
+		SmartIDELog.Debug(fmt.Sprintf("ssh jump host: %v@%v", jumpHost.UserName, jumpHost.Addr()))
+		signers, agentConn, err := getSSHSigners("", jumpHost.IdentityFile, promptPassphrase)
+		if err != nil {
+			if agentConn != nil {
+				agentConn.Close()
+			}
+			closeJumpClients()
+			return nil, fmt.Errorf(i18nInstance.Common.Err_ssh_jump_host_connect, jumpHost.Addr(), err)
+		}
+		jumpConfig := &ssh.ClientConfig{
+			User:            jumpHost.UserName,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+			Timeout:         10 * time.Second,         // 10 秒超时
+			HostKeyCallback: NewJumpHostKeyCallback(), // 通过 known_hosts 校验主机公钥
+			// 优先使用 known_hosts 中记录的公钥类型
+			HostKeyAlgorithms: GetKnownHostKeyAlgorithms(jumpHost.Addr()),
+		}
+
+		var client *ssh.Client
+		if len(jumpClients) == 0 {
+			client, err = ssh.Dial("tcp", jumpHost.Addr(), jumpConfig)
+		} else {
+			client, err = dialThroughClient(jumpClients[len(jumpClients)-1], jumpHost.Addr(), jumpConfig, nil)
+		}
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		if err != nil {
+			closeJumpClients()
+			return nil, fmt.Errorf(i18nInstance.Common.Err_ssh_jump_host_connect, jumpHost.Addr(), err)
+		}
+		jumpClients = append(jumpClients, client)
+	}
+
+	//2. 通过最后一个跳板机连接目标主机，关闭目标主机的连接时同时关闭所有跳板机的连接
+	client, err := dialThroughClient(jumpClients[len(jumpClients)-1], addr, clientConfig, jumpClients)
+	if err != nil {
+		closeJumpClients()
+		return nil, err
+	}
+	return client, nil
+}
+
+// 通过已有的 ssh 连接建立到下一个主机的 ssh 连接
+func dialThroughClient(through *ssh.Client, addr string, clientConfig *ssh.ClientConfig, jumpClients []*ssh.Client) (*ssh.Client, error) {
+	conn, err := through.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := newSSHClientConn(&jumpConn{Conn: conn, jumpClients: jumpClients}, addr, clientConfig)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// 在已有的连接上进行 ssh 握手，超时时间与直接连接一致（clientConfig.Timeout）
+// ssh 通道不支持 SetDeadline，超时后关闭连接；确认主机公钥时等待用户输入，不计算在超时时间内
+func newSSHClientConn(conn net.Conn, addr string, clientConfig *ssh.ClientConfig) (ssh.Conn, <-chan ssh.NewChannel, <-chan *ssh.Request, error) {
+	timeout := clientConfig.Timeout
+	if timeout <= 0 {
+		return ssh.NewClientConn(conn, addr, clientConfig)
+	}
+
+	var isTimeout int32
+	timer := time.AfterFunc(timeout, func() {
+		atomic.StoreInt32(&isTimeout, 1)
+		conn.Close()
+	})
+	config := *clientConfig
+	if hostKeyCallback := clientConfig.HostKeyCallback; hostKeyCallback != nil {
+		config.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			timer.Stop()
+			defer timer.Reset(timeout)
+			return hostKeyCallback(hostname, remote, key)
+		}
+	}
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, &config)
+	timer.Stop()
+	if atomic.LoadInt32(&isTimeout) == 1 {
+		if err == nil {
+			c.Close()
+		}
+		return nil, nil, nil, fmt.Errorf("ssh: handshake with %v timed out after %v", addr, timeout)
+	}
+	return c, chans, reqs, err
+}
+
+// 经过跳板机的连接，关闭时同时关闭跳板机的连接
+type jumpConn struct {
+	net.Conn
+	jumpClients []*ssh.Client
+}
+
+func (conn *jumpConn) Close() error {
+	err := conn.Conn.Close()
+	for i := len(conn.jumpClients) - 1; i >= 0; i-- {
+		conn.jumpClients[i].Close()
+	}
+	return err
+}
+
+// 是否为本机地址
+func isLoopbackHost(hostname string) bool {
+	host, _, err := net.SplitHostPort(hostname)
+	if err != nil {
+		host = hostname
+	}
+	if strings.ToLower(host) == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
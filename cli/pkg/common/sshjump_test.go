@@ -0,0 +1,275 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseSSHJumpHosts(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	getConfig := func(alias string, key string) string {
+		config := map[string]map[string]string{
+			"bastion": {"HostName": "10.0.0.1", "User": "admin", "Port": "2222", "IdentityFile": "~/.ssh/id_bastion"},
+		}
+		if value, ok := config[alias][key]; ok {
+			return value
+		}
+		if key == "Port" {
+			return "22"
+		}
+		return ""
+	}
+
+	tests := []struct {
+		value   string
+		want    []SSHJumpHost
+		wantErr bool
+	}{
+		{value: "", want: nil},
+		{value: "none", want: nil},
+		{value: "root@192.168.1.2", want: []SSHJumpHost{{Host: "192.168.1.2", Port: 22, UserName: "root"}}},
+		{value: "root@192.168.1.2:2022, ops@[fe80::1]:22", want: []SSHJumpHost{
+			{Host: "192.168.1.2", Port: 2022, UserName: "root"},
+			{Host: "fe80::1", Port: 22, UserName: "ops"},
+		}},
+		{value: "bastion", want: []SSHJumpHost{{Host: "10.0.0.1", Port: 2222, UserName: "admin", IdentityFile: "~/.ssh/id_bastion"}}},
+		{value: "smartide@bastion:22", want: []SSHJumpHost{{Host: "10.0.0.1", Port: 22, UserName: "smartide", IdentityFile: "~/.ssh/id_bastion"}}},
+		{value: "root@host:abc", wantErr: true},
+		{value: "root@:22", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSSHJumpHosts(tt.value, getConfig)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSSHJumpHosts(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ParseSSHJumpHosts(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+// 测试用的 ssh 服务，支持执行命令（固定输出 ok）以及 direct-tcpip 转发，连接断开时写入 closed
+func startTestSSHServer(t *testing.T, closed chan<- string) string {
+	addr, _ := startTestSSHServerWithHostKey(t, closed)
+	return addr
+}
+
+// 启动测试用的 ssh 服务，返回监听的地址以及主机公钥
+func startTestSSHServerWithHostKey(t *testing.T, closed chan<- string) (string, ssh.PublicKey) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback:  func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) { return nil, nil },
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) { return nil, nil },
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(conn, config, listener.Addr().String(), closed)
+		}
+	}()
+	return listener.Addr().String(), hostKey.PublicKey()
+}
+
+func serveTestSSHConn(conn net.Conn, config *ssh.ServerConfig, addr string, closed chan<- string) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		serverConn.Wait()
+		closed <- addr
+	}()
+
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "direct-tcpip":
+			var payload struct {
+				Host     string
+				Port     uint32
+				OrigHost string
+				OrigPort uint32
+			}
+			if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, fmt.Sprint(payload.Port)))
+			if err != nil {
+				newChannel.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			channel, channelReqs, _ := newChannel.Accept()
+			go ssh.DiscardRequests(channelReqs)
+			go func() {
+				io.Copy(channel, target)
+				channel.Close()
+			}()
+			go func() {
+				io.Copy(target, channel)
+				target.Close()
+			}()
+
+		case "session":
+			channel, channelReqs, _ := newChannel.Accept()
+			go func() {
+				for req := range channelReqs {
+					req.Reply(req.Type == "exec", nil)
+					if req.Type == "exec" {
+						channel.Write([]byte("ok"))
+						channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+						channel.Close()
+					}
+				}
+			}()
+
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+		}
+	}
+}
+
+func TestDialWithJumpHosts(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	t.Setenv("SSH_AUTH_SOCK", "")
+	_, identityFile := newTestIdentityFile(t, t.TempDir(), "id_jump", "")
+
+	t.Setenv("HOME", t.TempDir()) // 不修改用户的 known_hosts
+
+	closed := make(chan string, 10)
+	firstAddr, firstHostKey := startTestSSHServerWithHostKey(t, closed)
+	secondAddr, secondHostKey := startTestSSHServerWithHostKey(t, closed)
+	targetAddr := startTestSSHServer(t, closed)
+
+	// 跳板机在本机端口上，也需要校验公钥，通过 host=fingerprint 信任
+	originFingerprint := SSHHostKeyFingerprint
+	defer func() { SSHHostKeyFingerprint = originFingerprint }()
+	SSHHostKeyFingerprint = fmt.Sprintf("%v=%v,%v=%v", firstAddr, ssh.FingerprintSHA256(firstHostKey),
+		secondAddr, ssh.FingerprintSHA256(secondHostKey))
+
+	jumpHosts := []SSHJumpHost{}
+	for _, addr := range []string{firstAddr, secondAddr} {
+		host, port, _ := net.SplitHostPort(addr)
+		jumpHost := SSHJumpHost{Host: host, UserName: "jump", IdentityFile: identityFile}
+		fmt.Sscan(port, &jumpHost.Port)
+		jumpHosts = append(jumpHosts, jumpHost)
+	}
+
+	clientConfig := &ssh.ClientConfig{
+		User:            "smartide",
+		Auth:            []ssh.AuthMethod{ssh.Password("p@ssw0rd")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+	client, err := dialWithJumpHosts(targetAddr, clientConfig, jumpHosts)
+	if err != nil {
+		t.Fatalf("dialWithJumpHosts() error = %v", err)
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := session.Output("echo ok")
+	if err != nil || string(output) != "ok" {
+		t.Errorf("session.Output() = %q, %v", output, err)
+	}
+
+	// 关闭目标主机的连接时，跳板机的连接也需要关闭
+	client.Close()
+	closedAddrs := map[string]bool{}
+	timeout := time.After(5 * time.Second)
+	for len(closedAddrs) < 3 {
+		select {
+		case addr := <-closed:
+			closedAddrs[addr] = true
+		case <-timeout:
+			t.Fatalf("connections not closed, closed = %v", closedAddrs)
+		}
+	}
+}
+
+func TestDialThroughClient_HandshakeTimeout(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	jumpAddr := startTestSSHServer(t, make(chan string, 10))
+	jumpClient, err := ssh.Dial("tcp", jumpAddr, &ssh.ClientConfig{User: "smartide", Auth: []ssh.AuthMethod{ssh.Password("")}, HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jumpClient.Close()
+
+	// 目标端口可以连接，但不进行 ssh 握手
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	clientConfig := &ssh.ClientConfig{User: "smartide", HostKeyCallback: ssh.InsecureIgnoreHostKey(), Timeout: time.Millisecond * 200}
+	result := make(chan error, 1)
+	go func() {
+		client, err := dialThroughClient(jumpClient, silent.Addr().String(), clientConfig, nil)
+		if err == nil {
+			client.Close()
+		}
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Errorf("dialThroughClient() should fail when handshake timed out")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("dialThroughClient() handshake did not time out")
+	}
+}
@@ -20,9 +20,11 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"log"
+	"net"
 	"os/exec"
 	"path"
 
@@ -30,17 +32,14 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
-	scp "github.com/bramvdbogaerde/go-scp"
 	"github.com/howeyc/gopass"
 	"github.com/leansoftX/smartide-cli/internal/apk/i18n"
-	"github.com/pkg/sftp"
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/sync/errgroup"
@@ -54,13 +53,15 @@ type SSHRemote struct {
 	SSHKey         string
 	SSHKeyPassword string
 	SSHKeyPath     string
+	SSHJumpHosts   string // 跳板机，格式与 ssh 的 ProxyJump 一致
 	Connection     *ssh.Client
+	SSHClient      *ReconnectingSSHClient // 自动重连的连接，SSHRemote 的副本共用
 }
 
 var i18nInstance = i18n.GetInstance()
 
 // 实例
-func NewSSHRemote(host string, port int, userName, password string, idRsa string) (instance SSHRemote, err error) {
+func NewSSHRemote(host string, port int, userName, password string, idRsa string, identityFile string, jumpHosts string) (instance SSHRemote, err error) {
 
 	instance = SSHRemote{}
 
@@ -69,24 +70,96 @@ func NewSSHRemote(host string, port int, userName, password string, idRsa string
 		instance.SSHPort = port
 		instance.SSHUserName = userName
 		instance.SSHPassword = password
+		instance.SSHKeyPath = identityFile
+		instance.SSHJumpHosts = jumpHosts
 
-		connection, err := connectionDial(host, port, userName, password, idRsa)
+		connection, err := connectionDial(host, port, userName, password, idRsa, identityFile, jumpHosts)
 		if err != nil {
 			return instance, err
 		}
 
 		instance.Connection = connection
+		instance.SSHClient = NewReconnectingSSHClient(connection, func() (*ssh.Client, error) {
+			SmartIDELog.Debug(fmt.Sprintf("reconnect to %v:%v", host, port))
+			return connectionDial(host, port, userName, password, idRsa, identityFile, jumpHosts)
+		}, SSHKeepAliveInterval, false)
+	}
+
+	return instance, nil
+}
+
+// 本机端口上的开发容器，ssh 端口由 SmartIDE 绑定，容器重建后公钥会改变，不校验主机公钥
+func NewDevContainerSSHRemote(host string, port int, userName, password string) (instance SSHRemote, err error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	dial := func() (*ssh.Client, error) {
+		return DialSSHThrough(net.Dial, addr, userName, password)
+	}
+	connection, err := dial()
+	if err != nil {
+		return instance, err
 	}
 
+	instance = SSHRemote{SSHHost: host, SSHPort: port, SSHUserName: userName, SSHPassword: password, Connection: connection}
+	instance.SSHClient = NewReconnectingSSHClient(connection, func() (*ssh.Client, error) {
+		SmartIDELog.Debug(fmt.Sprintf("reconnect to %v", addr))
+		return dial()
+	}, SSHKeepAliveInterval, false)
 	return instance, nil
 }
 
+// 建立 ssh 连接，不会自动重连，由调用方管理连接
+func DialSSH(host string, port int, userName, password string, idRsa string, identityFile string, jumpHosts string) (*ssh.Client, error) {
+	return connectionDial(host, port, userName, password, idRsa, identityFile, jumpHosts)
+}
+
+// 自动重连的 ssh 连接，直接赋值 Connection 创建的实例不会重连
+func (instance *SSHRemote) GetSSHClient() *ReconnectingSSHClient {
+	if instance.SSHClient == nil && instance.Connection != nil {
+		instance.SSHClient = NewReconnectingSSHClient(instance.Connection, nil, 0, false)
+	}
+	return instance.SSHClient
+}
+
+// 当前可用的 ssh 连接，断开时重新连接
+func (instance *SSHRemote) GetConnection() (*ssh.Client, error) {
+	sshClient := instance.GetSSHClient()
+	if sshClient == nil {
+		return nil, errors.New(i18nInstance.Common.Err_ssh_dial_none)
+	}
+	connection, err := sshClient.Client()
+	if err != nil {
+		return nil, err
+	}
+	instance.Connection = connection
+	return connection, nil
+}
+
+// 创建会话，连接断开时重新连接
+func (instance *SSHRemote) newSession() (*ssh.Session, error) {
+	sshClient := instance.GetSSHClient()
+	if sshClient == nil {
+		return nil, errors.New(i18nInstance.Common.Err_ssh_dial_none)
+	}
+	return sshClient.NewSession()
+}
+
+// 关闭 ssh 连接
+func (instance *SSHRemote) Close() error {
+	if instance.SSHClient != nil {
+		return instance.SSHClient.Close()
+	}
+	if instance.Connection != nil {
+		return instance.Connection.Close()
+	}
+	return nil
+}
+
 // 验证
-func (instance *SSHRemote) CheckDail(host string, port int, userName, password string, idRsa string) error {
+func (instance *SSHRemote) CheckDail(host string, port int, userName, password string, idRsa string, identityFile string, jumpHosts string) error {
 
 	if (instance.Connection == &ssh.Client{}) || instance.Connection == nil {
 
-		connection, err := connectionDial(host, port, userName, password, idRsa)
+		connection, err := connectionDial(host, port, userName, password, idRsa, identityFile, jumpHosts)
 
 		if err != nil {
 			return err
@@ -289,44 +362,29 @@ func (instance *SSHRemote) CopyDirectory(srcDirPath string, remoteDestDirPath st
 
 // 获取文件内容
 func (instance *SSHRemote) GetContent(filepath string) string {
-
-	filepath = instance.ConvertFilePath(filepath)
-
-	command := fmt.Sprintf(`cat "%v"`, filepath)
-	outContent, err := instance.ExeSSHCommand(command)
+	content, err := instance.ReadFile(filepath)
 	CheckError(err)
 
-	return outContent
+	return strings.Trim(string(content), "\n")
 }
 
-// 创建文件，如果存在就附加内容
+// 创建文件，如果存在就附加内容，与 echo >> 一致，每次附加的内容后都有换行
 func (sshRemote *SSHRemote) CreateFileByEcho(filepath string, content string) error {
+	var existContent []byte
+	if sshRemote.IsFileExist(filepath) {
+		var err error
+		existContent, err = sshRemote.ReadFile(filepath)
+		if err != nil {
+			return err
+		}
+	}
 
-	filepath = sshRemote.ConvertFilePath(filepath)
-
-	/* 	// 检查并创建文件夹
-	dir := path.Dir(filepath)
-	err := sshRemote.CheckAndCreateDir(dir)
-	if err != nil {
-		return err
-	} */
-
-	// 创建文件
-	content = strings.ReplaceAll(content, "\"", "\\\"")
-	command := fmt.Sprintf(`sudo echo "%v" >> %v`, content, filepath)
-	_, err := sshRemote.ExeSSHCommand(command)
-
-	return err
+	return sshRemote.WriteFile(filepath, append(existContent, []byte(content+"\n")...), 0644)
 }
 
 // 检查并创建文件夹
 func (sshRemote *SSHRemote) CheckAndCreateDir(dir string) error {
-	dir = sshRemote.ConvertFilePath(dir)
-
-	command := fmt.Sprintf("[[ -f \"%v\" ]] && echo \"1\" || mkdir -p \"%v\"", dir, dir)
-	_, err := sshRemote.ExeSSHCommand(command)
-	return err
-
+	return sshRemote.MkdirAll(dir)
 }
 
 // 转换文件路径为远程主机支持的
@@ -347,7 +405,7 @@ func (instance *SSHRemote) ConvertFilePath(filepath string) (newFilepath string)
 	return newFilepath
 }
 
-// 检测远程服务器的环境，是否安装docker、docker-compose、git
+// 检测远程服务器的环境，是否安装docker、git
 func (instance *SSHRemote) CheckRemoteEnv() error {
 	var errMsg []string
 
@@ -370,18 +428,9 @@ func (instance *SSHRemote) CheckRemoteEnv() error {
 		return errors.New("请检查当前环境是否满足要求，参考：https://smartide.cn/zh/docs/install/docker/linux/")
 	}
 
-	//1.3. docker-compose
-	output, err = instance.ExeSSHCommand("docker-compose version")
-	if err != nil ||
-		(!strings.Contains(strings.ToLower(output), "docker-compose version") && !strings.Contains(strings.ToLower(output), "docker compose version")) ||
-		strings.Contains(strings.ToLower(output), "error:") {
-		if err != nil {
-			SmartIDELog.Importance(i18nInstance.Main.Err_env_Docker_Compose, err.Error(), output)
-		}
-		return errors.New("请检查当前环境是否满足要求，参考：https://smartide.cn/zh/docs/install/docker/linux/")
-	}
+	// docker compose 命令行只在无法通过 ssh 访问 docker api 时使用，在运行时检查
 
-	//1.4. 默认的shell 是否为bash
+	//1.3. 默认的shell 是否为bash
 	output, err = instance.ExeSSHCommand("echo $SHELL")
 	if err != nil || !strings.Contains(output, "/bash") {
 		if err != nil {
@@ -402,19 +451,6 @@ func (instance *SSHRemote) CheckRemoteEnv() error {
 		SmartIDELog.Debug(err.Error())
 	}
 
-	// clone 代码库时，不提示：“are you sure you want to continue connecting (yes/no) ”
-	sshConfig, err := instance.ExeSSHCommand("[[ -f \".ssh/config\" ]] && cat ~/.ssh/config || echo \"\"")
-	if err != nil {
-		return err
-	}
-	if !strings.Contains(sshConfig, "StrictHostKeyChecking no") { // 不包含就添加
-		command := "if [ ! -d ～/.ssh ]; then mkdir -p ~/.ssh; fi && echo -e \"StrictHostKeyChecking no\n\" >> ~/.ssh/config"
-		_, err := instance.ExeSSHCommand(command)
-		if err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
@@ -456,7 +492,8 @@ func (instance *SSHRemote) GitClone(gitCloneUrl string, workSpaceDir string, no
 
 	// 执行clone
 	//gitDirPath := strings.Replace(FilePahtJoin4Linux(workSpaceDir, ".git"), "~/", "", -1) // 把路径变成 “a/b/c” 的形式，不支持 “./a/b/c”、“～/a/b/c”、“./a/b/c”
-	GIT_SSH_COMMAND := fmt.Sprintf(`GIT_SSH_COMMAND='ssh -i ~/.ssh/id_rsa_%s_%s -o IdentitiesOnly=yes'`, userName, no)
+	// clone 代码库时，首次连接的 git 服务器自动记录公钥，公钥改变时仍然报错
+	GIT_SSH_COMMAND := fmt.Sprintf(`GIT_SSH_COMMAND='ssh -i ~/.ssh/id_rsa_%s_%s -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new'`, userName, no)
 
 	cloneCommand := fmt.Sprintf(`%s git clone %v %v`,
 		GIT_SSH_COMMAND, gitCloneUrl, workSpaceDir) // .git 文件如果不存在，在需要git clone
@@ -661,7 +698,7 @@ func (instance *SSHRemote) sshSaveEmptyPassphrase() {
 		return
 	}
 
-	session, err := instance.Connection.NewSession()
+	session, err := instance.newSession()
 	CheckError(err)
 	defer session.Close()
 
@@ -728,44 +765,16 @@ func (instance *SSHRemote) ExeSSHCommand(sshCommand string) (outContent string,
 	return instance.ExeSSHCommandConsole(sshCommand)
 }
 
-// 复制文件
+// 复制文件，大文件会输出上传的进度
 func (instance *SSHRemote) CopyFile(localFilePath string, remoteFilepath string) error {
-	var (
-		err        error
-		sftpClient *sftp.Client
-	)
-
-	// create sftp client
-	if sftpClient, err = sftp.NewClient(instance.Connection); err != nil {
+	var progress TransferProgressFunc
+	if fileInfo, err := os.Stat(localFilePath); err != nil {
 		return err
+	} else if fileInfo.Size() > remoteUploadProgressThreshold {
+		progress = newUploadProgressLogger(localFilePath)
 	}
-	defer sftpClient.Close()
 
-	//Local file path and folder on remote machine for testing
-	srcFile, err := os.Open(localFilePath)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer srcFile.Close()
-
-	// 创建目录
-	parent := filepath.Dir(remoteFilepath)
-	path := string(filepath.Separator)
-	dirs := strings.Split(parent, path)
-	for _, dir := range dirs {
-		path = filepath.Join(path, dir)
-		_ = sftpClient.Mkdir(path)
-	}
-
-	//
-	dstFile, err := sftpClient.Create(remoteFilepath)
-	if err != nil {
-		return err
-	}
-	defer dstFile.Close()
-
-	//
-	_, err = io.Copy(dstFile, srcFile)
+	err := instance.UploadFile(localFilePath, remoteFilepath, progress)
 	if err != nil {
 		return err
 	}
@@ -776,26 +785,37 @@ func (instance *SSHRemote) CopyFile(localFilePath string, remoteFilepath string)
 
 // 执行ssh command，在session模式下，standard output 只能在执行结束的时候获取到
 func (instance *SSHRemote) ExeSSHCommandConsole(sshCommand string) (outContent string, err error) {
-	return instance.exeSSHCommandConsole(sshCommand, false)
+	return instance.exeSSHCommandConsole(context.Background(), sshCommand, false)
 }
 
 func (instance *SSHRemote) ExeSSHCommandConsoleAndEncryptedOutput(sshCommand string) (outContent string, err error) {
-	return instance.exeSSHCommandConsole(sshCommand, true)
+	return instance.exeSSHCommandConsole(context.Background(), sshCommand, true)
+}
+
+// 执行ssh command，ctx 取消时结束远程命令
+func (instance *SSHRemote) ExeSSHCommandContext(ctx context.Context, sshCommand string) (outContent string, err error) {
+	return instance.exeSSHCommandConsole(ctx, sshCommand, false)
 }
 
-func (instance *SSHRemote) exeSSHCommandConsole(sshCommand string, isEncryptedOutput bool) (outContent string, err error) {
+func (instance *SSHRemote) exeSSHCommandConsole(parentCtx context.Context, sshCommand string, isEncryptedOutput bool) (outContent string, err error) {
 	if len(sshCommand) <= 0 {
 		return "", nil
 	}
 
-	session, err := instance.Connection.NewSession()
+	session, err := instance.newSession()
 	CheckError(err)
+	defer session.Close()
 
-	// 在ssh主机上执行命令
+	// 在ssh主机上执行命令，超时或者 Ctrl-C 时结束远程命令
 	SmartIDELog.Debug(fmt.Sprintf("SSH Console %v:%v -> %v ......", instance.SSHHost, instance.SSHPort, sshCommand))
-	out, err := session.CombinedOutput(sshCommand)
+	ctx, cancel := newSSHCommandContext(parentCtx)
+	defer cancel()
+	var out []byte
+	err = runSSHSessionContext(ctx, session, sshCommand, func() (err error) {
+		out, err = session.CombinedOutput(sshCommand)
+		return err
+	})
 	outContent = string(out)
-	defer session.Close()
 
 	// 空错误判断
 	if err != nil {
@@ -823,13 +843,18 @@ func (instance *SSHRemote) ExecSSHCommandRealTime(sshCommand string) (err error)
 
 // 实时执行，带函数
 func (instance *SSHRemote) ExecSSHCommandRealTimeFunc(sshCommand string, customExecuteFun func(output string) error) (err error) {
+	return instance.ExecSSHCommandRealTimeContext(context.Background(), sshCommand, customExecuteFun)
+}
+
+// 实时执行，ctx 取消、超时或者 Ctrl-C 时结束远程命令
+func (instance *SSHRemote) ExecSSHCommandRealTimeContext(parentCtx context.Context, sshCommand string, customExecuteFun func(output string) error) (err error) {
 
 	SmartIDELog.Debug(fmt.Sprintf("SSH RealTime %v:%v -> %v", instance.SSHHost, instance.SSHPort, sshCommand))
 	if (*instance == SSHRemote{}) {
 		return errors.New(i18nInstance.Common.Err_ssh_dial_none)
 	}
 
-	session, err := instance.Connection.NewSession()
+	session, err := instance.newSession()
 	CheckError(err)
 	defer session.Close()
 
@@ -907,7 +932,12 @@ func (instance *SSHRemote) ExecSSHCommandRealTimeFunc(sshCommand string, customE
 	group := new(errgroup.Group)
 	group.Go(func1)
 
-	err = session.Run(sshCommand)
+	ctx, cancel := newSSHCommandContext(parentCtx)
+	defer cancel()
+	runErr := runSSHSessionContext(ctx, session, sshCommand, func() error {
+		return session.Run(sshCommand)
+	})
+	err = runErr
 	close(chExit)
 
 	err2 := group.Wait()
@@ -916,98 +946,28 @@ func (instance *SSHRemote) ExecSSHCommandRealTimeFunc(sshCommand string, customE
 	} else {
 		err = err2
 	}
+	if ctx.Err() != nil && err == nil { // 超时或者取消时，返回取消的原因
+		err = runErr
+	}
 	fmt.Println()
 
 	return err
 }
 
+// 上传多个文件，key 为本地文件路径，value 为远程主机上的文件路径
 func (instance *SSHRemote) RemoteUpload(filesMaps map[string]string) (err error) {
-	// initialize SSH connection
-	var clientConfig *ssh.ClientConfig
-
-	if len(instance.SSHPassword) > 0 {
-
-		if len(strings.TrimSpace(instance.SSHPassword)) == 0 {
-			SmartIDELog.Error(i18nInstance.Common.Err_ssh_password_required)
-		}
-
-		clientConfig = &ssh.ClientConfig{
-			User: instance.SSHUserName,
-			Auth: []ssh.AuthMethod{
-				ssh.Password(instance.SSHPassword),
-			},
-			Timeout: 30 * time.Second, // 30 秒超时
-			// 解决 “ssh: must specify HostKeyCallback” 的问题
-			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-				return nil
-			},
-		}
-
-	} else { // 如果用户不输入用户名和密码，则尝试使用ssh key pair的方式链接远程服务器
-		//var hostKey ssh.PublicKey
-		homePath, err := os.UserHomeDir()
-		if err != nil {
-			CheckError(err)
-		}
-		filePath := filepath.Join(homePath, "/.ssh/id_rsa")
-		if SmartIDELog.Ws_id != "" && ServerUserName != "" {
-			filePath = fmt.Sprintf("%s_%s_%s", filePath, ServerUserName, SmartIDELog.Ws_id)
-		}
-		key, err := os.ReadFile(filePath)
-		CheckError(err, "unable to read private key:")
-
-		// Create the Signer for this private key.
-		signer, err := ssh.ParsePrivateKey(key)
-		CheckError(err, "unable to parse private key:")
-
-		clientConfig = &ssh.ClientConfig{
-			User: instance.SSHUserName,
-			Auth: []ssh.AuthMethod{
-				// Use the PublicKeys method for remote authentication.
-				ssh.PublicKeys(signer),
-			},
-			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-				// use OpenSSH's known_hosts file if you care about host validation
-				return nil
-			},
-		}
-
-	}
-
-	addr := fmt.Sprintf("%v:%v", instance.SSHHost, instance.SSHPort)
-
-	if err == nil {
-		for k, v := range filesMaps {
-
-			client := scp.NewClient(addr, clientConfig)
-			err = client.Connect()
-			if err != nil {
-				fmt.Println("Couldn't establish a connection to the remote server ", err)
-				return
-			}
-			// Open a file
-			f, _ := os.Open(k)
-
-			defer client.Close()
-			// Finaly, copy the file over
-			// Usage: CopyFile(fileReader, remotePath, permission)
-			defer f.Close()
-
-			err = client.CopyFile(f, v, "0777")
-			if err != nil {
-				fmt.Println("Error while copying file ", err)
-			}
-
+	for localFilePath, remoteFilePath := range filesMaps {
+		if err = instance.CopyFile(localFilePath, remoteFilePath); err != nil {
+			return err
 		}
-
-		// Close client connection after the file has been copied
-
 	}
-	return
+	return nil
 }
 
 // 连接到远程主机
-func connectionDial(sshHost string, sshPort int, sshUserName, sshPassword string, idRsa string) (clientConn *ssh.Client, err error) {
+// 指定密码时使用密码认证，否则使用公钥认证，私钥依次来自 ssh-agent、idRsa（私钥内容）或者 identityFile（私钥文件）、~/.ssh 下的默认私钥
+// 指定了跳板机（或者 ~/.ssh/config 中配置了 ProxyJump）时，通过跳板机连接
+func connectionDial(sshHost string, sshPort int, sshUserName, sshPassword string, idRsa string, identityFile string, jumpHosts string) (clientConn *ssh.Client, err error) {
 	// initialize SSH connection
 	var clientConfig *ssh.ClientConfig
 	if sshPort <= 0 {
@@ -1025,46 +985,39 @@ func connectionDial(sshHost string, sshPort int, sshUserName, sshPassword string
 			Auth: []ssh.AuthMethod{
 				ssh.Password(sshPassword),
 			},
-			Timeout: 10 * time.Second, // 10 秒超时
-			// 解决 “ssh: must specify HostKeyCallback” 的问题
-			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-				return nil
-			},
+			Timeout:         10 * time.Second,     // 10 秒超时
+			HostKeyCallback: NewHostKeyCallback(), // 通过 known_hosts 校验主机公钥
 		}
 
 	} else { // 如果用户不输入用户名和密码，则尝试使用ssh key pair的方式链接远程服务器
-		var key []byte
-		//var hostKey ssh.PublicKey
-		if idRsa == "" {
-			homePath, err := os.UserHomeDir()
-			CheckError(err)
-			filePath := filepath.Join(homePath, "/.ssh/id_rsa")
-			key, err = os.ReadFile(filePath)
-			CheckError(err, "unable to read ssh private key:")
-		} else {
-			key = []byte(idRsa)
+		signers, agentConn, err := getSSHSigners(idRsa, identityFile, promptPassphrase)
+		if agentConn != nil {
+			defer agentConn.Close() // 认证完成后就不再需要 ssh-agent
+		}
+		if err != nil {
+			return nil, err
 		}
-
-		// Create the Signer for this private key.
-		signer, err := ssh.ParsePrivateKey(key)
-		CheckError(err, "unable to parse private key:")
 
 		clientConfig = &ssh.ClientConfig{
 			User: sshUserName,
 			Auth: []ssh.AuthMethod{
 				// Use the PublicKeys method for remote authentication.
-				ssh.PublicKeys(signer),
-			},
-			HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
-				// use OpenSSH's known_hosts file if you care about host validation
-				return nil
+				ssh.PublicKeys(signers...),
 			},
+			Timeout:         10 * time.Second,     // 10 秒超时
+			HostKeyCallback: NewHostKeyCallback(), // 通过 known_hosts 校验主机公钥
 		}
 
 	}
 
+	jumpHostList, err := getSSHJumpHosts(sshHost, jumpHosts)
+	if err != nil {
+		return nil, err
+	}
+
 	addr := fmt.Sprintf("%v:%v", sshHost, sshPort)
-	return ssh.Dial("tcp", addr, clientConfig)
+	clientConfig.HostKeyAlgorithms = GetKnownHostKeyAlgorithms(addr)
+	return dialWithJumpHosts(addr, clientConfig, jumpHostList)
 }
 
 type GVA_MODEL struct {
@@ -0,0 +1,184 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/term"
+)
+
+// 检查终端窗口大小变化的间隔，windows 上没有 SIGWINCH，统一使用轮询
+const sshTerminalResizeInterval = time.Millisecond * 500
+
+// 终端窗口的默认大小，标准输出不是终端时使用
+const (
+	sshTerminalDefaultWidth  = 80
+	sshTerminalDefaultHeight = 24
+)
+
+// 在 ssh 连接上打开 shell（command 为空时）或者执行命令，输入输出使用当前进程的标准输入输出
+// 打开 shell 并且标准输入为终端，或者 isTTY 为 true 时分配终端；返回远程命令的退出码
+func RunSSHTerminal(client *ssh.Client, command string, isTTY bool, isForwardAgent bool) (exitCode int, err error) {
+	return RunSSHTerminalWithIO(client, command, isTTY, isForwardAgent, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// 同 RunSSHTerminal，指定输入输出，stdin 为空时不发送输入
+func RunSSHTerminalWithIO(client *ssh.Client, command string, isTTY bool, isForwardAgent bool,
+	stdin io.Reader, stdout io.Writer, stderr io.Writer) (exitCode int, err error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+
+	//1. 转发本机的 ssh-agent，在容器中可以使用本机的私钥（比如 git push）
+	if isForwardAgent {
+		if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+			if err := agent.ForwardToRemote(client, socket); err != nil {
+				SmartIDELog.Debug("forward ssh-agent failed: " + err.Error())
+			} else if err := agent.RequestAgentForwarding(session); err != nil {
+				SmartIDELog.Debug("request ssh-agent forwarding failed: " + err.Error())
+			}
+		}
+	}
+
+	//2. 分配终端，本地终端切换到 raw 模式，按键（包括 Ctrl-C）直接发送到远程
+	stdinFd, isStdinTerminal := getTerminalFd(stdin)
+	stdoutFd, _ := getTerminalFd(stdout)
+	if isTTY || (command == "" && isStdinTerminal) {
+		width, height := getTerminalSize(stdoutFd)
+		termType := os.Getenv("TERM")
+		if termType == "" {
+			termType = "xterm-256color"
+		}
+		modes := ssh.TerminalModes{
+			ssh.ECHO:          1,
+			ssh.TTY_OP_ISPEED: 14400,
+			ssh.TTY_OP_OSPEED: 14400,
+		}
+		if err = session.RequestPty(termType, height, width, modes); err != nil {
+			return -1, err
+		}
+		if isStdinTerminal {
+			state, err := term.MakeRaw(stdinFd)
+			if err != nil {
+				return -1, err
+			}
+			defer term.Restore(stdinFd, state)
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		go watchTerminalResize(stdoutFd, width, height, done, func(width int, height int) {
+			session.WindowChange(height, width)
+		})
+
+	} else {
+		//2.1. 没有终端时，Ctrl-C 转发为远程命令的信号
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt)
+		defer signal.Stop(signals)
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			for {
+				select {
+				case <-signals:
+					session.Signal(ssh.SIGINT)
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	//3. 执行
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if command == "" {
+		err = session.Shell()
+	} else {
+		err = session.Start(command)
+	}
+	if err != nil {
+		return -1, err
+	}
+	err = session.Wait()
+
+	//4. 远程命令的退出码
+	var exitError *ssh.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitStatus(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+// 终端窗口大小变化时通知远程
+func watchTerminalResize(fd int, width int, height int, done <-chan struct{}, resize func(width int, height int)) {
+	if fd < 0 {
+		return
+	}
+	ticker := time.NewTicker(sshTerminalResizeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		currentWidth, currentHeight := getTerminalSize(fd)
+		if currentWidth != width || currentHeight != height {
+			width, height = currentWidth, currentHeight
+			resize(width, height)
+		}
+	}
+}
+
+// 获取终端的文件描述符，不是终端时返回 false
+func getTerminalFd(value interface{}) (int, bool) {
+	file, ok := value.(*os.File)
+	if !ok {
+		return -1, false
+	}
+	fd := int(file.Fd())
+	if !term.IsTerminal(fd) {
+		return -1, false
+	}
+	return fd, true
+}
+
+func getTerminalSize(fd int) (width int, height int) {
+	if fd >= 0 {
+		if width, height, err := term.GetSize(fd); err == nil {
+			return width, height
+		}
+	}
+	return sshTerminalDefaultWidth, sshTerminalDefaultHeight
+}
@@ -0,0 +1,52 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package common
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunSSHTerminal_Command(t *testing.T) {
+	SmartIDELog.InitLogger("")
+	sshRemote := newTestFileSSHRemote(t, false)
+
+	// 标准输出、标准错误分开输出，并返回远程命令的退出码
+	var stdout, stderr bytes.Buffer
+	exitCode, err := RunSSHTerminalWithIO(sshRemote.Connection, "cat; echo out; echo err >&2; exit 3", false, false,
+		strings.NewReader("in\n"), &stdout, &stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != 3 {
+		t.Errorf("exitCode = %v, want 3", exitCode)
+	}
+	if stdout.String() != "in\nout\n" {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if stderr.String() != "err\n" {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+
+	exitCode, err = RunSSHTerminalWithIO(sshRemote.Connection, "true", false, false, strings.NewReader(""), &stdout, &stderr)
+	if err != nil || exitCode != 0 {
+		t.Errorf("RunSSHTerminalWithIO() = %v, %v", exitCode, err)
+	}
+}
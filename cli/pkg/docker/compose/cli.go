@@ -0,0 +1,44 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// compose 命令行工具，优先使用 v2 的 docker compose 插件
+var composeCliCommands = []string{"docker compose", "docker-compose"}
+
+// 获取可用的 compose 命令行工具，在无法直接访问 docker api 时使用
+// execFunc 在目标环境（本地或者远程主机）执行命令
+func GetComposeCli(execFunc func(command string) (string, error)) (string, error) {
+	for _, command := range composeCliCommands {
+		output, err := execFunc(command + " version")
+		if err == nil && strings.Contains(strings.ToLower(output), "version") {
+			return command, nil
+		}
+	}
+	return "", ErrComposeCliNotFound
+}
+
+// 拼接 compose 命令，e.g. docker compose -f {compose文件} --project-directory {工作目录} up -d
+func GetComposeCliCommand(composeCli string, composeFilePath string, projectDirectory string, args ...string) string {
+	return fmt.Sprintf("%v -f %v --project-directory %v %v", composeCli, composeFilePath, projectDirectory, strings.Join(args, " "))
+}
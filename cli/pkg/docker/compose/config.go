@@ -0,0 +1,61 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import "fmt"
+
+// 配置的公共接口
+type Config interface {
+	IsConfig() bool
+	// 转换为 long syntax
+	ToComplex() ConfigComplex
+}
+
+// 服务使用的配置列表，每一项可以是 short syntax（字符串）或者 long syntax（对象）
+type Configs []Config
+
+func (configs *Configs) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	var items []interface{}
+	if err = unmarshal(&items); err != nil {
+		return
+	}
+	result := Configs{}
+	for _, item := range items {
+		switch value := item.(type) {
+		case map[interface{}]interface{}:
+			var config ConfigComplex
+			if err = convertYaml(value, &config); err != nil {
+				return fmt.Errorf("docker: complex-config format error, %w", err)
+			}
+			if len(config.Source) == 0 {
+				return fmt.Errorf("docker: complex-config source can not be empty")
+			}
+			result = append(result, config)
+		case string:
+			if len(value) == 0 {
+				return fmt.Errorf("docker: simple-config format error")
+			}
+			result = append(result, NewConfigSimple(value))
+		default:
+			return fmt.Errorf("docker: config %v format error", item)
+		}
+	}
+	*configs = result
+	return
+}
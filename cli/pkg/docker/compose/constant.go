@@ -35,4 +35,10 @@ const (
 	// 挂载卷的读写模式
 	VolumeReadOnly  = "ro" // 只读模式
 	VolumeReadWrite = "rw" // 读写模式
+
+	// 挂载卷的类型
+	VolumeTypeBind   = "bind"   // 主机路径
+	VolumeTypeVolume = "volume" // 挂载卷
+	VolumeTypeTmpfs  = "tmpfs"  // 内存文件系统
+	VolumeTypeNpipe  = "npipe"  // windows 命名管道
 )
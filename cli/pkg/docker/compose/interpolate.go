@@ -0,0 +1,238 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// 变量替换时获取变量的值，变量没有设置时返回 false
+type LookupFunc func(name string) (string, bool)
+
+// 按照 compose 规范替换 yaml 中所有字符串值的变量，键不替换，https://docs.docker.com/compose/compose-file/#interpolation
+// 替换后的 $ 转义为 $$，交给 docker compose 或者 Project 时不会被再次替换；unsetNames 为没有设置并且没有默认值的变量
+func InterpolateYaml(content string, lookup LookupFunc) (result string, unsetNames []string, err error) {
+	if !strings.Contains(content, "$") {
+		return content, nil, nil
+	}
+
+	var document yaml.MapSlice
+	if err = yaml.Unmarshal([]byte(content), &document); err != nil {
+		return "", nil, err
+	}
+	unset := map[string]bool{}
+	value, err := interpolateYamlValue(document, lookup, unset)
+	if err != nil {
+		return "", nil, err
+	}
+	bytes, err := yaml.Marshal(value)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for name := range unset {
+		unsetNames = append(unsetNames, name)
+	}
+	sort.Strings(unsetNames)
+	return string(bytes), unsetNames, nil
+}
+
+func interpolateYamlValue(value interface{}, lookup LookupFunc, unset map[string]bool) (interface{}, error) {
+	switch item := value.(type) {
+	case yaml.MapSlice:
+		for index := range item {
+			result, err := interpolateYamlValue(item[index].Value, lookup, unset)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", item[index].Key, err)
+			}
+			item[index].Value = result
+		}
+		return item, nil
+	case []interface{}:
+		for index := range item {
+			result, err := interpolateYamlValue(item[index], lookup, unset)
+			if err != nil {
+				return nil, err
+			}
+			item[index] = result
+		}
+		return item, nil
+	case string:
+		result, err := interpolate(item, lookup, unset)
+		if err != nil {
+			return nil, err
+		}
+		return strings.ReplaceAll(result, "$", "$$"), nil
+	default:
+		return value, nil
+	}
+}
+
+// 替换字符串中的变量，支持 $VAR、${VAR}、${VAR:-default}、${VAR-default}、${VAR:?err}、${VAR?err}、${VAR:+alt}、${VAR+alt}，$$ 表示 $ 本身
+// 没有设置的变量替换为空字符串，与 docker compose 一致
+func Interpolate(value string, lookup LookupFunc) (string, error) {
+	return interpolate(value, lookup, map[string]bool{})
+}
+
+func interpolate(value string, lookup LookupFunc, unset map[string]bool) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+
+	var result strings.Builder
+	for index := 0; index < len(value); index++ {
+		if value[index] != '$' {
+			result.WriteByte(value[index])
+			continue
+		}
+		if index+1 >= len(value) {
+			return "", fmt.Errorf("invalid interpolation format for %q", value)
+		}
+
+		switch next := value[index+1]; {
+		case next == '$': // 转义
+			result.WriteByte('$')
+			index++
+
+		case next == '{': // ${VAR...}
+			end := findClosingBrace(value, index+2)
+			if end < 0 {
+				return "", fmt.Errorf("invalid interpolation format for %q", value)
+			}
+			replaced, err := interpolateBraced(value[index+2:end], lookup, unset)
+			if err != nil {
+				if err == errInvalidInterpolation {
+					return "", fmt.Errorf("invalid interpolation format for %q", value)
+				}
+				return "", err
+			}
+			result.WriteString(replaced)
+			index = end
+
+		case isVariableNameStart(next): // $VAR
+			end := index + 1
+			for end < len(value) && isVariableNameChar(value[end]) {
+				end++
+			}
+			name := value[index+1 : end]
+			variable, ok := lookup(name)
+			if !ok {
+				unset[name] = true
+			}
+			result.WriteString(variable)
+			index = end - 1
+
+		default:
+			return "", fmt.Errorf("invalid interpolation format for %q", value)
+		}
+	}
+	return result.String(), nil
+}
+
+var errInvalidInterpolation = fmt.Errorf("invalid interpolation format")
+
+// ${} 中的内容，e.g. VAR:-default
+func interpolateBraced(content string, lookup LookupFunc, unset map[string]bool) (string, error) {
+	//1. 变量名称
+	nameEnd := 0
+	for nameEnd < len(content) && isVariableNameChar(content[nameEnd]) {
+		nameEnd++
+	}
+	name := content[:nameEnd]
+	if name == "" || !isVariableNameStart(name[0]) {
+		return "", errInvalidInterpolation
+	}
+	variable, ok := lookup(name)
+	if nameEnd == len(content) {
+		if !ok {
+			unset[name] = true
+		}
+		return variable, nil
+	}
+
+	//2. 操作符，带 : 时空字符串与没有设置相同
+	operator := content[nameEnd:]
+	isEmptyAsUnset := strings.HasPrefix(operator, ":")
+	operator = strings.TrimPrefix(operator, ":")
+	if operator == "" {
+		return "", errInvalidInterpolation
+	}
+	isSet := ok && (!isEmptyAsUnset || variable != "")
+	word := operator[1:]
+	switch operator[0] {
+	case '-': // 默认值
+		if isSet {
+			return variable, nil
+		}
+		return interpolate(word, lookup, unset)
+	case '?': // 必须设置
+		if isSet {
+			return variable, nil
+		}
+		message, err := interpolate(word, lookup, unset)
+		if err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("required variable %v is missing a value: %v", name, message)
+	case '+': // 设置时使用替代值
+		if isSet {
+			return interpolate(word, lookup, unset)
+		}
+		return "", nil
+	default:
+		return "", errInvalidInterpolation
+	}
+}
+
+// 查找与 ${ 匹配的 }，支持默认值中嵌套的 ${}，start 为 ${ 之后的位置
+func findClosingBrace(value string, start int) int {
+	depth := 1
+	for index := start; index < len(value); index++ {
+		switch {
+		case strings.HasPrefix(value[index:], "$$"):
+			index++
+		case strings.HasPrefix(value[index:], "${"):
+			depth++
+			index++
+		case value[index] == '}':
+			depth--
+			if depth == 0 {
+				return index
+			}
+		}
+	}
+	return -1
+}
+
+func isVariableNameStart(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+func isVariableNameChar(char byte) bool {
+	return isVariableNameStart(char) || (char >= '0' && char <= '9')
+}
+
+// 解析 .env 文件，KEY=VALUE 格式，忽略注释以及空行
+func ParseEnvFile(content string) map[string]string {
+	return parseEnvFile(content, nil)
+}
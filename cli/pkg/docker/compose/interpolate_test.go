@@ -0,0 +1,132 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	environment := map[string]string{"USER": "smartide", "EMPTY": "", "PORT": "5432"}
+	lookup := func(name string) (string, bool) {
+		value, ok := environment[name]
+		return value, ok
+	}
+	tests := []struct {
+		value   string
+		want    string
+		wantErr string
+	}{
+		{value: "no variable", want: "no variable"},
+		{value: "$USER-${USER}", want: "smartide-smartide"},
+		{value: "${PORT}:5432", want: "5432:5432"},
+		{value: "$$USER $$$USER", want: "$USER $smartide"},
+		{value: "${UNSET}", want: ""},
+		{value: "${UNSET:-dev}", want: "dev"},
+		{value: "${EMPTY:-dev}", want: "dev"},
+		{value: "${EMPTY-dev}", want: ""},
+		{value: "${UNSET-dev}", want: "dev"},
+		{value: "${USER:+yes}|${EMPTY:+yes}|${EMPTY+yes}", want: "yes||yes"},
+		{value: "${UNSET:-${USER:-x}-$PORT}", want: "smartide-5432"},
+		{value: "${UNSET:-a}b}", want: "ab}"},
+		{value: "${USER:?must be set}", want: "smartide"},
+		{value: "${UNSET:?must be set}", wantErr: "required variable UNSET is missing a value: must be set"},
+		{value: "${EMPTY:?}", wantErr: "required variable EMPTY is missing a value"},
+		{value: "${EMPTY?}", want: ""},
+		{value: "${USER", wantErr: "invalid interpolation format"},
+		{value: "${}", wantErr: "invalid interpolation format"},
+		{value: "${1A}", wantErr: "invalid interpolation format"},
+		{value: "${USER:}", wantErr: "invalid interpolation format"},
+		{value: "${USER:=x}", wantErr: "invalid interpolation format"},
+		{value: "cost $", wantErr: "invalid interpolation format"},
+		{value: "$ 1", wantErr: "invalid interpolation format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := Interpolate(tt.value, lookup)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Interpolate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Interpolate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Interpolate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpolateYaml(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		value, ok := map[string]string{"DB_PORT": "15432", "PASSWORD": "pa$$word"}[name]
+		return value, ok
+	}
+
+	content := `version: "3"
+services:
+  db:
+    image: postgres:${PG_VERSION:-14}
+    ports:
+      - ${DB_PORT}:5432
+    environment:
+      POSTGRES_PASSWORD: ${PASSWORD}
+      PGDATA: $$HOME/data
+      UNSET_VALUE: $MISSING
+    ${KEY}: value
+`
+	got, unsetNames, err := InterpolateYaml(content, lookup)
+	if err != nil {
+		t.Fatalf("InterpolateYaml() error = %v", err)
+	}
+	if !reflect.DeepEqual(unsetNames, []string{"MISSING"}) {
+		t.Errorf("InterpolateYaml() unsetNames = %v, want [MISSING]", unsetNames)
+	}
+	want := `version: "3"
+services:
+  db:
+    image: postgres:14
+    ports:
+    - 15432:5432
+    environment:
+      POSTGRES_PASSWORD: pa$$$$word
+      PGDATA: $$HOME/data
+      UNSET_VALUE: ""
+    ${KEY}: value
+`
+	if got != want {
+		t.Errorf("InterpolateYaml() = \n%v\nwant\n%v", got, want)
+	}
+
+	// 再次替换时转义的 $ 保持不变
+	project := &Project{Environment: map[string]string{"HOME": "/home/smartide"}}
+	if value := project.interpolate("$$HOME/data"); value != "$HOME/data" {
+		t.Errorf("Project.interpolate() = %v, want $HOME/data", value)
+	}
+
+	if _, _, err = InterpolateYaml("image: ${UNSET:?image is required}", lookup); err == nil ||
+		!strings.Contains(err.Error(), "image is required") {
+		t.Errorf("InterpolateYaml() error = %v, want required error", err)
+	}
+}
@@ -18,7 +18,96 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package compose
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // 暴露的端口映射的公共接口
 type Port interface {
 	IsPort() bool
+	// 转换为 long syntax，容器端口为范围时展开为多个映射
+	ToComplex() ([]PortComplex, error)
+	// short syntax 格式的字符串，用于显示
+	String() string
+}
+
+// 服务的端口列表，每一项可以是 short syntax（字符串、数字）或者 long syntax（对象）
+type Ports []Port
+
+func (ports *Ports) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	var items []interface{}
+	if err = unmarshal(&items); err != nil {
+		return
+	}
+	result := Ports{}
+	for _, item := range items {
+		switch value := item.(type) {
+		case map[interface{}]interface{}:
+			var port PortComplex
+			if err = convertYaml(value, &port); err != nil {
+				return fmt.Errorf("docker: complex-port format error, %w", err)
+			}
+			result = append(result, port)
+		case string, int:
+			port, err := ParsePort(fmt.Sprint(value))
+			if err != nil {
+				return err
+			}
+			result = append(result, port)
+		default:
+			return fmt.Errorf("docker: port %v format error", item)
+		}
+	}
+	*ports = result
+	return
+}
+
+// 转换为 long syntax
+func (ports Ports) ToComplex() (result []PortComplex, err error) {
+	for _, port := range ports {
+		items, err := port.ToComplex()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+	}
+	return result, nil
+}
+
+// short syntax 格式的字符串列表
+func (ports Ports) Strings() (result []string) {
+	for _, port := range ports {
+		result = append(result, port.String())
+	}
+	return result
+}
+
+// 是否包含相同的端口映射
+func (ports Ports) Contains(port Port) bool {
+	for _, item := range ports {
+		if item.String() == port.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析端口或者端口范围，e.g. 3000、3000-3005
+func parsePortRange(value string) (start int, end int, err error) {
+	parts := strings.SplitN(value, "-", 2)
+	if start, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	end = start
+	if len(parts) > 1 {
+		if end, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if start <= 0 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid port range %v", value)
+	}
+	return start, end, nil
 }
@@ -18,15 +18,68 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package compose
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // 端口(Long Syntax)
 type PortComplex struct {
-	Target    string `yaml:"target"`             // 容器内部端口号
-	Published string `yaml:"published"`          // 暴露的端口号
-	Protocol  string `yaml:"protocol,omitempty"` // 传输协议
-	// TODO mode
+	Name        string `yaml:"name,omitempty"`         // 端口名称
+	Target      int    `yaml:"target"`                 // 容器内部端口号
+	HostIP      string `yaml:"host_ip,omitempty"`      // 绑定的主机 IP，为空时绑定所有 IP
+	Published   string `yaml:"published,omitempty"`    // 暴露的端口号，可以是端口范围，为空时随机分配
+	Protocol    string `yaml:"protocol,omitempty"`     // 传输协议
+	AppProtocol string `yaml:"app_protocol,omitempty"` // 应用层协议，e.g. http
+	Mode        string `yaml:"mode,omitempty"`         // host 或者 ingress
 }
 
 // 实现公共接口
 func (PortComplex) IsPort() bool {
 	return true
 }
+
+func (m PortComplex) ToComplex() ([]PortComplex, error) {
+	if m.Target <= 0 || m.Target > 65535 {
+		return nil, fmt.Errorf("docker: complex-port target %v format error", m.Target)
+	}
+	if m.Published != "" {
+		if _, _, err := parsePortRange(m.Published); err != nil {
+			return nil, fmt.Errorf("docker: complex-port published %v format error", m.Published)
+		}
+	}
+	return []PortComplex{m}, nil
+}
+
+// 暴露的端口号，端口范围时返回第一个端口，随机分配时返回 0
+func (m PortComplex) GetPublishedPort() int {
+	start, _, err := parsePortRange(m.Published)
+	if err != nil {
+		return 0
+	}
+	return start
+}
+
+// 转换为 short syntax
+func (m PortComplex) ToSimple() PortSimple {
+	result := PortSimple{Host: strconv.Itoa(m.Target)}
+	if m.HostIP != "" || m.Published != "" {
+		result.Host, result.Container = m.Published, strconv.Itoa(m.Target)
+		if m.HostIP != "" {
+			hostIP := m.HostIP
+			if strings.Contains(hostIP, ":") { // ipv6
+				hostIP = "[" + hostIP + "]"
+			}
+			result.Host = hostIP + ":" + m.Published
+		}
+	}
+	if m.Protocol != ProtocolTCP {
+		result.Protocol = m.Protocol
+	}
+	return result
+}
+
+func (m PortComplex) String() string {
+	return m.ToSimple().String()
+}
@@ -21,6 +21,7 @@ package compose
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -49,19 +50,75 @@ func (PortSimple) IsPort() bool {
 	return true
 }
 
+// 主机的 IP 地址，e.g. 127.0.0.1:3000-4000 -> 127.0.0.1
+func (m PortSimple) HostIP() string {
+	if len(m.Container) == 0 {
+		return ""
+	}
+	index := strings.LastIndex(m.Host, ":")
+	if index < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(m.Host[:index], "["), "]")
+}
+
+// 主机的端口或者端口范围，e.g. 127.0.0.1:3000-4000 -> 3000-4000，没有指定容器端口时为空
+func (m PortSimple) HostPort() string {
+	if len(m.Container) == 0 {
+		return ""
+	}
+	return m.Host[strings.LastIndex(m.Host, ":")+1:]
+}
+
+// 转换为 long syntax，e.g. 3000-3001:6000-6001 -> 3000:6000、3001:6001
+func (m PortSimple) ToComplex() (result []PortComplex, err error) {
+	containerPort := m.Container
+	if len(containerPort) == 0 {
+		containerPort = m.Host
+	}
+	targetStart, targetEnd, err := parsePortRange(containerPort)
+	if err != nil {
+		return nil, fmt.Errorf("docker: simple-port %v format error, %w", m.String(), err)
+	}
+	hostIP, hostPort := m.HostIP(), m.HostPort()
+	hostStart := 0
+	if hostPort != "" {
+		var hostEnd int
+		if hostStart, hostEnd, err = parsePortRange(hostPort); err != nil {
+			return nil, fmt.Errorf("docker: simple-port %v format error, %w", m.String(), err)
+		}
+		if targetStart != targetEnd && hostEnd-hostStart != targetEnd-targetStart {
+			return nil, fmt.Errorf("docker: simple-port %v format error, port ranges don't match", m.String())
+		}
+	}
+
+	for target := targetStart; target <= targetEnd; target++ {
+		port := PortComplex{Target: target, HostIP: hostIP, Published: hostPort, Protocol: m.Protocol}
+		if hostPort != "" && targetStart != targetEnd {
+			port.Published = strconv.Itoa(hostStart + target - targetStart)
+		}
+		result = append(result, port)
+	}
+	return result, nil
+}
+
+func (m PortSimple) String() string {
+	result := m.Host
+	if len(m.Container) > 0 {
+		result += fmt.Sprintf(":%s", m.Container)
+	}
+	if len(m.Protocol) > 0 {
+		result += fmt.Sprintf("/%s", m.Protocol)
+	}
+	return result
+}
+
 func (m PortSimple) MarshalYAML() (result interface{}, err error) {
 	if len(m.Host) == 0 {
 		err = errors.New("docker: simple-port host can not be empty")
 		return
 	}
-	tmp := m.Host
-	if len(m.Container) > 0 {
-		tmp += fmt.Sprintf(":%s", m.Container)
-		if len(m.Protocol) > 0 {
-			tmp += fmt.Sprintf("/%s", m.Protocol)
-		}
-	}
-	result = tmp
+	result = m.String()
 	return
 }
 
@@ -70,6 +127,12 @@ func (m *PortSimple) UnmarshalYAML(unmarshal func(interface{}) error) (err error
 	if err = unmarshal(&origin); err != nil {
 		return
 	}
+	*m, err = ParsePort(origin)
+	return
+}
+
+// 解析 short syntax 的端口，e.g. 3000、3000:6000/udp、127.0.0.1:3000-3005:6000-6005
+func ParsePort(origin string) (m PortSimple, err error) {
 	// 拆分协议部分
 	parts, remain := strings.Split(origin, "/"), ""
 	if len(parts) > 2 {
@@ -94,9 +157,5 @@ func (m *PortSimple) UnmarshalYAML(unmarshal func(interface{}) error) (err error
 		err = errors.New("docker: simple-port format error")
 		return
 	}
-	if len(m.Container) == 0 && len(m.Protocol) > 0 {
-		err = errors.New("docker: simple-port format error")
-		return
-	}
 	return
 }
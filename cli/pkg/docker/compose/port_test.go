@@ -0,0 +1,102 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPorts(t *testing.T) {
+	content := `- 3000
+- "8080:80/udp"
+- 127.0.0.1:6000-6001:7000-7001
+- "[::1]::22"
+- target: 443
+  host_ip: 0.0.0.0
+  published: "8443"
+  protocol: tcp
+  mode: host
+`
+	var ports Ports
+	if err := UnmarshalYaml(content, &ports); err != nil {
+		t.Fatalf("Ports.UnmarshalYAML() error = %v", err)
+	}
+	if _, ok := ports[4].(PortComplex); !ok || len(ports) != 5 {
+		t.Fatalf("Ports.UnmarshalYAML() = %#v", ports)
+	}
+
+	got, err := ports.ToComplex()
+	if err != nil {
+		t.Fatalf("Ports.ToComplex() error = %v", err)
+	}
+	want := []PortComplex{
+		{Target: 3000},
+		{Target: 80, Published: "8080", Protocol: ProtocolUDP},
+		{Target: 7000, HostIP: "127.0.0.1", Published: "6000"},
+		{Target: 7001, HostIP: "127.0.0.1", Published: "6001"},
+		{Target: 22, HostIP: "::1"},
+		{Target: 443, HostIP: "0.0.0.0", Published: "8443", Protocol: ProtocolTCP, Mode: "host"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Ports.ToComplex() = %+v, want %+v", got, want)
+	}
+
+	// 保持原来的格式
+	var roundTrip Ports
+	if err := UnmarshalYaml(MarshalYaml(ports), &roundTrip); err != nil || !reflect.DeepEqual(roundTrip, ports) {
+		t.Errorf("Ports.MarshalYAML() = %v, error = %v", MarshalYaml(ports), err)
+	}
+	wantStrings := []string{"3000", "8080:80/udp", "127.0.0.1:6000-6001:7000-7001", "[::1]::22", "0.0.0.0:8443:443"}
+	if !reflect.DeepEqual(ports.Strings(), wantStrings) {
+		t.Errorf("Ports.Strings() = %v, want %v", ports.Strings(), wantStrings)
+	}
+
+	service := Service{Ports: ports}
+	if !service.ContainContainerPort(7001) || service.ContainContainerPort(6000) {
+		t.Errorf("Service.ContainContainerPort() failed")
+	}
+	if port := service.GetPublishedPort(80); port != 8080 {
+		t.Errorf("Service.GetPublishedPort() = %v, want 8080", port)
+	}
+}
+
+func TestPortsToComplexError(t *testing.T) {
+	tests := []Port{
+		PortSimple{Host: "${WEB_PORT:-80}", Container: "3000"},
+		PortSimple{Host: "6000-6002", Container: "7000-7001"},
+		PortSimple{Host: "6000", Container: "7000-7001"},
+		PortSimple{Host: "70000"},
+		PortComplex{Published: "80"},
+		PortComplex{Target: 80, Published: "abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.String(), func(t *testing.T) {
+			if _, err := tt.ToComplex(); err == nil {
+				t.Errorf("ToComplex() should return error")
+			}
+		})
+	}
+
+	var ports Ports
+	if err := UnmarshalYaml("- target: 80\n  unknown: 1\n", &ports); err == nil || !strings.Contains(err.Error(), "complex-port") {
+		t.Errorf("Ports.UnmarshalYAML() error = %v, want complex-port error", err)
+	}
+}
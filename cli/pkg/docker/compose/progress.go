@@ -0,0 +1,117 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+// 运行 compose 项目的阶段
+type ProjectStageEnum string
+
+const (
+	ProjectStageEnum_Network ProjectStageEnum = "network" // 网络
+	ProjectStageEnum_Volume  ProjectStageEnum = "volume"  // 挂载卷
+	ProjectStageEnum_Pull    ProjectStageEnum = "pull"    // 拉取镜像
+	ProjectStageEnum_Build   ProjectStageEnum = "build"   // 构建镜像
+	ProjectStageEnum_Push    ProjectStageEnum = "push"    // 推送镜像
+	ProjectStageEnum_Create  ProjectStageEnum = "create"  // 创建容器
+	ProjectStageEnum_Start   ProjectStageEnum = "start"   // 启动容器
+	ProjectStageEnum_Stop    ProjectStageEnum = "stop"    // 停止容器
+	ProjectStageEnum_Remove  ProjectStageEnum = "remove"  // 删除容器、网络、挂载卷
+)
+
+// 进度的状态
+type ProgressStatusEnum string
+
+const (
+	ProgressStatusEnum_Working ProgressStatusEnum = "working" // 进行中
+	ProgressStatusEnum_Done    ProgressStatusEnum = "done"    // 完成
+	ProgressStatusEnum_Skipped ProgressStatusEnum = "skipped" // 跳过，比如容器已经是最新的
+	ProgressStatusEnum_Error   ProgressStatusEnum = "error"   // 失败
+)
+
+// compose 项目运行过程中的进度事件
+type ProgressEvent struct {
+	// 服务名称，网络、挂载卷等项目级别的资源为空
+	Service string
+	// 资源名称，比如容器名称、网络名称、镜像名称
+	Resource string
+	Stage    ProjectStageEnum
+	Status   ProgressStatusEnum
+	// 附加信息，比如拉取镜像的进度
+	Text string
+}
+
+func (event ProgressEvent) String() string {
+	result := fmt.Sprintf("[%v] %v %v", event.Stage, event.Resource, event.Status)
+	if event.Service != "" && event.Service != event.Resource {
+		result = fmt.Sprintf("[%v] %v (%v) %v", event.Stage, event.Resource, event.Service, event.Status)
+	}
+	if event.Text != "" {
+		result += ": " + event.Text
+	}
+	return result
+}
+
+// 默认的进度输出，进行中的事件只在 debug 模式下输出
+func LogProgressEvent(event ProgressEvent) {
+	switch event.Status {
+	case ProgressStatusEnum_Working:
+		common.SmartIDELog.Debug(event.String())
+	case ProgressStatusEnum_Error:
+		common.SmartIDELog.Importance(event.String())
+	default:
+		common.SmartIDELog.Info(event.String())
+	}
+}
+
+// 没有找到 docker compose 命令行工具
+var ErrComposeCliNotFound = errors.New("docker compose / docker-compose not found")
+
+// 服务之间的依赖存在循环
+var ErrDependencyCycle = errors.New("circular dependency between services")
+
+// compose 项目运行过程中的错误，包含出错的阶段和资源
+type ProjectError struct {
+	Service  string
+	Resource string
+	Stage    ProjectStageEnum
+	Err      error
+}
+
+func (e *ProjectError) Error() string {
+	if e.Service != "" {
+		return fmt.Sprintf("service %v: %v %v failed: %v", e.Service, e.Stage, e.Resource, e.Err)
+	}
+	return fmt.Sprintf("%v %v failed: %v", e.Stage, e.Resource, e.Err)
+}
+
+func (e *ProjectError) Unwrap() error {
+	return e.Err
+}
+
+// 是否是某个阶段的错误，比如拉取镜像失败
+func IsProjectStageError(err error, stage ProjectStageEnum) bool {
+	var projectError *ProjectError
+	return errors.As(err, &projectError) && projectError.Stage == stage
+}
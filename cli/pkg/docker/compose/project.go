@@ -0,0 +1,312 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+/*
+通过 docker api 运行 compose 项目，不再依赖 docker-compose 命令行工具
+容器、网络、挂载卷上的标签与 docker compose 保持一致，所以两者创建的资源可以互相识别
+*/
+
+package compose
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// docker compose 使用的标签
+const (
+	LabelProject         = "com.docker.compose.project"
+	LabelService         = "com.docker.compose.service"
+	LabelWorkingDir      = "com.docker.compose.project.working_dir"
+	LabelConfigFiles     = "com.docker.compose.project.config_files"
+	LabelContainerNumber = "com.docker.compose.container-number"
+	LabelOneoff          = "com.docker.compose.oneoff"
+	LabelConfigHash      = "com.docker.compose.config-hash"
+	LabelNetwork         = "com.docker.compose.network"
+	LabelVolume          = "com.docker.compose.volume"
+	LabelVersion         = "com.docker.compose.version"
+)
+
+// 默认网络的名称
+const DefaultNetworkName = "default"
+
+// 构建镜像，远程主机上的构建上下文不在本地，需要自定义；contextDir 为展开后的构建上下文路径
+type ImageBuilder func(ctx context.Context, serviceName string, service Service, imageName string, contextDir string) error
+
+// compose 项目
+type Project struct {
+	// 项目名称，用于容器名称以及 com.docker.compose.project 标签
+	Name string
+	// 项目目录，相对路径的挂载卷以此为基准
+	WorkingDir string
+	// compose 文件的路径，仅用于标签
+	ConfigFile string
+	// 用户目录，用于展开挂载卷中的 ~
+	HomeDir string
+	// 变量替换时使用的环境变量，比如 $HOME
+	Environment map[string]string
+
+	Compose DockerComposeYml
+
+	client *client.Client
+	// 自定义的镜像构建，为空时使用 docker api 构建本地的上下文
+	ImageBuilder ImageBuilder
+	// 自定义的构建上下文 hash 计算，为空时计算本地的上下文
+	BuildContextHasher BuildContextHasher
+	// 读取项目中的文件，比如 env_file，为空时读取本地文件
+	ReadFile func(filePath string) ([]byte, error)
+	// 进度事件
+	OnProgress func(event ProgressEvent)
+}
+
+// 新建 compose 项目，项目名称与 docker compose 的默认规则一致（项目目录的名称）
+func NewProject(cli *client.Client, dockerCompose DockerComposeYml, workingDir string, configFile string) *Project {
+	homeDir, _ := os.UserHomeDir()
+	environment := map[string]string{}
+	for _, item := range os.Environ() {
+		if index := strings.Index(item, "="); index > 0 {
+			environment[item[:index]] = item[index+1:]
+		}
+	}
+
+	return &Project{
+		Name:        GetProjectName(workingDir),
+		WorkingDir:  workingDir,
+		ConfigFile:  configFile,
+		HomeDir:     homeDir,
+		Environment: environment,
+		Compose:     dockerCompose,
+		client:      cli,
+		OnProgress:  LogProgressEvent,
+	}
+}
+
+var projectNameRegexp = regexp.MustCompile(`[^-_a-z0-9]`)
+
+// 根据项目目录获取项目名称，e.g. /home/smartide/My.Project -> myproject
+func GetProjectName(workingDir string) string {
+	workingDir = strings.TrimRight(strings.ReplaceAll(workingDir, "\\", "/"), "/")
+	return projectNameRegexp.ReplaceAllString(strings.ToLower(path.Base(workingDir)), "")
+}
+
+// 输出进度
+func (p *Project) progress(event ProgressEvent) {
+	if p.OnProgress != nil {
+		p.OnProgress(event)
+	}
+}
+
+// 读取文件
+func (p *Project) readFile(filePath string) ([]byte, error) {
+	if p.ReadFile != nil {
+		return p.ReadFile(filePath)
+	}
+	return os.ReadFile(filePath)
+}
+
+// 项目目录，展开 ~
+func (p *Project) getWorkingDir() string {
+	return p.expandHomeDir(p.WorkingDir)
+}
+
+// 展开路径中的 ~
+func (p *Project) expandHomeDir(filePath string) string {
+	if p.HomeDir != "" && (filePath == "~" || strings.HasPrefix(filePath, "~/") || strings.HasPrefix(filePath, "~\\")) {
+		return p.joinPath(p.HomeDir, filePath[1:])
+	}
+	return filePath
+}
+
+// 远程主机上的路径都是 linux 格式，本地的路径与操作系统一致
+func (p *Project) joinPath(elem ...string) string {
+	if strings.HasPrefix(p.WorkingDir, "/") || strings.HasPrefix(p.WorkingDir, "~/") {
+		return path.Join(elem...)
+	}
+	return filepath.Join(elem...)
+}
+
+// 变量替换，支持 compose 规范中的 ${VAR:-default} 等格式，$$ 表示 $ 本身，格式错误时保持原值
+func (p *Project) interpolate(value string) string {
+	result, err := Interpolate(value, func(name string) (string, bool) {
+		variable, ok := p.Environment[name]
+		return variable, ok
+	})
+	if err != nil {
+		return value
+	}
+	return result
+}
+
+// 替换数组中每一项的变量
+func (p *Project) interpolateSlice(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	result := make([]string, len(values))
+	for index, value := range values {
+		result[index] = p.interpolate(value)
+	}
+	return result
+}
+
+// 替换 map 中每个值的变量，键不替换
+func (p *Project) interpolateMap(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		result[key] = p.interpolate(value)
+	}
+	return result
+}
+
+// 服务对应的容器名称，与 docker-compose v1 的格式一致
+func (p *Project) getContainerName(serviceName string, service Service) string {
+	if service.ContainerName != "" {
+		return service.ContainerName
+	}
+	return fmt.Sprintf("%v_%v_1", p.Name, serviceName)
+}
+
+// 网络的实际名称，外部网络不加项目名称前缀
+func (p *Project) getNetworkName(name string) string {
+	if network, ok := p.Compose.Networks[name]; ok && network.External {
+		return name
+	}
+	return fmt.Sprintf("%v_%v", p.Name, name)
+}
+
+// 挂载卷的实际名称，外部挂载卷不加项目名称前缀
+func (p *Project) getVolumeName(name string) string {
+	if volume, ok := p.Compose.Volumes[name]; ok && volume.External {
+		return name
+	}
+	return fmt.Sprintf("%v_%v", p.Name, name)
+}
+
+// 服务加入的网络，没有定义时加入默认网络
+func (p *Project) getServiceNetworks(service Service) []string {
+	if service.NetworkMode != "" || service.Net != "" {
+		return []string{}
+	}
+	if len(service.Networks) == 0 {
+		return []string{DefaultNetworkName}
+	}
+	return service.Networks
+}
+
+// 项目相关的容器，包括已经停止的
+func (p *Project) ListContainers(ctx context.Context, serviceNames ...string) ([]types.Container, error) {
+	args := filters.NewArgs(filters.Arg("label", fmt.Sprintf("%v=%v", LabelProject, p.Name)))
+	if len(serviceNames) == 1 {
+		args.Add("label", fmt.Sprintf("%v=%v", LabelService, serviceNames[0]))
+	}
+	containers, err := p.client.ContainerList(ctx, types.ContainerListOptions{All: true, Filters: args})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(serviceNames) <= 1 {
+		return containers, nil
+	}
+	var result []types.Container
+	for _, container := range containers {
+		for _, serviceName := range serviceNames {
+			if container.Labels[LabelService] == serviceName {
+				result = append(result, container)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// 服务依赖的其他服务
+func getServiceDependencies(service Service) []string {
+	var result []string
+	switch dependsOn := service.DependsOn.(type) {
+	case []interface{}: // depends_on: [db, redis]
+		for _, item := range dependsOn {
+			result = append(result, fmt.Sprint(item))
+		}
+	case []string:
+		result = append(result, dependsOn...)
+	case map[interface{}]interface{}: // depends_on: { db: { condition: service_healthy } }
+		for key := range dependsOn {
+			result = append(result, fmt.Sprint(key))
+		}
+	case map[string]interface{}:
+		for key := range dependsOn {
+			result = append(result, key)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// 按照依赖关系对服务排序，被依赖的服务在前面
+func sortServicesByDependencies(services map[string]Service) ([]string, error) {
+	var serviceNames []string
+	for serviceName := range services {
+		serviceNames = append(serviceNames, serviceName)
+	}
+	sort.Strings(serviceNames) // 保证顺序稳定
+
+	var result []string
+	states := map[string]int{} // 0 未访问，1 访问中，2 已完成
+	var visit func(serviceName string, stack []string) error
+	visit = func(serviceName string, stack []string) error {
+		switch states[serviceName] {
+		case 1:
+			return fmt.Errorf("%w: %v", ErrDependencyCycle, strings.Join(append(stack, serviceName), " -> "))
+		case 2:
+			return nil
+		}
+		states[serviceName] = 1
+		stack = append(append([]string{}, stack...), serviceName)
+		for _, dependency := range getServiceDependencies(services[serviceName]) {
+			if _, ok := services[dependency]; !ok {
+				return fmt.Errorf("service %v depends on undefined service %v", serviceName, dependency)
+			}
+			if err := visit(dependency, stack); err != nil {
+				return err
+			}
+		}
+		states[serviceName] = 2
+		result = append(result, serviceName)
+		return nil
+	}
+
+	for _, serviceName := range serviceNames {
+		if err := visit(serviceName, nil); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,380 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/fileutils"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+// 镜像标签，构建配置（Dockerfile、构建上下文、参数等）的 hash 值，没有变化时不需要重新构建
+const LabelBuildHash = "com.smartide.build.hash"
+
+// 计算构建上下文的 hash 值，远程主机上的构建上下文不在本地，需要自定义
+type BuildContextHasher func(ctx context.Context, contextDir string) (string, error)
+
+// 构建配置的 hash 值，包括构建上下文中所有文件的内容，以及 Dockerfile、target、args 等参数
+func (p *Project) getBuildHash(ctx context.Context, service Service, contextDir string) (string, error) {
+	var contextHash string
+	var err error
+	if p.BuildContextHasher != nil {
+		contextHash, err = p.BuildContextHasher(ctx, contextDir)
+	} else {
+		contextHash, err = HashLocalBuildContext(contextDir)
+	}
+	if err != nil {
+		return "", err
+	}
+	// Dockerfile 可能被 .dockerignore 忽略，或者不在构建上下文中（比如 ../Dockerfile），需要单独计算
+	dockerfileContent, err := p.readFile(p.getDockerfilePath(service, contextDir))
+	if err != nil {
+		return "", err
+	}
+	dockerfileHash := sha256.Sum256(dockerfileContent)
+
+	items := []string{
+		"context=" + contextHash,
+		"dockerfile=" + service.Build.Dockerfile,
+		"dockerfile-content=" + hex.EncodeToString(dockerfileHash[:]),
+		"target=" + service.Build.Target,
+		"platform=" + service.Platform,
+	}
+	var args []string
+	for key, value := range service.Build.Args {
+		args = append(args, fmt.Sprintf("arg:%v=%v", key, p.interpolate(fmt.Sprint(value))))
+	}
+	sort.Strings(args)
+	items = append(items, args...)
+	for _, cacheFrom := range service.Build.CacheFrom {
+		items = append(items, "cache-from="+getImageRef(cacheFrom))
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join(items, "\n")))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// Dockerfile 的路径，相对路径基于构建上下文
+func (p *Project) getDockerfilePath(service Service, contextDir string) string {
+	dockerfile := service.Build.Dockerfile
+	if dockerfile == "" {
+		dockerfile = "Dockerfile"
+	}
+	if path.IsAbs(dockerfile) || filepath.IsAbs(dockerfile) {
+		return dockerfile
+	}
+	return p.joinPath(contextDir, dockerfile)
+}
+
+// 本地构建上下文的 hash 值，与 docker 一致忽略 .dockerignore 中的文件
+// 只计算文件的路径、权限以及内容，修改时间不影响 hash 值；.git 目录的变化不会触发重新构建
+func HashLocalBuildContext(contextDir string) (string, error) {
+	excludes, err := readDockerignore(contextDir)
+	if err != nil {
+		return "", err
+	}
+	matcher, err := fileutils.NewPatternMatcher(excludes)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	err = filepath.Walk(contextDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(contextDir, filePath)
+		if err != nil || relPath == "." {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		isExcluded, err := matcher.Matches(relPath)
+		if err != nil {
+			return err
+		}
+		if isExcluded {
+			if info.IsDir() && !matcher.Exclusions() { // 没有 ! 规则时，整个目录都可以跳过
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		fmt.Fprintf(hash, "%v %o\n", filepath.ToSlash(relPath), info.Mode())
+		switch {
+		case info.Mode().IsRegular():
+			file, err := os.Open(filePath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			if _, err = io.Copy(hash, file); err != nil {
+				return err
+			}
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(hash, target)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// 远程主机上构建上下文的 hash 值，通过 execute 在远程主机上执行 shell 命令（需要 GNU find 和 sha256sum）
+// 与 HashLocalBuildContext 一致忽略 .dockerignore 中的文件，只计算会发送到构建上下文中文件的路径、权限以及内容
+func HashRemoteBuildContext(contextDir string, execute func(command string) (string, error)) (string, error) {
+	//1. .dockerignore
+	output, err := execute(fmt.Sprintf("cat %v 2>/dev/null || true", common.ShellQuote(path.Join(contextDir, ".dockerignore"))))
+	if err != nil {
+		return "", err
+	}
+	excludes := parseDockerignore(output)
+	matcher, err := fileutils.NewPatternMatcher(excludes)
+	if err != nil {
+		return "", err
+	}
+
+	//2. 文件列表，每个文件依次为类型、权限、路径、链接目标；整个目录被忽略时不需要遍历
+	prune := getRemoteBuildContextPrune(excludes, matcher.Exclusions())
+	output, err = execute(fmt.Sprintf(`cd %v && find . %v -prune -o -printf '%%y\0%%m\0%%P\0%%l\0'`, common.ShellQuote(contextDir), prune))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Split(output, "\x00")
+	type contextFile struct {
+		fileType string
+		mode     string
+		target   string
+	}
+	files := map[string]contextFile{}
+	var relPaths []string
+	for index := 0; index+3 < len(fields); index += 4 {
+		relPath := fields[index+2]
+		if relPath == "" {
+			continue
+		}
+		isExcluded, err := matcher.Matches(filepath.FromSlash(relPath))
+		if err != nil {
+			return "", err
+		}
+		if isExcluded {
+			continue
+		}
+		files[relPath] = contextFile{fileType: fields[index], mode: fields[index+1], target: fields[index+3]}
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	//3. 文件内容的 hash 值，e.g. <sha256>  ./src/index.js
+	output, err = execute(fmt.Sprintf(`cd %v && find . %v -prune -o -type f -exec sha256sum -z -- {} +`, common.ShellQuote(contextDir), prune))
+	if err != nil {
+		return "", err
+	}
+	contentHashes := map[string]string{}
+	for _, item := range strings.Split(output, "\x00") {
+		if index := strings.Index(item, "  "); index > 0 {
+			contentHashes[strings.TrimPrefix(item[index+2:], "./")] = item[:index]
+		}
+	}
+
+	//4. 汇总
+	hash := sha256.New()
+	for _, relPath := range relPaths {
+		file := files[relPath]
+		fmt.Fprintf(hash, "%v %v %v\n", relPath, file.fileType, file.mode)
+		switch file.fileType {
+		case "f":
+			contentHash, ok := contentHashes[relPath]
+			if !ok {
+				return "", fmt.Errorf("failed to hash %v in the build context %v", relPath, contextDir)
+			}
+			fmt.Fprintln(hash, contentHash)
+		case "l":
+			fmt.Fprintln(hash, file.target)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// 远程主机上 find 命令跳过的目录：.git 目录，以及没有 ! 规则时 .dockerignore 中不含通配符的路径（e.g. node_modules、**/dist）
+func getRemoteBuildContextPrune(excludes []string, hasExclusions bool) string {
+	items := []string{"-type d -name .git"}
+	if !hasExclusions {
+		for _, exclude := range excludes {
+			exclude = filepath.ToSlash(exclude)
+			if strings.HasPrefix(exclude, "**/") {
+				name := strings.TrimPrefix(exclude, "**/")
+				if name != "" && !strings.ContainsAny(name, "*?[\\/") {
+					items = append(items, "-name "+common.ShellQuote(name))
+				}
+				continue
+			}
+			if exclude == "." || exclude == ".." || strings.HasPrefix(exclude, "../") || strings.HasPrefix(exclude, "/") ||
+				strings.ContainsAny(exclude, "*?[\\") {
+				continue
+			}
+			items = append(items, "-path "+common.ShellQuote("./"+exclude))
+		}
+	}
+	return `\( ` + strings.Join(items, " -o ") + ` \)`
+}
+
+// 读取构建上下文中的 .dockerignore，不存在时返回空
+func readDockerignore(contextDir string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(contextDir, ".dockerignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return parseDockerignore(string(content)), nil
+}
+
+// 解析 .dockerignore 的内容
+func parseDockerignore(content string) []string {
+	var excludes []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			excludes = append(excludes, "!"+filepath.Clean(line[1:]))
+		} else {
+			excludes = append(excludes, filepath.Clean(line))
+		}
+	}
+	return excludes
+}
+
+// 镜像的完整名称，e.g. node:16
+func getImageRef(image Image) string {
+	if image.Tag != "" {
+		return image.Name + ":" + image.Tag
+	}
+	return image.Name
+}
+
+// 确保服务的镜像存在，构建配置没有变化时使用已有的镜像，返回镜像id
+// 用于只需要镜像、不需要启动容器的场景，比如 k8s 工作区在本地构建镜像后推送到镜像仓库
+func (p *Project) EnsureImage(ctx context.Context, serviceName string) (imageId string, err error) {
+	if _, ok := p.Compose.Services[serviceName]; !ok {
+		return "", fmt.Errorf("service %v not found", serviceName)
+	}
+	return p.ensureImage(ctx, serviceName)
+}
+
+// 推送镜像到镜像仓库，使用 docker login 保存的认证信息
+func (p *Project) PushImage(ctx context.Context, serviceName string, imageName string) error {
+	p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Push, Status: ProgressStatusEnum_Working})
+	err := func() error {
+		reader, err := p.client.ImagePush(ctx, imageName, types.ImagePushOptions{RegistryAuth: getRegistryAuth(imageName)})
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		return p.readJsonMessages(reader, serviceName, imageName, ProjectStageEnum_Push)
+	}()
+	if err != nil {
+		p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Push, Status: ProgressStatusEnum_Error, Text: err.Error()})
+		return &ProjectError{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Push, Err: err}
+	}
+	p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Push, Status: ProgressStatusEnum_Done})
+	return nil
+}
+
+// 镜像仓库中是否已经存在该镜像
+func (p *Project) IsImageInRegistry(ctx context.Context, imageName string) bool {
+	_, err := p.client.DistributionInspect(ctx, imageName, getRegistryAuth(imageName))
+	return err == nil
+}
+
+// 从 docker 的配置文件（~/.docker/config.json）中获取镜像仓库的认证信息，不支持 credential helper
+func getRegistryAuth(imageName string) string {
+	registry := getImageRegistry(imageName)
+
+	configDir := os.Getenv("DOCKER_CONFIG")
+	if configDir == "" {
+		homeDir, _ := os.UserHomeDir()
+		configDir = filepath.Join(homeDir, ".docker")
+	}
+	content, err := os.ReadFile(filepath.Join(configDir, "config.json"))
+	if err != nil {
+		return ""
+	}
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(content, &dockerConfig); err != nil {
+		return ""
+	}
+	for key, item := range dockerConfig.Auths {
+		host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://"), "/v1/")
+		if host != registry && !(registry == "docker.io" && host == "index.docker.io") {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(item.Auth)
+		if err != nil {
+			return ""
+		}
+		items := strings.SplitN(string(decoded), ":", 2)
+		if len(items) != 2 {
+			return ""
+		}
+		authConfig, _ := json.Marshal(types.AuthConfig{Username: items[0], Password: items[1], ServerAddress: key})
+		return base64.URLEncoding.EncodeToString(authConfig)
+	}
+	return ""
+}
+
+// 镜像所在的镜像仓库，e.g. registry.cn-hangzhou.aliyuncs.com/smartide/node -> registry.cn-hangzhou.aliyuncs.com
+func getImageRegistry(imageName string) string {
+	index := strings.Index(imageName, "/")
+	if index < 0 {
+		return "docker.io"
+	}
+	domain := imageName[:index]
+	if strings.ContainsAny(domain, ".:") || domain == "localhost" {
+		return domain
+	}
+	return "docker.io"
+}
@@ -0,0 +1,338 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/archive"
+)
+
+func TestHashLocalBuildContext(t *testing.T) {
+	contextDir := t.TempDir()
+	writeFile := func(relPath string, content string) {
+		filePath := filepath.Join(contextDir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func() string {
+		result, err := HashLocalBuildContext(contextDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	writeFile("Dockerfile", "FROM node:16")
+	writeFile("src/index.js", "console.log(1)")
+	writeFile(".dockerignore", "node_modules\n# comment\n*.log\n")
+	origin := hash()
+
+	//1. 忽略的文件、.git 目录以及修改时间不影响 hash 值
+	writeFile("node_modules/lib/index.js", "module.exports = {}")
+	writeFile("debug.log", "log")
+	writeFile(".git/HEAD", "ref: refs/heads/main")
+	if err := os.Chtimes(filepath.Join(contextDir, "Dockerfile"), time.Now().Add(time.Hour), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got := hash(); got != origin {
+		t.Errorf("HashLocalBuildContext() = %v, want %v (ignored files changed)", got, origin)
+	}
+
+	//2. 文件内容、文件名改变时 hash 值改变
+	writeFile("src/index.js", "console.log(2)")
+	changed := hash()
+	if changed == origin {
+		t.Errorf("HashLocalBuildContext() should change when file content changed")
+	}
+	writeFile("src/main.js", "")
+	if got := hash(); got == changed {
+		t.Errorf("HashLocalBuildContext() should change when file added")
+	}
+}
+
+func TestHashRemoteBuildContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh is not available on windows")
+	}
+	contextDir := t.TempDir()
+	writeFile := func(relPath string, content string) {
+		filePath := filepath.Join(contextDir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var commands []string
+	hash := func() string {
+		// 在本地执行远程主机上的命令
+		result, err := HashRemoteBuildContext(contextDir, func(command string) (string, error) {
+			commands = append(commands, command)
+			output, err := exec.Command("sh", "-c", command).CombinedOutput()
+			return string(output), err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	writeFile("Dockerfile", "FROM node:16")
+	writeFile("src/index.js", "console.log(1)")
+	writeFile(".dockerignore", "node_modules\n# comment\n*.log\n")
+	origin := hash()
+
+	//1. 忽略的文件、.git 目录不影响 hash 值，被忽略的目录不需要遍历
+	writeFile("node_modules/lib/index.js", "module.exports = {}")
+	writeFile("debug.log", "log")
+	writeFile(".git/HEAD", "ref: refs/heads/main")
+	commands = nil
+	if got := hash(); got != origin {
+		t.Errorf("HashRemoteBuildContext() = %v, want %v (ignored files changed)", got, origin)
+	}
+	if len(commands) != 3 || !strings.Contains(commands[1], "-path './node_modules'") {
+		t.Errorf("HashRemoteBuildContext() commands = %v, want node_modules pruned", commands)
+	}
+
+	//2. 文件内容、权限改变时 hash 值改变
+	writeFile("src/index.js", "console.log(2)")
+	changed := hash()
+	if changed == origin {
+		t.Errorf("HashRemoteBuildContext() should change when file content changed")
+	}
+	if err := os.Chmod(filepath.Join(contextDir, "src", "index.js"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := hash(); got == changed {
+		t.Errorf("HashRemoteBuildContext() should change when file mode changed")
+	}
+
+	//3. ! 规则重新包含的文件
+	writeFile(".dockerignore", "node_modules\n!node_modules/lib/index.js\n")
+	changed = hash()
+	writeFile("node_modules/lib/index.js", "module.exports = {a: 1}")
+	if got := hash(); got == changed {
+		t.Errorf("HashRemoteBuildContext() should change when included file changed")
+	}
+}
+
+func TestGetRemoteBuildContextPrune(t *testing.T) {
+	tests := []struct {
+		name          string
+		excludes      []string
+		hasExclusions bool
+		want          string
+	}{
+		{"empty", nil, false, `\( -type d -name .git \)`},
+		{"paths", []string{"node_modules", "build/out", "**/dist", "*.log", "../x"}, false,
+			`\( -type d -name .git -o -path './node_modules' -o -path './build/out' -o -name 'dist' \)`},
+		{"exclusions", []string{"node_modules", "!node_modules/a"}, true, `\( -type d -name .git \)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getRemoteBuildContextPrune(tt.excludes, tt.hasExclusions); got != tt.want {
+				t.Errorf("getRemoteBuildContextPrune() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBuildHash(t *testing.T) {
+	files := map[string]string{"/app/Dockerfile": "FROM node:16", "/app/dev.Dockerfile": "FROM node:16", "/app2/Dockerfile": "FROM node:16"}
+	project := &Project{
+		WorkingDir:  "/app",
+		Environment: map[string]string{"NODE_VERSION": "16"},
+		BuildContextHasher: func(ctx context.Context, contextDir string) (string, error) {
+			return "context:" + contextDir, nil
+		},
+		ReadFile: func(filePath string) ([]byte, error) {
+			if content, ok := files[filePath]; ok {
+				return []byte(content), nil
+			}
+			return nil, os.ErrNotExist
+		},
+	}
+	service := Service{Build: Build{Context: ".", Args: map[string]interface{}{"NODE": "${NODE_VERSION}", "DEBUG": "1"}}}
+	origin, err := project.getBuildHash(context.Background(), service, "/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		service    Service
+		contextDir string
+		wantEqual  bool
+	}{
+		{name: "same", service: service, contextDir: "/app", wantEqual: true},
+		{name: "context", service: service, contextDir: "/app2"},
+		{name: "args", service: Service{Build: Build{Context: ".", Args: map[string]interface{}{"NODE": "18", "DEBUG": "1"}}}, contextDir: "/app"},
+		{name: "target", service: Service{Build: Build{Context: ".", Args: service.Build.Args, Target: "dev"}}, contextDir: "/app"},
+		{name: "dockerfile", service: Service{Build: Build{Context: ".", Args: service.Build.Args, Dockerfile: "dev.Dockerfile"}}, contextDir: "/app"},
+		{name: "cache_from", service: Service{Build: Build{Context: ".", Args: service.Build.Args, CacheFrom: []Image{NewImage("node", "16")}}}, contextDir: "/app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := project.getBuildHash(context.Background(), tt.service, tt.contextDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == origin) != tt.wantEqual {
+				t.Errorf("getBuildHash() = %v, origin %v, want equal %v", got, origin, tt.wantEqual)
+			}
+		})
+	}
+
+	// Dockerfile 的内容改变，即使被 .dockerignore 忽略或者在构建上下文之外
+	files["/app/Dockerfile"] = "FROM node:18"
+	if got, err := project.getBuildHash(context.Background(), service, "/app"); err != nil || got == origin {
+		t.Errorf("getBuildHash() = %v, %v, should change with Dockerfile content", got, err)
+	}
+	files["/Dockerfile"] = "FROM node:16"
+	outside := Service{Build: Build{Context: ".", Args: service.Build.Args, Dockerfile: "../Dockerfile"}}
+	before, err := project.getBuildHash(context.Background(), outside, "/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["/Dockerfile"] = "FROM node:18"
+	if got, err := project.getBuildHash(context.Background(), outside, "/app"); err != nil || got == before {
+		t.Errorf("getBuildHash() = %v, %v, should change with Dockerfile outside the context", got, err)
+	}
+
+	// Dockerfile 不存在
+	if _, err := project.getBuildHash(context.Background(), Service{Build: Build{Context: ".", Dockerfile: "not-exist"}}, "/app"); err == nil {
+		t.Errorf("getBuildHash() should return error when Dockerfile not exist")
+	}
+}
+
+func TestKeepBuildFiles(t *testing.T) {
+	contextDir := t.TempDir()
+	for _, fileName := range []string{"Dockerfile", ".dockerignore", "index.js", "debug.log"} {
+		if err := os.WriteFile(filepath.Join(contextDir, fileName), []byte(fileName), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	excludes, err := keepBuildFiles([]string{"Dockerfile", ".dockerignore", "*.log"}, "Dockerfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := archive.TarWithOptions(contextDir, &archive.TarOptions{ExcludePatterns: excludes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var got []string
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, header.Name)
+	}
+	sort.Strings(got)
+	if want := []string{".dockerignore", "Dockerfile", "index.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("build context = %v, want %v", got, want)
+	}
+}
+
+func TestGetImageRegistry(t *testing.T) {
+	tests := []struct {
+		imageName string
+		want      string
+	}{
+		{imageName: "node:16", want: "docker.io"},
+		{imageName: "smartide/node:16", want: "docker.io"},
+		{imageName: "registry.cn-hangzhou.aliyuncs.com/smartide/node:16", want: "registry.cn-hangzhou.aliyuncs.com"},
+		{imageName: "localhost:5000/node", want: "localhost:5000"},
+		{imageName: "localhost/node", want: "localhost"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if got := getImageRegistry(tt.imageName); got != tt.want {
+				t.Errorf("getImageRegistry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRegistryAuth(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", configDir)
+	content := fmt.Sprintf(`{"auths": {"https://index.docker.io/v1/": {"auth": "%v"}, "registry.example.com": {"auth": "%v"}}}`,
+		base64.StdEncoding.EncodeToString([]byte("hub:secret")), base64.StdEncoding.EncodeToString([]byte("user:pass:word")))
+	if err := os.WriteFile(filepath.Join(configDir, "config.json"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		imageName    string
+		wantUsername string
+		wantPassword string
+	}{
+		{imageName: "smartide/node", wantUsername: "hub", wantPassword: "secret"},
+		{imageName: "registry.example.com/smartide/node:1", wantUsername: "user", wantPassword: "pass:word"},
+		{imageName: "registry.other.com/node"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got := getRegistryAuth(tt.imageName)
+			if tt.wantUsername == "" {
+				if got != "" {
+					t.Errorf("getRegistryAuth() = %v, want empty", got)
+				}
+				return
+			}
+			decoded, err := base64.URLEncoding.DecodeString(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var authConfig types.AuthConfig
+			if err := json.Unmarshal(decoded, &authConfig); err != nil {
+				t.Fatal(err)
+			}
+			if authConfig.Username != tt.wantUsername || authConfig.Password != tt.wantPassword {
+				t.Errorf("getRegistryAuth() = %v:%v, want %v:%v", authConfig.Username, authConfig.Password, tt.wantUsername, tt.wantPassword)
+			}
+		})
+	}
+}
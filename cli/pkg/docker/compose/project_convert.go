@@ -0,0 +1,474 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/go-connections/nat"
+)
+
+// 服务转换为创建容器的参数
+type containerCreateOptions struct {
+	Name             string
+	Config           *container.Config
+	HostConfig       *container.HostConfig
+	NetworkingConfig *network.NetworkingConfig
+	// 除了第一个网络以外，需要在容器创建后再连接的网络
+	ExtraNetworks []string
+}
+
+// 把服务转换为 docker api 创建容器的参数，imageId 用于计算配置的 hash 值
+func (p *Project) toContainerCreateOptions(serviceName string, service Service, imageId string) (result containerCreateOptions, err error) {
+	result.Name = p.getContainerName(serviceName, service)
+
+	//1. 端口
+	exposedPorts, portBindings := nat.PortSet{}, nat.PortMap{}
+	for _, port := range service.Ports {
+		portConfigs, err := p.interpolatePort(port).ToComplex()
+		if err != nil {
+			return result, fmt.Errorf("ports: %w", err)
+		}
+		for _, portConfig := range portConfigs {
+			protocol := portConfig.Protocol
+			if protocol == "" {
+				protocol = ProtocolTCP
+			}
+			natPort, err := nat.NewPort(protocol, strconv.Itoa(portConfig.Target))
+			if err != nil {
+				return result, fmt.Errorf("ports: %w", err)
+			}
+			exposedPorts[natPort] = struct{}{}
+			portBindings[natPort] = append(portBindings[natPort], nat.PortBinding{HostIP: portConfig.HostIP, HostPort: portConfig.Published})
+		}
+	}
+	for _, expose := range service.Expose {
+		proto, port := nat.SplitProtoPort(p.interpolate(expose))
+		natPort, err := nat.NewPort(proto, port)
+		if err != nil {
+			return result, fmt.Errorf("expose: %w", err)
+		}
+		exposedPorts[natPort] = struct{}{}
+	}
+
+	//2. 挂载卷
+	var binds []string
+	anonymousVolumes := map[string]struct{}{}
+	tmpfs := map[string]string{}
+	for _, volume := range service.Volumes {
+		if volumeConfig, err := volume.ToComplex(); err == nil && volumeConfig.Type == VolumeTypeTmpfs {
+			tmpfs[p.interpolate(volumeConfig.Target)] = getTmpfsOptions(volumeConfig)
+			continue
+		}
+		bind, anonymousVolume, err := p.parseServiceVolume(volume)
+		if err != nil {
+			return result, err
+		}
+		if anonymousVolume != "" {
+			anonymousVolumes[anonymousVolume] = struct{}{}
+		} else {
+			binds = append(binds, bind)
+		}
+	}
+	//2.1. 密钥，以只读文件的方式挂载
+	for _, secret := range service.Secrets {
+		bind, err := p.getSecretBind(secret.ToComplex())
+		if err != nil {
+			return result, err
+		}
+		binds = append(binds, bind)
+	}
+	//2.2. 配置，以只读文件的方式挂载
+	for _, config := range service.Configs {
+		bind, err := p.getConfigBind(config.ToComplex())
+		if err != nil {
+			return result, err
+		}
+		binds = append(binds, bind)
+	}
+
+	//3. 环境变量
+	environment, err := p.getServiceEnvironment(service)
+	if err != nil {
+		return result, err
+	}
+
+	//4. 标签
+	labels := map[string]string{}
+	for key, value := range service.Labels {
+		labels[key] = p.interpolate(value)
+	}
+	labels[LabelProject] = p.Name
+	labels[LabelService] = serviceName
+	labels[LabelWorkingDir] = p.getWorkingDir()
+	labels[LabelConfigFiles] = p.ConfigFile
+	labels[LabelContainerNumber] = "1"
+	labels[LabelOneoff] = "False"
+	labels[LabelVersion] = "smartide"
+	labels[LabelConfigHash] = getServiceConfigHash(service, imageId)
+
+	//5. 容器配置，所有的字符串都需要替换变量，InterpolateYaml 替换后的 $ 转义为 $$
+	result.Config = &container.Config{
+		Image:        p.interpolate(service.Image),
+		Hostname:     p.interpolate(service.Hostname),
+		Domainname:   p.interpolate(service.DomainName),
+		User:         p.interpolate(service.User),
+		WorkingDir:   p.interpolate(service.WorkingDir),
+		Env:          environment,
+		Labels:       labels,
+		ExposedPorts: exposedPorts,
+		Volumes:      anonymousVolumes,
+		Tty:          service.Tty,
+		OpenStdin:    service.StdinOpen,
+		StopSignal:   p.interpolate(service.StopSignal),
+		MacAddress:   p.interpolate(service.MacAddress),
+	}
+	if len(service.Command) > 0 {
+		result.Config.Cmd = strslice.StrSlice(p.interpolateSlice(service.Command))
+	}
+	if len(service.Entrypoint) > 0 {
+		result.Config.Entrypoint = strslice.StrSlice(p.interpolateSlice(service.Entrypoint))
+	}
+	if service.StopGracePeriod != nil {
+		stopTimeout := int(*service.StopGracePeriod)
+		result.Config.StopTimeout = &stopTimeout
+	}
+	if len(service.HealthCheck) > 0 {
+		if result.Config.Healthcheck, err = parseHealthCheck(service.HealthCheck); err != nil {
+			return result, fmt.Errorf("healthcheck: %w", err)
+		}
+		result.Config.Healthcheck.Test = p.interpolateSlice(result.Config.Healthcheck.Test)
+	}
+
+	//6. 主机配置
+	networkMode := p.interpolate(service.NetworkMode)
+	if networkMode == "" {
+		networkMode = p.interpolate(service.Net)
+	}
+	if strings.HasPrefix(networkMode, "service:") { // 共享其他服务的网络
+		otherServiceName := strings.TrimPrefix(networkMode, "service:")
+		networkMode = "container:" + p.getContainerName(otherServiceName, p.Compose.Services[otherServiceName])
+	}
+	hostConfig := &container.HostConfig{
+		Binds:          binds,
+		Tmpfs:          tmpfs,
+		PortBindings:   portBindings,
+		RestartPolicy:  container.RestartPolicy{Name: p.interpolate(service.Restart)},
+		NetworkMode:    container.NetworkMode(networkMode),
+		CapAdd:         strslice.StrSlice(p.interpolateSlice(service.CapAdd)),
+		CapDrop:        strslice.StrSlice(p.interpolateSlice(service.CapDrop)),
+		DNS:            p.interpolateSlice(service.DNS),
+		DNSOptions:     p.interpolateSlice(service.DNSOpts),
+		DNSSearch:      p.interpolateSlice(service.DNSSearch),
+		ExtraHosts:     p.interpolateSlice(service.ExtraHosts),
+		GroupAdd:       p.interpolateSlice(service.GroupAdd),
+		IpcMode:        container.IpcMode(p.interpolate(service.Ipc)),
+		PidMode:        container.PidMode(p.interpolate(service.Pid)),
+		UTSMode:        container.UTSMode(p.interpolate(service.Uts)),
+		UsernsMode:     container.UsernsMode(p.interpolate(service.UserNSMode)),
+		Privileged:     service.Privileged,
+		ReadonlyRootfs: service.ReadOnly,
+		SecurityOpt:    p.interpolateSlice(service.SecurityOpt),
+		ShmSize:        service.ShmSize,
+		Sysctls:        p.interpolateMap(service.Sysctls),
+		Runtime:        p.interpolate(service.Runtime),
+		Isolation:      container.Isolation(p.interpolate(service.Isolation)),
+		VolumesFrom:    p.interpolateSlice(service.VolumesFrom),
+		OomScoreAdj:    int(service.OomScoreAdj),
+		Init:           service.Init,
+		VolumeDriver:   p.interpolate(service.VolumeDriver),
+		LogConfig:      container.LogConfig{Type: p.interpolate(service.LogDriver), Config: p.interpolateMap(service.LogOpt)},
+		Resources: container.Resources{
+			CgroupParent:       p.interpolate(service.CgroupParent),
+			CPUCount:           service.CPUCount,
+			CPUPercent:         int64(service.CPUPercent),
+			CPUPeriod:          service.CPUPeriod,
+			CPUQuota:           service.CPUQuota,
+			CPURealtimePeriod:  service.CPURTPeriod,
+			CPURealtimeRuntime: service.CPURTRuntime,
+			CpusetCpus:         p.interpolate(service.CPUSet),
+			CPUShares:          service.CPUShares,
+			NanoCPUs:           int64(service.CPUS * 1e9),
+		},
+	}
+	if service.OomKillDisable {
+		hostConfig.OomKillDisable = &service.OomKillDisable
+	}
+	if service.PidsLimit != 0 {
+		hostConfig.PidsLimit = &service.PidsLimit
+	}
+	for _, device := range service.Devices {
+		deviceMapping, err := parseDevice(p.interpolate(device))
+		if err != nil {
+			return result, err
+		}
+		hostConfig.Devices = append(hostConfig.Devices, deviceMapping)
+	}
+	for _, link := range service.Links { // e.g. db、db:database
+		parts := strings.SplitN(link, ":", 2)
+		alias := parts[0]
+		if len(parts) == 2 {
+			alias = parts[1]
+		}
+		hostConfig.Links = append(hostConfig.Links, fmt.Sprintf("%v:%v", p.getContainerName(parts[0], p.Compose.Services[parts[0]]), alias))
+	}
+	result.HostConfig = hostConfig
+
+	//7. 网络，创建时只能指定一个网络，其他的网络在创建后连接
+	networks := p.getServiceNetworks(service)
+	if len(networks) > 0 {
+		result.HostConfig.NetworkMode = container.NetworkMode(p.getNetworkName(networks[0]))
+		result.NetworkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				p.getNetworkName(networks[0]): {Aliases: []string{serviceName}},
+			},
+		}
+		for _, networkName := range networks[1:] {
+			result.ExtraNetworks = append(result.ExtraNetworks, p.getNetworkName(networkName))
+		}
+	}
+
+	return result, nil
+}
+
+// 服务配置的 hash 值，配置或者镜像改变的时候才需要重新创建容器
+func getServiceConfigHash(service Service, imageId string) string {
+	hash := sha256.Sum256([]byte(MarshalYaml(service) + imageId))
+	return hex.EncodeToString(hash[:])
+}
+
+// 环境变量，env_file 中的变量会被 environment 覆盖
+func (p *Project) getServiceEnvironment(service Service) ([]string, error) {
+	environment := map[string]string{}
+	for _, envFile := range service.EnvFile {
+		envFilePath := p.resolvePath(p.interpolate(envFile))
+		content, err := p.readFile(envFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("env_file %v: %w", envFile, err)
+		}
+		for key, value := range parseEnvFile(string(content), p.Environment) {
+			environment[key] = value
+		}
+	}
+	for key, value := range service.Environment {
+		environment[key] = p.interpolate(value)
+	}
+
+	var result []string
+	for key, value := range environment {
+		result = append(result, fmt.Sprintf("%v=%v", key, value))
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// 解析 env 文件，只有变量名时从 lookup 中取值
+func parseEnvFile(content string, lookup map[string]string) map[string]string {
+	result := map[string]string{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		if index := strings.Index(line, "="); index >= 0 {
+			key, value := strings.TrimSpace(line[:index]), strings.TrimSpace(line[index+1:])
+			if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+				value = value[1 : len(value)-1]
+			}
+			result[key] = value
+		} else if value, ok := lookup[line]; ok {
+			result[line] = value
+		}
+	}
+	return result
+}
+
+// 是否为绝对路径，包括 windows 的盘符路径
+var windowsAbsPathRegexp = regexp.MustCompile(`^[a-zA-Z]:[\\/]`)
+
+// 相对路径转换为基于项目目录的绝对路径
+func (p *Project) resolvePath(filePath string) string {
+	filePath = p.expandHomeDir(filePath)
+	if strings.HasPrefix(filePath, "/") || strings.HasPrefix(filePath, "\\\\") || windowsAbsPathRegexp.MatchString(filePath) {
+		return filePath
+	}
+	return p.joinPath(p.getWorkingDir(), filePath)
+}
+
+// 解析服务的挂载卷，e.g. ./src:/home/project、data:/var/lib/mysql:rw、/tmp
+// 返回 binds 格式的字符串，或者匿名挂载卷（只有容器路径）
+func (p *Project) parseServiceVolume(volume VolumeMap) (bind string, anonymousVolume string, err error) {
+	volumeConfig, err := p.interpolateVolume(volume).ToComplex()
+	if err != nil {
+		return "", "", err
+	}
+
+	source := volumeConfig.Source
+	switch {
+	case source == "": // 匿名挂载卷
+		return "", volumeConfig.Target, nil
+	case volumeConfig.Type == VolumeTypeBind: // 主机路径
+		source = p.resolvePath(source)
+	case volumeConfig.Type == VolumeTypeVolume: // 申明的挂载卷
+		if _, ok := p.Compose.Volumes[source]; ok {
+			source = p.getVolumeName(source)
+		}
+	}
+	bind = source + ":" + volumeConfig.Target
+	if modes := volumeConfig.GetModes(); len(modes) > 0 {
+		bind += ":" + strings.Join(modes, ",")
+	}
+	return bind, "", nil
+}
+
+// 端口中的变量替换
+func (p *Project) interpolatePort(port Port) Port {
+	switch item := port.(type) {
+	case PortSimple:
+		if result, err := ParsePort(p.interpolate(item.String())); err == nil {
+			return result
+		}
+	case PortComplex:
+		item.HostIP, item.Published = p.interpolate(item.HostIP), p.interpolate(item.Published)
+		return item
+	}
+	return port
+}
+
+// 挂载卷中的变量替换
+func (p *Project) interpolateVolume(volume VolumeMap) VolumeMap {
+	switch item := volume.(type) {
+	case VolumeMapSimple:
+		if result, err := ParseVolumeMap(p.interpolate(item.String())); err == nil {
+			return result
+		}
+	case VolumeMapComplex:
+		item.Source, item.Target = p.interpolate(item.Source), p.interpolate(item.Target)
+		return item
+	}
+	return volume
+}
+
+// tmpfs 的挂载选项，e.g. size=64m,mode=1777
+func getTmpfsOptions(volumeConfig VolumeMapComplex) string {
+	var options []string
+	if volumeConfig.ReadOnly {
+		options = append(options, VolumeReadOnly)
+	}
+	if volumeConfig.Tmpfs != nil {
+		if volumeConfig.Tmpfs.Size != "" {
+			options = append(options, "size="+volumeConfig.Tmpfs.Size)
+		}
+		if volumeConfig.Tmpfs.Mode != nil {
+			options = append(options, fmt.Sprintf("mode=%o", *volumeConfig.Tmpfs.Mode))
+		}
+	}
+	return strings.Join(options, ",")
+}
+
+// 密钥对应的 binds 格式的字符串，只支持 file 类型的密钥
+func (p *Project) getSecretBind(secret SecretComplex) (string, error) {
+	secretConfig, ok := p.Compose.Secrets[secret.Source]
+	if !ok {
+		return "", fmt.Errorf("secret %v is not defined", secret.Source)
+	}
+	if secretConfig.File == "" {
+		return "", fmt.Errorf("secret %v: only file secrets are supported", secret.Source)
+	}
+	return p.resolvePath(p.interpolate(secretConfig.File)) + ":" + secret.GetTargetPath() + ":" + VolumeReadOnly, nil
+}
+
+// 配置对应的 binds 格式的字符串，只支持 file 类型的配置
+// 以只读的方式挂载宿主机上的文件，无法修改文件的所有者和权限，设置了 uid、gid、mode 时返回错误
+func (p *Project) getConfigBind(config ConfigComplex) (string, error) {
+	configDefine, ok := p.Compose.Configs[config.Source]
+	if !ok {
+		return "", fmt.Errorf("config %v is not defined", config.Source)
+	}
+	if config.Uid != "" || config.Gid != "" || config.Mode != nil {
+		return "", fmt.Errorf("config %v: uid, gid and mode are not supported", config.Source)
+	}
+	if configDefine.File == "" {
+		return "", fmt.Errorf("config %v: only file configs are supported", config.Source)
+	}
+	return p.resolvePath(p.interpolate(configDefine.File)) + ":" + config.GetTargetPath() + ":" + VolumeReadOnly, nil
+}
+
+// 解析设备映射，e.g. /dev/ttyUSB0:/dev/ttyUSB0:rwm
+func parseDevice(device string) (result container.DeviceMapping, err error) {
+	parts := strings.Split(device, ":")
+	switch len(parts) {
+	case 1:
+		return container.DeviceMapping{PathOnHost: parts[0], PathInContainer: parts[0], CgroupPermissions: "rwm"}, nil
+	case 2:
+		return container.DeviceMapping{PathOnHost: parts[0], PathInContainer: parts[1], CgroupPermissions: "rwm"}, nil
+	case 3:
+		return container.DeviceMapping{PathOnHost: parts[0], PathInContainer: parts[1], CgroupPermissions: parts[2]}, nil
+	}
+	return result, fmt.Errorf("device %v format error", device)
+}
+
+// 解析健康检查的配置
+func parseHealthCheck(healthCheck map[string]interface{}) (*container.HealthConfig, error) {
+	result := &container.HealthConfig{}
+	if disable, ok := healthCheck["disable"].(bool); ok && disable {
+		result.Test = []string{"NONE"}
+		return result, nil
+	}
+
+	switch test := healthCheck["test"].(type) {
+	case string: // test: curl -f http://localhost
+		result.Test = []string{"CMD-SHELL", test}
+	case []interface{}: // test: ["CMD", "curl", "-f", "http://localhost"]
+		for _, item := range test {
+			result.Test = append(result.Test, fmt.Sprint(item))
+		}
+	}
+
+	var err error
+	durations := map[string]*time.Duration{
+		"interval":     &result.Interval,
+		"timeout":      &result.Timeout,
+		"start_period": &result.StartPeriod,
+	}
+	for key, duration := range durations {
+		if value, ok := healthCheck[key]; ok {
+			if *duration, err = time.ParseDuration(fmt.Sprint(value)); err != nil {
+				return nil, fmt.Errorf("%v: %w", key, err)
+			}
+		}
+	}
+	if value, ok := healthCheck["retries"]; ok {
+		if result.Retries, err = strconv.Atoi(fmt.Sprint(value)); err != nil {
+			return nil, fmt.Errorf("retries: %w", err)
+		}
+	}
+	return result, nil
+}
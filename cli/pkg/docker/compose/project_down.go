@@ -0,0 +1,167 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// 停止容器时默认的等待时间
+const defaultStopTimeout = 10 * time.Second
+
+// 停止所有的服务，相当于 docker compose stop
+func (p *Project) Stop(ctx context.Context) error {
+	containers, err := p.getContainersInStopOrder(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, container := range containers {
+		if container.State != "running" {
+			continue
+		}
+		if err := p.stopContainer(ctx, container); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 停止并删除所有的容器、网络，相当于 docker compose down
+// isRemoveVolumes 为 true 时，同时删除申明的挂载卷以及匿名挂载卷（-v）
+func (p *Project) Down(ctx context.Context, isRemoveVolumes bool) error {
+	//1. 容器
+	containers, err := p.getContainersInStopOrder(ctx)
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if container.State == "running" {
+			if err := p.stopContainer(ctx, container); err != nil {
+				return err
+			}
+		}
+
+		serviceName, containerName := container.Labels[LabelService], getContainerDisplayName(container)
+		p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Working})
+		if err := p.client.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{RemoveVolumes: isRemoveVolumes, Force: true}); err != nil {
+			p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Error, Text: err.Error()})
+			return &ProjectError{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Remove, Err: err}
+		}
+		p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Done})
+	}
+
+	//2. 网络，只删除项目创建的网络
+	networks, err := p.client.NetworkList(ctx, types.NetworkListOptions{Filters: p.getProjectFilters()})
+	if err != nil {
+		return &ProjectError{Stage: ProjectStageEnum_Remove, Err: err}
+	}
+	for _, network := range networks {
+		p.progress(ProgressEvent{Resource: network.Name, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Working})
+		if err := p.client.NetworkRemove(ctx, network.ID); err != nil {
+			p.progress(ProgressEvent{Resource: network.Name, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Error, Text: err.Error()})
+			return &ProjectError{Resource: network.Name, Stage: ProjectStageEnum_Remove, Err: err}
+		}
+		p.progress(ProgressEvent{Resource: network.Name, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Done})
+	}
+
+	//3. 挂载卷，外部挂载卷不会被删除
+	if isRemoveVolumes {
+		volumes, err := p.client.VolumeList(ctx, p.getProjectFilters())
+		if err != nil {
+			return &ProjectError{Stage: ProjectStageEnum_Remove, Err: err}
+		}
+		for _, volume := range volumes.Volumes {
+			p.progress(ProgressEvent{Resource: volume.Name, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Working})
+			if err := p.client.VolumeRemove(ctx, volume.Name, false); err != nil {
+				p.progress(ProgressEvent{Resource: volume.Name, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Error, Text: err.Error()})
+				return &ProjectError{Resource: volume.Name, Stage: ProjectStageEnum_Remove, Err: err}
+			}
+			p.progress(ProgressEvent{Resource: volume.Name, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Done})
+		}
+	}
+
+	return nil
+}
+
+// 停止单个容器
+func (p *Project) stopContainer(ctx context.Context, container types.Container) error {
+	serviceName, containerName := container.Labels[LabelService], getContainerDisplayName(container)
+	timeout := defaultStopTimeout
+	if service, ok := p.Compose.Services[serviceName]; ok && service.StopGracePeriod != nil {
+		timeout = time.Duration(*service.StopGracePeriod) * time.Second
+	}
+
+	p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Stop, Status: ProgressStatusEnum_Working})
+	if err := p.client.ContainerStop(ctx, container.ID, &timeout); err != nil {
+		p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Stop, Status: ProgressStatusEnum_Error, Text: err.Error()})
+		return &ProjectError{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Stop, Err: err}
+	}
+	p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Stop, Status: ProgressStatusEnum_Done})
+	return nil
+}
+
+// 项目的容器，依赖其他服务的容器排在前面
+func (p *Project) getContainersInStopOrder(ctx context.Context) ([]types.Container, error) {
+	containers, err := p.client.ContainerList(ctx, types.ContainerListOptions{All: true,
+		Filters: filters.NewArgs(filters.Arg("label", LabelProject+"="+p.Name), filters.Arg("label", LabelOneoff+"=False"))})
+	if err != nil {
+		return nil, &ProjectError{Stage: ProjectStageEnum_Stop, Err: err}
+	}
+
+	// 依赖关系出错时（比如配置文件已经改变），按照名称排序
+	orders := map[string]int{}
+	if serviceNames, err := sortServicesByDependencies(p.Compose.Services); err == nil {
+		for index, serviceName := range serviceNames {
+			orders[serviceName] = len(serviceNames) - index
+		}
+	}
+	sort.SliceStable(containers, func(i, j int) bool {
+		orderI, orderJ := orders[containers[i].Labels[LabelService]], orders[containers[j].Labels[LabelService]]
+		if orderI != orderJ {
+			return orderI > orderJ
+		}
+		return getContainerDisplayName(containers[i]) < getContainerDisplayName(containers[j])
+	})
+	return containers, nil
+}
+
+// 容器名称，去掉开头的 /
+func getContainerDisplayName(container types.Container) string {
+	if len(container.Names) > 0 {
+		return strings.TrimPrefix(container.Names[0], "/")
+	}
+	return container.ID
+}
+
+// map 的 key 排序后返回
+func sortedKeys(items map[string]bool) []string {
+	var result []string
+	for key := range items {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,342 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestGetProjectName(t *testing.T) {
+	tests := []struct {
+		workingDir string
+		want       string
+	}{
+		{workingDir: "/home/smartide/My.Project", want: "myproject"},
+		{workingDir: "/home/smartide/smartide-cli/", want: "smartide-cli"},
+		{workingDir: "C:\\Users\\smartide\\boat_house", want: "boat_house"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if got := GetProjectName(tt.workingDir); got != tt.want {
+				t.Errorf("GetProjectName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortServicesByDependencies(t *testing.T) {
+	tests := []struct {
+		services  map[string]Service
+		want      []string
+		wantCycle bool
+		wantErr   bool
+	}{
+		{
+			services: map[string]Service{
+				"web":   {DependsOn: []interface{}{"db", "redis"}},
+				"db":    {},
+				"redis": {DependsOn: map[interface{}]interface{}{"db": map[interface{}]interface{}{"condition": "service_healthy"}}},
+			},
+			want: []string{"db", "redis", "web"},
+		},
+		{
+			services: map[string]Service{
+				"a": {DependsOn: []interface{}{"b"}},
+				"b": {DependsOn: []interface{}{"a"}},
+			},
+			wantCycle: true,
+			wantErr:   true,
+		},
+		{
+			services: map[string]Service{"a": {DependsOn: []interface{}{"none"}}},
+			wantErr:  true,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got, err := sortServicesByDependencies(tt.services)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sortServicesByDependencies() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if errors.Is(err, ErrDependencyCycle) != tt.wantCycle {
+				t.Errorf("sortServicesByDependencies() error = %v, wantCycle %v", err, tt.wantCycle)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortServicesByDependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectParseServiceVolume(t *testing.T) {
+	project := &Project{
+		Name:        "demo",
+		WorkingDir:  "/home/smartide/demo",
+		HomeDir:     "/home/smartide",
+		Environment: map[string]string{"HOME": "/home/smartide"},
+		Compose:     DockerComposeYml{Volumes: map[string]Volume{"data": {}, "shared": {External: true}}},
+	}
+	tests := []struct {
+		volume        string
+		wantBind      string
+		wantAnonymous string
+		wantErr       bool
+	}{
+		{volume: "./src:/home/project", wantBind: "/home/smartide/demo/src:/home/project"},
+		{volume: "~/.gitconfig:/home/smartide/.gitconfig", wantBind: "/home/smartide/.gitconfig:/home/smartide/.gitconfig"},
+		{volume: "$HOME/.ssh/id_rsa:/home/smartide/.ssh/id_rsa:ro", wantBind: "/home/smartide/.ssh/id_rsa:/home/smartide/.ssh/id_rsa:ro"},
+		{volume: "data:/var/lib/mysql", wantBind: "demo_data:/var/lib/mysql"},
+		{volume: "shared:/shared", wantBind: "shared:/shared"},
+		{volume: "/var/run/docker.sock:/var/run/docker.sock", wantBind: "/var/run/docker.sock:/var/run/docker.sock"},
+		{volume: "\\'C:\\Users\\smartide\\demo:/home/project/demo\\'", wantBind: "C:\\Users\\smartide\\demo:/home/project/demo"},
+		{volume: "/tmp", wantAnonymous: "/tmp"},
+		{volume: "a:b:c:d", wantErr: true},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			volume, err := ParseVolumeMap(tt.volume)
+			var bind, anonymous string
+			if err == nil {
+				bind, anonymous, err = project.parseServiceVolume(volume)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseServiceVolume() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if bind != tt.wantBind || anonymous != tt.wantAnonymous {
+				t.Errorf("parseServiceVolume() = %v, %v, want %v, %v", bind, anonymous, tt.wantBind, tt.wantAnonymous)
+			}
+		})
+	}
+}
+
+func TestProjectToContainerCreateOptions(t *testing.T) {
+	project := &Project{
+		Name:       "demo",
+		WorkingDir: "/home/smartide/demo",
+		Compose: DockerComposeYml{
+			Networks: map[string]Network{"smartide-network": {External: true}},
+			Services: map[string]Service{
+				"web": {
+					Image:       "nginx",
+					Ports:       Ports{NewPortSimple(6800, 3000), PortSimple{Host: "127.0.0.1:6822", Container: "22", Protocol: ProtocolTCP}},
+					Environment: map[string]string{"B": "2", "A": "1"},
+					Networks:    []string{"smartide-network", "backend"},
+					Restart:     "always",
+				},
+				"db": {Image: "mysql", ContainerName: "demo_mysql"},
+			},
+		},
+	}
+
+	web, err := project.toContainerCreateOptions("web", project.Compose.Services["web"], "sha256:1")
+	if err != nil {
+		t.Fatalf("toContainerCreateOptions() error = %v", err)
+	}
+	if web.Name != "demo_web_1" {
+		t.Errorf("toContainerCreateOptions() name = %v", web.Name)
+	}
+	if !reflect.DeepEqual([]string(web.Config.Env), []string{"A=1", "B=2"}) {
+		t.Errorf("toContainerCreateOptions() env = %v", web.Config.Env)
+	}
+	if web.Config.Labels[LabelProject] != "demo" || web.Config.Labels[LabelService] != "web" ||
+		web.Config.Labels[LabelWorkingDir] != "/home/smartide/demo" {
+		t.Errorf("toContainerCreateOptions() labels = %v", web.Config.Labels)
+	}
+	if bindings := web.HostConfig.PortBindings["22/tcp"]; len(bindings) != 1 || bindings[0].HostIP != "127.0.0.1" || bindings[0].HostPort != "6822" {
+		t.Errorf("toContainerCreateOptions() port bindings = %v", web.HostConfig.PortBindings)
+	}
+	if string(web.HostConfig.NetworkMode) != "smartide-network" || !reflect.DeepEqual(web.ExtraNetworks, []string{"demo_backend"}) {
+		t.Errorf("toContainerCreateOptions() networks = %v, %v", web.HostConfig.NetworkMode, web.ExtraNetworks)
+	}
+
+	db, err := project.toContainerCreateOptions("db", project.Compose.Services["db"], "sha256:2")
+	if err != nil {
+		t.Fatalf("toContainerCreateOptions() error = %v", err)
+	}
+	if db.Name != "demo_mysql" || string(db.HostConfig.NetworkMode) != "demo_default" {
+		t.Errorf("toContainerCreateOptions() name = %v, network = %v", db.Name, db.HostConfig.NetworkMode)
+	}
+
+	// 镜像改变后，配置的 hash 值也需要改变
+	changed, _ := project.toContainerCreateOptions("web", project.Compose.Services["web"], "sha256:3")
+	if changed.Config.Labels[LabelConfigHash] == web.Config.Labels[LabelConfigHash] {
+		t.Errorf("toContainerCreateOptions() config hash should change with image")
+	}
+
+	// 端口格式错误
+	service := project.Compose.Services["web"]
+	service.Ports = Ports{PortSimple{Host: "abc", Container: "3000"}}
+	if _, err := project.toContainerCreateOptions("web", service, ""); err == nil {
+		t.Errorf("toContainerCreateOptions() should return error for invalid port")
+	}
+}
+
+// InterpolateYaml 替换后的 $ 转义为 $$，创建容器时需要还原
+func TestProjectToContainerCreateOptionsEscaped(t *testing.T) {
+	content, _, err := InterpolateYaml(`services:
+  web:
+    image: ${REGISTRY}/web
+    command: ["sh", "-c", "echo $$HOME ${GREETING}"]
+    entrypoint: ["/entry.sh", "$$1"]
+    labels:
+      price: "$$5"
+    user: ${USER_NAME}
+    working_dir: /home/$$USER
+    hostname: web-$$
+`, func(name string) (string, bool) {
+		values := map[string]string{"REGISTRY": "registry.example.com", "GREETING": "hi $", "USER_NAME": "smartide"}
+		value, ok := values[name]
+		return value, ok
+	})
+	if err != nil {
+		t.Fatalf("InterpolateYaml() error = %v", err)
+	}
+	var compose DockerComposeYml
+	if err := yaml.Unmarshal([]byte(content), &compose); err != nil {
+		t.Fatal(err)
+	}
+	project := &Project{Name: "demo", WorkingDir: "/home/smartide/demo", Compose: compose}
+
+	web, err := project.toContainerCreateOptions("web", project.Compose.Services["web"], "")
+	if err != nil {
+		t.Fatalf("toContainerCreateOptions() error = %v", err)
+	}
+	config := web.Config
+	if config.Image != "registry.example.com/web" || config.User != "smartide" || config.WorkingDir != "/home/$USER" || config.Hostname != "web-$" {
+		t.Errorf("toContainerCreateOptions() config = %v, %v, %v, %v", config.Image, config.User, config.WorkingDir, config.Hostname)
+	}
+	if !reflect.DeepEqual([]string(config.Cmd), []string{"sh", "-c", "echo $HOME hi $"}) {
+		t.Errorf("toContainerCreateOptions() cmd = %v", config.Cmd)
+	}
+	if !reflect.DeepEqual([]string(config.Entrypoint), []string{"/entry.sh", "$1"}) {
+		t.Errorf("toContainerCreateOptions() entrypoint = %v", config.Entrypoint)
+	}
+	if config.Labels["price"] != "$5" {
+		t.Errorf("toContainerCreateOptions() labels = %v", config.Labels)
+	}
+}
+
+func TestProjectToContainerCreateOptionsLongSyntax(t *testing.T) {
+	mode := uint32(01777)
+	project := &Project{
+		Name:       "demo",
+		WorkingDir: "/home/smartide/demo",
+		Compose: DockerComposeYml{
+			Secrets: map[string]YmlSecret{"db_password": {File: "./secrets/db.txt"}, "token": {Environment: "TOKEN"}},
+			Configs: map[string]YmlConfig{"nginx_conf": {File: "./nginx.conf"}, "inline": {Content: "abc"}},
+			Services: map[string]Service{
+				"web": {
+					Image: "nginx",
+					Ports: Ports{PortComplex{Target: 80, HostIP: "127.0.0.1", Published: "8080"}, PortSimple{Host: "9000-9001", Container: "90-91", Protocol: ProtocolUDP}},
+					Volumes: VolumeMaps{
+						VolumeMapComplex{Type: VolumeTypeBind, Source: "./src", Target: "/src", ReadOnly: true},
+						VolumeMapComplex{Type: VolumeTypeTmpfs, Target: "/tmp", Tmpfs: &VolumeMapTmpfsOpt{Size: "64m", Mode: &mode}},
+					},
+					Secrets: Secrets{NewSecretSimple("db_password")},
+					Configs: Configs{ConfigComplex{Source: "nginx_conf", Target: "/etc/nginx/nginx.conf"}},
+				},
+			},
+		},
+	}
+
+	web, err := project.toContainerCreateOptions("web", project.Compose.Services["web"], "")
+	if err != nil {
+		t.Fatalf("toContainerCreateOptions() error = %v", err)
+	}
+	if bindings := web.HostConfig.PortBindings["80/tcp"]; len(bindings) != 1 || bindings[0].HostIP != "127.0.0.1" || bindings[0].HostPort != "8080" {
+		t.Errorf("toContainerCreateOptions() port bindings = %v", web.HostConfig.PortBindings)
+	}
+	if bindings := web.HostConfig.PortBindings["91/udp"]; len(bindings) != 1 || bindings[0].HostPort != "9001" {
+		t.Errorf("toContainerCreateOptions() port bindings = %v", web.HostConfig.PortBindings)
+	}
+	wantBinds := []string{"/home/smartide/demo/src:/src:ro", "/home/smartide/demo/secrets/db.txt:/run/secrets/db_password:ro",
+		"/home/smartide/demo/nginx.conf:/etc/nginx/nginx.conf:ro"}
+	if !reflect.DeepEqual(web.HostConfig.Binds, wantBinds) {
+		t.Errorf("toContainerCreateOptions() binds = %v, want %v", web.HostConfig.Binds, wantBinds)
+	}
+	if web.HostConfig.Tmpfs["/tmp"] != "size=64m,mode=1777" {
+		t.Errorf("toContainerCreateOptions() tmpfs = %v", web.HostConfig.Tmpfs)
+	}
+
+	// 只支持 file 类型的密钥
+	service := project.Compose.Services["web"]
+	service.Secrets = Secrets{NewSecretSimple("token")}
+	if _, err := project.toContainerCreateOptions("web", service, ""); err == nil {
+		t.Errorf("toContainerCreateOptions() should return error for environment secret")
+	}
+
+	// 只支持 file 类型的配置
+	service = project.Compose.Services["web"]
+	service.Configs = Configs{NewConfigSimple("inline")}
+	if _, err := project.toContainerCreateOptions("web", service, ""); err == nil {
+		t.Errorf("toContainerCreateOptions() should return error for content config")
+	}
+
+	// 不支持修改配置文件的所有者和权限
+	service = project.Compose.Services["web"]
+	service.Configs = Configs{ConfigComplex{Source: "nginx_conf", Target: "/etc/nginx/nginx.conf", Mode: &mode}}
+	if _, err := project.toContainerCreateOptions("web", service, ""); err == nil {
+		t.Errorf("toContainerCreateOptions() should return error for config mode")
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	content := "# comment\nA=1\nexport B=\"2 3\"\n\nC\nD\r\n"
+	got := parseEnvFile(content, map[string]string{"C": "from-env"})
+	want := map[string]string{"A": "1", "B": "2 3", "C": "from-env"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseEnvFile() = %v, want %v", got, want)
+	}
+}
+
+func TestGetComposeCli(t *testing.T) {
+	tests := []struct {
+		installed []string
+		want      string
+		wantErr   error
+	}{
+		{installed: []string{"docker compose", "docker-compose"}, want: "docker compose"},
+		{installed: []string{"docker-compose"}, want: "docker-compose"},
+		{installed: []string{}, wantErr: ErrComposeCliNotFound},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			execFunc := func(command string) (string, error) {
+				for _, installed := range tt.installed {
+					if strings.HasPrefix(command, installed+" ") {
+						return installed + " version 2.0.0", nil
+					}
+				}
+				return "command not found", errors.New("exit status 127")
+			}
+			got, err := GetComposeCli(execFunc)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("GetComposeCli() = %v, %v, want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,523 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	volumeTypes "github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/archive"
+	"github.com/docker/docker/pkg/fileutils"
+)
+
+// 等待依赖服务健康检查通过的超时时间
+const dependencyHealthyTimeout = 5 * time.Minute
+
+// 创建并启动所有的服务，相当于 docker compose up -d
+// 配置和镜像都没有变化的容器不会被重新创建
+func (p *Project) Up(ctx context.Context) error {
+	//1. 服务的启动顺序
+	serviceNames, err := sortServicesByDependencies(p.Compose.Services)
+	if err != nil {
+		return err
+	}
+
+	//2. 网络
+	if err := p.ensureNetworks(ctx); err != nil {
+		return err
+	}
+
+	//3. 挂载卷
+	if err := p.ensureVolumes(ctx); err != nil {
+		return err
+	}
+
+	//4. 镜像
+	imageIds := map[string]string{}
+	for _, serviceName := range serviceNames {
+		imageId, err := p.ensureImage(ctx, serviceName)
+		if err != nil {
+			return err
+		}
+		imageIds[serviceName] = imageId
+	}
+
+	//5. 容器
+	for _, serviceName := range serviceNames {
+		if err := p.waitForDependencies(ctx, serviceName); err != nil {
+			return err
+		}
+		if err := p.upService(ctx, serviceName, imageIds[serviceName]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// 创建项目的网络，外部网络不存在时也会创建（与之前的行为保持一致）
+func (p *Project) ensureNetworks(ctx context.Context) error {
+	networkNames := map[string]bool{}
+	for _, service := range p.Compose.Services {
+		for _, networkName := range p.getServiceNetworks(service) {
+			networkNames[networkName] = true
+		}
+	}
+	for networkName := range p.Compose.Networks {
+		networkNames[networkName] = true
+	}
+
+	existNetworks, err := p.client.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return &ProjectError{Stage: ProjectStageEnum_Network, Err: err}
+	}
+	for _, networkName := range sortedKeys(networkNames) {
+		name := p.getNetworkName(networkName)
+		isExist := false
+		for _, existNetwork := range existNetworks {
+			if existNetwork.Name == name {
+				isExist = true
+				break
+			}
+		}
+		if isExist {
+			continue
+		}
+
+		options := types.NetworkCreate{CheckDuplicate: true}
+		if composeNetwork, ok := p.Compose.Networks[networkName]; !ok || !composeNetwork.External {
+			options.Labels = map[string]string{LabelProject: p.Name, LabelNetwork: networkName, LabelVersion: "smartide"}
+		}
+		p.progress(ProgressEvent{Resource: name, Stage: ProjectStageEnum_Network, Status: ProgressStatusEnum_Working})
+		if _, err := p.client.NetworkCreate(ctx, name, options); err != nil {
+			p.progress(ProgressEvent{Resource: name, Stage: ProjectStageEnum_Network, Status: ProgressStatusEnum_Error, Text: err.Error()})
+			return &ProjectError{Resource: name, Stage: ProjectStageEnum_Network, Err: err}
+		}
+		p.progress(ProgressEvent{Resource: name, Stage: ProjectStageEnum_Network, Status: ProgressStatusEnum_Done})
+	}
+	return nil
+}
+
+// 创建申明的挂载卷，外部挂载卷必须已经存在
+func (p *Project) ensureVolumes(ctx context.Context) error {
+	volumeNames := map[string]bool{}
+	for volumeName := range p.Compose.Volumes {
+		volumeNames[volumeName] = true
+	}
+	for _, volumeName := range sortedKeys(volumeNames) {
+		name := p.getVolumeName(volumeName)
+		if _, err := p.client.VolumeInspect(ctx, name); err == nil {
+			continue
+		} else if !client.IsErrNotFound(err) {
+			return &ProjectError{Resource: name, Stage: ProjectStageEnum_Volume, Err: err}
+		}
+		if p.Compose.Volumes[volumeName].External {
+			return &ProjectError{Resource: name, Stage: ProjectStageEnum_Volume, Err: fmt.Errorf("external volume %v not found", name)}
+		}
+
+		p.progress(ProgressEvent{Resource: name, Stage: ProjectStageEnum_Volume, Status: ProgressStatusEnum_Working})
+		_, err := p.client.VolumeCreate(ctx, volumeTypes.VolumeCreateBody{
+			Name:   name,
+			Labels: map[string]string{LabelProject: p.Name, LabelVolume: volumeName, LabelVersion: "smartide"},
+		})
+		if err != nil {
+			p.progress(ProgressEvent{Resource: name, Stage: ProjectStageEnum_Volume, Status: ProgressStatusEnum_Error, Text: err.Error()})
+			return &ProjectError{Resource: name, Stage: ProjectStageEnum_Volume, Err: err}
+		}
+		p.progress(ProgressEvent{Resource: name, Stage: ProjectStageEnum_Volume, Status: ProgressStatusEnum_Done})
+	}
+	return nil
+}
+
+// 确保服务的镜像存在，不存在时构建或者拉取，返回镜像id
+func (p *Project) ensureImage(ctx context.Context, serviceName string) (imageId string, err error) {
+	service := p.Compose.Services[serviceName]
+	imageName := p.interpolate(service.Image)
+	if imageName == "" {
+		imageName = fmt.Sprintf("%v_%v", p.Name, serviceName) // 与 docker-compose 构建时默认的镜像名称一致
+		service.Image = imageName
+		p.Compose.Services[serviceName] = service
+	}
+
+	//1. 需要构建的镜像，计算构建配置的 hash 值
+	isBuild := service.Build.Context != "" && service.PullPolicy != "always"
+	contextDir, buildHash := "", ""
+	if isBuild {
+		contextDir = p.resolvePath(p.interpolate(service.Build.Context))
+		if buildHash, err = p.getBuildHash(ctx, service, contextDir); err != nil {
+			return "", &ProjectError{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Build, Err: err}
+		}
+	}
+
+	//2. 本地已经存在，构建的镜像只有在 hash 值没有变化时才可以使用
+	if service.PullPolicy != "always" && service.PullPolicy != "build" {
+		if image, _, err := p.client.ImageInspectWithRaw(ctx, imageName); err == nil {
+			if !isBuild {
+				return image.ID, nil
+			}
+			if image.Config != nil && image.Config.Labels[LabelBuildHash] == buildHash {
+				p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Build, Status: ProgressStatusEnum_Skipped})
+				return image.ID, nil
+			}
+		} else if !client.IsErrNotFound(err) {
+			return "", &ProjectError{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Pull, Err: err}
+		}
+	}
+
+	//3. 构建，hash 值保存在镜像的标签中
+	if isBuild {
+		p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Build, Status: ProgressStatusEnum_Working})
+		buildService := service
+		buildService.Build.Labels = map[string]interface{}{LabelBuildHash: buildHash}
+		for key, value := range service.Build.Labels {
+			buildService.Build.Labels[key] = value
+		}
+		if p.ImageBuilder != nil {
+			err = p.ImageBuilder(ctx, serviceName, buildService, imageName, contextDir)
+		} else {
+			err = p.buildImage(ctx, serviceName, buildService, imageName, contextDir)
+		}
+		if err != nil {
+			p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Build, Status: ProgressStatusEnum_Error, Text: err.Error()})
+			return "", &ProjectError{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Build, Err: err}
+		}
+		p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Build, Status: ProgressStatusEnum_Done})
+
+	} else { //4. 拉取
+		if service.PullPolicy == "never" {
+			return "", &ProjectError{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Pull, Err: errors.New("image not found and pull_policy is never")}
+		}
+		p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Pull, Status: ProgressStatusEnum_Working})
+		if err := p.pullImage(ctx, serviceName, service, imageName); err != nil {
+			p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Pull, Status: ProgressStatusEnum_Error, Text: err.Error()})
+			return "", &ProjectError{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Pull, Err: err}
+		}
+		p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Pull, Status: ProgressStatusEnum_Done})
+	}
+
+	image, _, err := p.client.ImageInspectWithRaw(ctx, imageName)
+	if err != nil {
+		return "", &ProjectError{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Pull, Err: err}
+	}
+	return image.ID, nil
+}
+
+// 拉取镜像
+func (p *Project) pullImage(ctx context.Context, serviceName string, service Service, imageName string) error {
+	reader, err := p.client.ImagePull(ctx, imageName, types.ImagePullOptions{Platform: service.Platform})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return p.readJsonMessages(reader, serviceName, imageName, ProjectStageEnum_Pull)
+}
+
+// 使用 docker api 构建镜像，构建上下文在本地
+// 优先使用 BuildKit，并在镜像中保存缓存信息（inline cache），使构建的镜像可以作为其他构建的 cache_from；docker 不支持时使用传统的构建方式
+func (p *Project) buildImage(ctx context.Context, serviceName string, service Service, imageName string, contextDir string) error {
+	excludes, err := readDockerignore(contextDir)
+	if err != nil {
+		return err
+	}
+
+	//1. 与 docker cli 一致，Dockerfile 和 .dockerignore 即使被忽略也需要发送；Dockerfile 不在构建上下文中时，以单独的文件名加入
+	dockerfilePath := p.getDockerfilePath(service, contextDir)
+	dockerfile, err := filepath.Rel(contextDir, dockerfilePath)
+	var dockerfileContent []byte
+	if err != nil || dockerfile == ".." || strings.HasPrefix(dockerfile, ".."+string(filepath.Separator)) {
+		if dockerfileContent, err = os.ReadFile(dockerfilePath); err != nil {
+			return err
+		}
+		dockerfileHash := sha256.Sum256(dockerfileContent)
+		dockerfile = ".dockerfile." + hex.EncodeToString(dockerfileHash[:])[:20]
+	}
+	excludes, err = keepBuildFiles(excludes, dockerfile)
+	if err != nil {
+		return err
+	}
+
+	options := types.ImageBuildOptions{
+		Tags:       []string{imageName},
+		Dockerfile: filepath.ToSlash(dockerfile),
+		Target:     service.Build.Target,
+		Remove:     true,
+		BuildArgs:  map[string]*string{},
+		Labels:     map[string]string{},
+		Platform:   service.Platform,
+	}
+	for key, value := range service.Build.Args {
+		argValue := p.interpolate(fmt.Sprint(value))
+		options.BuildArgs[key] = &argValue
+	}
+	for key, value := range service.Build.Labels {
+		options.Labels[key] = fmt.Sprint(value)
+	}
+	for _, cacheFrom := range service.Build.CacheFrom {
+		options.CacheFrom = append(options.CacheFrom, getImageRef(cacheFrom))
+	}
+
+	for _, version := range []types.BuilderVersion{types.BuilderBuildKit, types.BuilderV1} {
+		buildOptions := options
+		buildOptions.Version = version
+		if version == types.BuilderBuildKit {
+			inlineCache := "1"
+			buildOptions.BuildArgs = map[string]*string{"BUILDKIT_INLINE_CACHE": &inlineCache}
+			for key, value := range options.BuildArgs {
+				buildOptions.BuildArgs[key] = value
+			}
+		}
+
+		buildContext, err := archive.TarWithOptions(contextDir, &archive.TarOptions{ExcludePatterns: excludes})
+		if err != nil {
+			return err
+		}
+		if dockerfileContent != nil {
+			buildContext = archive.ReplaceFileTarWrapper(buildContext, map[string]archive.TarModifierFunc{
+				filepath.ToSlash(dockerfile): func(_ string, _ *tar.Header, _ io.Reader) (*tar.Header, []byte, error) {
+					return &tar.Header{Mode: 0600, ModTime: time.Now(), Typeflag: tar.TypeReg}, dockerfileContent, nil
+				},
+			})
+		}
+		response, err := p.client.ImageBuild(ctx, buildContext, buildOptions)
+		if err != nil {
+			buildContext.Close()
+			if version == types.BuilderBuildKit { // 不支持 BuildKit，比如 windows 容器
+				p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: ProjectStageEnum_Build, Status: ProgressStatusEnum_Working, Text: fmt.Sprintf("buildkit: %v", err)})
+				continue
+			}
+			return err
+		}
+		err = p.readJsonMessages(response.Body, serviceName, imageName, ProjectStageEnum_Build)
+		response.Body.Close()
+		buildContext.Close()
+		return err
+	}
+	return nil
+}
+
+// .dockerignore 中忽略了 Dockerfile 或者 .dockerignore 时，增加例外规则，docker 构建时需要这两个文件
+func keepBuildFiles(excludes []string, dockerfile string) ([]string, error) {
+	matcher, err := fileutils.NewPatternMatcher(excludes)
+	if err != nil {
+		return nil, err
+	}
+	for _, fileName := range []string{".dockerignore", filepath.Clean(dockerfile)} {
+		if isExcluded, err := matcher.Matches(fileName); err != nil {
+			return nil, err
+		} else if isExcluded {
+			excludes = append(excludes, "!"+fileName)
+		}
+	}
+	return excludes, nil
+}
+
+// docker api 返回的 json 消息
+type jsonMessage struct {
+	Stream   string `json:"stream,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Progress string `json:"progress,omitempty"`
+	ID       string `json:"id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// 读取拉取、构建镜像时返回的消息，转换为进度事件
+func (p *Project) readJsonMessages(reader io.Reader, serviceName string, imageName string, stage ProjectStageEnum) error {
+	decoder := json.NewDecoder(reader)
+	for {
+		var message jsonMessage
+		if err := decoder.Decode(&message); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if message.Error != "" {
+			return errors.New(message.Error)
+		}
+		if strings.HasPrefix(message.ID, "moby.") { // BuildKit 的进度（moby.buildkit.trace）以及镜像id，不需要输出
+			continue
+		}
+
+		text := strings.TrimSpace(message.Stream)
+		if text == "" {
+			text = strings.TrimSpace(strings.Join([]string{message.ID, message.Status, message.Progress}, " "))
+		}
+		if text != "" {
+			p.progress(ProgressEvent{Service: serviceName, Resource: imageName, Stage: stage, Status: ProgressStatusEnum_Working, Text: text})
+		}
+	}
+}
+
+// 等待依赖的服务满足条件，比如 condition: service_healthy
+func (p *Project) waitForDependencies(ctx context.Context, serviceName string) error {
+	var conditions map[interface{}]interface{}
+	switch dependsOn := p.Compose.Services[serviceName].DependsOn.(type) {
+	case map[interface{}]interface{}:
+		conditions = dependsOn
+	default:
+		return nil
+	}
+
+	for key, value := range conditions {
+		dependency := fmt.Sprint(key)
+		condition := ""
+		if options, ok := value.(map[interface{}]interface{}); ok {
+			condition = fmt.Sprint(options["condition"])
+		}
+		if condition != "service_healthy" && condition != "service_completed_successfully" {
+			continue
+		}
+
+		containerName := p.getContainerName(dependency, p.Compose.Services[dependency])
+		p.progress(ProgressEvent{Service: dependency, Resource: containerName, Stage: ProjectStageEnum_Start, Status: ProgressStatusEnum_Working, Text: condition})
+		timeoutCtx, cancel := context.WithTimeout(ctx, dependencyHealthyTimeout)
+		err := p.waitForContainerCondition(timeoutCtx, containerName, condition)
+		cancel()
+		if err != nil {
+			return &ProjectError{Service: dependency, Resource: containerName, Stage: ProjectStageEnum_Start, Err: err}
+		}
+	}
+	return nil
+}
+
+// 轮询容器状态，直到满足条件
+func (p *Project) waitForContainerCondition(ctx context.Context, containerName string, condition string) error {
+	for {
+		containerInfo, err := p.client.ContainerInspect(ctx, containerName)
+		if err != nil {
+			return err
+		}
+		switch condition {
+		case "service_healthy":
+			if containerInfo.State.Health == nil {
+				return errors.New("container has no healthcheck")
+			}
+			if containerInfo.State.Health.Status == types.Healthy {
+				return nil
+			}
+			if containerInfo.State.Health.Status == types.Unhealthy {
+				return errors.New("container is unhealthy")
+			}
+		case "service_completed_successfully":
+			if !containerInfo.State.Running && containerInfo.State.FinishedAt != "" && containerInfo.State.FinishedAt != "0001-01-01T00:00:00Z" {
+				if containerInfo.State.ExitCode == 0 {
+					return nil
+				}
+				return fmt.Errorf("container exited with code %v", containerInfo.State.ExitCode)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// 创建并启动单个服务的容器，配置没有变化时只启动
+func (p *Project) upService(ctx context.Context, serviceName string, imageId string) error {
+	service := p.Compose.Services[serviceName]
+	options, err := p.toContainerCreateOptions(serviceName, service, imageId)
+	if err != nil {
+		return &ProjectError{Service: serviceName, Resource: p.getContainerName(serviceName, service), Stage: ProjectStageEnum_Create, Err: err}
+	}
+	containerName := options.Name
+
+	//1. 已经存在的容器
+	containers, err := p.ListContainers(ctx, serviceName)
+	if err != nil {
+		return &ProjectError{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Create, Err: err}
+	}
+	containerId := ""
+	for _, existContainer := range containers {
+		if existContainer.Labels[LabelConfigHash] == options.Config.Labels[LabelConfigHash] && containerId == "" { // 配置没有变化
+			containerId = existContainer.ID
+			if existContainer.State == "running" {
+				p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Start, Status: ProgressStatusEnum_Skipped, Text: "running"})
+				return nil
+			}
+			continue
+		}
+
+		// 配置改变，删除后重新创建
+		p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Remove, Status: ProgressStatusEnum_Working, Text: "recreate"})
+		if err := p.client.ContainerRemove(ctx, existContainer.ID, types.ContainerRemoveOptions{Force: true}); err != nil {
+			return &ProjectError{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Remove, Err: err}
+		}
+	}
+
+	//2. 创建容器
+	if containerId == "" {
+		// 非 compose 创建的同名容器
+		if _, err := p.client.ContainerInspect(ctx, containerName); err == nil {
+			return &ProjectError{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Create,
+				Err: fmt.Errorf("container name %v is already in use", containerName)}
+		}
+
+		p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Create, Status: ProgressStatusEnum_Working})
+		response, err := p.client.ContainerCreate(ctx, options.Config, options.HostConfig, options.NetworkingConfig, nil, containerName)
+		if err != nil {
+			p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Create, Status: ProgressStatusEnum_Error, Text: err.Error()})
+			return &ProjectError{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Create, Err: err}
+		}
+		containerId = response.ID
+		for _, warning := range response.Warnings {
+			p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Create, Status: ProgressStatusEnum_Working, Text: warning})
+		}
+
+		for _, networkName := range options.ExtraNetworks {
+			endpoint := &network.EndpointSettings{Aliases: []string{serviceName}}
+			if err := p.client.NetworkConnect(ctx, networkName, containerId, endpoint); err != nil {
+				return &ProjectError{Service: serviceName, Resource: networkName, Stage: ProjectStageEnum_Network, Err: err}
+			}
+		}
+		p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Create, Status: ProgressStatusEnum_Done})
+	}
+
+	//3. 启动容器
+	p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Start, Status: ProgressStatusEnum_Working})
+	if err := p.client.ContainerStart(ctx, containerId, types.ContainerStartOptions{}); err != nil {
+		p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Start, Status: ProgressStatusEnum_Error, Text: err.Error()})
+		return &ProjectError{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Start, Err: err}
+	}
+	p.progress(ProgressEvent{Service: serviceName, Resource: containerName, Stage: ProjectStageEnum_Start, Status: ProgressStatusEnum_Done})
+
+	return nil
+}
+
+// 只属于当前项目的资源过滤条件
+func (p *Project) getProjectFilters() filters.Args {
+	return filters.NewArgs(filters.Arg("label", fmt.Sprintf("%v=%v", LabelProject, p.Name)))
+}
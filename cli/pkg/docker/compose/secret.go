@@ -18,7 +18,44 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package compose
 
+import "fmt"
+
 // 暴露的映射的公共接口
 type Secret interface {
 	IsSecret() bool
+	// 转换为 long syntax
+	ToComplex() SecretComplex
+}
+
+// 服务使用的密钥列表，每一项可以是 short syntax（字符串）或者 long syntax（对象）
+type Secrets []Secret
+
+func (secrets *Secrets) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	var items []interface{}
+	if err = unmarshal(&items); err != nil {
+		return
+	}
+	result := Secrets{}
+	for _, item := range items {
+		switch value := item.(type) {
+		case map[interface{}]interface{}:
+			var secret SecretComplex
+			if err = convertYaml(value, &secret); err != nil {
+				return fmt.Errorf("docker: complex-secret format error, %w", err)
+			}
+			if len(secret.Source) == 0 {
+				return fmt.Errorf("docker: complex-secret source can not be empty")
+			}
+			result = append(result, secret)
+		case string:
+			if len(value) == 0 {
+				return fmt.Errorf("docker: simple-secret format error")
+			}
+			result = append(result, NewSecretSimple(value))
+		default:
+			return fmt.Errorf("docker: secret %v format error", item)
+		}
+	}
+	*secrets = result
+	return
 }
@@ -18,16 +18,34 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package compose
 
+import "strings"
+
 // 密钥(Long Syntax)
 type SecretComplex struct {
-	Source string `yaml:"source"`           // 名称
-	Target string `yaml:"target,omitempty"` // 文件名
-	Uid    string `yaml:"uid,omitempty"`    // 文件UID
-	Gid    string `yaml:"gid,omitempty"`    // 文件GID
-	Mode   string `yaml:"mode,omitempty"`   // 文件权限
+	Source string  `yaml:"source"`           // 名称
+	Target string  `yaml:"target,omitempty"` // 文件名
+	Uid    string  `yaml:"uid,omitempty"`    // 文件UID
+	Gid    string  `yaml:"gid,omitempty"`    // 文件GID
+	Mode   *uint32 `yaml:"mode,omitempty"`   // 文件权限，e.g. 0440
 }
 
 // 实现公共接口
 func (SecretComplex) IsSecret() bool {
 	return true
 }
+
+func (m SecretComplex) ToComplex() SecretComplex {
+	return m
+}
+
+// 容器内的文件路径，默认为 /run/secrets/<source>
+func (m SecretComplex) GetTargetPath() string {
+	target := m.Target
+	if len(target) == 0 {
+		target = m.Source
+	}
+	if strings.HasPrefix(target, "/") {
+		return target
+	}
+	return "/run/secrets/" + target
+}
@@ -39,6 +39,10 @@ func (SecretSimple) IsSecret() bool {
 	return true
 }
 
+func (m SecretSimple) ToComplex() SecretComplex {
+	return SecretComplex{Source: m.Source}
+}
+
 func (m SecretSimple) MarshalYAML() (result interface{}, err error) {
 	if len(m.Source) == 0 {
 		err = errors.New("docker: simple-secret source can not be empty")
@@ -0,0 +1,54 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecrets(t *testing.T) {
+	content := `- db_password
+- source: api_key
+  target: /etc/api_key
+  mode: 0400
+`
+	var secrets Secrets
+	if err := UnmarshalYaml(content, &secrets); err != nil {
+		t.Fatalf("Secrets.UnmarshalYAML() error = %v", err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("Secrets.UnmarshalYAML() = %#v", secrets)
+	}
+	if target := secrets[0].ToComplex().GetTargetPath(); target != "/run/secrets/db_password" {
+		t.Errorf("GetTargetPath() = %v", target)
+	}
+	secret := secrets[1].ToComplex()
+	if secret.GetTargetPath() != "/etc/api_key" || secret.Mode == nil || *secret.Mode != 0400 {
+		t.Errorf("ToComplex() = %+v", secret)
+	}
+
+	var roundTrip Secrets
+	if err := UnmarshalYaml(MarshalYaml(secrets), &roundTrip); err != nil || !reflect.DeepEqual(roundTrip, secrets) {
+		t.Errorf("Secrets.MarshalYAML() = %v, error = %v", MarshalYaml(secrets), err)
+	}
+	if err := UnmarshalYaml("- target: abc\n", &secrets); err == nil {
+		t.Errorf("Secrets.UnmarshalYAML() should return error without source")
+	}
+}
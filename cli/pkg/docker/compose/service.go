@@ -18,10 +18,7 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package compose
 
-import (
-	"strconv"
-	"strings"
-)
+import "github.com/leansoftX/smartide-cli/pkg/common"
 
 type ShellCommand []string
 
@@ -47,7 +44,7 @@ type Service struct {
 	CPUS         float32  `mapstructure:"cpus" yaml:"cpus,omitempty" json:"cpus,omitempty"`
 	CPUSet       string   `mapstructure:"cpuset" yaml:"cpuset,omitempty" json:"cpuset,omitempty"`
 	CPUShares    int64    `mapstructure:"cpu_shares" yaml:"cpu_shares,omitempty" json:"cpu_shares,omitempty"`
-	//Configs        []ServiceConfigObjConfig `yaml:",omitempty" json:"configs,omitempty"`
+	Configs      Configs  `yaml:"configs,omitempty"` // 配置
 	//CredentialSpec *CredentialSpecConfig    `mapstructure:"credential_spec" yaml:"credential_spec,omitempty" json:"credential_spec,omitempty"`
 	ContainerName string      `yaml:"container_name,omitempty"` // 容器名称
 	DependsOn     interface{} `yaml:"depends_on,omitempty"`     // 服务之间的依赖关系 //TODO 有时候是[]string, 有时候是 map[string]interface{}
@@ -88,15 +85,15 @@ type Service struct {
 	NetworkMode string   `mapstructure:"network_mode" yaml:"network_mode,omitempty" json:"network_mode,omitempty"`
 	Networks    []string `yaml:"networks,omitempty"` // 加入的网络
 
-	OomKillDisable bool     `mapstructure:"oom_kill_disable" yaml:"oom_kill_disable,omitempty" json:"oom_kill_disable,omitempty"`
-	OomScoreAdj    int64    `mapstructure:"oom_score_adj" yaml:"oom_score_adj,omitempty" json:"oom_score_adj,omitempty"`
-	Pid            string   `yaml:",omitempty" json:"pid,omitempty"`
-	PidsLimit      int64    `mapstructure:"pids_limit" yaml:"pids_limit,omitempty" json:"pids_limit,omitempty"`
-	Platform       string   `yaml:",omitempty" json:"platform,omitempty"`
-	Ports          []string `yaml:"ports,omitempty"` // 暴露的端口号
+	OomKillDisable bool   `mapstructure:"oom_kill_disable" yaml:"oom_kill_disable,omitempty" json:"oom_kill_disable,omitempty"`
+	OomScoreAdj    int64  `mapstructure:"oom_score_adj" yaml:"oom_score_adj,omitempty" json:"oom_score_adj,omitempty"`
+	Pid            string `yaml:",omitempty" json:"pid,omitempty"`
+	PidsLimit      int64  `mapstructure:"pids_limit" yaml:"pids_limit,omitempty" json:"pids_limit,omitempty"`
+	Platform       string `yaml:",omitempty" json:"platform,omitempty"`
+	Ports          Ports  `yaml:"ports,omitempty"` // 暴露的端口号
 
-	Restart string   `yaml:"restart,omitempty"` // 重启策略
-	Secrets []Secret `yaml:"secrets,omitempty"` // 密钥
+	Restart string  `yaml:"restart,omitempty"` // 重启策略
+	Secrets Secrets `yaml:"secrets,omitempty"` // 密钥
 
 	Privileged      bool              `yaml:",omitempty" json:"privileged,omitempty"`
 	PullPolicy      string            `mapstructure:"pull_policy" yaml:"pull_policy,omitempty" json:"pull_policy,omitempty"`
@@ -113,28 +110,69 @@ type Service struct {
 	Tty             bool              `mapstructure:"tty" yaml:"tty,omitempty" json:"tty,omitempty"`
 	//Ulimits         map[string]*UlimitsConfig `yaml:",omitempty" json:"ulimits,omitempty"`
 
-	User         string   `yaml:",omitempty" json:"user,omitempty"` // 执行的用户
-	UserNSMode   string   `mapstructure:"userns_mode" yaml:"userns_mode,omitempty" json:"userns_mode,omitempty"`
-	Uts          string   `yaml:"uts,omitempty" json:"uts,omitempty"`
-	VolumeDriver string   `mapstructure:"volume_driver" yaml:"volume_driver,omitempty" json:"volume_driver,omitempty"`
-	VolumesFrom  []string `mapstructure:"volumes_from" yaml:"volumes_from,omitempty" json:"volumes_from,omitempty"`
-	WorkingDir   string   `mapstructure:"working_dir" yaml:"working_dir,omitempty" json:"working_dir,omitempty"`
-	Volumes      []string `yaml:"volumes,omitempty"` // 挂载卷
+	User         string     `yaml:",omitempty" json:"user,omitempty"` // 执行的用户
+	UserNSMode   string     `mapstructure:"userns_mode" yaml:"userns_mode,omitempty" json:"userns_mode,omitempty"`
+	Uts          string     `yaml:"uts,omitempty" json:"uts,omitempty"`
+	VolumeDriver string     `mapstructure:"volume_driver" yaml:"volume_driver,omitempty" json:"volume_driver,omitempty"`
+	VolumesFrom  []string   `mapstructure:"volumes_from" yaml:"volumes_from,omitempty" json:"volumes_from,omitempty"`
+	WorkingDir   string     `mapstructure:"working_dir" yaml:"working_dir,omitempty" json:"working_dir,omitempty"`
+	Volumes      VolumeMaps `yaml:"volumes,omitempty"` // 挂载卷
+
+	// 扩展字段（x-*）以及其他没有定义的节点（比如 deploy、logging），原样保留
+	Extensions map[string]interface{} `yaml:",inline" json:"-"`
 }
 
-func (service *Service) AppendPort(port string) {
+func (service *Service) AppendPort(port Port) {
 	service.Ports = append(service.Ports, port)
 }
 
+func (service *Service) AppendVolume(volume VolumeMap) {
+	service.Volumes = append(service.Volumes, volume)
+}
+
+// 在激活的 profiles 下是否启用，没有设置 profiles 的服务始终启用
+func (service *Service) IsEnabled(profiles []string) bool {
+	if len(service.Profiles) == 0 {
+		return true
+	}
+	for _, profile := range profiles {
+		if profile == "*" || common.Contains(service.Profiles, profile) { // * 表示激活所有的 profile
+			return true
+		}
+	}
+	return false
+}
+
 // 是否包含某个容器端口的映射
 func (service *Service) ContainContainerPort(port int) bool {
-	originPort := strconv.Itoa(port)
-	for _, portStr := range service.Ports {
-		array := strings.Split(portStr, ":")
-		if array[1] == originPort {
-			return true
+	for _, item := range service.Ports {
+		portConfigs, err := item.ToComplex()
+		if err != nil {
+			continue
+		}
+		for _, portConfig := range portConfigs {
+			if portConfig.Target == port {
+				return true
+			}
 		}
 	}
 
 	return false
 }
+
+// 容器端口绑定的主机端口，没有绑定或者随机分配时返回 0
+func (service *Service) GetPublishedPort(containerPort int) int {
+	for _, item := range service.Ports {
+		portConfigs, err := item.ToComplex()
+		if err != nil {
+			continue
+		}
+		for _, portConfig := range portConfigs {
+			if portConfig.Target == containerPort {
+				return portConfig.GetPublishedPort()
+			}
+		}
+	}
+
+	return 0
+}
@@ -33,3 +33,12 @@ func UnmarshalYaml(content string, obj interface{}) (err error) {
 	err = yaml.Unmarshal([]byte(content), obj)
 	return
 }
+
+// 把 yaml 解析后的通用对象（比如 map[interface{}]interface{}）转换为结构体
+func convertYaml(in interface{}, out interface{}) error {
+	content, err := yaml.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(content, out)
+}
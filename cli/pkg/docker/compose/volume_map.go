@@ -18,7 +18,71 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package compose
 
+import (
+	"fmt"
+	"strings"
+)
+
 // 暴露的路径映射的公共接口
 type VolumeMap interface {
 	IsVolumeMap() bool
+	// 转换为 long syntax
+	ToComplex() (VolumeMapComplex, error)
+	// short syntax 格式的字符串，用于显示
+	String() string
+}
+
+// 服务的挂载卷列表，每一项可以是 short syntax（字符串）或者 long syntax（对象）
+type VolumeMaps []VolumeMap
+
+func (volumes *VolumeMaps) UnmarshalYAML(unmarshal func(interface{}) error) (err error) {
+	var items []interface{}
+	if err = unmarshal(&items); err != nil {
+		return
+	}
+	result := VolumeMaps{}
+	for _, item := range items {
+		switch value := item.(type) {
+		case map[interface{}]interface{}:
+			var volume VolumeMapComplex
+			if err = convertYaml(value, &volume); err != nil {
+				return fmt.Errorf("docker: complex-volume-map format error, %w", err)
+			}
+			result = append(result, volume)
+		case string:
+			volume, err := ParseVolumeMap(value)
+			if err != nil {
+				return err
+			}
+			result = append(result, volume)
+		default:
+			return fmt.Errorf("docker: volume-map %v format error", item)
+		}
+	}
+	*volumes = result
+	return
+}
+
+// short syntax 格式的字符串列表
+func (volumes VolumeMaps) Strings() (result []string) {
+	for _, volume := range volumes {
+		result = append(result, volume.String())
+	}
+	return result
+}
+
+// 容器内的目标路径对应的挂载卷，没有时返回 -1
+func (volumes VolumeMaps) IndexOfTarget(target string) int {
+	for index, volume := range volumes {
+		if item, err := volume.ToComplex(); err == nil && item.Target == target {
+			return index
+		}
+	}
+	return -1
+}
+
+// 是否为主机路径，否则为挂载卷的名称
+func isVolumeHostPath(source string) bool {
+	return strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") || strings.HasPrefix(source, "/") ||
+		strings.HasPrefix(source, "$") || strings.HasPrefix(source, "\\\\") || windowsAbsPathRegexp.MatchString(source)
 }
@@ -18,19 +18,94 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 package compose
 
-// 挂载卷
+import (
+	"fmt"
+	"strings"
+)
+
+// 挂载卷(Long Syntax)
 type VolumeMapComplex struct {
-	Type     string `yaml:"type"`                // 挂载类型
-	Source   string `yaml:"source"`              // 外部的源地址
-	Target   string `yaml:"target"`              // 容器内的目标地址
-	ReadOnly string `yaml:"read_only,omitempty"` // 只读标志
-	// TODO bind
-	// TODO volume
-	// TODO tmpfs
-	// TODO consistency
+	Type        string              `yaml:"type"`                  // 挂载类型，bind、volume、tmpfs、npipe
+	Source      string              `yaml:"source,omitempty"`      // 外部的源地址，主机路径或者挂载卷的名称
+	Target      string              `yaml:"target"`                // 容器内的目标地址
+	ReadOnly    bool                `yaml:"read_only,omitempty"`   // 只读标志
+	Consistency string              `yaml:"consistency,omitempty"` // 一致性要求，consistent、cached、delegated
+	Bind        *VolumeMapBindOpt   `yaml:"bind,omitempty"`        // bind 类型的选项
+	Volume      *VolumeMapVolumeOpt `yaml:"volume,omitempty"`      // volume 类型的选项
+	Tmpfs       *VolumeMapTmpfsOpt  `yaml:"tmpfs,omitempty"`       // tmpfs 类型的选项
+}
+
+// bind 类型的选项
+type VolumeMapBindOpt struct {
+	Propagation    string `yaml:"propagation,omitempty"`      // 传播方式，e.g. rshared
+	CreateHostPath *bool  `yaml:"create_host_path,omitempty"` // 主机路径不存在时是否创建，默认创建
+	SELinux        string `yaml:"selinux,omitempty"`          // z 共享、Z 私有
+}
+
+// volume 类型的选项
+type VolumeMapVolumeOpt struct {
+	NoCopy bool `yaml:"nocopy,omitempty"` // 创建时不复制容器内的数据
+}
+
+// tmpfs 类型的选项
+type VolumeMapTmpfsOpt struct {
+	Size string  `yaml:"size,omitempty"` // 大小，e.g. 100m、1048576
+	Mode *uint32 `yaml:"mode,omitempty"` // 文件权限
 }
 
 // 实现公共接口
 func (VolumeMapComplex) IsVolumeMap() bool {
 	return true
 }
+
+func (m VolumeMapComplex) ToComplex() (VolumeMapComplex, error) {
+	if len(m.Target) == 0 {
+		return m, fmt.Errorf("docker: complex-volume-map target can not be empty")
+	}
+	switch m.Type {
+	case VolumeTypeBind, VolumeTypeNpipe:
+		if len(m.Source) == 0 {
+			return m, fmt.Errorf("docker: complex-volume-map %v source can not be empty", m.Target)
+		}
+	case VolumeTypeVolume, VolumeTypeTmpfs:
+	default:
+		return m, fmt.Errorf("docker: complex-volume-map %v type %v is not supported", m.Target, m.Type)
+	}
+	return m, nil
+}
+
+// 挂载选项，与 short syntax 中的 mode 一致，e.g. ro,z
+func (m VolumeMapComplex) GetModes() (modes []string) {
+	if m.ReadOnly {
+		modes = append(modes, VolumeReadOnly)
+	}
+	if m.Bind != nil {
+		if m.Bind.SELinux != "" {
+			modes = append(modes, m.Bind.SELinux)
+		}
+		if m.Bind.Propagation != "" {
+			modes = append(modes, m.Bind.Propagation)
+		}
+	}
+	if m.Volume != nil && m.Volume.NoCopy {
+		modes = append(modes, "nocopy")
+	}
+	if m.Consistency != "" {
+		modes = append(modes, m.Consistency)
+	}
+	return modes
+}
+
+func (m VolumeMapComplex) String() string {
+	if m.Type == VolumeTypeTmpfs {
+		return VolumeTypeTmpfs + ":" + m.Target
+	}
+	if len(m.Source) == 0 {
+		return m.Target
+	}
+	result := m.Source + ":" + m.Target
+	if modes := m.GetModes(); len(modes) > 0 {
+		result += ":" + strings.Join(modes, ",")
+	}
+	return result
+}
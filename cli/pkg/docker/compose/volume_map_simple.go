@@ -51,19 +51,59 @@ func (VolumeMapSimple) IsVolumeMap() bool {
 	return true
 }
 
-func (m VolumeMapSimple) MarshalYAML() (result interface{}, err error) {
-	if len(m.Host) == 0 {
-		err = errors.New("docker: simple-volume-map host can not be empty")
-		return
+// 转换为 long syntax，主机路径为 bind 类型，其他为 volume 类型
+func (m VolumeMapSimple) ToComplex() (result VolumeMapComplex, err error) {
+	if len(m.Container) == 0 { // 匿名挂载卷
+		return VolumeMapComplex{Type: VolumeTypeVolume, Target: m.Host}, nil
+	}
+
+	result = VolumeMapComplex{Type: VolumeTypeVolume, Source: m.Host, Target: m.Container}
+	if isVolumeHostPath(m.Host) {
+		result.Type = VolumeTypeBind
 	}
-	tmp := m.Host
+	for _, mode := range strings.Split(m.Mode, ",") {
+		switch mode {
+		case "", VolumeReadWrite:
+		case VolumeReadOnly:
+			result.ReadOnly = true
+		case "z", "Z":
+			if result.Bind == nil {
+				result.Bind = &VolumeMapBindOpt{}
+			}
+			result.Bind.SELinux = mode
+		case "shared", "rshared", "slave", "rslave", "private", "rprivate":
+			if result.Bind == nil {
+				result.Bind = &VolumeMapBindOpt{}
+			}
+			result.Bind.Propagation = mode
+		case "nocopy":
+			result.Volume = &VolumeMapVolumeOpt{NoCopy: true}
+		case "consistent", "cached", "delegated":
+			result.Consistency = mode
+		default:
+			return result, fmt.Errorf("docker: simple-volume-map %v mode %v is not supported", m.String(), mode)
+		}
+	}
+	return result, nil
+}
+
+func (m VolumeMapSimple) String() string {
+	result := m.Host
 	if len(m.Container) > 0 {
-		tmp += fmt.Sprintf(":%s", m.Container)
+		result += fmt.Sprintf(":%s", m.Container)
 		if len(m.Mode) > 0 {
-			tmp += fmt.Sprintf(":%s", m.Mode)
+			result += fmt.Sprintf(":%s", m.Mode)
 		}
 	}
-	result = tmp
+	return result
+}
+
+func (m VolumeMapSimple) MarshalYAML() (result interface{}, err error) {
+	if len(m.Host) == 0 {
+		err = errors.New("docker: simple-volume-map host can not be empty")
+		return
+	}
+	result = m.String()
 	return
 }
 
@@ -72,8 +112,20 @@ func (m *VolumeMapSimple) UnmarshalYAML(unmarshal func(interface{}) error) (err
 	if err = unmarshal(&origin); err != nil {
 		return
 	}
+	*m, err = ParseVolumeMap(origin)
+	return
+}
+
+// 解析 short syntax 的挂载卷，e.g. /var/lib/mysql、./cache:/tmp/cache、C:\data:/data:ro
+func ParseVolumeMap(origin string) (m VolumeMapSimple, err error) {
+	// 兼容旧版本在 windows 下用 \' 包裹的路径
+	origin = strings.TrimSuffix(strings.TrimPrefix(origin, "\\'"), "\\'")
+
 	// 拆分
 	parts := strings.Split(origin, ":")
+	if len(parts) > 1 && windowsAbsPathRegexp.MatchString(parts[0]+":"+parts[1]) { // 盘符
+		parts = append([]string{parts[0] + ":" + parts[1]}, parts[2:]...)
+	}
 	if len(parts) > 3 {
 		err = errors.New("docker: simple-volume-map format error")
 		return
@@ -0,0 +1,85 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVolumeMaps(t *testing.T) {
+	content := `- /var/lib/mysql
+- ./src:/home/project:ro,cached
+- data:/data:nocopy
+- C:\data:/windows:z
+- type: bind
+  source: ./cache
+  target: /cache
+  read_only: true
+  bind:
+    propagation: rshared
+    create_host_path: false
+- type: tmpfs
+  target: /tmp
+  tmpfs:
+    size: 64m
+    mode: 01777
+`
+	var volumes VolumeMaps
+	if err := UnmarshalYaml(content, &volumes); err != nil {
+		t.Fatalf("VolumeMaps.UnmarshalYAML() error = %v", err)
+	}
+	if len(volumes) != 6 {
+		t.Fatalf("VolumeMaps.UnmarshalYAML() = %#v", volumes)
+	}
+
+	createHostPath, mode := false, uint32(01777)
+	want := []VolumeMapComplex{
+		{Type: VolumeTypeVolume, Target: "/var/lib/mysql"},
+		{Type: VolumeTypeBind, Source: "./src", Target: "/home/project", ReadOnly: true, Consistency: "cached"},
+		{Type: VolumeTypeVolume, Source: "data", Target: "/data", Volume: &VolumeMapVolumeOpt{NoCopy: true}},
+		{Type: VolumeTypeBind, Source: "C:\\data", Target: "/windows", Bind: &VolumeMapBindOpt{SELinux: "z"}},
+		{Type: VolumeTypeBind, Source: "./cache", Target: "/cache", ReadOnly: true, Bind: &VolumeMapBindOpt{Propagation: "rshared", CreateHostPath: &createHostPath}},
+		{Type: VolumeTypeTmpfs, Target: "/tmp", Tmpfs: &VolumeMapTmpfsOpt{Size: "64m", Mode: &mode}},
+	}
+	for index, volume := range volumes {
+		got, err := volume.ToComplex()
+		if err != nil {
+			t.Fatalf("ToComplex() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, want[index]) {
+			t.Errorf("ToComplex() = %+v, want %+v", got, want[index])
+		}
+	}
+
+	var roundTrip VolumeMaps
+	if err := UnmarshalYaml(MarshalYaml(volumes), &roundTrip); err != nil || !reflect.DeepEqual(roundTrip, volumes) {
+		t.Errorf("VolumeMaps.MarshalYAML() = %v, error = %v", MarshalYaml(volumes), err)
+	}
+	if index := volumes.IndexOfTarget("/cache"); index != 4 {
+		t.Errorf("VolumeMaps.IndexOfTarget() = %v, want 4", index)
+	}
+
+	// 不支持的选项
+	for _, volume := range []VolumeMap{VolumeMapSimple{Host: "data", Container: "/data", Mode: "abc"}, VolumeMapComplex{Type: "abc", Target: "/data"}, VolumeMapComplex{Type: VolumeTypeBind, Target: "/data"}} {
+		if _, err := volume.ToComplex(); err == nil {
+			t.Errorf("ToComplex() %v should return error", volume)
+		}
+	}
+}
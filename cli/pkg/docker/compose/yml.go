@@ -37,21 +37,59 @@ type DockerComposeYml struct {
 	Volumes  map[string]Volume    `yaml:"volumes,omitempty"`  // 挂载卷配置
 	Networks map[string]Network   `yaml:"networks,omitempty"` // 网络配置
 	Secrets  map[string]YmlSecret `yaml:"secrets,omitempty"`  // 密钥
-	// TODO configs
-	// TODO Variable substitution
-	// TODO Extension fields
+	Configs  map[string]YmlConfig `yaml:"configs,omitempty"`  // 配置
+
+	// 扩展字段（x-*）以及其他没有定义的节点，原样保留
+	Extensions map[string]interface{} `yaml:",inline" json:"-"`
 
 	//SmartIDE SmartIDE `yaml:"smartide,omitempty"` // 一些自定义的信息
 }
 
 func (c *DockerComposeYml) IsNil() bool {
-	return c.Version == "" && len(c.Services) == 0 && len(c.Volumes) == 0 && len(c.Networks) == 0 && len(c.Secrets) == 0
+	return c.Version == "" && len(c.Services) == 0 && len(c.Volumes) == 0 && len(c.Networks) == 0 && len(c.Secrets) == 0 &&
+		len(c.Configs) == 0 && len(c.Extensions) == 0
 }
 
 func (c *DockerComposeYml) IsNotNil() bool {
 	return !c.IsNil()
 }
 
+// 根据激活的 profiles 过滤服务，与 docker compose 的规则一致
+// 没有设置 profiles 的服务始终启用；requiredServices（比如开发容器）以及启用的服务所依赖的服务也会被启用
+func (c *DockerComposeYml) ApplyProfiles(profiles []string, requiredServices ...string) {
+	//1. 启用的服务
+	enabledServices := map[string]bool{}
+	var pendingServices []string
+	for serviceName, service := range c.Services {
+		if service.IsEnabled(profiles) || common.Contains(requiredServices, serviceName) {
+			enabledServices[serviceName] = true
+			pendingServices = append(pendingServices, serviceName)
+		}
+	}
+
+	//2. 被依赖的服务
+	for len(pendingServices) > 0 {
+		serviceName := pendingServices[0]
+		pendingServices = pendingServices[1:]
+		for _, dependency := range getServiceDependencies(c.Services[serviceName]) {
+			if _, ok := c.Services[dependency]; ok && !enabledServices[dependency] {
+				enabledServices[dependency] = true
+				pendingServices = append(pendingServices, dependency)
+			}
+		}
+	}
+
+	//3. 移除没有启用的服务，保留的服务清除 profiles，否则 docker compose up 时没有指定 --profile 会跳过这些服务
+	for serviceName, service := range c.Services {
+		if !enabledServices[serviceName] {
+			delete(c.Services, serviceName)
+		} else if len(service.Profiles) > 0 {
+			service.Profiles = nil
+			c.Services[serviceName] = service
+		}
+	}
+}
+
 /* // 从compose中 获取宿主端口（可能会变） 和 容器端口的绑定关系
 func (c *DockerComposeYml) GetPortBindings() map[string]string {
 	var result map[string]string = map[string]string{}
@@ -0,0 +1,127 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package compose
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestDockerComposeYmlExtensions(t *testing.T) {
+	content := `version: "3.9"
+x-common: &common
+  restart: always
+services:
+  web:
+    <<: *common
+    image: nginx
+    profiles: [debug]
+    deploy:
+      replicas: 2
+    x-label: web
+    configs:
+      - nginx_conf
+      - source: app_conf
+        target: /etc/app.conf
+        mode: 0440
+configs:
+  nginx_conf:
+    file: ./nginx.conf
+  app_conf:
+    content: abc
+`
+	var dockerCompose DockerComposeYml
+	if err := yaml.Unmarshal([]byte(content), &dockerCompose); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	web := dockerCompose.Services["web"]
+	if web.Restart != "always" || !reflect.DeepEqual(web.Profiles, []string{"debug"}) {
+		t.Errorf("yaml.Unmarshal() service = %+v", web)
+	}
+	if len(web.Configs) != 2 || web.Configs[0].ToComplex().GetTargetPath() != "/nginx_conf" ||
+		web.Configs[1].ToComplex().GetTargetPath() != "/etc/app.conf" {
+		t.Errorf("yaml.Unmarshal() configs = %+v", web.Configs)
+	}
+	if dockerCompose.Configs["nginx_conf"].File != "./nginx.conf" || dockerCompose.Configs["app_conf"].Content != "abc" {
+		t.Errorf("yaml.Unmarshal() top level configs = %+v", dockerCompose.Configs)
+	}
+
+	// 扩展字段以及未定义的节点在转换后保留
+	output, err := dockerCompose.ToYaml()
+	if err != nil {
+		t.Fatalf("ToYaml() error = %v", err)
+	}
+	for _, want := range []string{"x-common:", "deploy:", "replicas: 2", "x-label: web", "profiles:", "configs:", "file: ./nginx.conf"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("ToYaml() = %v, want contains %v", output, want)
+		}
+	}
+	var roundTrip DockerComposeYml
+	if err := yaml.Unmarshal([]byte(output), &roundTrip); err != nil || !reflect.DeepEqual(roundTrip, dockerCompose) {
+		t.Errorf("ToYaml() round trip = %+v, error = %v", roundTrip, err)
+	}
+}
+
+func TestDockerComposeYmlApplyProfiles(t *testing.T) {
+	newCompose := func() DockerComposeYml {
+		return DockerComposeYml{
+			Services: map[string]Service{
+				"web":     {Image: "nginx", DependsOn: []interface{}{"db"}},
+				"db":      {Image: "mysql", Profiles: []string{"db"}},
+				"debug":   {Image: "busybox", Profiles: []string{"debug"}},
+				"monitor": {Image: "grafana", Profiles: []string{"debug", "monitor"}},
+				"dev":     {Image: "smartide", Profiles: []string{"dev"}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		profiles []string
+		want     []string
+	}{
+		{"none", nil, []string{"db", "dev", "web"}},
+		{"debug", []string{"debug"}, []string{"db", "debug", "dev", "monitor", "web"}},
+		{"monitor", []string{"monitor"}, []string{"db", "dev", "monitor", "web"}},
+		{"all", []string{"*"}, []string{"db", "debug", "dev", "monitor", "web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dockerCompose := newCompose()
+			dockerCompose.ApplyProfiles(tt.profiles, "dev")
+			var got []string
+			for serviceName := range dockerCompose.Services {
+				got = append(got, serviceName)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyProfiles() = %v, want %v", got, tt.want)
+			}
+			for serviceName, service := range dockerCompose.Services {
+				if len(service.Profiles) > 0 {
+					t.Errorf("ApplyProfiles() service %v profiles = %v, want empty", serviceName, service.Profiles)
+				}
+			}
+		})
+	}
+}
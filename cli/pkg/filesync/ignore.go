@@ -0,0 +1,135 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package filesync
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+)
+
+// 忽略规则文件，子目录中的规则只对子目录生效
+var ignoreFileNames = []string{".gitignore", ".ideignore"}
+
+// 始终忽略的文件夹
+const gitDirName = ".git"
+
+// 同步时的忽略规则
+type IgnoreMatcher struct {
+	matcher gitignore.Matcher
+}
+
+// 加载文件夹中所有的 .gitignore、.ideignore，extraPatterns 为额外的规则（相对于根目录）
+func LoadIgnoreMatcher(rootDir string, extraPatterns []string) (*IgnoreMatcher, error) {
+	patterns := []gitignore.Pattern{}
+	for _, item := range extraPatterns {
+		patterns = append(patterns, gitignore.ParsePattern(item, nil))
+	}
+
+	// 从上往下加载，已经忽略的文件夹不再进入
+	err := filepath.Walk(rootDir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			if filePath == rootDir {
+				return err
+			}
+			return nil // 没有权限等，跳过
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		relPath, _ := filepath.Rel(rootDir, filePath)
+		domain := splitPath(relPath)
+		if len(domain) > 0 &&
+			(info.Name() == gitDirName || gitignore.NewMatcher(patterns).Match(domain, true)) {
+			return filepath.SkipDir
+		}
+
+		for _, fileName := range ignoreFileNames {
+			items, err := readIgnoreFile(filepath.Join(filePath, fileName), domain)
+			if err != nil {
+				return err
+			}
+			patterns = append(patterns, items...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &IgnoreMatcher{matcher: gitignore.NewMatcher(patterns)}, nil
+}
+
+// 读取忽略规则，文件不存在时返回空
+func readIgnoreFile(filePath string, domain []string) (patterns []gitignore.Pattern, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns, scanner.Err()
+}
+
+// 是否忽略，relPath 为相对于根目录的路径，使用 / 分隔
+func (m *IgnoreMatcher) Match(relPath string, isDir bool) bool {
+	items := splitPath(relPath)
+	if len(items) <= 0 {
+		return false
+	}
+	for _, item := range items {
+		if item == gitDirName {
+			return true
+		}
+	}
+	return m.matcher.Match(items, isDir)
+}
+
+// 是否为忽略规则文件
+func isIgnoreFile(relPath string) bool {
+	fileName := relPath[strings.LastIndex(relPath, "/")+1:]
+	for _, item := range ignoreFileNames {
+		if fileName == item {
+			return true
+		}
+	}
+	return false
+}
+
+func splitPath(relPath string) []string {
+	relPath = filepath.ToSlash(relPath)
+	if relPath == "." || relPath == "" {
+		return nil
+	}
+	return strings.Split(relPath, "/")
+}
@@ -0,0 +1,245 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package filesync
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+// 文件状态，大小以及修改时间（秒）一致时认为内容相同，传输时会保留修改时间
+type FileState struct {
+	Size    int64
+	ModTime int64
+}
+
+// 远程主机上的文件操作，files 为相对路径，使用 / 分隔
+type Remote interface {
+	// 列出文件夹中所有的文件，文件夹不存在时返回空
+	List(remoteDir string) (map[string]FileState, error)
+	// 上传本地文件，保留修改时间以及权限
+	Push(localDir string, remoteDir string, files []string) error
+	// 下载远程文件，保留修改时间以及权限
+	Pull(remoteDir string, localDir string, files []string) error
+	// 删除远程文件
+	Remove(remoteDir string, files []string) error
+}
+
+// 在远程主机上执行命令，stdin、stdout 可以为空
+type CommandFunc func(command string, stdin io.Reader, stdout io.Writer) error
+
+// 通过 find、tar 等命令实现的 Remote，每次操作只执行一条命令
+type commandRemote struct {
+	run CommandFunc
+}
+
+// 创建通过命令操作文件的 Remote
+func NewCommandRemote(run CommandFunc) Remote {
+	return &commandRemote{run: run}
+}
+
+// 通过 ssh 操作远程主机上的文件，连接断开时自动重连
+func NewSSHCommandRemote(sshRemote *common.SSHRemote) Remote {
+	return NewCommandRemote(func(command string, stdin io.Reader, stdout io.Writer) error {
+		session, err := sshRemote.GetSSHClient().NewSession()
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+
+		var stderr bytes.Buffer
+		session.Stdin = stdin
+		session.Stdout = stdout
+		session.Stderr = &stderr
+		if err = session.Run(command); err != nil {
+			return fmt.Errorf("%v %v", err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	})
+}
+
+// 输出 相对路径\t大小\t修改时间，.git 文件夹不需要列出
+func (r *commandRemote) List(remoteDir string) (map[string]FileState, error) {
+	command := fmt.Sprintf(`[ -d %v ] || exit 0; find %v -path %v -prune -o -type f -printf '%%P\t%%s\t%%T@\n'`,
+		common.ShellQuote(remoteDir), common.ShellQuote(remoteDir), common.ShellQuote(remoteDir+"/"+gitDirName))
+	var stdout bytes.Buffer
+	if err := r.run(command, nil, &stdout); err != nil {
+		return nil, err
+	}
+	return parseFindOutput(stdout.String()), nil
+}
+
+func parseFindOutput(output string) map[string]FileState {
+	result := map[string]FileState{}
+	for _, line := range strings.Split(output, "\n") {
+		items := strings.Split(line, "\t")
+		if len(items) != 3 || items[0] == "" {
+			continue
+		}
+		size, err1 := strconv.ParseInt(items[1], 10, 64)
+		modTime, err2 := strconv.ParseInt(strings.SplitN(items[2], ".", 2)[0], 10, 64)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		result[items[0]] = FileState{Size: size, ModTime: modTime}
+	}
+	return result
+}
+
+// 打包成 tar 后通过一条命令解压，tar 会保留修改时间
+func (r *commandRemote) Push(localDir string, remoteDir string, files []string) error {
+	if len(files) <= 0 {
+		return nil
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close() // 远程命令提前退出时，结束写入
+	go func() {
+		pipeWriter.CloseWithError(writeTar(pipeWriter, localDir, files))
+	}()
+
+	command := fmt.Sprintf(`mkdir -p %v && tar -xf - -C %v`, common.ShellQuote(remoteDir), common.ShellQuote(remoteDir))
+	return r.run(command, pipeReader, nil)
+}
+
+func writeTar(writer io.Writer, localDir string, files []string) error {
+	tarWriter := tar.NewWriter(writer)
+	for _, relPath := range files {
+		if err := writeTarFile(tarWriter, localDir, relPath); err != nil {
+			return err
+		}
+	}
+	return tarWriter.Close()
+}
+
+func writeTarFile(tarWriter *tar.Writer, localDir string, relPath string) error {
+	file, err := os.Open(filepath.Join(localDir, filepath.FromSlash(relPath)))
+	if err != nil {
+		if os.IsNotExist(err) { // 文件已经被删除
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
+	if err != nil || !fileInfo.Mode().IsRegular() {
+		return err
+	}
+	header := &tar.Header{
+		Name:     relPath,
+		Mode:     int64(fileInfo.Mode().Perm()),
+		Size:     fileInfo.Size(),
+		ModTime:  fileInfo.ModTime(),
+		Typeflag: tar.TypeReg,
+	}
+	if err = tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.CopyN(tarWriter, file, fileInfo.Size())
+	return err
+}
+
+// 远程打包后在本地解压，文件列表通过标准输入传递，避免命令过长
+func (r *commandRemote) Pull(remoteDir string, localDir string, files []string) error {
+	if len(files) <= 0 {
+		return nil
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	result := make(chan error, 1)
+	go func() {
+		err := readTar(pipeReader, localDir)
+		if err == nil {
+			io.Copy(io.Discard, pipeReader) // tar 结束标记之后还有补齐的数据
+		}
+		pipeReader.CloseWithError(err) // 解压失败时结束远程命令
+		result <- err
+	}()
+
+	command := fmt.Sprintf(`tar -cf - -C %v --null -T -`, common.ShellQuote(remoteDir))
+	err := r.run(command, strings.NewReader(strings.Join(files, "\x00")), pipeWriter)
+	pipeWriter.CloseWithError(err)
+	readErr := <-result
+	if err == nil {
+		err = readErr
+	}
+	return err
+}
+
+// 解压到本地，先写入临时文件再重命名，避免编辑器读取到不完整的文件
+func readTar(reader io.Reader, localDir string) error {
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			continue // 不允许写入到文件夹之外
+		}
+
+		filePath := filepath.Join(localDir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+			return err
+		}
+		tempFile, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tempFile, tarReader)
+		tempFile.Close()
+		if err == nil {
+			err = os.Chmod(tempFile.Name(), os.FileMode(header.Mode).Perm())
+		}
+		if err == nil {
+			err = os.Chtimes(tempFile.Name(), time.Now(), header.ModTime)
+		}
+		if err == nil {
+			err = os.Rename(tempFile.Name(), filePath)
+		}
+		if err != nil {
+			os.Remove(tempFile.Name())
+			return err
+		}
+	}
+}
+
+// 删除远程文件，文件列表通过标准输入传递
+func (r *commandRemote) Remove(remoteDir string, files []string) error {
+	if len(files) <= 0 {
+		return nil
+	}
+	command := fmt.Sprintf(`cd %v && xargs -0 rm -f --`, common.ShellQuote(remoteDir))
+	return r.run(command, strings.NewReader(strings.Join(files, "\x00")), nil)
+}
@@ -0,0 +1,369 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package filesync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 同步的结果，均为相对路径
+type SyncResult struct {
+	Pushed        []string // 上传到远程主机
+	RemovedRemote []string // 在远程主机上删除
+	Pulled        []string // 从远程主机下载
+	RemovedLocal  []string // 在本地删除
+	Unchanged     int      // 两端一致，不需要同步
+	Conflicts     []string // 两端都有修改
+}
+
+// 本地文件夹与远程文件夹之间的双向同步
+// 记录上次同步后两端一致的文件状态，一端修改时同步到另一端，两端都修改时只提示冲突
+// 冲突时两端都不覆盖，并以远程的当前状态作为基准，本地之后的修改会覆盖远程文件
+type Syncer struct {
+	remote        Remote
+	localDir      string
+	remoteDir     string
+	extraPatterns []string
+
+	mutex      sync.Mutex
+	ignore     *IgnoreMatcher
+	synced     map[string]FileState // 上次同步后两端一致的状态
+	lastRemote map[string]FileState // 上次检查时远程文件的状态
+}
+
+// 创建同步，extraPatterns 为额外的忽略规则，比如临时文件夹
+func NewSyncer(remote Remote, localDir string, remoteDir string, extraPatterns []string) (*Syncer, error) {
+	localDir, err := filepath.Abs(localDir)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := LoadIgnoreMatcher(localDir, extraPatterns)
+	if err != nil {
+		return nil, err
+	}
+	return &Syncer{
+		remote:        remote,
+		localDir:      localDir,
+		remoteDir:     strings.TrimSuffix(remoteDir, "/"),
+		extraPatterns: extraPatterns,
+		ignore:        ignore,
+		synced:        map[string]FileState{},
+		lastRemote:    map[string]FileState{},
+	}, nil
+}
+
+// 本地文件夹的绝对路径
+func (s *Syncer) LocalDir() string {
+	return s.localDir
+}
+
+// 首次同步，上传本地所有的文件，远程的文件更新时提示冲突
+// 只存在于远程主机上的文件（比如编译的输出）保持不变
+func (s *Syncer) InitialSync() (result SyncResult, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	//1. 本地以及远程的文件
+	localFiles, err := s.listLocal()
+	if err != nil {
+		return result, err
+	}
+	remoteFiles, err := s.listRemote()
+	if err != nil {
+		return result, err
+	}
+
+	//2. 比较
+	for _, relPath := range sortedKeys(localFiles) {
+		localState := localFiles[relPath]
+		remoteState, isRemoteExist := remoteFiles[relPath]
+		switch {
+		case isRemoteExist && remoteState == localState:
+			s.synced[relPath] = localState
+			result.Unchanged++
+		case isRemoteExist && remoteState.ModTime > localState.ModTime:
+			s.synced[relPath] = remoteState
+			result.Conflicts = append(result.Conflicts, relPath)
+		default:
+			result.Pushed = append(result.Pushed, relPath)
+		}
+	}
+
+	//3. 上传
+	if err = s.remote.Push(s.localDir, s.remoteDir, result.Pushed); err != nil {
+		return result, err
+	}
+	for _, relPath := range result.Pushed {
+		s.synced[relPath] = localFiles[relPath]
+		remoteFiles[relPath] = localFiles[relPath]
+	}
+	s.lastRemote = remoteFiles
+	return result, nil
+}
+
+// 上传本地的修改，paths 为发生变化的文件或者文件夹（相对路径）
+func (s *Syncer) PushChanges(paths []string) (result SyncResult, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	//1. 忽略规则改变时重新加载
+	for _, relPath := range paths {
+		if isIgnoreFile(relPath) {
+			if ignore, err := LoadIgnoreMatcher(s.localDir, s.extraPatterns); err == nil {
+				s.ignore = ignore
+			}
+			break
+		}
+	}
+
+	//2. 需要检查的文件，文件夹展开为其中的文件，已删除的文件夹使用上次同步的文件
+	localFiles := map[string]FileState{}
+	checkPaths := map[string]bool{}
+	for _, relPath := range paths {
+		relPath = filepath.ToSlash(filepath.Clean(relPath))
+		if relPath == "." || s.ignore.Match(relPath, false) {
+			continue
+		}
+		fileInfo, err := os.Stat(s.localPath(relPath))
+		if err == nil && fileInfo.IsDir() {
+			files, err := s.listLocalDir(relPath)
+			if err != nil {
+				return result, err
+			}
+			for filePath, state := range files {
+				localFiles[filePath] = state
+				checkPaths[filePath] = true
+			}
+			continue
+		}
+		if err == nil && fileInfo.Mode().IsRegular() {
+			localFiles[relPath] = newFileState(fileInfo)
+		}
+		checkPaths[relPath] = true
+		for syncedPath := range s.synced {
+			if strings.HasPrefix(syncedPath, relPath+"/") {
+				checkPaths[syncedPath] = true
+			}
+		}
+	}
+	if len(checkPaths) <= 0 {
+		return result, nil
+	}
+
+	//3. 比较，远程文件在上次同步后也被修改时为冲突
+	remoteFiles, err := s.listRemote()
+	if err != nil {
+		return result, err
+	}
+	for _, relPath := range sortedPaths(checkPaths) {
+		localState, isLocalExist := localFiles[relPath]
+		syncedState, isSynced := s.synced[relPath]
+		if isLocalExist == isSynced && localState == syncedState { // 本地没有修改
+			continue
+		}
+		remoteState, isRemoteExist := remoteFiles[relPath]
+		if isRemoteExist != isSynced || remoteState != syncedState { // 远程也有修改
+			if isLocalExist && isRemoteExist && localState == remoteState {
+				s.synced[relPath] = localState
+				continue
+			}
+			result.Conflicts = append(result.Conflicts, relPath)
+			s.setSynced(relPath, remoteState, isRemoteExist)
+			continue
+		}
+		if isLocalExist {
+			result.Pushed = append(result.Pushed, relPath)
+		} else {
+			result.RemovedRemote = append(result.RemovedRemote, relPath)
+		}
+	}
+
+	//4. 上传以及删除
+	if err = s.remote.Push(s.localDir, s.remoteDir, result.Pushed); err != nil {
+		return result, err
+	}
+	if err = s.remote.Remove(s.remoteDir, result.RemovedRemote); err != nil {
+		return result, err
+	}
+	for _, relPath := range result.Pushed {
+		s.synced[relPath] = localFiles[relPath]
+		s.lastRemote[relPath] = localFiles[relPath]
+	}
+	for _, relPath := range result.RemovedRemote {
+		delete(s.synced, relPath)
+		delete(s.lastRemote, relPath)
+	}
+	return result, nil
+}
+
+// 下载远程主机上的修改，只处理上次检查之后远程发生变化的文件
+func (s *Syncer) PullChanges() (result SyncResult, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	remoteFiles, err := s.listRemote()
+	if err != nil {
+		return result, err
+	}
+
+	//1. 远程发生变化的文件
+	changedPaths := map[string]bool{}
+	for relPath, remoteState := range remoteFiles {
+		if lastState, ok := s.lastRemote[relPath]; !ok || lastState != remoteState {
+			changedPaths[relPath] = true
+		}
+	}
+	for relPath := range s.lastRemote {
+		if _, ok := remoteFiles[relPath]; !ok {
+			changedPaths[relPath] = true
+		}
+	}
+
+	//2. 比较，本地文件在上次同步后也被修改时为冲突
+	for _, relPath := range sortedPaths(changedPaths) {
+		remoteState, isRemoteExist := remoteFiles[relPath]
+		syncedState, isSynced := s.synced[relPath]
+		if isRemoteExist == isSynced && remoteState == syncedState { // 本地上传的修改
+			continue
+		}
+		localState, isLocalExist := s.statLocal(relPath)
+		if isLocalExist != isSynced || localState != syncedState { // 本地也有修改
+			if isLocalExist && isRemoteExist && localState == remoteState {
+				s.synced[relPath] = remoteState
+				continue
+			}
+			result.Conflicts = append(result.Conflicts, relPath)
+			s.setSynced(relPath, remoteState, isRemoteExist)
+			continue
+		}
+		if isRemoteExist {
+			result.Pulled = append(result.Pulled, relPath)
+		} else {
+			result.RemovedLocal = append(result.RemovedLocal, relPath)
+		}
+	}
+
+	//3. 下载以及删除，先更新状态，避免本地的文件监听再次上传
+	for _, relPath := range result.Pulled {
+		s.synced[relPath] = remoteFiles[relPath]
+	}
+	for _, relPath := range result.RemovedLocal {
+		delete(s.synced, relPath)
+	}
+	if err = s.remote.Pull(s.remoteDir, s.localDir, result.Pulled); err != nil {
+		return result, err
+	}
+	for _, relPath := range result.RemovedLocal {
+		if err = os.Remove(s.localPath(relPath)); err != nil && !os.IsNotExist(err) {
+			return result, err
+		}
+	}
+	s.lastRemote = remoteFiles
+	return result, nil
+}
+
+func (s *Syncer) setSynced(relPath string, state FileState, isExist bool) {
+	if isExist {
+		s.synced[relPath] = state
+	} else {
+		delete(s.synced, relPath)
+	}
+}
+
+func (s *Syncer) localPath(relPath string) string {
+	return filepath.Join(s.localDir, filepath.FromSlash(relPath))
+}
+
+func (s *Syncer) statLocal(relPath string) (FileState, bool) {
+	fileInfo, err := os.Stat(s.localPath(relPath))
+	if err != nil || !fileInfo.Mode().IsRegular() {
+		return FileState{}, false
+	}
+	return newFileState(fileInfo), true
+}
+
+// 本地所有需要同步的文件
+func (s *Syncer) listLocal() (map[string]FileState, error) {
+	return s.listLocalDir(".")
+}
+
+func (s *Syncer) listLocalDir(relDir string) (map[string]FileState, error) {
+	result := map[string]FileState{}
+	err := filepath.Walk(s.localPath(relDir), func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // 文件已经被删除等，跳过
+		}
+		relPath, _ := filepath.Rel(s.localDir, filePath)
+		relPath = filepath.ToSlash(relPath)
+		if relPath == "." {
+			return nil
+		}
+		if s.ignore.Match(relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			result[relPath] = newFileState(info)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// 远程所有需要同步的文件
+func (s *Syncer) listRemote() (map[string]FileState, error) {
+	files, err := s.remote.List(s.remoteDir)
+	if err != nil {
+		return nil, err
+	}
+	for relPath := range files {
+		if s.ignore.Match(relPath, false) {
+			delete(files, relPath)
+		}
+	}
+	return files, nil
+}
+
+func newFileState(fileInfo os.FileInfo) FileState {
+	return FileState{Size: fileInfo.Size(), ModTime: fileInfo.ModTime().Unix()}
+}
+
+func sortedKeys(items map[string]FileState) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedPaths(items map[string]bool) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
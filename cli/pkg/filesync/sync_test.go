@@ -0,0 +1,230 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package filesync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+// 在本机执行命令，模拟远程主机
+func newLocalCommandRemote(t *testing.T) Remote {
+	for _, command := range []string{"sh", "find", "tar", "xargs"} {
+		if _, err := exec.LookPath(command); err != nil {
+			t.Skipf("%v is required", command)
+		}
+	}
+	return NewCommandRemote(func(command string, stdin io.Reader, stdout io.Writer) error {
+		var stderr bytes.Buffer
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = stdin, stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%v %v", err, stderr.String())
+		}
+		return nil
+	})
+}
+
+// 写入文件，并指定修改时间，避免在同一秒内修改时无法识别
+func writeTestFile(t *testing.T, filePath string, content string, modTime time.Time) {
+	os.MkdirAll(filepath.Dir(filePath), 0755)
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(filePath string) string {
+	content, _ := os.ReadFile(filePath)
+	return string(content)
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, ".gitignore"), "# comment\nnode_modules/\n*.log\n!keep.log\n", time.Now())
+	writeTestFile(t, filepath.Join(dir, ".ideignore"), "/secret\n", time.Now())
+	writeTestFile(t, filepath.Join(dir, "sub", ".gitignore"), "local.txt\n", time.Now())
+
+	matcher, err := LoadIgnoreMatcher(dir, []string{"/.ide/.temp/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]bool{
+		"main.go":                 false,
+		"node_modules/a/index.js": true,
+		"app.log":                 true,
+		"keep.log":                false,
+		"secret/key":              true,
+		"src/secret/key":          false,
+		"sub/local.txt":           true,
+		"local.txt":               false,
+		".git/config":             true,
+		".ide/.temp/compose.yaml": true,
+		".ide/.ide.yaml":          false,
+	}
+	for relPath, want := range tests {
+		if got := matcher.Match(relPath, false); got != want {
+			t.Errorf("Match(%q) = %v, want %v", relPath, got, want)
+		}
+	}
+}
+
+func TestSyncer(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+	remote := newLocalCommandRemote(t)
+	localDir, remoteDir := t.TempDir(), t.TempDir()
+	baseTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	writeTestFile(t, filepath.Join(localDir, ".gitignore"), "node_modules/\n", baseTime)
+	writeTestFile(t, filepath.Join(localDir, "a.txt"), "a", baseTime)
+	writeTestFile(t, filepath.Join(localDir, "sub", "b.txt"), "b", baseTime)
+	writeTestFile(t, filepath.Join(localDir, "c.txt"), "local c", baseTime)
+	writeTestFile(t, filepath.Join(localDir, "node_modules", "x.js"), "x", baseTime)
+	writeTestFile(t, filepath.Join(remoteDir, "c.txt"), "remote c", baseTime.Add(time.Minute)) // 远程的文件更新
+	writeTestFile(t, filepath.Join(remoteDir, "out.bin"), "out", baseTime)                     // 只存在于远程
+
+	syncer, err := NewSyncer(remote, localDir, remoteDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//1. 首次同步
+	result, err := syncer.InitialSync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(result.Pushed) != "[.gitignore a.txt sub/b.txt]" || fmt.Sprint(result.Conflicts) != "[c.txt]" {
+		t.Errorf("InitialSync() pushed = %v, conflicts = %v", result.Pushed, result.Conflicts)
+	}
+	if readTestFile(filepath.Join(remoteDir, "sub", "b.txt")) != "b" || readTestFile(filepath.Join(remoteDir, "c.txt")) != "remote c" {
+		t.Errorf("remote files are not synced")
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, "node_modules")); !os.IsNotExist(err) {
+		t.Errorf("ignored folder should not be synced")
+	}
+	if fileInfo, err := os.Stat(filepath.Join(remoteDir, "a.txt")); err != nil || !fileInfo.ModTime().Equal(baseTime) {
+		t.Errorf("modification time should be kept, %v", err)
+	}
+
+	// 再次同步时没有变化
+	syncer, _ = NewSyncer(remote, localDir, remoteDir, nil)
+	if result, err = syncer.InitialSync(); err != nil || len(result.Pushed) != 0 || result.Unchanged != 3 {
+		t.Errorf("InitialSync() again = %+v, %v", result, err)
+	}
+
+	//2. 本地修改、删除
+	writeTestFile(t, filepath.Join(localDir, "a.txt"), "a2", baseTime.Add(time.Second))
+	os.RemoveAll(filepath.Join(localDir, "sub"))
+	result, err = syncer.PushChanges([]string{"a.txt", "sub"})
+	if err != nil || fmt.Sprint(result.Pushed) != "[a.txt]" || fmt.Sprint(result.RemovedRemote) != "[sub/b.txt]" {
+		t.Errorf("PushChanges() = %+v, %v", result, err)
+	}
+	if readTestFile(filepath.Join(remoteDir, "a.txt")) != "a2" {
+		t.Errorf("remote a.txt is not updated")
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, "sub", "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("remote sub/b.txt should be removed")
+	}
+
+	//3. 远程修改、新增、删除
+	writeTestFile(t, filepath.Join(remoteDir, "a.txt"), "a3", baseTime.Add(time.Second*2))
+	writeTestFile(t, filepath.Join(remoteDir, "d", "d.txt"), "d", baseTime)
+	os.Remove(filepath.Join(remoteDir, ".gitignore"))
+	result, err = syncer.PullChanges()
+	if err != nil || fmt.Sprint(result.Pulled) != "[a.txt d/d.txt]" || fmt.Sprint(result.RemovedLocal) != "[.gitignore]" {
+		t.Errorf("PullChanges() = %+v, %v", result, err)
+	}
+	if readTestFile(filepath.Join(localDir, "a.txt")) != "a3" || readTestFile(filepath.Join(localDir, "d", "d.txt")) != "d" {
+		t.Errorf("local files are not updated")
+	}
+	// 下载的文件不会再次上传
+	if result, err = syncer.PushChanges([]string{"a.txt", "d"}); err != nil || len(result.Pushed) != 0 {
+		t.Errorf("PushChanges() after pull = %+v, %v", result, err)
+	}
+
+	//4. 两端都修改时提示冲突，不覆盖远程文件，本地再次修改时上传
+	writeTestFile(t, filepath.Join(remoteDir, "a.txt"), "remote a4", baseTime.Add(time.Second*3))
+	writeTestFile(t, filepath.Join(localDir, "a.txt"), "local a4", baseTime.Add(time.Second*4))
+	result, err = syncer.PushChanges([]string{"a.txt"})
+	if err != nil || fmt.Sprint(result.Conflicts) != "[a.txt]" || readTestFile(filepath.Join(remoteDir, "a.txt")) != "remote a4" {
+		t.Errorf("PushChanges() conflict = %+v, %v", result, err)
+	}
+	writeTestFile(t, filepath.Join(localDir, "a.txt"), "local a5", baseTime.Add(time.Second*5))
+	result, err = syncer.PushChanges([]string{"a.txt"})
+	if err != nil || fmt.Sprint(result.Pushed) != "[a.txt]" || readTestFile(filepath.Join(remoteDir, "a.txt")) != "local a5" {
+		t.Errorf("PushChanges() after conflict = %+v, %v", result, err)
+	}
+}
+
+func TestSyncer_Watch(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+	remote := newLocalCommandRemote(t)
+	localDir, remoteDir := t.TempDir(), t.TempDir()
+	writeTestFile(t, filepath.Join(localDir, "a.txt"), "a", time.Now().Add(-time.Hour))
+
+	syncer, err := NewSyncer(remote, localDir, remoteDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = syncer.InitialSync(); err != nil {
+		t.Fatal(err)
+	}
+
+	WatchDebounce = time.Millisecond * 50
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- syncer.Watch(ctx, time.Millisecond*100, func(result SyncResult, err error) {
+			if err != nil {
+				t.Log(err)
+			}
+		})
+	}()
+	time.Sleep(time.Millisecond * 100) // 等待开始监听
+
+	writeTestFile(t, filepath.Join(localDir, "new", "b.txt"), "b", time.Now().Add(-time.Minute))
+	writeTestFile(t, filepath.Join(remoteDir, "c.txt"), "c", time.Now().Add(-time.Minute))
+	waitFor := func(filePath string, content string) {
+		deadline := time.Now().Add(time.Second * 5)
+		for readTestFile(filePath) != content {
+			if time.Now().After(deadline) {
+				t.Fatalf("%v is not synced", filePath)
+			}
+			time.Sleep(time.Millisecond * 20)
+		}
+	}
+	waitFor(filepath.Join(remoteDir, "new", "b.txt"), "b")
+	waitFor(filepath.Join(localDir, "c.txt"), "c")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch() error = %v", err)
+	}
+}
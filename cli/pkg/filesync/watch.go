@@ -0,0 +1,118 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package filesync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// 本地修改后等待的时间，编辑器保存时会产生多个事件，合并后一起上传
+var WatchDebounce = time.Millisecond * 300
+
+// 每次同步后的回调，用于输出同步的结果
+type ReportFunc func(result SyncResult, err error)
+
+// 监听本地的修改并上传，同时每隔 pollInterval 检查一次远程的修改，直到 ctx 取消
+func (s *Syncer) Watch(ctx context.Context, pollInterval time.Duration, report ReportFunc) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	if err = s.addWatchDir(watcher, "."); err != nil {
+		return err
+	}
+
+	var pollChan <-chan time.Time
+	if pollInterval > 0 {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		pollChan = ticker.C
+	}
+	debounce := time.NewTimer(WatchDebounce)
+	debounce.Stop()
+	pendingPaths := map[string]bool{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			relPath, err := filepath.Rel(s.localDir, event.Name)
+			if err != nil {
+				continue
+			}
+			relPath = filepath.ToSlash(relPath)
+			if s.isIgnored(relPath, false) {
+				continue
+			}
+			// 新建的文件夹需要监听，其中已经存在的文件在上传时展开
+			if event.Op&fsnotify.Create == fsnotify.Create {
+				if fileInfo, err := os.Stat(event.Name); err == nil && fileInfo.IsDir() {
+					s.addWatchDir(watcher, relPath)
+				}
+			}
+			pendingPaths[relPath] = true
+			debounce.Reset(WatchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			report(SyncResult{}, err)
+
+		case <-debounce.C:
+			paths := sortedPaths(pendingPaths)
+			pendingPaths = map[string]bool{}
+			report(s.PushChanges(paths))
+
+		case <-pollChan:
+			report(s.PullChanges())
+		}
+	}
+}
+
+// 监听文件夹以及所有没有被忽略的子文件夹
+func (s *Syncer) addWatchDir(watcher *fsnotify.Watcher, relDir string) error {
+	return filepath.Walk(s.localPath(relDir), func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		relPath, _ := filepath.Rel(s.localDir, filePath)
+		if relPath != "." && s.isIgnored(filepath.ToSlash(relPath), true) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(filePath)
+	})
+}
+
+func (s *Syncer) isIgnored(relPath string, isDir bool) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ignore.Match(relPath, isDir)
+}
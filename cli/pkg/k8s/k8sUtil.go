@@ -19,15 +19,19 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package k8s
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/leansoftX/smartide-cli/pkg/common"
 	"github.com/spf13/cobra"
@@ -207,7 +211,7 @@ sudo chmod -R 700 ~/.ssh`, runAsUser, runAsUser)
 	}
 
 	// chmod
-	commad := `sudo echo -e 'Host *\n	StrictHostKeyChecking no' >>  ~/.ssh/config`
+	commad := `sudo echo -e 'Host *\n	StrictHostKeyChecking accept-new' >>  ~/.ssh/config`
 	k.ExecuteCommandRealtimeInPod(pod, containerName, commad, runAsUser)
 
 	return nil
@@ -552,6 +556,46 @@ func (k *KubernetesUtil) ExecKubectlCommand(command string, dirctory string, isL
 	return execCommand.Run()
 }
 
+// 在后台执行 kubectl port-forward，本地端口可以连接后返回，返回的进程需要调用方结束
+func (k *KubernetesUtil) StartPortForward(resource string, localPort int, remotePort int, timeout time.Duration) (*exec.Cmd, error) {
+	var execCommand *exec.Cmd
+
+	kubeCommand := fmt.Sprintf("%v %v port-forward %v %v:%v", k.KubectlFilePath, k.Commands, resource, localPort, remotePort)
+	common.SmartIDELog.Debug(kubeCommand)
+	switch runtime.GOOS {
+	case "windows":
+		execCommand = exec.Command("powershell", "/c", kubeCommand)
+	default:
+		execCommand = exec.Command("bash", "-c", "exec "+kubeCommand) // 结束进程时 kubectl 一起退出
+	}
+	var output bytes.Buffer
+	execCommand.Stdout = &output
+	execCommand.Stderr = &output
+	if err := execCommand.Start(); err != nil {
+		return nil, err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- execCommand.Wait() }()
+
+	// 等待本地端口可以连接
+	addr := fmt.Sprintf("localhost:%v", localPort)
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		select {
+		case err := <-exited:
+			return nil, fmt.Errorf("kubectl port-forward exited: %v %v", err, strings.TrimSpace(output.String()))
+		case <-time.After(time.Millisecond * 200):
+		}
+		if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+			conn.Close()
+			return execCommand, nil
+		}
+	}
+	execCommand.Process.Kill()
+	<-exited
+	return nil, fmt.Errorf("kubectl port-forward %v timeout: %v", resource, strings.TrimSpace(output.String()))
+}
+
 // 一次性执行kubectl命令
 func (k *KubernetesUtil) ExecKubectlCommandCombined(command string, dirctory string) (string, error) {
 	return execKubectlCommandCombined(k.KubectlFilePath, k.Commands+" "+command, dirctory)
@@ -676,6 +720,70 @@ func (k *KubernetesUtil) ExecuteCommandCombinedBackgroundInPod(pod coreV1.Pod, c
 	k.ExecKubectlCommandCombined(kubeCommand, "")
 }
 
+// 在pod中执行命令，标准输出、标准错误分开输出，返回退出码（kubectl exec 的退出码与远程命令一致）
+// isInteractive 为 true 时发送 stdin，isTTY 为 true 时分配终端，终端的 raw 模式、窗口大小由 kubectl 处理
+func (k *KubernetesUtil) ExecuteCommandStreamInPod(pod coreV1.Pod, containerName string, args []string, runAsUser string,
+	isInteractive bool, isTTY bool, stdin io.Reader, stdout io.Writer, stderr io.Writer) (exitCode int, err error) {
+	//1. 命令
+	if runAsUser != "" && runAsUser != "root" {
+		args = []string{"su", runAsUser, "-c", strings.Join(quoteCommandArgs(args, common.ShellQuote), " ")}
+	}
+	execFlags := ""
+	if isInteractive {
+		execFlags += "-i "
+	}
+	if isTTY {
+		execFlags += "-t "
+	}
+	if containerName != "" {
+		execFlags += fmt.Sprintf("--container %v ", containerName)
+	}
+
+	//2. 执行，本地使用 shell 启动 kubectl，参数需要按照本地 shell 转义
+	var execCommand *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		kubeCommand := fmt.Sprintf("%v %v exec %v%v -- %v", k.KubectlFilePath, k.Commands, execFlags, pod.Name,
+			strings.Join(quoteCommandArgs(args, powershellQuote), " "))
+		common.SmartIDELog.Debug(kubeCommand)
+		execCommand = exec.Command("powershell", "/c", kubeCommand)
+	default:
+		kubeCommand := fmt.Sprintf("%v %v exec %v%v -- %v", k.KubectlFilePath, k.Commands, execFlags, pod.Name,
+			strings.Join(quoteCommandArgs(args, common.ShellQuote), " "))
+		common.SmartIDELog.Debug(kubeCommand)
+		execCommand = exec.Command("bash", "-c", "exec "+kubeCommand)
+	}
+	if isInteractive {
+		execCommand.Stdin = stdin
+	}
+	execCommand.Stdout = stdout
+	execCommand.Stderr = stderr
+
+	//3. 退出码
+	err = execCommand.Run()
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+func quoteCommandArgs(args []string, quote func(string) string) []string {
+	result := []string{}
+	for _, arg := range args {
+		result = append(result, quote(arg))
+	}
+	return result
+}
+
+// powershell 中的单引号字符串，单引号需要写两次
+func powershellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
 func formartCommand(pod coreV1.Pod, containerName string, command string, runAsUser string) string {
 	if runAsUser != "" && runAsUser != "root" {
 		if runtime.GOOS == "windows" {
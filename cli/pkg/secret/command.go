@@ -0,0 +1,143 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// 在 secret-service、pass 中的命名空间
+const secretNamespace = "smartide"
+
+// 执行命令，input 通过标准输入传递，避免密码出现在进程参数中
+// 失败时有错误输出的返回错误输出，否则包含 *exec.ExitError
+var runSecretCommand = func(input string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return stdout.String(), fmt.Errorf("%v: %v", name, message)
+		}
+		return stdout.String(), fmt.Errorf("%v: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// freedesktop Secret Service，通过 libsecret 提供的 secret-tool 访问
+type secretServiceStore struct{}
+
+func (s *secretServiceStore) Backend() SecretBackendEnum {
+	return SecretBackendEnum_SecretService
+}
+
+func (s *secretServiceStore) Get(key string) (string, error) {
+	output, err := runSecretCommand("", "secret-tool", "lookup", "service", secretNamespace, "key", key)
+	if err != nil {
+		// 没有找到时 secret-tool 返回 1，并且没有任何输出；D-Bus 不可用、keyring 锁定等情况下有错误输出
+		var exitErr *exec.ExitError
+		if output == "" && errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return output, nil
+}
+
+func (s *secretServiceStore) Set(key string, value string) error {
+	_, err := runSecretCommand(value, "secret-tool", "store", "--label", secretNamespace+": "+key, "service", secretNamespace, "key", key)
+	return err
+}
+
+func (s *secretServiceStore) Delete(key string) error {
+	runSecretCommand("", "secret-tool", "clear", "service", secretNamespace, "key", key) // 不存在时也会返回错误
+	return nil
+}
+
+// pass 密码管理器，保存在 smartide/ 目录下
+type passStore struct{}
+
+func (s *passStore) Backend() SecretBackendEnum {
+	return SecretBackendEnum_Pass
+}
+
+func (s *passStore) Get(key string) (string, error) {
+	output, err := runSecretCommand("", "pass", "show", secretNamespace+"/"+key)
+	if err != nil {
+		if strings.Contains(err.Error(), "is not in the password store") {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(output, "\n"), nil
+}
+
+func (s *passStore) Set(key string, value string) error {
+	_, err := runSecretCommand(value+"\n", "pass", "insert", "--multiline", "--force", secretNamespace+"/"+key)
+	return err
+}
+
+func (s *passStore) Delete(key string) error {
+	_, err := runSecretCommand("", "pass", "rm", "--force", secretNamespace+"/"+key)
+	if err != nil && strings.Contains(err.Error(), "is not in the password store") {
+		return nil
+	}
+	return err
+}
+
+// 从环境变量中读取，只读
+// e.g. remote/root@192.168.1.2/password 对应的环境变量为 SMARTIDE_SECRET_REMOTE_ROOT_192_168_1_2_PASSWORD
+type envStore struct{}
+
+var envNameRegexp = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// 敏感信息对应的环境变量名称
+func GetSecretEnvName(key string) string {
+	return "SMARTIDE_SECRET_" + strings.Trim(envNameRegexp.ReplaceAllString(strings.ToUpper(key), "_"), "_")
+}
+
+func (s *envStore) Backend() SecretBackendEnum {
+	return SecretBackendEnum_Env
+}
+
+func (s *envStore) Get(key string) (string, error) {
+	value, ok := os.LookupEnv(GetSecretEnvName(key))
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (s *envStore) Set(key string, value string) error {
+	if current, ok := os.LookupEnv(GetSecretEnvName(key)); ok && current == value {
+		return nil
+	}
+	return ErrSecretStoreReadOnly
+}
+
+func (s *envStore) Delete(key string) error {
+	return nil
+}
@@ -0,0 +1,241 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// 口令的环境变量，设置后不再提示输入
+const CONST_SecretPassphraseEnv = "SMARTIDE_SECRET_PASSPHRASE"
+
+// 加密文件的默认路径
+func GetDefaultSecretFilePath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ide", ".secrets")
+}
+
+// 从环境变量获取口令，没有设置时在终端中输入
+func GetPassphraseFromEnvOrTerminal(isNew bool) (string, error) {
+	if passphrase := os.Getenv(CONST_SecretPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("无法输入口令，请设置环境变量 %v", CONST_SecretPassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, "请输入 smartide 密钥文件的口令: ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if isNew { // 新建时需要确认
+		fmt.Fprint(os.Stderr, "请再次输入口令: ")
+		confirm, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if string(confirm) != string(passphrase) {
+			return "", errors.New("两次输入的口令不一致")
+		}
+	}
+	if len(passphrase) == 0 {
+		return "", errors.New("口令不能为空")
+	}
+	return string(passphrase), nil
+}
+
+// 加密文件的内容
+type secretFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Data    []byte `json:"data"` // nonce + 密文
+}
+
+// 保存在加密文件中，整个文件使用 AES-GCM 加密，密钥通过 scrypt 由口令生成
+type fileSecretStore struct {
+	filePath      string
+	getPassphrase func(isNew bool) (string, error)
+
+	mutex   sync.Mutex
+	key     []byte
+	salt    []byte
+	secrets map[string]string
+}
+
+// 创建加密文件存储，getPassphrase 在第一次读写时调用
+func NewFileSecretStore(filePath string, getPassphrase func(isNew bool) (string, error)) SecretStore {
+	return &fileSecretStore{filePath: filePath, getPassphrase: getPassphrase}
+}
+
+func (s *fileSecretStore) Backend() SecretBackendEnum {
+	return SecretBackendEnum_File
+}
+
+func (s *fileSecretStore) Get(key string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.load(); err != nil {
+		return "", err
+	}
+	value, ok := s.secrets[key]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func (s *fileSecretStore) Set(key string, value string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.secrets[key] = value
+	return s.save()
+}
+
+func (s *fileSecretStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+	if _, ok := s.secrets[key]; !ok {
+		return nil
+	}
+	delete(s.secrets, key)
+	return s.save()
+}
+
+// 读取并解密文件，文件不存在时初始化
+func (s *fileSecretStore) load() error {
+	if s.secrets != nil {
+		return nil
+	}
+
+	content, err := os.ReadFile(s.filePath)
+	if os.IsNotExist(err) {
+		passphrase, err := s.getPassphrase(true)
+		if err != nil {
+			return err
+		}
+		s.salt = make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, s.salt); err != nil {
+			return err
+		}
+		if s.key, err = deriveSecretKey(passphrase, s.salt); err != nil {
+			return err
+		}
+		s.secrets = map[string]string{}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var file secretFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return fmt.Errorf("%v 格式错误: %v", s.filePath, err)
+	}
+	passphrase, err := s.getPassphrase(false)
+	if err != nil {
+		return err
+	}
+	key, err := deriveSecretKey(passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	if len(file.Data) < gcm.NonceSize() {
+		return fmt.Errorf("%v 格式错误", s.filePath)
+	}
+	plaintext, err := gcm.Open(nil, file.Data[:gcm.NonceSize()], file.Data[gcm.NonceSize():], nil)
+	if err != nil {
+		return errors.New("口令错误，无法解密 " + s.filePath)
+	}
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return err
+	}
+
+	s.key, s.salt, s.secrets = key, file.Salt, secrets
+	return nil
+}
+
+// 加密后写入文件，先写入临时文件再替换，避免写入中断导致文件损坏
+func (s *fileSecretStore) save() error {
+	plaintext, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	content, err := json.Marshal(secretFile{Version: 1, Salt: s.salt, Data: gcm.Seal(nonce, nonce, plaintext, nil)})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.filePath), 0700); err != nil {
+		return err
+	}
+	tempFilePath := s.filePath + ".tmp"
+	if err := os.WriteFile(tempFilePath, content, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tempFilePath, s.filePath)
+}
+
+// 由口令生成 32 位的密钥
+func deriveSecretKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
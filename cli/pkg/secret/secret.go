@@ -0,0 +1,114 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 密码、密钥等敏感信息的存储方式
+type SecretBackendEnum string
+
+const (
+	// 保存在本地数据库中，使用程序内置的密钥加密，兼容旧版本
+	SecretBackendEnum_Database SecretBackendEnum = "db"
+	// 保存在加密文件中，密钥由用户的口令生成
+	SecretBackendEnum_File SecretBackendEnum = "file"
+	// freedesktop Secret Service（gnome-keyring、kwallet 等），通过 secret-tool 访问
+	SecretBackendEnum_SecretService SecretBackendEnum = "secret-service"
+	// pass 密码管理器
+	SecretBackendEnum_Pass SecretBackendEnum = "pass"
+	// 环境变量，只读
+	SecretBackendEnum_Env SecretBackendEnum = "env"
+)
+
+var (
+	// 没有找到对应的敏感信息
+	ErrSecretNotFound = errors.New("secret not found")
+	// 存储方式只读，无法保存
+	ErrSecretStoreReadOnly = errors.New("secret store is read only")
+)
+
+// 敏感信息的存储
+type SecretStore interface {
+	// 存储方式
+	Backend() SecretBackendEnum
+	// 获取，不存在时返回 ErrSecretNotFound
+	Get(key string) (string, error)
+	// 保存，已经存在时覆盖
+	Set(key string, value string) error
+	// 删除，不存在时不返回错误
+	Delete(key string) error
+}
+
+// 所有支持的存储方式
+func GetSecretBackends() []SecretBackendEnum {
+	return []SecretBackendEnum{SecretBackendEnum_Database, SecretBackendEnum_File, SecretBackendEnum_SecretService, SecretBackendEnum_Pass, SecretBackendEnum_Env}
+}
+
+// 解析存储方式，为空时使用数据库
+func ParseSecretBackend(value string) (SecretBackendEnum, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return SecretBackendEnum_Database, nil
+	}
+	for _, backend := range GetSecretBackends() {
+		if string(backend) == value {
+			return backend, nil
+		}
+	}
+	return "", fmt.Errorf("不支持的 secret-backend: %v，可选值为 %v", value, GetSecretBackends())
+}
+
+// 创建存储，数据库方式由 dal 直接处理，不需要创建
+func NewSecretStore(backend SecretBackendEnum) (SecretStore, error) {
+	switch backend {
+	case SecretBackendEnum_File:
+		return NewFileSecretStore(GetDefaultSecretFilePath(), GetPassphraseFromEnvOrTerminal), nil
+	case SecretBackendEnum_SecretService:
+		return &secretServiceStore{}, nil
+	case SecretBackendEnum_Pass:
+		return &passStore{}, nil
+	case SecretBackendEnum_Env:
+		return &envStore{}, nil
+	}
+	return nil, fmt.Errorf("secret-backend %v 没有对应的存储", backend)
+}
+
+// 引用的前缀，e.g. secret://file/remote/root@192.168.1.2/password
+const referencePrefix = "secret://"
+
+// 生成保存到数据库中的引用
+func GetReference(backend SecretBackendEnum, key string) string {
+	return referencePrefix + string(backend) + "/" + key
+}
+
+// 解析引用，不是引用时返回 false
+func ParseReference(value string) (backend SecretBackendEnum, key string, ok bool) {
+	if !strings.HasPrefix(value, referencePrefix) {
+		return "", "", false
+	}
+	items := strings.SplitN(strings.TrimPrefix(value, referencePrefix), "/", 2)
+	if len(items) != 2 || items[1] == "" {
+		return "", "", false
+	}
+	return SecretBackendEnum(items[0]), items[1], true
+}
@@ -0,0 +1,151 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package secret
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileSecretStore(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), ".secrets")
+	passphrase := func(isNew bool) (string, error) { return "passphrase", nil }
+
+	store := NewFileSecretStore(filePath, passphrase)
+	if err := store.Set("remote/root@192.168.1.2/password", "p@ssw0rd"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	content, _ := os.ReadFile(filePath)
+	if strings.Contains(string(content), "p@ssw0rd") {
+		t.Errorf("Set() secret file should be encrypted")
+	}
+
+	// 重新打开
+	store = NewFileSecretStore(filePath, passphrase)
+	if got, err := store.Get("remote/root@192.168.1.2/password"); err != nil || got != "p@ssw0rd" {
+		t.Errorf("Get() = %v, %v, want p@ssw0rd", got, err)
+	}
+	if _, err := store.Get("none"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, ErrSecretNotFound)
+	}
+	if err := store.Delete("remote/root@192.168.1.2/password"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get("remote/root@192.168.1.2/password"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Get() after Delete() error = %v", err)
+	}
+
+	// 口令错误
+	store = NewFileSecretStore(filePath, func(isNew bool) (string, error) { return "wrong", nil })
+	if _, err := store.Get("remote/root@192.168.1.2/password"); err == nil {
+		t.Errorf("Get() with wrong passphrase should return error")
+	}
+}
+
+func TestEnvStore(t *testing.T) {
+	store := &envStore{}
+	key := "remote/root@192.168.1.2/password"
+	if got := GetSecretEnvName(key); got != "SMARTIDE_SECRET_REMOTE_ROOT_192_168_1_2_PASSWORD" {
+		t.Errorf("GetSecretEnvName() = %v", got)
+	}
+	if _, err := store.Get(key); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, ErrSecretNotFound)
+	}
+	t.Setenv(GetSecretEnvName(key), "p@ssw0rd")
+	if got, err := store.Get(key); err != nil || got != "p@ssw0rd" {
+		t.Errorf("Get() = %v, %v", got, err)
+	}
+	if err := store.Set(key, "other"); !errors.Is(err, ErrSecretStoreReadOnly) {
+		t.Errorf("Set() error = %v, want %v", err, ErrSecretStoreReadOnly)
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		value       string
+		wantBackend SecretBackendEnum
+		wantKey     string
+		wantOk      bool
+	}{
+		{value: GetReference(SecretBackendEnum_File, "remote/root@host/password"), wantBackend: SecretBackendEnum_File, wantKey: "remote/root@host/password", wantOk: true},
+		{value: "secret://pass/workspace/1/git-password", wantBackend: SecretBackendEnum_Pass, wantKey: "workspace/1/git-password", wantOk: true},
+		{value: "secret://file"},
+		{value: "p@ssw0rd"},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			backend, key, ok := ParseReference(tt.value)
+			if backend != tt.wantBackend || key != tt.wantKey || ok != tt.wantOk {
+				t.Errorf("ParseReference() = %v, %v, %v, want %v, %v, %v", backend, key, ok, tt.wantBackend, tt.wantKey, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestParseSecretBackend(t *testing.T) {
+	if got, err := ParseSecretBackend(""); err != nil || got != SecretBackendEnum_Database {
+		t.Errorf("ParseSecretBackend() = %v, %v", got, err)
+	}
+	if got, err := ParseSecretBackend("Secret-Service"); err != nil || got != SecretBackendEnum_SecretService {
+		t.Errorf("ParseSecretBackend() = %v, %v", got, err)
+	}
+	if _, err := ParseSecretBackend("keychain"); err == nil {
+		t.Errorf("ParseSecretBackend() should return error")
+	}
+}
+
+func TestSecretServiceStore_Get(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("secret-tool is only available on linux")
+	}
+
+	// 通过 PATH 中的脚本模拟 secret-tool
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+case "$SECRET_TOOL_CASE" in
+found) printf 'p@ssw0rd' ;;
+not-found) exit 1 ;;
+dbus) echo "Cannot autolaunch D-Bus without X11 \$DISPLAY" >&2; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(binDir, "secret-tool"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	store := &secretServiceStore{}
+	t.Setenv("SECRET_TOOL_CASE", "found")
+	if value, err := store.Get("remote/root@192.168.1.2:22/password"); err != nil || value != "p@ssw0rd" {
+		t.Errorf("Get() = %v, %v, want p@ssw0rd", value, err)
+	}
+	t.Setenv("SECRET_TOOL_CASE", "not-found")
+	if _, err := store.Get("remote/root@192.168.1.2:22/password"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Get() error = %v, want ErrSecretNotFound", err)
+	}
+	t.Setenv("SECRET_TOOL_CASE", "dbus")
+	if _, err := store.Get("remote/root@192.168.1.2:22/password"); err == nil || errors.Is(err, ErrSecretNotFound) ||
+		!strings.Contains(err.Error(), "D-Bus") {
+		t.Errorf("Get() error = %v, want the stderr of secret-tool", err)
+	}
+}
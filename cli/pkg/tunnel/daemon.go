@@ -0,0 +1,578 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"golang.org/x/crypto/ssh"
+)
+
+// 守护进程的控制命令
+type DaemonActionEnum string
+
+const (
+	DaemonActionEnum_List DaemonActionEnum = "list"
+	DaemonActionEnum_Add  DaemonActionEnum = "add"
+	// 远程端口转发，开发容器中的端口转发到本机
+	DaemonActionEnum_Reverse DaemonActionEnum = "reverse"
+	DaemonActionEnum_Remove  DaemonActionEnum = "remove"
+	DaemonActionEnum_Stop    DaemonActionEnum = "stop"
+)
+
+// 守护进程检查 ssh 连接的间隔
+var DaemonKeepAliveInterval = time.Second * 10
+
+// ssh 连接信息
+type DaemonTarget struct {
+	Host         string
+	Port         int
+	UserName     string
+	Password     string
+	SSHKey       string
+	IdentityFile string
+	JumpHosts    string
+
+	// 开发容器的 ssh 服务在 ssh 主机上的地址，比如 localhost:6822，为空时 ssh 主机就是开发容器
+	ContainerSSHAddr  string
+	ContainerUserName string
+	ContainerPassword string
+}
+
+// 单个端口转发
+type DaemonForward struct {
+	WorkspaceID string `json:"workspaceId"`
+	Label       string `json:"label"`
+	LocalPort   int    `json:"localPort"`
+	// ssh 主机上的地址，比如 localhost:3000；远程端口转发时为开发容器中监听的地址
+	RemoteAddr string `json:"remoteAddr"`
+	// 是否为远程端口转发，开发容器中的端口转发到本机的 LocalPort
+	IsReverse bool `json:"reverse,omitempty"`
+	// ssh 连接是否正常
+	IsConnected bool `json:"isConnected"`
+	// 最近一次的错误
+	LastError string `json:"lastError,omitempty"`
+}
+
+// 发送到控制 socket 的请求
+type DaemonRequest struct {
+	Action      DaemonActionEnum `json:"action"`
+	WorkspaceID string           `json:"workspaceId,omitempty"`
+	// 指定的端口，格式为 本地端口:远程端口，为空时使用工作区的端口映射
+	// 远程端口转发时格式为 容器端口[:本地端口]，为空时使用工作区配置的 reverse-ports
+	Ports []string `json:"ports,omitempty"`
+	// 删除时指定的本地端口，为空时删除工作区的所有端口
+	LocalPorts []int `json:"localPorts,omitempty"`
+	// 删除指定的本地端口时，是否为远程端口转发
+	IsReverse bool `json:"reverse,omitempty"`
+}
+
+// 控制 socket 的返回
+type DaemonResponse struct {
+	Error    string          `json:"error,omitempty"`
+	Forwards []DaemonForward `json:"forwards"`
+}
+
+// 根据工作区id 获取 ssh 连接信息，以及默认需要转发的端口
+type DaemonResolveFunc func(workspaceId string) (DaemonTarget, []DaemonForward, error)
+
+// 端口转发守护进程，通过本地的 socket 接收控制命令
+type Daemon struct {
+	socketPath string
+	resolve    DaemonResolveFunc
+
+	mutex    sync.Mutex
+	groups   map[string]*forwardGroup
+	listener net.Listener
+	done     chan struct{}
+}
+
+// 同一个工作区的端口转发，共用一个 ssh 连接
+type forwardGroup struct {
+	workspaceId string
+	resolve     DaemonResolveFunc
+
+	mutex           sync.Mutex
+	client          *common.ReconnectingSSHClient // 断开后自动重连
+	containerClient *common.ReconnectingSSHClient // 远程端口转发使用的开发容器连接，在第一次使用时创建
+	forwards        map[int]*forwardItem
+	reverses        map[string]*reverseItem // key 为开发容器中监听的地址
+}
+
+type forwardItem struct {
+	forward  DaemonForward
+	listener net.Listener
+}
+
+type reverseItem struct {
+	forward   DaemonForward
+	forwarder *ReverseForwarder
+}
+
+// 控制 socket 的默认路径
+func GetDaemonSocketPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ide", "tunnel.sock")
+}
+
+// 创建守护进程
+func NewDaemon(socketPath string, resolve DaemonResolveFunc) *Daemon {
+	return &Daemon{
+		socketPath: socketPath,
+		resolve:    resolve,
+		groups:     map[string]*forwardGroup{},
+		done:       make(chan struct{}),
+	}
+}
+
+// 监听控制 socket，直到收到 stop 命令
+func (d *Daemon) Run() error {
+	//1. 检查是否已经有守护进程在运行
+	if IsDaemonRunning(d.socketPath) {
+		return fmt.Errorf("tunnel daemon is already running on %v", d.socketPath)
+	}
+	os.Remove(d.socketPath) // 上次异常退出时遗留的文件
+	if err := os.MkdirAll(filepath.Dir(d.socketPath), 0700); err != nil {
+		return err
+	}
+
+	//2. 监听
+	listener, err := net.Listen("unix", d.socketPath)
+	if err != nil {
+		return err
+	}
+	d.listener = listener
+	defer os.Remove(d.socketPath)
+	common.SmartIDELog.InfoF("tunnel daemon listening on %v", d.socketPath)
+
+	//3. 处理请求
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-d.done:
+				return nil
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			common.SmartIDELog.Warning(err.Error())
+			continue
+		}
+		go d.handle(conn)
+	}
+}
+
+// 停止所有的端口转发，并退出
+func (d *Daemon) Stop() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for id, group := range d.groups {
+		group.close()
+		delete(d.groups, id)
+	}
+	select {
+	case <-d.done:
+	default:
+		close(d.done)
+	}
+	if d.listener != nil {
+		d.listener.Close()
+	}
+}
+
+// 处理单个控制连接，一个请求对应一个返回
+func (d *Daemon) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var request DaemonRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&request); err != nil {
+		json.NewEncoder(conn).Encode(DaemonResponse{Error: err.Error()})
+		return
+	}
+
+	response := d.Execute(request)
+	json.NewEncoder(conn).Encode(response)
+
+	if request.Action == DaemonActionEnum_Stop {
+		d.Stop()
+	}
+}
+
+// 执行控制命令
+func (d *Daemon) Execute(request DaemonRequest) (response DaemonResponse) {
+	var err error
+	switch request.Action {
+	case DaemonActionEnum_List, DaemonActionEnum_Stop:
+	case DaemonActionEnum_Add:
+		err = d.add(request.WorkspaceID, request.Ports, false)
+	case DaemonActionEnum_Reverse:
+		err = d.add(request.WorkspaceID, request.Ports, true)
+	case DaemonActionEnum_Remove:
+		err = d.remove(request.WorkspaceID, request.LocalPorts, request.IsReverse)
+	default:
+		err = fmt.Errorf("unknown action %v", request.Action)
+	}
+	if err != nil {
+		response.Error = err.Error()
+	}
+	response.Forwards = d.list()
+	return response
+}
+
+// 增加端口转发，isReverse 为 true 时增加远程端口转发
+func (d *Daemon) add(workspaceId string, ports []string, isReverse bool) error {
+	if workspaceId == "" {
+		return errors.New("workspace id is empty")
+	}
+
+	//1. 获取工作区的端口映射
+	_, resolvedForwards, err := d.resolve(workspaceId)
+	if err != nil {
+		return err
+	}
+	forwards := []DaemonForward{}
+	for _, forward := range resolvedForwards {
+		if forward.IsReverse == isReverse {
+			forwards = append(forwards, forward)
+		}
+	}
+	if len(ports) > 0 { // 指定了端口时，只转发指定的端口
+		forwards = []DaemonForward{}
+		for _, port := range ports {
+			var forward DaemonForward
+			if isReverse {
+				forward, err = ParseReverseForward(port)
+			} else {
+				forward, err = ParseDaemonForward(port)
+			}
+			if err != nil {
+				return err
+			}
+			forward.WorkspaceID = workspaceId
+			forwards = append(forwards, forward)
+		}
+	}
+	if len(forwards) <= 0 {
+		return fmt.Errorf("workspace %v has no ports to forward", workspaceId)
+	}
+
+	//2. 加入到分组中
+	d.mutex.Lock()
+	group, ok := d.groups[workspaceId]
+	if !ok {
+		group = newForwardGroup(workspaceId, d.resolve)
+		d.groups[workspaceId] = group
+	}
+	d.mutex.Unlock()
+
+	err = group.add(forwards)
+	if group.isEmpty() { // 所有端口都监听失败
+		d.mutex.Lock()
+		if d.groups[workspaceId] == group {
+			group.close()
+			delete(d.groups, workspaceId)
+		}
+		d.mutex.Unlock()
+	}
+	return err
+}
+
+// 删除端口转发，isReverse 为 true 时删除远程端口转发
+func (d *Daemon) remove(workspaceId string, localPorts []int, isReverse bool) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	group, ok := d.groups[workspaceId]
+	if !ok {
+		return fmt.Errorf("workspace %v has no forwards", workspaceId)
+	}
+	if len(localPorts) <= 0 {
+		group.close()
+		delete(d.groups, workspaceId)
+		return nil
+	}
+
+	err := group.remove(localPorts, isReverse)
+	if group.isEmpty() {
+		group.close()
+		delete(d.groups, workspaceId)
+	}
+	return err
+}
+
+// 当前所有的端口转发，按照工作区、本地端口排序
+func (d *Daemon) list() []DaemonForward {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	result := []DaemonForward{}
+	for _, group := range d.groups {
+		result = append(result, group.list()...)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].WorkspaceID != result[j].WorkspaceID {
+			return result[i].WorkspaceID < result[j].WorkspaceID
+		}
+		if result[i].IsReverse != result[j].IsReverse {
+			return !result[i].IsReverse
+		}
+		return result[i].LocalPort < result[j].LocalPort
+	})
+	return result
+}
+
+func newForwardGroup(workspaceId string, resolve DaemonResolveFunc) *forwardGroup {
+	group := &forwardGroup{
+		workspaceId: workspaceId,
+		resolve:     resolve,
+		forwards:    map[int]*forwardItem{},
+		reverses:    map[string]*reverseItem{},
+	}
+	group.client = common.NewReconnectingSSHClient(nil, group.connect, DaemonKeepAliveInterval, true)
+	return group
+}
+
+// 监听本地端口，连接在有请求时才建立
+// 任意一个端口失败时，撤销本次已经增加的端口转发，之前已经存在的端口转发不受影响
+func (g *forwardGroup) add(forwards []DaemonForward) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	//1. 远程端口转发
+	addedReverses := []string{}
+	rollbackReverses := func() {
+		for _, remoteAddr := range addedReverses {
+			g.reverses[remoteAddr].forwarder.Close()
+			delete(g.reverses, remoteAddr)
+		}
+	}
+	mapping := map[string]string{}
+	for _, forward := range forwards {
+		if forward.IsReverse {
+			isAdded, err := g.addReverse(forward)
+			if err != nil {
+				rollbackReverses()
+				return err
+			}
+			if isAdded {
+				addedReverses = append(addedReverses, forward.RemoteAddr)
+			}
+			continue
+		}
+		if _, ok := g.forwards[forward.LocalPort]; ok {
+			continue
+		}
+		mapping[fmt.Sprintf("localhost:%v", forward.LocalPort)] = forward.RemoteAddr
+	}
+
+	//2. 本地端口转发
+	listeners, err := ForwardMultiple(g.client.Dial, mapping)
+	if err != nil {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		rollbackReverses()
+		return err
+	}
+	for _, forward := range forwards {
+		if listener, ok := listeners[fmt.Sprintf("localhost:%v", forward.LocalPort)]; ok && !forward.IsReverse {
+			g.forwards[forward.LocalPort] = &forwardItem{forward: forward, listener: listener}
+		}
+	}
+	return nil
+}
+
+// 在开发容器中监听端口，监听失败时在后台重试，通过 list 查看错误
+// 已经存在时 isAdded 为 false
+func (g *forwardGroup) addReverse(forward DaemonForward) (isAdded bool, err error) {
+	if _, ok := g.reverses[forward.RemoteAddr]; ok {
+		return false, nil
+	}
+	if g.containerClient == nil {
+		target, _, err := g.resolve(g.workspaceId)
+		if err != nil {
+			return false, err
+		}
+		if target.ContainerSSHAddr == "" { // ssh 主机就是开发容器
+			g.containerClient = g.client
+		} else {
+			g.containerClient = common.NewReconnectingSSHClient(nil, g.connectContainer, DaemonKeepAliveInterval, true)
+		}
+	}
+	forwarder := NewReverseForwarder(g.containerClient, forward.RemoteAddr, fmt.Sprintf("localhost:%v", forward.LocalPort))
+	g.reverses[forward.RemoteAddr] = &reverseItem{forward: forward, forwarder: forwarder}
+	return true, nil
+}
+
+// 同一个本地端口可能同时用于本地端口转发和远程端口转发，只删除指定方向的
+func (g *forwardGroup) remove(localPorts []int, isReverse bool) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for _, localPort := range localPorts {
+		isFound := false
+		if isReverse {
+			for remoteAddr, item := range g.reverses {
+				if item.forward.LocalPort == localPort {
+					item.forwarder.Close()
+					delete(g.reverses, remoteAddr)
+					isFound = true
+				}
+			}
+		} else if item, ok := g.forwards[localPort]; ok {
+			item.listener.Close()
+			delete(g.forwards, localPort)
+			isFound = true
+		}
+		if !isFound {
+			return fmt.Errorf("local port %v is not forwarded for workspace %v", localPort, g.workspaceId)
+		}
+	}
+	return nil
+}
+
+func (g *forwardGroup) isEmpty() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return len(g.forwards) <= 0 && len(g.reverses) <= 0
+}
+
+func (g *forwardGroup) list() []DaemonForward {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	result := []DaemonForward{}
+	for _, item := range g.forwards {
+		forward := item.forward
+		forward.IsConnected = g.client.IsConnected()
+		forward.LastError = g.client.LastError()
+		result = append(result, forward)
+	}
+	for _, item := range g.reverses {
+		forward := item.forward
+		forward.IsConnected = item.forwarder.IsListening()
+		forward.LastError = item.forwarder.LastError()
+		result = append(result, forward)
+	}
+	return result
+}
+
+// 关闭所有的监听以及 ssh 连接
+func (g *forwardGroup) close() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	for localPort, item := range g.forwards {
+		item.listener.Close()
+		delete(g.forwards, localPort)
+	}
+	for remoteAddr, item := range g.reverses {
+		item.forwarder.Close()
+		delete(g.reverses, remoteAddr)
+	}
+	if g.containerClient != nil && g.containerClient != g.client {
+		g.containerClient.Close()
+	}
+	g.client.Close()
+}
+
+// 建立 ssh 连接，每次都重新获取连接信息，容器重启后端口、密码可能已经改变
+func (g *forwardGroup) connect() (*ssh.Client, error) {
+	target, _, err := g.resolve(g.workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	return common.DialSSH(target.Host, target.Port, target.UserName, target.Password, target.SSHKey, target.IdentityFile, target.JumpHosts)
+}
+
+// 通过 ssh 主机连接到开发容器的 ssh 服务
+func (g *forwardGroup) connectContainer() (*ssh.Client, error) {
+	target, _, err := g.resolve(g.workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	return common.DialSSHThrough(g.client.Dial, target.ContainerSSHAddr, target.ContainerUserName, target.ContainerPassword)
+}
+
+// 解析 本地端口:远程端口 或者 本地端口:远程主机:远程端口
+func ParseDaemonForward(value string) (forward DaemonForward, err error) {
+	invalidErr := fmt.Errorf("invalid port %v, should be <local port>:[<remote host>:]<remote port>", value)
+
+	items := strings.Split(value, ":")
+	remoteHost := "localhost"
+	if len(items) == 3 {
+		remoteHost = items[1]
+	} else if len(items) != 2 {
+		return forward, invalidErr
+	}
+	localPort, err1 := strconv.Atoi(items[0])
+	remotePort, err2 := strconv.Atoi(items[len(items)-1])
+	if err1 != nil || err2 != nil || remoteHost == "" ||
+		localPort <= 0 || localPort > 65535 || remotePort <= 0 || remotePort > 65535 {
+		return forward, invalidErr
+	}
+
+	forward.LocalPort = localPort
+	forward.RemoteAddr = net.JoinHostPort(remoteHost, strconv.Itoa(remotePort))
+	return forward, nil
+}
+
+// 守护进程是否在运行
+func IsDaemonRunning(socketPath string) bool {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// 发送控制命令到守护进程
+func SendDaemonRequest(socketPath string, request DaemonRequest) (response DaemonResponse, err error) {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second*3)
+	if err != nil {
+		return response, err
+	}
+	defer conn.Close()
+
+	if err = json.NewEncoder(conn).Encode(request); err != nil {
+		return response, err
+	}
+	if err = json.NewDecoder(conn).Decode(&response); err != nil {
+		return response, err
+	}
+	if response.Error != "" {
+		return response, errors.New(response.Error)
+	}
+	return response, nil
+}
@@ -0,0 +1,243 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseDaemonForward(t *testing.T) {
+	tests := []struct {
+		value          string
+		wantLocalPort  int
+		wantRemoteAddr string
+		wantErr        bool
+	}{
+		{value: "8080:3000", wantLocalPort: 8080, wantRemoteAddr: "localhost:3000"},
+		{value: "8080:db:5432", wantLocalPort: 8080, wantRemoteAddr: "db:5432"},
+		{value: "8080", wantErr: true},
+		{value: "8080:abc", wantErr: true},
+		{value: "0:3000", wantErr: true},
+		{value: "8080::3000", wantErr: true},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got, err := ParseDaemonForward(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDaemonForward() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.LocalPort != tt.wantLocalPort || got.RemoteAddr != tt.wantRemoteAddr {
+				t.Errorf("ParseDaemonForward() = %v, %v, want %v, %v", got.LocalPort, got.RemoteAddr, tt.wantLocalPort, tt.wantRemoteAddr)
+			}
+		})
+	}
+}
+
+func TestForwardMultiple(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+
+	// 远程的 echo 服务
+	remote, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+	go func() {
+		for {
+			conn, err := remote.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+
+	localPort, err := common.GetAvailablePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := fmt.Sprintf("localhost:%v", localPort)
+	listeners, err := ForwardMultiple(net.Dial, map[string]string{local: remote.Addr().String()})
+	if err != nil || len(listeners) != 1 {
+		t.Fatalf("ForwardMultiple() = %v, %v", listeners, err)
+	}
+
+	conn, err := net.Dial("tcp", local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("smartide"))
+	buf := make([]byte, 8)
+	conn.SetReadDeadline(time.Now().Add(time.Second * 3))
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "smartide" {
+		t.Errorf("forwarded read = %v, %v", string(buf), err)
+	}
+	conn.Close()
+
+	// 关闭后端口释放
+	listeners[local].Close()
+	if listener, err := net.Listen("tcp", local); err != nil {
+		t.Errorf("port %v should be released, error = %v", localPort, err)
+	} else {
+		listener.Close()
+	}
+}
+
+func TestDaemon_Control(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+
+	dir, err := os.MkdirTemp("", "tunnel") // unix socket 的路径不能太长
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "tunnel.sock")
+
+	resolve := func(workspaceId string) (DaemonTarget, []DaemonForward, error) {
+		return DaemonTarget{}, nil, errors.New("workspace not found")
+	}
+	daemon := NewDaemon(socketPath, resolve)
+	result := make(chan error, 1)
+	go func() { result <- daemon.Run() }()
+	for i := 0; i < 50 && !IsDaemonRunning(socketPath); i++ {
+		time.Sleep(time.Millisecond * 20)
+	}
+
+	response, err := SendDaemonRequest(socketPath, DaemonRequest{Action: DaemonActionEnum_List})
+	if err != nil || len(response.Forwards) != 0 {
+		t.Errorf("list = %v, %v", response, err)
+	}
+	if _, err := SendDaemonRequest(socketPath, DaemonRequest{Action: DaemonActionEnum_Add, WorkspaceID: "1"}); err == nil {
+		t.Errorf("add should return the resolve error")
+	}
+	if _, err := SendDaemonRequest(socketPath, DaemonRequest{Action: DaemonActionEnum_Remove, WorkspaceID: "1"}); err == nil {
+		t.Errorf("remove not exist workspace should return error")
+	}
+	if _, err := SendDaemonRequest(socketPath, DaemonRequest{Action: DaemonActionEnum_Stop}); err != nil {
+		t.Errorf("stop error = %v", err)
+	}
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatalf("daemon not stopped")
+	}
+	if IsDaemonRunning(socketPath) {
+		t.Errorf("daemon should not be running after stop")
+	}
+}
+
+func TestForwardGroup_AddRollback(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+
+	resolve := func(workspaceId string) (DaemonTarget, []DaemonForward, error) {
+		return DaemonTarget{}, nil, errors.New("workspace not found")
+	}
+	group := newForwardGroup("1", resolve)
+	defer group.close()
+
+	// 被占用的端口
+	busy, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	busyPort := busy.Addr().(*net.TCPAddr).Port
+	free, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freePort := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	err = group.add([]DaemonForward{
+		{WorkspaceID: "1", LocalPort: freePort, RemoteAddr: "localhost:3000"},
+		{WorkspaceID: "1", LocalPort: busyPort, RemoteAddr: "localhost:3001"},
+	})
+	if err == nil {
+		t.Fatalf("add() should return error when port %v is in use", busyPort)
+	}
+	if !group.isEmpty() {
+		t.Errorf("add() should roll back forwards, got %v", group.list())
+	}
+	if listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%v", freePort)); err != nil {
+		t.Errorf("port %v should be released, error = %v", freePort, err)
+	} else {
+		listener.Close()
+	}
+
+	// 远程端口转发失败时，同样撤销
+	err = group.add([]DaemonForward{
+		{WorkspaceID: "1", LocalPort: freePort, RemoteAddr: "localhost:3000"},
+		{WorkspaceID: "1", LocalPort: 5432, RemoteAddr: "localhost:5432", IsReverse: true},
+	})
+	if err == nil || !group.isEmpty() {
+		t.Errorf("add() = %v, forwards = %v", err, group.list())
+	}
+}
+
+func TestForwardGroup_RemoveDirection(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+
+	resolve := func(workspaceId string) (DaemonTarget, []DaemonForward, error) {
+		return DaemonTarget{}, nil, errors.New("workspace not found")
+	}
+	group := newForwardGroup("1", resolve)
+	defer group.close()
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	localPort := listener.Addr().(*net.TCPAddr).Port
+	group.forwards[localPort] = &forwardItem{forward: DaemonForward{LocalPort: localPort, RemoteAddr: "localhost:3000"}, listener: listener}
+	containerClient := common.NewReconnectingSSHClient(nil, func() (*ssh.Client, error) { return nil, errors.New("not connected") }, 0, false)
+	defer containerClient.Close()
+	reverse := DaemonForward{LocalPort: localPort, RemoteAddr: "localhost:5432", IsReverse: true}
+	group.reverses[reverse.RemoteAddr] = &reverseItem{forward: reverse, forwarder: NewReverseForwarder(containerClient, reverse.RemoteAddr, listener.Addr().String())}
+
+	// 只删除远程端口转发
+	if err := group.remove([]int{localPort}, true); err != nil {
+		t.Fatalf("remove() error = %v", err)
+	}
+	if len(group.reverses) != 0 || len(group.forwards) != 1 {
+		t.Errorf("remove() reverse, forwards = %v", group.list())
+	}
+	if err := group.remove([]int{localPort}, true); err == nil {
+		t.Errorf("remove() not exist reverse forward should return error")
+	}
+
+	// 删除本地端口转发
+	if err := group.remove([]int{localPort}, false); err != nil || !group.isEmpty() {
+		t.Errorf("remove() = %v, forwards = %v", err, group.list())
+	}
+}
@@ -0,0 +1,178 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"encoding/hex"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/leansoftX/smartide-cli/internal/model"
+	"github.com/leansoftX/smartide-cli/pkg/common"
+)
+
+// 正在监听的端口
+type ListenPort struct {
+	Host   string
+	Port   int
+	IsIPv6 bool
+}
+
+// 是否只监听了本机回环地址，这类端口无法从容器外访问，也不需要转发
+func (p ListenPort) IsLoopback() bool {
+	ip := net.ParseIP(p.Host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// /proc/net/tcp 中 LISTEN 状态的值
+const procNetTcpStateListen = "0A"
+
+// 读取 /proc/net/tcp 以及 /proc/net/tcp6，tcp6 不存在时（禁用了 ipv6）只输出 tcp
+const procNetTcpCommand = `cat /proc/net/tcp /proc/net/tcp6 2>/dev/null`
+
+// /proc 不可用时使用 ss
+const ssCommand = `ss -tln`
+
+// 通过ssh通道获取正在监听的 tcp 端口，优先读取 /proc/net，失败时使用 ss
+func discoverListenPorts(clientConn SSHConn) (ports []ListenPort, output string, err error) {
+	output, err = runSSHCommand(clientConn, procNetTcpCommand)
+	if strings.Contains(output, "local_address") {
+		return parseProcNetTcp(output), output, nil
+	}
+	common.SmartIDELog.Debug("read /proc/net/tcp failed, fallback to ss")
+
+	output, err = runSSHCommand(clientConn, ssCommand)
+	if strings.Contains(output, "Local Address") {
+		return parseSSOutput(output), output, nil
+	}
+	if err == nil {
+		err = errors.New("unable to discover listening ports: " + strings.TrimSpace(output))
+	}
+	return nil, output, err
+}
+
+// 在ssh主机上执行命令，只返回标准输出
+func runSSHCommand(clientConn SSHConn, cmd string) (string, error) {
+	session, err := clientConn.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	out, err := session.Output(cmd)
+	return string(out), err
+}
+
+// 解析 /proc/net/tcp 以及 /proc/net/tcp6 的内容，只返回 LISTEN 状态的端口
+/*
+  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 17065 1 0000000000000000 100 0 0 10 0
+   0: 00000000000000000000000000000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 18345 1 0000000000000000 100 0 0 10 0
+*/
+func parseProcNetTcp(content string) (result []ListenPort) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[3] != procNetTcpStateListen {
+			continue
+		}
+		items := strings.Split(fields[1], ":")
+		if len(items) != 2 {
+			continue
+		}
+		ip, err := parseProcNetIP(items[0])
+		if err != nil {
+			common.SmartIDELog.Debug(err.Error())
+			continue
+		}
+		port, err := strconv.ParseUint(items[1], 16, 16)
+		if err != nil {
+			continue
+		}
+		result = append(result, ListenPort{Host: ip.String(), Port: int(port), IsIPv6: len(ip) == net.IPv6len})
+	}
+	return result
+}
+
+// /proc/net 中的地址是按照 32 位分组的主机字节序（小端）
+func parseProcNetIP(value string) (net.IP, error) {
+	bytes, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) != net.IPv4len && len(bytes) != net.IPv6len {
+		return nil, errors.New("invalid address " + value)
+	}
+	ip := make(net.IP, len(bytes))
+	for i := 0; i < len(bytes); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = bytes[i+3], bytes[i+2], bytes[i+1], bytes[i]
+	}
+	return ip, nil
+}
+
+// 解析 ss -tln 的输出，按空白分列，不依赖列宽
+/*
+State    Recv-Q   Send-Q     Local Address:Port     Peer Address:Port   Process
+LISTEN   0        128              0.0.0.0:22            0.0.0.0:*
+LISTEN   0        511                 [::]:3000             [::]:*
+*/
+func parseSSOutput(output string) (result []ListenPort) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "LISTEN" {
+			continue
+		}
+		localAddress := fields[3]
+		lastColonIndex := strings.LastIndex(localAddress, ":")
+		if lastColonIndex < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(localAddress[lastColonIndex+1:])
+		if err != nil {
+			continue
+		}
+		host := strings.Trim(localAddress[:lastColonIndex], "[]")
+		if index := strings.Index(host, "%"); index >= 0 { // 127.0.0.53%lo
+			host = host[:index]
+		}
+		isIPv6 := strings.HasPrefix(localAddress, "[")
+		if host == "*" {
+			host = "0.0.0.0"
+		}
+		result = append(result, ListenPort{Host: host, Port: port, IsIPv6: isIPv6})
+	}
+	return result
+}
+
+// 需要自动转发的端口，排除 IDE、SSH 以及只监听本机回环地址的端口
+func filterForwardPorts(listenPorts []ListenPort) (ports []int) {
+	for _, listenPort := range listenPorts {
+		if listenPort.Port == model.CONST_Container_WebIDEPort ||
+			listenPort.Port == model.CONST_Container_JetBrainsIDEPort ||
+			listenPort.Port == model.CONST_Container_OpensumiIDEPort ||
+			listenPort.Port == model.CONST_Container_SSHPort ||
+			listenPort.IsLoopback() ||
+			common.Contains4Int(ports, listenPort.Port) {
+			continue
+		}
+		ports = append(ports, listenPort.Port)
+	}
+	return ports
+}
@@ -0,0 +1,78 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProcNetTcp(t *testing.T) {
+	content := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 17065 1 0000000000000000 100 0 0 10 0
+   1: 0B00007F:9475 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 16522 1 0000000000000000 100 0 0 10 0
+   2: 020011AC:0016 010011AC:D3E4 01 00000000:00000000 02:0009A1B8 00000000     0        0 18001 4 0000000000000000 20 4 29 10 -1
+  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 18345 1 0000000000000000 100 0 0 10 0
+   1: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 18346 1 0000000000000000 100 0 0 10 0
+`
+	want := []ListenPort{
+		{Host: "0.0.0.0", Port: 22},
+		{Host: "127.0.0.11", Port: 38005},
+		{Host: "::", Port: 3000, IsIPv6: true},
+		{Host: "::1", Port: 8080, IsIPv6: true},
+	}
+	if got := parseProcNetTcp(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseProcNetTcp() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSSOutput(t *testing.T) {
+	output := `State    Recv-Q   Send-Q     Local Address:Port     Peer Address:Port   Process
+LISTEN   0        128              0.0.0.0:22            0.0.0.0:*
+LISTEN   0        4096       127.0.0.53%lo:53            0.0.0.0:*
+LISTEN   0        511                 [::]:3000             [::]:*
+LISTEN   0        511                    *:5000                *:*
+`
+	want := []ListenPort{
+		{Host: "0.0.0.0", Port: 22},
+		{Host: "127.0.0.53", Port: 53},
+		{Host: "::", Port: 3000, IsIPv6: true},
+		{Host: "0.0.0.0", Port: 5000},
+	}
+	if got := parseSSOutput(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseSSOutput() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterForwardPorts(t *testing.T) {
+	listenPorts := []ListenPort{
+		{Host: "0.0.0.0", Port: 22},
+		{Host: "127.0.0.11", Port: 38005},
+		{Host: "0.0.0.0", Port: 3000},
+		{Host: "0.0.0.0", Port: 4200},
+		{Host: "::", Port: 4200, IsIPv6: true},
+		{Host: "::1", Port: 8080, IsIPv6: true},
+		{Host: "::", Port: 5000, IsIPv6: true},
+	}
+	want := []int{4200, 5000}
+	if got := filterForwardPorts(listenPorts); !reflect.DeepEqual(got, want) {
+		t.Errorf("filterForwardPorts() = %v, want %v", got, want)
+	}
+}
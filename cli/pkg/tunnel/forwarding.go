@@ -19,6 +19,7 @@ along with this program.  If not, see <http://www.gnu.org/licenses/>.
 package tunnel
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -27,7 +28,6 @@ import (
 	"time"
 
 	"github.com/leansoftX/smartide-cli/internal/apk/i18n"
-	"github.com/leansoftX/smartide-cli/internal/model"
 	"github.com/leansoftX/smartide-cli/pkg/common"
 	"golang.org/x/crypto/ssh"
 )
@@ -66,22 +66,50 @@ const (
 	PortMapInfo_OnlyCompose PortMapTypeEnum = "compose"
 )
 
+// 自动转发的端口
+type tunneledPort struct {
+	localPort int
+	// 动态转发时的本地监听，compose 中已经绑定的端口为空
+	listener net.Listener
+}
+
+// ssh 连接，*ssh.Client 以及自动重连的 *common.ReconnectingSSHClient 都实现了该接口
+type SSHConn interface {
+	NewSession() (*ssh.Session, error)
+	Dial(network, addr string) (net.Conn, error)
+}
+
 // 自动端口转发
-func AutoTunnel(clientConn *ssh.Client, options AutoTunnelMultipleOptions) {
+func AutoTunnel(clientConn SSHConn, options AutoTunnelMultipleOptions) {
 
-	var tunneledContainerPorts []int // 已打通隧道的端口列表
+	tunneledContainerPorts := map[int]*tunneledPort{} // 已打通隧道的端口列表
 
 	go func() {
 		for {
-			//TOOD: 完善端口被删除的情况，又开放的情况
 			// 获取开放的端口
-			scanContainerPorts := scanServerPorts(clientConn)       // 扫描出来的端口
-			var mapping map[string]string = make(map[string]string) //
+			scanContainerPorts, err := scanServerPorts(clientConn) // 扫描出来的端口
+			if err != nil {
+				common.SmartIDELog.Debug(err.Error())
+				time.Sleep(time.Second * 3)
+				continue
+			}
+
+			// 端口已经停止监听，释放本地端口
+			for containerPort, tunneled := range tunneledContainerPorts {
+				if common.Contains4Int(scanContainerPorts, containerPort) {
+					continue
+				}
+				if tunneled.listener != nil {
+					tunneled.listener.Close()
+					common.SmartIDELog.InfoF(i18n.GetInstance().Common.Info_port_unbinding, containerPort, tunneled.localPort)
+				}
+				delete(tunneledContainerPorts, containerPort)
+			}
 
 			// 发现新的端口
 			for _, scanContainerPort := range scanContainerPorts {
 				// 是否已经映射
-				if common.Contains4Int(tunneledContainerPorts, scanContainerPort) {
+				if _, ok := tunneledContainerPorts[scanContainerPort]; ok {
 					continue
 				}
 
@@ -104,11 +132,12 @@ func AutoTunnel(clientConn *ssh.Client, options AutoTunnelMultipleOptions) {
 							}
 						}
 
+						tunneledContainerPorts[scanContainerPort] = &tunneledPort{localPort: item.CurrentLocalPort}
 						break
 					}
 				}
 
-				// 如果没有在compose文件中定义，那么就是要映射端口
+				// 如果没有在compose文件中定义，那么就是要映射端口（只转发新增的端口）
 				if !hasMappingWithCompose {
 					dynamicContainerPort := scanContainerPort // 动态的端口
 					localPort, err := common.CheckAndGetAvailableLocalPort(scanContainerPort, 100)
@@ -116,27 +145,19 @@ func AutoTunnel(clientConn *ssh.Client, options AutoTunnelMultipleOptions) {
 						common.SmartIDELog.Warning(err.Error())
 					}
 
-					if localPort != dynamicContainerPort {
+					local := "localhost:" + strconv.Itoa(localPort)
+					listeners, err := ForwardMultiple(clientConn.Dial, map[string]string{local: "localhost:" + strconv.Itoa(dynamicContainerPort)})
+					if err != nil {
+						common.SmartIDELog.Warning(err.Error())
+					} else if localPort != dynamicContainerPort {
 						common.SmartIDELog.InfoF(i18n.GetInstance().Common.Info_port_binding_result2, localPortStr, localPort, dynamicContainerPort)
 					} else {
 						common.SmartIDELog.InfoF(i18n.GetInstance().Common.Info_port_binding_result, localPortStr, dynamicContainerPort)
 					}
 
-					mapping["localhost:"+strconv.Itoa(localPort)] = "localhost:" + strconv.Itoa(dynamicContainerPort)
-				}
-
-				// 记录到已经映射
-				tunneledContainerPorts = append(tunneledContainerPorts, scanContainerPort)
-			}
-
-			// 端口转发（只转发新增的端口）
-			if len(mapping) > 0 {
-
-				err := TunnelMultiple(clientConn, mapping) // 执行绑定
-				if err != nil {
-					common.SmartIDELog.Fatal(err)
+					// 记录到已经映射，监听失败时也记录，避免重复提示
+					tunneledContainerPorts[scanContainerPort] = &tunneledPort{localPort: localPort, listener: listeners[local]}
 				}
-
 			}
 
 			// 避免太多频繁
@@ -147,171 +168,59 @@ func AutoTunnel(clientConn *ssh.Client, options AutoTunnelMultipleOptions) {
 }
 
 // 通过ssh通道去扫描容器内都除了22端口，都开放了哪些
-func scanServerPorts(clientConn *ssh.Client) []int {
-	session, err := clientConn.NewSession()
+func scanServerPorts(clientConn SSHConn) ([]int, error) {
+	listenPorts, output, err := discoverListenPorts(clientConn)
 	if err != nil {
-		clientConn.Close()
+		return nil, err
 	}
 
-	// 在ssh主机上执行命令
-	/* 	tmpSesssion, _ := clientConn.NewSession()
-	   	tmpSesssion.Run(`apt update
-	   					apt install net-tools`) */
-	// https://www.tecmint.com/install-netstat-in-linux/
-	cmd := `netstat -tunlp`
-	out, err := session.CombinedOutput(cmd)
-	outContent := string(out)
-	common.CheckError(err)
-
-	// 分析命令行打印的结果，提取端口
-	var ports []int
-	//common.SmartIDELog.Debug(outContent)
-	netstatInfoArray := parseCmdOutput(outContent)
-	for _, netstatInfo := range netstatInfoArray {
-		if netstatInfo.Port != model.CONST_Container_WebIDEPort &&
-			netstatInfo.Port != model.CONST_Container_JetBrainsIDEPort &&
-			netstatInfo.Port != model.CONST_Container_OpensumiIDEPort &&
-			netstatInfo.Host != "127.0.0.1" &&
-			netstatInfo.Port != model.CONST_Container_SSHPort &&
-			netstatInfo.PID != "" &&
-			!common.Contains4Int(ports, netstatInfo.Port) {
-
-			// 防止重复的debug
-			if statOutput != outContent {
-				statOutput = outContent
-				common.SmartIDELog.Debug(outContent)
-			}
-
-			ports = append(ports, netstatInfo.Port)
-		}
+	// 防止重复的debug
+	if statOutput != output {
+		statOutput = output
+		common.SmartIDELog.Debug(output)
 	}
 
-	return ports
+	return filterForwardPorts(listenPorts), nil
 }
 
 var statOutput string = ""
 
-// 解析netstat的运行结果
-func parseCmdOutput(cmdOutput string) (result []NetstatInfo) {
-	startInex := strings.Index(cmdOutput, "Active Internet connections")
-	if startInex < 0 {
-		return nil
-	}
-	strArray := strings.Split(cmdOutput[startInex+1:], "\n")
-
-	header := strArray[1]
-	indexs := []int{
-		strings.Index(header, "Proto"),
-		strings.Index(header, "Recv-Q"),
-		strings.Index(header, "Send-Q"),
-		strings.Index(header, "Local Address"),
-		strings.Index(header, "Foreign Address"),
-		strings.Index(header, "State"),
-		strings.Index(header, "PID/Program name"),
-	}
-
-	for _, index := range indexs {
-		if index < 0 {
-			common.SmartIDELog.Error(cmdOutput)
-		}
-	}
-
-	for _, str := range strArray[2:] {
-
-		if strings.Contains(str, "Not all processes could be identified, non-owned process info") ||
-			strings.Contains(str, "you would have to be root to see it all.") ||
-			strings.Contains(str, "No info could be read for \"-p\": geteuid()=1000 but you should be root.") {
-			continue
-		}
-
-		if len(strings.ReplaceAll(str, " ", "")) == 0 {
-			continue
-		}
-		if indexs[6] > len(str) { // 当最后1列的数据位置 比 当前数据还小 时
-			//common.SmartIDELog.Debug(cmdOutput)
-			common.SmartIDELog.Importance(str)
-		}
-		tmp := strings.ReplaceAll(str[indexs[6]:], " ", "")
-		pid, programName := "", ""
-		if tmp != "-" {
-			tmpArray := strings.Split(tmp, "/")
-			pid = tmpArray[0]
-			programName = tmpArray[1]
-		}
-		localAddress := strings.ReplaceAll(str[indexs[3]:indexs[4]], " ", "")
-		lastColonIndex := strings.LastIndex(localAddress, ":")
-		host := localAddress[0:lastColonIndex]
-		port, _ := strconv.Atoi(localAddress[lastColonIndex+1:]) // 从最后一个“:”开始截取
-		info := NetstatInfo{
-			Proto:          strings.ReplaceAll(str[0:indexs[1]], " ", ""),
-			RecvQ:          strings.ReplaceAll(str[indexs[1]:indexs[2]], " ", ""),
-			SendQ:          strings.ReplaceAll(str[indexs[2]:indexs[3]], " ", ""),
-			LocalAddress:   localAddress,
-			Host:           host,
-			Port:           port,
-			ForeignAddress: strings.ReplaceAll(str[indexs[4]:indexs[5]], " ", ""),
-			State:          strings.ReplaceAll(str[indexs[5]:indexs[6]], " ", ""),
-			PID:            pid,
-			ProgramName:    programName,
-		}
-		result = append(result, info)
+// 转发指定SSH服务器的多个端口 到本地（Localhost）
+func TunnelMultiple(clientConn SSHConn, mapping map[string]string) error {
+	_, err := ForwardMultiple(clientConn.Dial, mapping)
+	if err != nil {
+		common.SmartIDELog.Warning(err.Error())
 	}
-	return result
-}
-
-//
-/*
-Proto Recv-Q Send-Q Local Address           Foreign Address         State       PID/Program name
-tcp        0      0 127.0.0.11:38053        0.0.0.0:*               LISTEN      -
-tcp        0      0 0.0.0.0:22              0.0.0.0:*               LISTEN      13/sshd
-tcp        0      0 0.0.0.0:3000            0.0.0.0:*               LISTEN      14/node
-tcp        0      0 :::22                   :::*                    LISTEN      13/sshd
-udp        0      0 127.0.0.11:56284        0.0.0.0:*                           -
-*/
-type NetstatInfo struct {
-	Host           string
-	Port           int
-	Proto          string
-	RecvQ          string
-	SendQ          string
-	LocalAddress   string
-	ForeignAddress string
-	State          string
-	PID            string
-	ProgramName    string
+	return nil
 }
 
-// 转发指定SSH服务器的多个端口 到本地（Localhost）
-func TunnelMultiple(clientConn *ssh.Client, mapping map[string]string) error {
+// 建立远程连接的方法，比如 ssh.Client.Dial
+type DialFunc func(network, addr string) (net.Conn, error)
 
+// 监听本地的多个端口，通过 dial 转发到远程，返回已经监听成功的端口，关闭后停止转发
+func ForwardMultiple(dial DialFunc, mapping map[string]string) (listeners map[string]net.Listener, err error) {
+	listeners = map[string]net.Listener{}
 	if len(mapping) <= 0 {
-		return nil
-	}
-
-	pipe := func(writer, reader net.Conn) {
-		defer writer.Close()
-		defer reader.Close()
-
-		_, err := io.Copy(writer, reader)
-		if err != nil {
-			common.SmartIDELog.Warning(err.Error())
-		}
+		return listeners, nil
 	}
 
-	//TODO: 这里可能会有问题
+	errMsgs := []string{}
 	for local, remote := range mapping {
+		listener, listenErr := net.Listen("tcp", local)
+		if listenErr != nil {
+			errMsgs = append(errMsgs, fmt.Sprintf("failed to listen on %v: %v", local, listenErr.Error()))
+			continue
+		}
+		listeners[local] = listener
 
-		go func(local, remote string) {
-
-			listener, err := net.Listen("tcp", local)
-			if err != nil {
-				common.SmartIDELog.Warning("failed to dial to remote: ", err.Error())
-			}
-
+		go func(listener net.Listener, local, remote string) {
 			for {
 				here, err := listener.Accept()
 				if err != nil {
-					common.SmartIDELog.Warning("failed to dial to remote: ", err.Error())
+					if errors.Is(err, net.ErrClosed) { // 监听已经关闭
+						return
+					}
+					common.SmartIDELog.Warning("failed to accept on local: ", err.Error())
 					time.Sleep(time.Second * 1)
 					continue
 				}
@@ -321,23 +230,34 @@ func TunnelMultiple(clientConn *ssh.Client, mapping map[string]string) error {
 					continue
 				}
 				go func(here net.Conn) {
-					there, err := clientConn.Dial("tcp", remote)
-					if there == nil {
+					there, err := dial("tcp", remote)
+					if err != nil || there == nil {
 						common.SmartIDELog.Importance("ssh 连接失败，请确保远程主机上相应端口已打开 " + remote)
-						time.Sleep(time.Second * 1)
+						here.Close()
 						return
 					}
-					if err != nil {
-						common.SmartIDELog.Warning(err.Error())
-					}
-					go pipe(there, here)
-					go pipe(here, there)
+					go pipeConn(there, here)
+					go pipeConn(here, there)
 				}(here)
 			}
 
-		}(local, remote)
+		}(listener, local, remote)
 
 	}
 
-	return nil
+	if len(errMsgs) > 0 {
+		err = errors.New(strings.Join(errMsgs, "; "))
+	}
+	return listeners, err
+}
+
+// 在两个连接之间复制数据，任意一端关闭时关闭两端
+func pipeConn(writer, reader net.Conn) {
+	defer writer.Close()
+	defer reader.Close()
+
+	_, err := io.Copy(writer, reader)
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		common.SmartIDELog.Debug(err.Error())
+	}
 }
@@ -0,0 +1,203 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"golang.org/x/crypto/ssh"
+)
+
+// 远程端口转发失败后重试的间隔
+var ReverseRetryInterval = time.Second * 3
+
+// 远程端口转发（ssh -R），在开发容器中监听端口，连接转发到本机的端口
+// ssh 连接断开或者监听失败时自动重试，直到关闭
+type ReverseForwarder struct {
+	client     *common.ReconnectingSSHClient
+	remoteAddr string // 开发容器中监听的地址，比如 localhost:5432
+	localAddr  string // 本机的地址，比如 localhost:5432
+
+	mutex     sync.Mutex
+	listener  net.Listener
+	lastError string
+	done      chan struct{}
+}
+
+// 创建远程端口转发，并在后台监听
+func NewReverseForwarder(client *common.ReconnectingSSHClient, remoteAddr string, localAddr string) *ReverseForwarder {
+	forwarder := &ReverseForwarder{
+		client:     client,
+		remoteAddr: remoteAddr,
+		localAddr:  localAddr,
+		done:       make(chan struct{}),
+	}
+	go forwarder.run()
+	return forwarder
+}
+
+// 远程端口是否在监听
+func (f *ReverseForwarder) IsListening() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.listener != nil
+}
+
+// 最近一次的错误
+func (f *ReverseForwarder) LastError() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.lastError
+}
+
+// 停止监听
+func (f *ReverseForwarder) Close() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	select {
+	case <-f.done:
+	default:
+		close(f.done)
+	}
+	if f.listener != nil {
+		f.listener.Close()
+		f.listener = nil
+	}
+}
+
+func (f *ReverseForwarder) run() {
+	for {
+		select {
+		case <-f.done:
+			return
+		default:
+		}
+
+		//1. 在远程监听，端口被占用或者连接断开时稍后重试
+		client, listener, err := f.listen()
+		f.mutex.Lock()
+		if err != nil {
+			f.lastError = err.Error()
+		} else {
+			f.listener = listener
+			f.lastError = ""
+		}
+		f.mutex.Unlock()
+		if err != nil {
+			common.SmartIDELog.Debug(fmt.Sprintf("reverse forward %v -> %v failed: %v", f.remoteAddr, f.localAddr, err))
+			select {
+			case <-f.done:
+				return
+			case <-time.After(ReverseRetryInterval):
+			}
+			continue
+		}
+
+		//2. 转发，连接断开后 Accept 会返回错误，断开连接以便下次重新连接
+		serveReverse(listener, f.localAddr)
+		f.mutex.Lock()
+		isClosed := f.listener != listener
+		if !isClosed {
+			f.listener.Close()
+			f.listener = nil
+		}
+		f.mutex.Unlock()
+		if !isClosed {
+			f.client.Reset(client, errors.New("remote listener closed"))
+		}
+	}
+}
+
+func (f *ReverseForwarder) listen() (*ssh.Client, net.Listener, error) {
+	client, err := f.client.Client()
+	if err != nil {
+		return nil, nil, err
+	}
+	listener, err := client.Listen("tcp", f.remoteAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 监听成功前已经关闭
+	select {
+	case <-f.done:
+		listener.Close()
+		return nil, nil, errors.New("reverse forward is closed")
+	default:
+	}
+	return client, listener, nil
+}
+
+// 转发多个远程端口到本机，key 为开发容器中监听的地址，value 为本机的地址
+func ReverseMultiple(client *common.ReconnectingSSHClient, mapping map[string]string) []*ReverseForwarder {
+	forwarders := []*ReverseForwarder{}
+	for remote, local := range mapping {
+		forwarders = append(forwarders, NewReverseForwarder(client, remote, local))
+	}
+	return forwarders
+}
+
+// 接收远程的连接，并转发到本机
+func serveReverse(listener net.Listener, localAddr string) {
+	for {
+		there, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func(there net.Conn) {
+			here, err := net.DialTimeout("tcp", localAddr, time.Second*10)
+			if err != nil {
+				common.SmartIDELog.Debug(fmt.Sprintf("reverse forward failed to connect %v: %v", localAddr, err))
+				there.Close()
+				return
+			}
+			go pipeConn(here, there)
+			go pipeConn(there, here)
+		}(there)
+	}
+}
+
+// 解析 远程端口 或者 远程端口:本地端口，比如 5432、15432:5432
+func ParseReverseForward(value string) (forward DaemonForward, err error) {
+	invalidErr := fmt.Errorf("invalid reverse port %v, should be <container port>[:<local port>]", value)
+
+	items := strings.Split(value, ":")
+	if len(items) > 2 {
+		return forward, invalidErr
+	}
+	remotePort, err1 := strconv.Atoi(items[0])
+	localPort, err2 := strconv.Atoi(items[len(items)-1])
+	if err1 != nil || err2 != nil ||
+		localPort <= 0 || localPort > 65535 || remotePort <= 0 || remotePort > 65535 {
+		return forward, invalidErr
+	}
+
+	forward.IsReverse = true
+	forward.LocalPort = localPort
+	forward.RemoteAddr = net.JoinHostPort("localhost", strconv.Itoa(remotePort))
+	return forward, nil
+}
@@ -0,0 +1,253 @@
+/*
+SmartIDE - CLI
+Copyright (C) 2023 leansoftX.com
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package tunnel
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leansoftX/smartide-cli/pkg/common"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseReverseForward(t *testing.T) {
+	tests := []struct {
+		value          string
+		wantLocalPort  int
+		wantRemoteAddr string
+		wantErr        bool
+	}{
+		{value: "5432", wantLocalPort: 5432, wantRemoteAddr: "localhost:5432"},
+		{value: "15432:5432", wantLocalPort: 5432, wantRemoteAddr: "localhost:15432"},
+		{value: "15432:db:5432", wantErr: true},
+		{value: "abc", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "5432:70000", wantErr: true},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got, err := ParseReverseForward(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseReverseForward() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.IsReverse || got.LocalPort != tt.wantLocalPort || got.RemoteAddr != tt.wantRemoteAddr {
+				t.Errorf("ParseReverseForward() = %+v, want %v, %v", got, tt.wantLocalPort, tt.wantRemoteAddr)
+			}
+		})
+	}
+}
+
+// 支持 tcpip-forward 的 ssh 服务，模拟开发容器中的 sshd
+func startTestReverseSSHServer(t *testing.T) string {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) { return nil, nil },
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestReverseSSHConn(conn, config)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func serveTestReverseSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go func() { // 只需要处理全局请求
+		for newChannel := range chans {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+		}
+	}()
+
+	var mutex sync.Mutex
+	listeners := map[string]net.Listener{}
+	go func() { // 连接断开时停止监听
+		serverConn.Wait()
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
+
+	for req := range reqs {
+		var payload struct {
+			Addr string
+			Port uint32
+		}
+		if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+			req.Reply(false, nil)
+			continue
+		}
+		addr := net.JoinHostPort(payload.Addr, strconv.Itoa(int(payload.Port)))
+		switch req.Type {
+		case "tcpip-forward":
+			listener, err := net.Listen("tcp", addr)
+			if err != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			mutex.Lock()
+			listeners[addr] = listener
+			mutex.Unlock()
+			req.Reply(true, ssh.Marshal(struct{ Port uint32 }{payload.Port}))
+			go func(listener net.Listener) {
+				for {
+					here, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					channel, channelReqs, err := serverConn.OpenChannel("forwarded-tcpip", ssh.Marshal(struct {
+						Addr       string
+						Port       uint32
+						OriginAddr string
+						OriginPort uint32
+					}{payload.Addr, payload.Port, "127.0.0.1", uint32(here.RemoteAddr().(*net.TCPAddr).Port)}))
+					if err != nil {
+						here.Close()
+						continue
+					}
+					go ssh.DiscardRequests(channelReqs)
+					go func() { io.Copy(channel, here); channel.CloseWrite() }()
+					go func() { io.Copy(here, channel); here.Close() }()
+				}
+			}(listener)
+		case "cancel-tcpip-forward":
+			mutex.Lock()
+			if listener, ok := listeners[addr]; ok {
+				listener.Close()
+				delete(listeners, addr)
+			}
+			mutex.Unlock()
+			req.Reply(true, nil)
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func TestReverseForwarder(t *testing.T) {
+	common.SmartIDELog.InitLogger("")
+	ReverseRetryInterval = time.Millisecond * 50
+	addr := startTestReverseSSHServer(t)
+
+	// 本机的 echo 服务
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go io.Copy(conn, conn)
+		}
+	}()
+
+	sshClient := common.NewReconnectingSSHClient(nil, func() (*ssh.Client, error) {
+		return ssh.Dial("tcp", addr, &ssh.ClientConfig{User: "smartide", Auth: []ssh.AuthMethod{ssh.Password("")}, HostKeyCallback: ssh.InsecureIgnoreHostKey()})
+	}, 0, false)
+	defer sshClient.Close()
+
+	remotePort, err := common.GetAvailablePort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remoteAddr := fmt.Sprintf("127.0.0.1:%v", remotePort)
+	forwarder := NewReverseForwarder(sshClient, remoteAddr, echo.Addr().String())
+	defer forwarder.Close()
+
+	ping := func() {
+		deadline := time.Now().Add(time.Second * 5)
+		for {
+			if forwarder.IsListening() {
+				conn, err := net.Dial("tcp", remoteAddr)
+				if err == nil {
+					conn.Write([]byte("ping"))
+					buf := make([]byte, 4)
+					conn.SetReadDeadline(time.Now().Add(time.Second))
+					_, err = io.ReadFull(conn, buf)
+					conn.Close()
+					if err == nil && string(buf) == "ping" {
+						return
+					}
+				}
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("reverse forward not working, last error = %v", forwarder.LastError())
+			}
+			time.Sleep(time.Millisecond * 20)
+		}
+	}
+	ping()
+
+	// 模拟连接断开，自动重新连接并监听
+	client, _ := sshClient.Client()
+	client.Close()
+	ping()
+
+	// 关闭后释放远程端口
+	forwarder.Close()
+	deadline := time.Now().Add(time.Second * 3)
+	for {
+		listener, err := net.Listen("tcp", remoteAddr)
+		if err == nil {
+			listener.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("remote port %v should be released, error = %v", remotePort, err)
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+}